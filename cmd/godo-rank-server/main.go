@@ -0,0 +1,180 @@
+// Command godo-rank-server runs a self-hosted Rank server. It is a separate
+// binary so the SQLite driver is not linked into godo.
+package main
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"log"
+	"net/http"
+	"os"
+	"os/signal"
+	"syscall"
+	"time"
+
+	"github.com/spf13/cobra"
+	"github.com/yejune/godo/internal/rankserver"
+)
+
+var version string
+
+var rootCmd = &cobra.Command{
+	Use:   "godo-rank-server",
+	Short: "Run a self-hosted Rank server",
+	Long: `godo-rank-server runs the Rank API on your own infrastructure, backed by a SQLite
+database, so a team can keep an internal leaderboard. It accepts the
+HMAC-signed submissions of 'godo rank submit' and 'godo rank sync' and
+answers 'godo rank status' with daily, weekly, monthly and all-time
+positions, ranked by input plus output tokens.
+
+There is no OAuth: issue each user an API key with 'godo-rank-server keys
+add <name>'. Users then point their client at the server and store the key:
+
+  export RANK_API_URL=http://rank.internal:8080
+  godo rank login --api-key <key>`,
+	Args: cobra.NoArgs,
+	RunE: runServe,
+}
+
+var keysCmd = &cobra.Command{
+	Use:   "keys",
+	Short: "Manage API keys of the self-hosted server",
+}
+
+var keysAddCmd = &cobra.Command{
+	Use:   "add <username>",
+	Short: "Create a user and print their API key",
+	Args:  cobra.ExactArgs(1),
+	RunE: func(cmd *cobra.Command, args []string) error {
+		return withStore(func(store *rankserver.Store) error {
+			key, err := store.IssueKey(args[0])
+			if err != nil {
+				return err
+			}
+			fmt.Fprintln(cmd.OutOrStdout(), key)
+			return nil
+		})
+	},
+}
+
+var keysRotateCmd = &cobra.Command{
+	Use:   "rotate <username>",
+	Short: "Replace a user's API key and print the new one",
+	Args:  cobra.ExactArgs(1),
+	RunE: func(cmd *cobra.Command, args []string) error {
+		return withStore(func(store *rankserver.Store) error {
+			key, err := store.RotateKey(args[0])
+			if err != nil {
+				return err
+			}
+			fmt.Fprintln(cmd.OutOrStdout(), key)
+			return nil
+		})
+	},
+}
+
+var keysRevokeCmd = &cobra.Command{
+	Use:   "revoke <username>",
+	Short: "Disable a user's API key; their sessions stay on the board",
+	Args:  cobra.ExactArgs(1),
+	RunE: func(cmd *cobra.Command, args []string) error {
+		return withStore(func(store *rankserver.Store) error {
+			if err := store.RevokeKey(args[0]); err != nil {
+				return err
+			}
+			fmt.Fprintf(cmd.OutOrStdout(), "revoked %s\n", args[0])
+			return nil
+		})
+	},
+}
+
+var keysListCmd = &cobra.Command{
+	Use:   "list",
+	Short: "List users of the self-hosted server",
+	Args:  cobra.NoArgs,
+	RunE: func(cmd *cobra.Command, args []string) error {
+		return withStore(func(store *rankserver.Store) error {
+			users, err := store.Users()
+			if err != nil {
+				return err
+			}
+			out := cmd.OutOrStdout()
+			if len(users) == 0 {
+				fmt.Fprintln(out, "no users")
+				return nil
+			}
+			fmt.Fprintf(out, "%-20s %-10s %-8s %8s %12s\n", "USER", "CREATED", "STATUS", "SESSIONS", "TOKENS")
+			for _, u := range users {
+				status := "active"
+				if u.Revoked {
+					status = "revoked"
+				}
+				fmt.Fprintf(out, "%-20s %-10s %-8s %8d %12d\n",
+					u.Username, u.CreatedAt.Format("2006-01-02"), status, u.Sessions, u.Tokens)
+			}
+			return nil
+		})
+	},
+}
+
+var (
+	serveAddr string
+	serveDB   string
+)
+
+func init() {
+	rootCmd.PersistentFlags().StringVar(&serveDB, "db", "", "SQLite database path (default ~/.do/rank/server.db)")
+	rootCmd.Flags().StringVar(&serveAddr, "addr", ":8080", "listen address")
+	keysCmd.AddCommand(keysAddCmd)
+	keysCmd.AddCommand(keysListCmd)
+	keysCmd.AddCommand(keysRevokeCmd)
+	keysCmd.AddCommand(keysRotateCmd)
+	rootCmd.AddCommand(keysCmd)
+}
+
+func main() {
+	rootCmd.Version = version
+	if err := rootCmd.Execute(); err != nil {
+		os.Exit(1)
+	}
+}
+
+func withStore(fn func(*rankserver.Store) error) error {
+	path := serveDB
+	if path == "" {
+		path = rankserver.DefaultDBPath()
+	}
+	store, err := rankserver.Open(path)
+	if err != nil {
+		return err
+	}
+	defer store.Close()
+	return fn(store)
+}
+
+func runServe(cmd *cobra.Command, args []string) error {
+	return withStore(func(store *rankserver.Store) error {
+		logger := log.New(cmd.ErrOrStderr(), "rank: ", log.LstdFlags)
+		srv := &http.Server{
+			Addr:              serveAddr,
+			Handler:           rankserver.New(store, logger).Handler(),
+			ReadHeaderTimeout: 10 * time.Second,
+		}
+
+		ctx, stop := signal.NotifyContext(context.Background(), os.Interrupt, syscall.SIGTERM)
+		defer stop()
+		go func() {
+			<-ctx.Done()
+			shutdown, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+			defer cancel()
+			_ = srv.Shutdown(shutdown)
+		}()
+
+		logger.Printf("listening on %s", serveAddr)
+		if err := srv.ListenAndServe(); err != nil && !errors.Is(err, http.ErrServerClosed) {
+			return fmt.Errorf("serve: %w", err)
+		}
+		return nil
+	})
+}
@@ -29,6 +29,6 @@ func main() {
 	normalizeLegacyAliases()
 	cli.SetVersion(version)
 	if err := cli.Execute(); err != nil {
-		os.Exit(1)
+		os.Exit(cli.ExitCode(err))
 	}
 }
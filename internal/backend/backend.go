@@ -0,0 +1,345 @@
+// Package backend keeps named model backends: Anthropic-compatible APIs
+// that Claude Code can be pointed at through its ANTHROPIC_* environment
+// variables.
+package backend
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"sort"
+	"strings"
+	"time"
+
+	"gopkg.in/yaml.v3"
+)
+
+const (
+	subdir         = "backend"
+	configFilename = "backends.yaml"
+	configDirPerm  = 0700
+	configFilePerm = 0600
+)
+
+// None selects no backend: Claude Code talks to Anthropic directly.
+const None = "none"
+
+// ErrNotFound is returned for an unknown backend name.
+var ErrNotFound = errors.New("backend not found")
+
+// Models maps Claude Code's model tiers to the backend's model names.
+type Models struct {
+	Haiku  string `yaml:"haiku,omitempty"`
+	Sonnet string `yaml:"sonnet,omitempty"`
+	Opus   string `yaml:"opus,omitempty"`
+}
+
+// TokenSource says where the auth token comes from. Exactly one field is
+// set. A file holds the token, or JSON with an "api_key" field.
+type TokenSource struct {
+	Value   string `yaml:"value,omitempty"`
+	Env     string `yaml:"env,omitempty"`
+	Command string `yaml:"command,omitempty"`
+	File    string `yaml:"file,omitempty"`
+}
+
+// Backend is an Anthropic-compatible API.
+type Backend struct {
+	BaseURL string            `yaml:"base_url"`
+	Token   TokenSource       `yaml:"token"`
+	Models  Models            `yaml:"models,omitempty"`
+	Env     map[string]string `yaml:"env,omitempty"`
+}
+
+// Config holds the named backends and the one `godo claude` uses by
+// default.
+type Config struct {
+	Default  string              `yaml:"default,omitempty"`
+	Backends map[string]*Backend `yaml:"backends"`
+}
+
+// GetConfigPath returns the config path (~/.do/backend/backends.yaml).
+func GetConfigPath() string {
+	home, err := os.UserHomeDir()
+	if err != nil {
+		home = "."
+	}
+	return filepath.Join(home, ".do", subdir, configFilename)
+}
+
+// Load reads the config. A missing file has no backends.
+func Load() (*Config, error) {
+	c := &Config{Backends: make(map[string]*Backend)}
+	data, err := os.ReadFile(GetConfigPath())
+	if errors.Is(err, os.ErrNotExist) {
+		return c, nil
+	}
+	if err != nil {
+		return nil, fmt.Errorf("read backend config: %w", err)
+	}
+	if err := yaml.Unmarshal(data, c); err != nil {
+		return nil, fmt.Errorf("parse backend config %s: %w", GetConfigPath(), err)
+	}
+	if c.Backends == nil {
+		c.Backends = make(map[string]*Backend)
+	}
+	return c, nil
+}
+
+// Save writes the config atomically. It may hold tokens, so only the
+// owner can read it.
+func (c *Config) Save() error {
+	path := GetConfigPath()
+	if err := os.MkdirAll(filepath.Dir(path), configDirPerm); err != nil {
+		return fmt.Errorf("create backend config directory: %w", err)
+	}
+	data, err := yaml.Marshal(c)
+	if err != nil {
+		return fmt.Errorf("marshal backend config: %w", err)
+	}
+	tmp := path + ".tmp"
+	if err := os.WriteFile(tmp, data, configFilePerm); err != nil {
+		return fmt.Errorf("write backend config: %w", err)
+	}
+	if err := os.Rename(tmp, path); err != nil {
+		_ = os.Remove(tmp)
+		return fmt.Errorf("rename backend config: %w", err)
+	}
+	return nil
+}
+
+// Names returns the backend names, sorted.
+func (c *Config) Names() []string {
+	names := make([]string, 0, len(c.Backends))
+	for name := range c.Backends {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	return names
+}
+
+// Get returns the backend called name.
+func (c *Config) Get(name string) (*Backend, error) {
+	b, ok := c.Backends[name]
+	if !ok {
+		return nil, fmt.Errorf("%w: %s", ErrNotFound, name)
+	}
+	return b, nil
+}
+
+// Resolve returns the backend registered as name, else the preset of that
+// name.
+func (c *Config) Resolve(name string) (*Backend, error) {
+	if b, ok := c.Backends[name]; ok {
+		return b, nil
+	}
+	if b, ok := Preset(name); ok {
+		return b, nil
+	}
+	return nil, fmt.Errorf("%w: %s", ErrNotFound, name)
+}
+
+// Add stores b as name, replacing a backend of that name only if replace
+// is set.
+func (c *Config) Add(name string, b *Backend, replace bool) error {
+	if name == "" || name == None || strings.ContainsAny(name, " /\\") {
+		return fmt.Errorf("invalid backend name %q", name)
+	}
+	if err := b.Validate(); err != nil {
+		return err
+	}
+	if _, exists := c.Backends[name]; exists && !replace {
+		return fmt.Errorf("backend %s already exists", name)
+	}
+	c.Backends[name] = b
+	return nil
+}
+
+// Remove deletes a backend, and unsets it as the default.
+func (c *Config) Remove(name string) error {
+	if _, err := c.Get(name); err != nil {
+		return err
+	}
+	delete(c.Backends, name)
+	if c.Default == name {
+		c.Default = ""
+	}
+	return nil
+}
+
+// Use makes name, a registered backend or a preset, the default backend;
+// None clears the default.
+func (c *Config) Use(name string) error {
+	if name == None {
+		c.Default = ""
+		return nil
+	}
+	if _, err := c.Resolve(name); err != nil {
+		return err
+	}
+	c.Default = name
+	return nil
+}
+
+// Validate checks the base URL and that one token source is set.
+func (b *Backend) Validate() error {
+	if !strings.HasPrefix(b.BaseURL, "http://") && !strings.HasPrefix(b.BaseURL, "https://") {
+		return fmt.Errorf("base URL must start with http:// or https://, got %q", b.BaseURL)
+	}
+	n := 0
+	for _, v := range []string{b.Token.Value, b.Token.Env, b.Token.Command, b.Token.File} {
+		if v != "" {
+			n++
+		}
+	}
+	if n != 1 {
+		return fmt.Errorf("set exactly one token source (value, env, command or file)")
+	}
+	for k := range b.Env {
+		if k == "" || strings.ContainsAny(k, "= ") {
+			return fmt.Errorf("invalid env name %q", k)
+		}
+	}
+	return nil
+}
+
+// Describe shows where the token comes from without revealing it.
+func (t TokenSource) Describe() string {
+	switch {
+	case t.Value != "":
+		return "stored (" + MaskToken(t.Value) + ")"
+	case t.Env != "":
+		return "env $" + t.Env
+	case t.Command != "":
+		return "command `" + t.Command + "`"
+	case t.File != "":
+		return "file " + t.File
+	}
+	return "none"
+}
+
+// Resolve returns the token.
+func (t TokenSource) Resolve() (string, error) {
+	var token string
+	switch {
+	case t.Value != "":
+		token = t.Value
+	case t.Env != "":
+		token = os.Getenv(t.Env)
+		if token == "" {
+			return "", fmt.Errorf("token env var %s is not set", t.Env)
+		}
+	case t.Command != "":
+		ctx, cancel := context.WithTimeout(context.Background(), 30*time.Second)
+		defer cancel()
+		out, err := exec.CommandContext(ctx, "sh", "-c", t.Command).Output()
+		if err != nil {
+			return "", fmt.Errorf("run token command: %w", err)
+		}
+		token = strings.TrimSpace(string(out))
+	case t.File != "":
+		data, err := os.ReadFile(expandHome(t.File))
+		if err != nil {
+			return "", fmt.Errorf("read token file: %w", err)
+		}
+		token = strings.TrimSpace(string(data))
+		var creds struct {
+			APIKey string `json:"api_key"`
+		}
+		if strings.HasPrefix(token, "{") && json.Unmarshal(data, &creds) == nil {
+			token = creds.APIKey
+		}
+	default:
+		return "", fmt.Errorf("no token source")
+	}
+	if token == "" {
+		return "", fmt.Errorf("token from %s is empty", t.Describe())
+	}
+	return token, nil
+}
+
+// Environ returns the environment variables that point Claude Code at the
+// backend.
+func (b *Backend) Environ() (map[string]string, error) {
+	token, err := b.Token.Resolve()
+	if err != nil {
+		return nil, err
+	}
+	env := make(map[string]string, len(b.Env)+5)
+	for k, v := range b.Env {
+		env[k] = v
+	}
+	env["ANTHROPIC_BASE_URL"] = b.BaseURL
+	env["ANTHROPIC_AUTH_TOKEN"] = token
+	for k, v := range map[string]string{
+		"ANTHROPIC_DEFAULT_HAIKU_MODEL":  b.Models.Haiku,
+		"ANTHROPIC_DEFAULT_SONNET_MODEL": b.Models.Sonnet,
+		"ANTHROPIC_DEFAULT_OPUS_MODEL":   b.Models.Opus,
+	} {
+		if v != "" {
+			env[k] = v
+		}
+	}
+	return env, nil
+}
+
+// Apply sets the backend's environment in the current process, for the
+// Claude Code process exec'd next. Other ANTHROPIC_* variables, such as
+// ANTHROPIC_API_KEY or a model mapping from the shell or an earlier
+// backend, are unset so they cannot override it.
+func (b *Backend) Apply() error {
+	env, err := b.Environ()
+	if err != nil {
+		return err
+	}
+	if err := clearEnv(env); err != nil {
+		return err
+	}
+	for k, v := range env {
+		if err := os.Setenv(k, v); err != nil {
+			return fmt.Errorf("set %s: %w", k, err)
+		}
+	}
+	return nil
+}
+
+// Clear unsets every ANTHROPIC_* variable in the current process, so the
+// Claude Code process exec'd next uses its own login.
+func Clear() error {
+	return clearEnv(nil)
+}
+
+// clearEnv unsets the ANTHROPIC_* variables not in keep.
+func clearEnv(keep map[string]string) error {
+	for _, kv := range os.Environ() {
+		k, _, _ := strings.Cut(kv, "=")
+		if _, ok := keep[k]; ok || !strings.HasPrefix(k, "ANTHROPIC_") {
+			continue
+		}
+		if err := os.Unsetenv(k); err != nil {
+			return fmt.Errorf("unset %s: %w", k, err)
+		}
+	}
+	return nil
+}
+
+// MaskToken masks a token for display, showing only prefix and suffix.
+func MaskToken(token string) string {
+	if len(token) <= 8 {
+		return "****"
+	}
+	return token[:4] + "****" + token[len(token)-4:]
+}
+
+func expandHome(path string) string {
+	if rest, ok := strings.CutPrefix(path, "~/"); ok {
+		if home, err := os.UserHomeDir(); err == nil {
+			return filepath.Join(home, rest)
+		}
+	}
+	return path
+}
@@ -0,0 +1,224 @@
+package backend
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+func Test_Config_add_use_remove_round_trip(t *testing.T) {
+	t.Setenv("HOME", t.TempDir())
+
+	cfg, err := Load()
+	if err != nil || len(cfg.Backends) != 0 {
+		t.Fatalf("empty load: %+v, %v", cfg, err)
+	}
+	b := &Backend{BaseURL: "http://localhost:4000", Token: TokenSource{Env: "PROXY_KEY"},
+		Models: Models{Sonnet: "m"}, Env: map[string]string{"API_TIMEOUT_MS": "1000"}}
+	if err := cfg.Add("proxy", b, false); err != nil {
+		t.Fatal(err)
+	}
+	if err := cfg.Add("proxy", b, false); err == nil {
+		t.Error("adding an existing name without replace should fail")
+	}
+	if err := cfg.Use("proxy"); err != nil {
+		t.Fatal(err)
+	}
+	if err := cfg.Save(); err != nil {
+		t.Fatal(err)
+	}
+	if info, err := os.Stat(GetConfigPath()); err != nil || info.Mode().Perm() != configFilePerm {
+		t.Fatalf("config file: %v, %v", info, err)
+	}
+
+	cfg, err = Load()
+	if err != nil {
+		t.Fatal(err)
+	}
+	got, err := cfg.Get("proxy")
+	if err != nil || cfg.Default != "proxy" || got.Models.Sonnet != "m" || got.Env["API_TIMEOUT_MS"] != "1000" {
+		t.Fatalf("reloaded: %+v, %+v, %v", cfg, got, err)
+	}
+
+	if err := cfg.Remove("proxy"); err != nil || cfg.Default != "" {
+		t.Errorf("remove: default %q, %v", cfg.Default, err)
+	}
+	if _, err := cfg.Get("proxy"); !errors.Is(err, ErrNotFound) {
+		t.Errorf("get after remove: %v", err)
+	}
+	if err := cfg.Use("nope"); !errors.Is(err, ErrNotFound) {
+		t.Errorf("use unknown: %v", err)
+	}
+}
+
+func Test_Config_Resolve_falls_back_to_preset(t *testing.T) {
+	cfg := &Config{Backends: map[string]*Backend{}}
+	b, err := cfg.Resolve("glm")
+	if err != nil || b.BaseURL != "https://api.z.ai/api/anthropic" {
+		t.Fatalf("preset: %+v, %v", b, err)
+	}
+	// Changing the copy leaves the preset alone.
+	b.BaseURL = "http://x"
+	if p, _ := Preset("glm"); p.BaseURL == "http://x" {
+		t.Error("Preset returned a shared value")
+	}
+
+	own := &Backend{BaseURL: "http://own", Token: TokenSource{Value: "k"}}
+	cfg.Backends["glm"] = own
+	if b, _ := cfg.Resolve("glm"); b != own {
+		t.Error("a registered backend should take precedence over the preset")
+	}
+}
+
+func Test_Backend_Validate(t *testing.T) {
+	tests := []struct {
+		name string
+		b    Backend
+		ok   bool
+	}{
+		{"valid", Backend{BaseURL: "https://a", Token: TokenSource{Env: "K"}}, true},
+		{"bad url", Backend{BaseURL: "a.com", Token: TokenSource{Env: "K"}}, false},
+		{"no token", Backend{BaseURL: "https://a"}, false},
+		{"two tokens", Backend{BaseURL: "https://a", Token: TokenSource{Env: "K", Value: "v"}}, false},
+		{"bad env", Backend{BaseURL: "https://a", Token: TokenSource{Env: "K"}, Env: map[string]string{"A=B": ""}}, false},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if err := tt.b.Validate(); (err == nil) != tt.ok {
+				t.Errorf("Validate() = %v, want ok %v", err, tt.ok)
+			}
+		})
+	}
+}
+
+func Test_TokenSource_Resolve(t *testing.T) {
+	home := t.TempDir()
+	t.Setenv("HOME", home)
+	t.Setenv("TEST_BACKEND_KEY", "from-env")
+	os.MkdirAll(filepath.Join(home, ".do", "glm"), 0700)
+	os.WriteFile(filepath.Join(home, ".do", "glm", "credentials.json"), []byte(`{"api_key":"from-json"}`), 0600)
+	plain := filepath.Join(home, "token")
+	os.WriteFile(plain, []byte("from-file\n"), 0600)
+
+	tests := []struct {
+		src  TokenSource
+		want string
+	}{
+		{TokenSource{Value: "stored"}, "stored"},
+		{TokenSource{Env: "TEST_BACKEND_KEY"}, "from-env"},
+		{TokenSource{Command: "echo from-cmd"}, "from-cmd"},
+		{TokenSource{File: plain}, "from-file"},
+		{TokenSource{File: GLMCredentialsFile}, "from-json"},
+	}
+	for _, tt := range tests {
+		got, err := tt.src.Resolve()
+		if err != nil || got != tt.want {
+			t.Errorf("%s: got %q, %v; want %q", tt.src.Describe(), got, err, tt.want)
+		}
+	}
+	if _, err := (TokenSource{Env: "TEST_BACKEND_UNSET"}).Resolve(); err == nil {
+		t.Error("unset env var should fail")
+	}
+}
+
+func Test_Backend_Environ(t *testing.T) {
+	b := &Backend{BaseURL: "http://localhost:4000", Token: TokenSource{Value: "tok"},
+		Models: Models{Haiku: "small", Opus: "big"},
+		Env:    map[string]string{"API_TIMEOUT_MS": "1000", "ANTHROPIC_BASE_URL": "ignored"}}
+	env, err := b.Environ()
+	if err != nil {
+		t.Fatal(err)
+	}
+	want := map[string]string{
+		"ANTHROPIC_BASE_URL":            "http://localhost:4000",
+		"ANTHROPIC_AUTH_TOKEN":          "tok",
+		"ANTHROPIC_DEFAULT_HAIKU_MODEL": "small",
+		"ANTHROPIC_DEFAULT_OPUS_MODEL":  "big",
+		"API_TIMEOUT_MS":                "1000",
+	}
+	if len(env) != len(want) {
+		t.Errorf("got %v, want %v", env, want)
+	}
+	for k, v := range want {
+		if env[k] != v {
+			t.Errorf("%s = %q, want %q", k, env[k], v)
+		}
+	}
+}
+
+func Test_Check_against_stub_server(t *testing.T) {
+	var gotModel, gotAuth, gotPath string
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		gotPath, gotAuth = r.URL.Path, r.Header.Get("Authorization")
+		var req struct {
+			Model string `json:"model"`
+		}
+		json.NewDecoder(r.Body).Decode(&req)
+		gotModel = req.Model
+		if gotAuth != "Bearer good" {
+			w.WriteHeader(http.StatusUnauthorized)
+			w.Write([]byte(`{"type":"error","error":{"type":"authentication_error","message":"invalid x-api-key"}}`))
+			return
+		}
+		w.Write([]byte(`{"type":"message","content":[]}`))
+	}))
+	defer srv.Close()
+
+	b := &Backend{BaseURL: srv.URL + "/api/anthropic/", Token: TokenSource{Value: "good"}, Models: Models{Sonnet: "m-sonnet"}}
+	res, err := Check(context.Background(), b, srv.Client())
+	if err != nil || res.Status != http.StatusOK {
+		t.Fatalf("check: %+v, %v", res, err)
+	}
+	if gotPath != "/api/anthropic/v1/messages" || gotModel != "m-sonnet" {
+		t.Errorf("request: path %q, model %q", gotPath, gotModel)
+	}
+
+	b.Token.Value = "bad"
+	_, err = Check(context.Background(), b, srv.Client())
+	if err == nil || !strings.Contains(err.Error(), "authentication failed") || !strings.Contains(err.Error(), "invalid x-api-key") {
+		t.Errorf("bad token: %v", err)
+	}
+
+	srv.Close()
+	if _, err := Check(context.Background(), b, srv.Client()); err == nil {
+		t.Error("closed server should fail")
+	}
+}
+
+func Test_Apply_and_Clear_unset_other_anthropic_vars(t *testing.T) {
+	t.Setenv("ANTHROPIC_API_KEY", "sk-shell")
+	t.Setenv("ANTHROPIC_DEFAULT_SONNET_MODEL", "stale")
+	t.Setenv("ANTHROPIC_MODEL", "stale")
+	t.Setenv("API_TIMEOUT_MS", "5")
+
+	b := &Backend{BaseURL: "http://localhost:4000", Token: TokenSource{Value: "tok"}, Models: Models{Haiku: "small"}}
+	if err := b.Apply(); err != nil {
+		t.Fatal(err)
+	}
+	for _, k := range []string{"ANTHROPIC_API_KEY", "ANTHROPIC_DEFAULT_SONNET_MODEL", "ANTHROPIC_MODEL"} {
+		if v, ok := os.LookupEnv(k); ok {
+			t.Errorf("%s = %q, want unset", k, v)
+		}
+	}
+	if os.Getenv("ANTHROPIC_DEFAULT_HAIKU_MODEL") != "small" || os.Getenv("ANTHROPIC_AUTH_TOKEN") != "tok" {
+		t.Error("backend variables not set")
+	}
+	if os.Getenv("API_TIMEOUT_MS") != "5" {
+		t.Error("non-ANTHROPIC variables must be kept")
+	}
+
+	if err := Clear(); err != nil {
+		t.Fatal(err)
+	}
+	for _, kv := range os.Environ() {
+		if strings.HasPrefix(kv, "ANTHROPIC_") {
+			t.Errorf("after Clear: %s", kv)
+		}
+	}
+}
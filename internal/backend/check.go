@@ -0,0 +1,88 @@
+package backend
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"strings"
+	"time"
+)
+
+// CheckTimeout bounds a connectivity check.
+const CheckTimeout = 15 * time.Second
+
+// CheckResult is the outcome of a connectivity check.
+type CheckResult struct {
+	Status  int
+	Model   string
+	Latency time.Duration
+}
+
+// Check sends a one-token Messages API request to the backend, the way
+// Claude Code authenticates against ANTHROPIC_BASE_URL, and reports
+// whether the URL, token and model work. A nil client uses
+// http.DefaultClient.
+func Check(ctx context.Context, b *Backend, client *http.Client) (*CheckResult, error) {
+	if client == nil {
+		client = http.DefaultClient
+	}
+	token, err := b.Token.Resolve()
+	if err != nil {
+		return nil, err
+	}
+	model := b.Models.Haiku
+	if model == "" {
+		model = b.Models.Sonnet
+	}
+	if model == "" {
+		model = "claude-haiku-4-5"
+	}
+	body, _ := json.Marshal(map[string]any{
+		"model":      model,
+		"max_tokens": 1,
+		"messages":   []map[string]string{{"role": "user", "content": "ping"}},
+	})
+
+	ctx, cancel := context.WithTimeout(ctx, CheckTimeout)
+	defer cancel()
+	url := strings.TrimRight(b.BaseURL, "/") + "/v1/messages"
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, url, bytes.NewReader(body))
+	if err != nil {
+		return nil, fmt.Errorf("create request: %w", err)
+	}
+	req.Header.Set("Content-Type", "application/json")
+	req.Header.Set("Authorization", "Bearer "+token)
+	req.Header.Set("anthropic-version", "2023-06-01")
+
+	start := time.Now()
+	resp, err := client.Do(req)
+	if err != nil {
+		return nil, fmt.Errorf("connect to %s: %w", b.BaseURL, err)
+	}
+	defer resp.Body.Close()
+	res := &CheckResult{Status: resp.StatusCode, Model: model, Latency: time.Since(start)}
+	if resp.StatusCode/100 == 2 {
+		return res, nil
+	}
+
+	data, _ := io.ReadAll(io.LimitReader(resp.Body, 4096))
+	var apiErr struct {
+		Error struct {
+			Message string `json:"message"`
+		} `json:"error"`
+	}
+	msg := strings.TrimSpace(string(data))
+	if json.Unmarshal(data, &apiErr) == nil && apiErr.Error.Message != "" {
+		msg = apiErr.Error.Message
+	}
+	switch resp.StatusCode {
+	case http.StatusUnauthorized, http.StatusForbidden:
+		return res, fmt.Errorf("authentication failed (HTTP %d): %s", resp.StatusCode, msg)
+	case http.StatusNotFound:
+		return res, fmt.Errorf("no Messages API at %s (HTTP 404), check the base URL", url)
+	}
+	return res, fmt.Errorf("HTTP %d: %s", resp.StatusCode, msg)
+}
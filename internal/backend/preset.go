@@ -0,0 +1,41 @@
+package backend
+
+import "sort"
+
+// GLMCredentialsFile is where `godo glm setup` stores the Z.AI API key.
+const GLMCredentialsFile = "~/.do/glm/credentials.json"
+
+var presets = map[string]Backend{
+	"glm": {
+		BaseURL: "https://api.z.ai/api/anthropic",
+		Token:   TokenSource{File: GLMCredentialsFile},
+		Models:  Models{Haiku: "glm-4.7-flash", Sonnet: "glm-5", Opus: "glm-5"},
+	},
+}
+
+// Preset returns a copy of a built-in backend. Its token source is a
+// default the caller may replace.
+func Preset(name string) (*Backend, bool) {
+	p, ok := presets[name]
+	if !ok {
+		return nil, false
+	}
+	b := p
+	if p.Env != nil {
+		b.Env = make(map[string]string, len(p.Env))
+		for k, v := range p.Env {
+			b.Env[k] = v
+		}
+	}
+	return &b, true
+}
+
+// PresetNames returns the built-in backend names, sorted.
+func PresetNames() []string {
+	names := make([]string, 0, len(presets))
+	for name := range presets {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	return names
+}
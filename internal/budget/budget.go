@@ -0,0 +1,157 @@
+// Package budget checks session and daily spend against configured cost
+// and token limits. Daily spend is computed from local transcripts.
+package budget
+
+import (
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+
+	"github.com/yejune/godo/internal/usage"
+	"gopkg.in/yaml.v3"
+)
+
+// ConfigFile is the budget config path, relative to the project root or,
+// for projects without one, the home directory.
+const ConfigFile = ".do/budget.yaml"
+
+// Periods a budget applies to.
+const (
+	PeriodSession = "session"
+	PeriodDaily   = "daily"
+)
+
+// Units a budget is measured in.
+const (
+	UnitUSD    = "usd"
+	UnitTokens = "tokens"
+)
+
+// Limit caps spend in USD, tokens or both. Zero means no limit. Tokens
+// count input and output tokens; cache tokens only count towards cost.
+type Limit struct {
+	USD    float64 `yaml:"usd,omitempty"`
+	Tokens int64   `yaml:"tokens,omitempty"`
+}
+
+// Set reports whether any limit is configured.
+func (l Limit) Set() bool {
+	return l.USD > 0 || l.Tokens > 0
+}
+
+// Config holds the budgets. Warn makes the UserPromptSubmit hook remind
+// the assistant when a budget is exceeded.
+type Config struct {
+	Session Limit `yaml:"session"`
+	Daily   Limit `yaml:"daily"`
+	Warn    bool  `yaml:"warn"`
+}
+
+// Enabled reports whether any budget is configured.
+func (c *Config) Enabled() bool {
+	return c.Session.Set() || c.Daily.Set()
+}
+
+// Load reads the budget config of the project at dir, falling back to the
+// user's config. With neither, no budget is set.
+func Load(dir string) (*Config, error) {
+	paths := []string{filepath.Join(dir, ConfigFile)}
+	if home, err := os.UserHomeDir(); err == nil {
+		paths = append(paths, filepath.Join(home, ConfigFile))
+	}
+	for _, path := range paths {
+		data, err := os.ReadFile(path)
+		if errors.Is(err, os.ErrNotExist) {
+			continue
+		}
+		if err != nil {
+			return nil, fmt.Errorf("read budget config: %w", err)
+		}
+		var c Config
+		if err := yaml.Unmarshal(data, &c); err != nil {
+			return nil, fmt.Errorf("parse budget config %s: %w", path, err)
+		}
+		if c.Session.USD < 0 || c.Session.Tokens < 0 || c.Daily.USD < 0 || c.Daily.Tokens < 0 {
+			return nil, fmt.Errorf("budget config %s: limits must not be negative", path)
+		}
+		return &c, nil
+	}
+	return &Config{}, nil
+}
+
+// Spend is cost and token use over a period.
+type Spend struct {
+	USD    float64 `json:"usd"`
+	Tokens int64   `json:"tokens"`
+}
+
+// Status compares spend against one limit.
+type Status struct {
+	Period string
+	Unit   string
+	Used   float64
+	Limit  float64
+}
+
+// Ratio is the fraction of the limit used.
+func (s Status) Ratio() float64 {
+	return s.Used / s.Limit
+}
+
+// Exceeded reports whether spend has reached the limit.
+func (s Status) Exceeded() bool {
+	return s.Used >= s.Limit
+}
+
+// Amounts formats used and limit, e.g. "$3.20/$5.00" or "1.20M/2.00M tok".
+func (s Status) Amounts() string {
+	amounts := FormatAmount(s.Unit, s.Used) + "/" + FormatAmount(s.Unit, s.Limit)
+	if s.Unit == UnitTokens {
+		amounts += " tok"
+	}
+	return amounts
+}
+
+// FormatAmount formats a USD or token amount.
+func FormatAmount(unit string, v float64) string {
+	if unit == UnitTokens {
+		return usage.FormatTokens(int64(v))
+	}
+	return usage.FormatCost(v)
+}
+
+// String describes the status, e.g. "daily $3.20/$5.00".
+func (s Status) String() string {
+	return s.Period + " " + s.Amounts()
+}
+
+// Check compares spend with every configured limit, session first.
+func (c *Config) Check(session, daily Spend) []Status {
+	var out []Status
+	add := func(period string, l Limit, sp Spend) {
+		if l.USD > 0 {
+			out = append(out, Status{Period: period, Unit: UnitUSD, Used: sp.USD, Limit: l.USD})
+		}
+		if l.Tokens > 0 {
+			out = append(out, Status{Period: period, Unit: UnitTokens, Used: float64(sp.Tokens), Limit: float64(l.Tokens)})
+		}
+	}
+	add(PeriodSession, c.Session, session)
+	add(PeriodDaily, c.Daily, daily)
+	return out
+}
+
+// Worst returns the status closest to (or furthest over) its limit.
+func Worst(statuses []Status) (Status, bool) {
+	if len(statuses) == 0 {
+		return Status{}, false
+	}
+	worst := statuses[0]
+	for _, s := range statuses[1:] {
+		if s.Ratio() > worst.Ratio() {
+			worst = s
+		}
+	}
+	return worst, true
+}
@@ -0,0 +1,186 @@
+package budget
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/yejune/godo/internal/usage"
+)
+
+const replyLine = `{"type":"assistant","timestamp":"%s","sessionId":"%s","requestId":"%s","message":{"id":"%s","model":"claude-sonnet-4-5","usage":{"input_tokens":%d,"output_tokens":%d}}}`
+
+func writeTranscript(t *testing.T, path string, modTime time.Time, lines ...string) {
+	t.Helper()
+	if err := os.MkdirAll(filepath.Dir(path), 0755); err != nil {
+		t.Fatal(err)
+	}
+	var data []byte
+	for _, l := range lines {
+		data = append(data, l+"\n"...)
+	}
+	if err := os.WriteFile(path, data, 0644); err != nil {
+		t.Fatal(err)
+	}
+	if err := os.Chtimes(path, modTime, modTime); err != nil {
+		t.Fatal(err)
+	}
+}
+
+func Test_Load_prefers_project_config_and_checks_limits(t *testing.T) {
+	home, dir := t.TempDir(), t.TempDir()
+	t.Setenv("HOME", home)
+	if c, err := Load(dir); err != nil || c.Enabled() {
+		t.Fatalf("no config: %+v, %v", c, err)
+	}
+
+	os.MkdirAll(filepath.Join(home, ".do"), 0755)
+	os.WriteFile(filepath.Join(home, ConfigFile), []byte("daily: {usd: 20}\n"), 0644)
+	os.MkdirAll(filepath.Join(dir, ".do"), 0755)
+	os.WriteFile(filepath.Join(dir, ConfigFile), []byte("session: {usd: 5, tokens: 1000}\nwarn: true\n"), 0644)
+
+	c, err := Load(dir)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if c.Daily.Set() || !c.Warn {
+		t.Errorf("project config should win: %+v", c)
+	}
+	statuses := c.Check(Spend{USD: 2, Tokens: 1500}, Spend{})
+	if len(statuses) != 2 {
+		t.Fatalf("statuses: %+v", statuses)
+	}
+	worst, _ := Worst(statuses)
+	if worst.Unit != UnitTokens || !worst.Exceeded() || worst.String() != "session 1500/1000 tok" {
+		t.Errorf("worst: %+v %q", worst, worst.String())
+	}
+	if statuses[0].Exceeded() || statuses[0].Amounts() != "$2.00/$5.00" {
+		t.Errorf("usd status: %q", statuses[0].Amounts())
+	}
+
+	os.WriteFile(filepath.Join(dir, ConfigFile), []byte("daily: {usd: -1}\n"), 0644)
+	if _, err := Load(dir); err == nil {
+		t.Error("negative limit should be rejected")
+	}
+}
+
+func Test_DailySpend_counts_only_today(t *testing.T) {
+	dir := t.TempDir()
+	now := time.Date(2026, 3, 10, 15, 0, 0, 0, time.Local)
+	today := now.Add(-time.Hour).UTC().Format(time.RFC3339)
+	yesterday := now.AddDate(0, 0, -1).UTC().Format(time.RFC3339)
+
+	// A session spanning midnight counts only today's part; a file last
+	// written yesterday is not read at all.
+	spanning := filepath.Join(dir, "a.jsonl")
+	writeTranscript(t, spanning, now,
+		fmt.Sprintf(replyLine, yesterday, "a", "r1", "m1", 1_000_000, 0),
+		fmt.Sprintf(replyLine, today, "a", "r2", "m2", 1_000_000, 100_000),
+	)
+	old := filepath.Join(dir, "b.jsonl")
+	writeTranscript(t, old, now.AddDate(0, 0, -1),
+		fmt.Sprintf(replyLine, today, "b", "r1", "m1", 5_000_000, 0),
+	)
+
+	got := DailySpend([]string{spanning, old}, now, usage.DefaultPrices())
+	if got.Tokens != 1_100_000 || got.USD < 4.49 || got.USD > 4.51 {
+		t.Errorf("daily spend: %+v", got)
+	}
+
+	session, err := SessionSpend(spanning, usage.DefaultPrices())
+	if err != nil || session.Tokens != 2_100_000 {
+		t.Errorf("session spend: %+v, %v", session, err)
+	}
+}
+
+func Test_Today_reuses_recent_result(t *testing.T) {
+	home := t.TempDir()
+	t.Setenv("HOME", home)
+	y, m, d := time.Now().Date()
+	now := time.Date(y, m, d, 12, 0, 0, 0, time.Local)
+	transcript := filepath.Join(home, ".claude", "projects", "p", "s.jsonl")
+	writeTranscript(t, transcript, now, fmt.Sprintf(replyLine, now.UTC().Format(time.RFC3339), "s", "r1", "m1", 100, 10))
+
+	if got := Today(now); got.Tokens != 110 {
+		t.Fatalf("first: %+v", got)
+	}
+	writeTranscript(t, transcript, now,
+		fmt.Sprintf(replyLine, now.UTC().Format(time.RFC3339), "s", "r1", "m1", 100, 10),
+		fmt.Sprintf(replyLine, now.UTC().Format(time.RFC3339), "s", "r2", "m2", 100, 10),
+	)
+	if got := Today(now.Add(time.Second)); got.Tokens != 110 {
+		t.Errorf("cached: %+v", got)
+	}
+	if got := Today(now.Add(DailyCacheTTL)); got.Tokens != 220 {
+		t.Errorf("expired: %+v", got)
+	}
+}
+
+func Test_Session_reuses_result_until_transcript_changes(t *testing.T) {
+	home := t.TempDir()
+	t.Setenv("HOME", home)
+	now := time.Now()
+	stamp := now.UTC().Format(time.RFC3339)
+	transcript := filepath.Join(home, "s.jsonl")
+	writeTranscript(t, transcript, now.Add(-time.Minute), fmt.Sprintf(replyLine, stamp, "s", "r1", "m1", 100, 10))
+
+	if got, err := Session(transcript); err != nil || got.Tokens != 110 {
+		t.Fatalf("first: %+v, %v", got, err)
+	}
+	writeTranscript(t, filepath.Join(home, "s", "subagents", "agent-a.jsonl"), now,
+		fmt.Sprintf(replyLine, stamp, "s", "r2", "m2", 100, 10))
+	if got, err := Session(transcript); err != nil || got.Tokens != 220 {
+		t.Errorf("after a subagent wrote: %+v, %v", got, err)
+	}
+}
+
+func Test_SessionBy_and_TodayBy_fall_back_to_cache_at_deadline(t *testing.T) {
+	home := t.TempDir()
+	t.Setenv("HOME", home)
+	now := time.Now()
+	transcript := filepath.Join(home, ".claude", "projects", "p", "s.jsonl")
+	writeTranscript(t, transcript, now, fmt.Sprintf(replyLine, now.UTC().Format(time.RFC3339), "s", "r1", "m1", 100, 10))
+
+	past := now.Add(-time.Second)
+	if _, ok := SessionBy(transcript, past); ok {
+		t.Error("session: nothing cached yet, want not ok")
+	}
+	if _, ok := TodayBy(now, past); ok {
+		t.Error("today: nothing cached yet, want not ok")
+	}
+
+	future := time.Now().Add(10 * time.Second)
+	if got, ok := SessionBy(transcript, future); !ok || got.Tokens != 110 {
+		t.Fatalf("session: %+v, %v", got, ok)
+	}
+	if got, ok := TodayBy(now, future); !ok || got.Tokens != 110 {
+		t.Fatalf("today: %+v, %v", got, ok)
+	}
+
+	// Past the deadline, the last results are shown however old.
+	writeTranscript(t, transcript, now.Add(time.Second),
+		fmt.Sprintf(replyLine, now.UTC().Format(time.RFC3339), "s", "r1", "m1", 100, 10),
+		fmt.Sprintf(replyLine, now.UTC().Format(time.RFC3339), "s", "r2", "m2", 100, 10),
+	)
+	if got, ok := SessionBy(transcript, past); !ok || got.Tokens != 110 {
+		t.Errorf("stale session: %+v, %v", got, ok)
+	}
+	if got, ok := TodayBy(now.Add(time.Hour), past); !ok || got.Tokens != 110 {
+		t.Errorf("stale today: %+v, %v", got, ok)
+	}
+	other := filepath.Join(home, ".claude", "projects", "p", "other.jsonl")
+	if _, ok := SessionBy(other, past); ok {
+		t.Error("another session must not show this session's spend")
+	}
+
+	// Measuring another session keeps this session's result.
+	writeTranscript(t, other, now, fmt.Sprintf(replyLine, now.UTC().Format(time.RFC3339), "o", "r1", "m1", 10, 1))
+	if got, ok := SessionBy(other, future); !ok || got.Tokens != 11 {
+		t.Fatalf("other session: %+v, %v", got, ok)
+	}
+	if got, ok := SessionBy(transcript, past); !ok || got.Tokens != 110 {
+		t.Errorf("session evicted by another: %+v, %v", got, ok)
+	}
+}
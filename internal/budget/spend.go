@@ -0,0 +1,232 @@
+package budget
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"os"
+	"path/filepath"
+	"slices"
+	"time"
+
+	"github.com/yejune/godo/internal/rank"
+	"github.com/yejune/godo/internal/transcript"
+	"github.com/yejune/godo/internal/usage"
+)
+
+// DailyCacheTTL is how long a computed daily spend is reused.
+const DailyCacheTTL = time.Minute
+
+// Of prices entries and counts their input and output tokens.
+func Of(entries []usage.Entry, prices *usage.PriceTable) Spend {
+	var s Spend
+	for _, e := range entries {
+		if e.Prompt {
+			continue
+		}
+		p, _ := prices.Lookup(e.Model)
+		s.USD += p.Cost(e.Tokens)
+		s.Tokens += e.Tokens.Input + e.Tokens.Output
+	}
+	return s
+}
+
+// SessionSpend returns the spend recorded in one transcript.
+func SessionSpend(path string, prices *usage.PriceTable) (Spend, error) {
+	_, entries, err := usage.ParseFile(path)
+	if err != nil {
+		return Spend{}, err
+	}
+	return Of(entries, prices), nil
+}
+
+// DailySpend returns the spend of the local day containing now across the
+// transcripts at paths, subagents included. Sessions whose transcript and
+// subagent files were all last written before that day are skipped
+// without being read.
+func DailySpend(paths []string, now time.Time, prices *usage.PriceTable) Spend {
+	start := time.Date(now.Year(), now.Month(), now.Day(), 0, 0, 0, 0, now.Location())
+	writtenToday := func(p string) bool {
+		info, err := os.Stat(p)
+		return err == nil && !info.ModTime().Before(start)
+	}
+	var recent []string
+	for _, p := range paths {
+		if writtenToday(p) || slices.ContainsFunc(transcript.SubagentFiles(p), writtenToday) {
+			recent = append(recent, p)
+		}
+	}
+	data, _ := usage.Load(recent)
+	return Of(data.Filter(start, start.AddDate(0, 0, 1), "").Entries, prices)
+}
+
+// Prices returns the user's price table, or the built-in one.
+func Prices() *usage.PriceTable {
+	prices, err := usage.LoadPrices(usage.DefaultPricesPath())
+	if err != nil {
+		return usage.DefaultPrices()
+	}
+	return prices
+}
+
+type sessionCache struct {
+	Path    string    `json:"path"`
+	Size    int64     `json:"size"`
+	ModTime time.Time `json:"mod_time"`
+	Spend   Spend     `json:"spend"`
+}
+
+// Session returns the spend of the session whose transcript is at path,
+// subagents included. The status line and the prompt hook both measure
+// the session this way. The last result is cached per transcript and
+// reused until the transcript or one of its subagent files changes.
+func Session(path string) (Spend, error) {
+	size, modTime, err := transcriptStamp(path)
+	if err != nil {
+		return Spend{}, err
+	}
+	cachePath := sessionCachePath(path)
+	var c sessionCache
+	if readCache(cachePath, &c) && c.Path == path && c.Size == size && c.ModTime.Equal(modTime) {
+		return c.Spend, nil
+	}
+	spend, err := SessionSpend(path, Prices())
+	if err != nil {
+		return Spend{}, err
+	}
+	writeCache(cachePath, sessionCache{Path: path, Size: size, ModTime: modTime, Spend: spend})
+	return spend, nil
+}
+
+// SessionBy is Session for callers with a time budget, like the status
+// line. When the spend is not measured before deadline, the last result
+// for the transcript is returned, however old; ok is false when there is
+// none. The measurement is abandoned at the deadline, so the cache relies
+// on the prompt hook, which measures without one.
+func SessionBy(path string, deadline time.Time) (spend Spend, ok bool) {
+	if spend, ok := runBy(deadline, func() (Spend, error) { return Session(path) }); ok {
+		return spend, true
+	}
+	var c sessionCache
+	if readCache(sessionCachePath(path), &c) && c.Path == path {
+		return c.Spend, true
+	}
+	return Spend{}, false
+}
+
+// transcriptStamp returns the total size and latest modification time of
+// a transcript and its subagent files.
+func transcriptStamp(path string) (int64, time.Time, error) {
+	info, err := os.Stat(path)
+	if err != nil {
+		return 0, time.Time{}, err
+	}
+	size, modTime := info.Size(), info.ModTime()
+	for _, p := range transcript.SubagentFiles(path) {
+		if info, err := os.Stat(p); err == nil {
+			size += info.Size()
+			if info.ModTime().After(modTime) {
+				modTime = info.ModTime()
+			}
+		}
+	}
+	return size, modTime, nil
+}
+
+type dailyCache struct {
+	Day        string    `json:"day"`
+	ComputedAt time.Time `json:"computed_at"`
+	Spend      Spend     `json:"spend"`
+}
+
+// GetCachePath returns the daily spend cache path (~/.do/budget/daily.json).
+func GetCachePath() string {
+	return filepath.Join(cacheDir(), "daily.json")
+}
+
+// sessionCachePath returns the cache of the session whose transcript is at
+// path (~/.do/budget/sessions/<hash>.json), so concurrent sessions do not
+// evict each other.
+func sessionCachePath(path string) string {
+	sum := sha256.Sum256([]byte(path))
+	return filepath.Join(cacheDir(), "sessions", hex.EncodeToString(sum[:8])+".json")
+}
+
+func cacheDir() string {
+	home, err := os.UserHomeDir()
+	if err != nil {
+		home = "."
+	}
+	return filepath.Join(home, ".do", "budget")
+}
+
+// Today returns today's spend across all local transcripts, reusing a
+// result computed less than DailyCacheTTL ago, so frequent callers like
+// the status line do not rescan transcripts on every render.
+func Today(now time.Time) Spend {
+	day := now.Format("2006-01-02")
+	path := GetCachePath()
+	var c dailyCache
+	if readCache(path, &c) && c.Day == day && now.Sub(c.ComputedAt) >= 0 && now.Sub(c.ComputedAt) < DailyCacheTTL {
+		return c.Spend
+	}
+	c = dailyCache{Day: day, ComputedAt: now, Spend: DailySpend(rank.FindAllTranscripts(), now, Prices())}
+	writeCache(path, c)
+	return c.Spend
+}
+
+// TodayBy is Today for callers with a time budget, like the status line.
+// When today's spend is not measured before deadline, the last result
+// computed today is returned, however old; ok is false when there is none.
+// As with SessionBy, the prompt hook keeps that result fresh.
+func TodayBy(now, deadline time.Time) (spend Spend, ok bool) {
+	if spend, ok := runBy(deadline, func() (Spend, error) { return Today(now), nil }); ok {
+		return spend, true
+	}
+	var c dailyCache
+	if readCache(GetCachePath(), &c) && c.Day == now.Format("2006-01-02") {
+		return c.Spend, true
+	}
+	return Spend{}, false
+}
+
+// runBy runs measure, waiting for it until deadline. ok is false when
+// measure failed or was still running at the deadline.
+func runBy(deadline time.Time, measure func() (Spend, error)) (Spend, bool) {
+	if !time.Now().Before(deadline) {
+		return Spend{}, false
+	}
+	type result struct {
+		spend Spend
+		err   error
+	}
+	done := make(chan result, 1)
+	go func() {
+		spend, err := measure()
+		done <- result{spend, err}
+	}()
+	timer := time.NewTimer(time.Until(deadline))
+	defer timer.Stop()
+	select {
+	case r := <-done:
+		return r.spend, r.err == nil
+	case <-timer.C:
+		return Spend{}, false
+	}
+}
+
+func readCache(path string, v any) bool {
+	data, err := os.ReadFile(path)
+	return err == nil && json.Unmarshal(data, v) == nil
+}
+
+func writeCache(path string, v any) {
+	data, err := json.Marshal(v)
+	if err != nil || os.MkdirAll(filepath.Dir(path), 0755) != nil {
+		return
+	}
+	tmp := path + ".tmp"
+	if os.WriteFile(tmp, data, 0644) == nil && os.Rename(tmp, path) != nil {
+		_ = os.Remove(tmp)
+	}
+}
@@ -0,0 +1,256 @@
+package cli
+
+import (
+	"context"
+	"fmt"
+	"strings"
+	"text/tabwriter"
+	"time"
+
+	"github.com/spf13/cobra"
+	"github.com/yejune/godo/internal/backend"
+)
+
+var backendCmd = &cobra.Command{
+	Use:   "backend",
+	Short: "Manage model backends for Claude Code",
+	Long: `A backend is an Anthropic-compatible API that Claude Code is pointed at
+through ANTHROPIC_BASE_URL, ANTHROPIC_AUTH_TOKEN and the
+ANTHROPIC_DEFAULT_{HAIKU,SONNET,OPUS}_MODEL mapping. Backends are stored in
+~/.do/backend/backends.yaml and used with 'godo claude --backend <name>', or
+by default after 'godo backend use <name>'.
+
+Examples:
+  godo backend add glm --preset glm --token-env ZAI_API_KEY
+  godo backend add proxy --base-url http://localhost:4000 \
+      --token-cmd 'pass show proxy' --sonnet my-model --env API_TIMEOUT_MS=600000
+  godo backend check proxy
+  godo backend use proxy
+
+Presets (usable without 'add'): ` + strings.Join(backend.PresetNames(), ", ") + `.`,
+	Args: cobra.NoArgs,
+	RunE: runBackendList,
+}
+
+var backendListCmd = &cobra.Command{
+	Use:   "list",
+	Short: "List backends and presets",
+	Args:  cobra.NoArgs,
+	RunE:  runBackendList,
+}
+
+var backendAddCmd = &cobra.Command{
+	Use:   "add <name>",
+	Short: "Register a backend",
+	Args:  cobra.ExactArgs(1),
+	RunE:  runBackendAdd,
+}
+
+var backendUseCmd = &cobra.Command{
+	Use:   "use <name|none>",
+	Short: "Set the backend 'godo claude' uses by default",
+	Args:  cobra.ExactArgs(1),
+	RunE:  runBackendUse,
+}
+
+var backendRemoveCmd = &cobra.Command{
+	Use:     "remove <name>",
+	Aliases: []string{"rm"},
+	Short:   "Remove a backend",
+	Args:    cobra.ExactArgs(1),
+	RunE:    runBackendRemove,
+}
+
+var backendCheckCmd = &cobra.Command{
+	Use:   "check [name]",
+	Short: "Send a one-token request to check URL, token and model",
+	Args:  cobra.MaximumNArgs(1),
+	RunE:  runBackendCheck,
+}
+
+var (
+	backendPreset    string
+	backendBaseURL   string
+	backendToken     backend.TokenSource
+	backendModels    backend.Models
+	backendEnv       []string
+	backendForce     bool
+	backendAddAndUse bool
+)
+
+func init() {
+	f := backendAddCmd.Flags()
+	f.StringVar(&backendPreset, "preset", "", "start from a preset ("+strings.Join(backend.PresetNames(), ", ")+")")
+	f.StringVar(&backendBaseURL, "base-url", "", "Anthropic-compatible API base URL")
+	f.StringVar(&backendToken.Value, "token", "", "auth token, stored in the config file")
+	f.StringVar(&backendToken.Env, "token-env", "", "read the auth token from this environment variable")
+	f.StringVar(&backendToken.Command, "token-cmd", "", "read the auth token from this shell command's output")
+	f.StringVar(&backendToken.File, "token-file", "", "read the auth token from this file")
+	f.StringVar(&backendModels.Haiku, "haiku", "", "model used for the haiku tier")
+	f.StringVar(&backendModels.Sonnet, "sonnet", "", "model used for the sonnet tier")
+	f.StringVar(&backendModels.Opus, "opus", "", "model used for the opus tier")
+	f.StringArrayVar(&backendEnv, "env", nil, "extra environment variable KEY=VALUE (repeatable)")
+	f.BoolVar(&backendForce, "force", false, "replace an existing backend of the same name")
+	f.BoolVar(&backendAddAndUse, "use", false, "also make it the default backend")
+
+	backendCmd.AddCommand(backendListCmd, backendAddCmd, backendUseCmd, backendRemoveCmd, backendCheckCmd)
+	rootCmd.AddCommand(backendCmd)
+}
+
+func runBackendList(cmd *cobra.Command, args []string) error {
+	cfg, err := backend.Load()
+	if err != nil {
+		return err
+	}
+	out := cmd.OutOrStdout()
+	w := tabwriter.NewWriter(out, 0, 0, 2, ' ', 0)
+	fmt.Fprintln(w, "  NAME\tBASE URL\tMODELS (haiku/sonnet/opus)\tTOKEN")
+	row := func(name string, b *backend.Backend, note string) {
+		marker := " "
+		if name == cfg.Default {
+			marker = "*"
+		}
+		fmt.Fprintf(w, "%s %s\t%s\t%s\t%s%s\n", marker, name, b.BaseURL, formatModels(b.Models), b.Token.Describe(), note)
+	}
+	for _, name := range cfg.Names() {
+		row(name, cfg.Backends[name], "")
+	}
+	for _, name := range backend.PresetNames() {
+		if _, ok := cfg.Backends[name]; !ok {
+			b, _ := backend.Preset(name)
+			row(name, b, " (preset)")
+		}
+	}
+	if err := w.Flush(); err != nil {
+		return err
+	}
+	if cfg.Default == "" {
+		fmt.Fprintln(out, "No default backend: 'godo claude' uses Anthropic directly.")
+	}
+	return nil
+}
+
+func formatModels(m backend.Models) string {
+	tiers := []string{m.Haiku, m.Sonnet, m.Opus}
+	for i, t := range tiers {
+		if t == "" {
+			tiers[i] = "-"
+		}
+	}
+	return strings.Join(tiers, "/")
+}
+
+func runBackendAdd(cmd *cobra.Command, args []string) error {
+	name := args[0]
+	b := &backend.Backend{}
+	if backendPreset != "" {
+		p, ok := backend.Preset(backendPreset)
+		if !ok {
+			return fmt.Errorf("unknown preset %q (available: %s)", backendPreset, strings.Join(backend.PresetNames(), ", "))
+		}
+		b = p
+	}
+	if backendBaseURL != "" {
+		b.BaseURL = strings.TrimRight(backendBaseURL, "/")
+	}
+	if backendToken != (backend.TokenSource{}) {
+		b.Token = backendToken
+	}
+	if backendModels.Haiku != "" {
+		b.Models.Haiku = backendModels.Haiku
+	}
+	if backendModels.Sonnet != "" {
+		b.Models.Sonnet = backendModels.Sonnet
+	}
+	if backendModels.Opus != "" {
+		b.Models.Opus = backendModels.Opus
+	}
+	for _, kv := range backendEnv {
+		k, v, ok := strings.Cut(kv, "=")
+		if !ok {
+			return fmt.Errorf("invalid --env %q, want KEY=VALUE", kv)
+		}
+		if b.Env == nil {
+			b.Env = make(map[string]string)
+		}
+		b.Env[k] = v
+	}
+
+	cfg, err := backend.Load()
+	if err != nil {
+		return err
+	}
+	if err := cfg.Add(name, b, backendForce); err != nil {
+		return fmt.Errorf("add backend: %w", err)
+	}
+	if backendAddAndUse {
+		cfg.Default = name
+	}
+	if err := cfg.Save(); err != nil {
+		return err
+	}
+	fmt.Fprintf(cmd.OutOrStdout(), "Backend %s added (%s, token: %s)\n", name, b.BaseURL, b.Token.Describe())
+	if backendAddAndUse {
+		fmt.Fprintf(cmd.OutOrStdout(), "Default backend: %s\n", name)
+	}
+	return nil
+}
+
+func runBackendUse(cmd *cobra.Command, args []string) error {
+	cfg, err := backend.Load()
+	if err != nil {
+		return err
+	}
+	if err := cfg.Use(args[0]); err != nil {
+		return fmt.Errorf("use backend: %w", err)
+	}
+	if err := cfg.Save(); err != nil {
+		return err
+	}
+	if cfg.Default == "" {
+		fmt.Fprintln(cmd.OutOrStdout(), "No default backend: 'godo claude' uses Anthropic directly.")
+		return nil
+	}
+	fmt.Fprintf(cmd.OutOrStdout(), "Default backend: %s\n", cfg.Default)
+	return nil
+}
+
+func runBackendRemove(cmd *cobra.Command, args []string) error {
+	cfg, err := backend.Load()
+	if err != nil {
+		return err
+	}
+	if err := cfg.Remove(args[0]); err != nil {
+		return fmt.Errorf("remove backend: %w", err)
+	}
+	if err := cfg.Save(); err != nil {
+		return err
+	}
+	fmt.Fprintf(cmd.OutOrStdout(), "Backend %s removed\n", args[0])
+	return nil
+}
+
+func runBackendCheck(cmd *cobra.Command, args []string) error {
+	cfg, err := backend.Load()
+	if err != nil {
+		return err
+	}
+	name := cfg.Default
+	if len(args) == 1 {
+		name = args[0]
+	}
+	if name == "" {
+		return fmt.Errorf("no default backend; usage: godo backend check <name>")
+	}
+	b, err := cfg.Resolve(name)
+	if err != nil {
+		return err
+	}
+	res, err := backend.Check(context.Background(), b, nil)
+	if err != nil {
+		return fmt.Errorf("backend %s: %w", name, err)
+	}
+	fmt.Fprintf(cmd.OutOrStdout(), "Backend %s OK: %s answered with %s in %s\n",
+		name, b.BaseURL, res.Model, res.Latency.Round(time.Millisecond))
+	return nil
+}
@@ -0,0 +1,72 @@
+package cli
+
+import (
+	"fmt"
+	"os"
+	"text/tabwriter"
+	"time"
+
+	"github.com/spf13/cobra"
+	"github.com/yejune/godo/internal/budget"
+)
+
+var budgetCmd = &cobra.Command{
+	Use:   "budget",
+	Short: "Show spend against the session and daily budgets",
+	Long: `Budget shows today's spend, computed from local transcripts, against
+the budgets in .do/budget.yaml (or ~/.do/budget.yaml):
+
+  session: {usd: 5}
+  daily:   {usd: 20, tokens: 5000000}
+  warn: true   # remind the assistant on each prompt once a budget is exceeded
+
+Tokens count input and output tokens. The "budget" statusline segment turns
+yellow at 80% and red at 100% of the budget closest to its limit.`,
+	Args: cobra.NoArgs,
+	RunE: runBudget,
+}
+
+func init() {
+	rootCmd.AddCommand(budgetCmd)
+}
+
+func runBudget(cmd *cobra.Command, args []string) error {
+	dir, err := os.Getwd()
+	if err != nil {
+		return fmt.Errorf("get working directory: %w", err)
+	}
+	cfg, err := budget.Load(dir)
+	if err != nil {
+		return err
+	}
+	out := cmd.OutOrStdout()
+	if !cfg.Enabled() {
+		fmt.Fprintf(out, "No budget set. Add one to %s or ~/%s.\n", budget.ConfigFile, budget.ConfigFile)
+		return nil
+	}
+
+	// The session budget is checked by the status line and the hook, which
+	// know the current session; here only today's spend is measured.
+	statuses := cfg.Check(budget.Spend{}, budget.Today(time.Now()))
+	w := tabwriter.NewWriter(out, 0, 0, 2, ' ', 0)
+	fmt.Fprintln(w, "BUDGET\tUSED\tLIMIT\t%")
+	for _, st := range statuses {
+		if st.Period == budget.PeriodSession {
+			fmt.Fprintf(w, "%s (%s)\t-\t%s\t-\n", st.Period, st.Unit, budget.FormatAmount(st.Unit, st.Limit))
+			continue
+		}
+		state := ""
+		if st.Exceeded() {
+			state = " exceeded"
+		}
+		fmt.Fprintf(w, "%s (%s)\t%s\t%s\t%.0f%%%s\n", st.Period, st.Unit,
+			budget.FormatAmount(st.Unit, st.Used), budget.FormatAmount(st.Unit, st.Limit), st.Ratio()*100, state)
+	}
+	if err := w.Flush(); err != nil {
+		return err
+	}
+	if !cfg.Warn {
+		fmt.Fprintln(out, "\nPrompt warnings are off (set warn: true to enable).")
+	}
+	return nil
+}
@@ -4,9 +4,11 @@ import (
 	"fmt"
 	"os"
 	"os/exec"
+	"strings"
 	"syscall"
 
 	"github.com/spf13/cobra"
+	"github.com/yejune/godo/internal/backend"
 	"github.com/yejune/godo/internal/profile"
 )
 
@@ -15,7 +17,13 @@ var claudeCmd = &cobra.Command{
 	Short: "Launch Claude Code with configured flags",
 	Long: `Launch Claude Code with flags configured via 'godo setup'.
 Reads DO_CLAUDE_* settings from .claude/settings.local.json.
-Pass additional arguments to Claude Code after --.`,
+Pass additional arguments to Claude Code after --.
+
+--backend <name> points Claude Code at a model backend registered with
+'godo backend add' (or a preset such as glm); without it the default set by
+'godo backend use' applies. A backend unsets the ANTHROPIC_* variables it
+does not set, ANTHROPIC_API_KEY included; --backend none unsets them all and
+talks to Anthropic directly.`,
 	Aliases:            []string{"cc"},
 	RunE:               runClaude,
 	DisableFlagParsing: true,
@@ -30,7 +38,8 @@ func runClaude(cmd *cobra.Command, args []string) error {
 		return runClaudeProfileCompat(cmd, args[1:])
 	}
 
-	profileName, filteredArgs := parseClaudeProfileFlag(args)
+	backendName, filteredArgs := parseClaudeBackendFlag(args)
+	profileName, filteredArgs := parseClaudeProfileFlag(filteredArgs)
 	if profileName != "" && profileName != "default" {
 		if err := profile.EnsureDir(profileName); err != nil {
 			return fmt.Errorf("set profile: %w", err)
@@ -38,6 +47,10 @@ func runClaude(cmd *cobra.Command, args []string) error {
 		fmt.Fprintf(cmd.ErrOrStderr(), "Profile: %s\n", profileName)
 	}
 
+	if err := applyClaudeBackend(cmd, backendName); err != nil {
+		return err
+	}
+
 	claudeBin, err := exec.LookPath("claude")
 	if err != nil {
 		return fmt.Errorf("claude not found in PATH. Install Claude Code first")
@@ -116,6 +129,64 @@ func parseClaudeProfileFlag(args []string) (string, []string) {
 	return profileName, filtered
 }
 
+func parseClaudeBackendFlag(args []string) (string, []string) {
+	var name string
+	filtered := make([]string, 0, len(args))
+
+	for i := 0; i < len(args); i++ {
+		if args[i] == "--" {
+			filtered = append(filtered, args[i:]...)
+			break
+		}
+		if v, ok := strings.CutPrefix(args[i], "--backend="); ok {
+			name = v
+			continue
+		}
+		if args[i] == "--backend" && i+1 < len(args) {
+			name = args[i+1]
+			i++
+			continue
+		}
+		filtered = append(filtered, args[i])
+	}
+
+	return name, filtered
+}
+
+// applyClaudeBackend sets the environment of the named backend, or of the
+// default backend when name is empty. Registered backends take precedence
+// over presets of the same name. With "none" every ANTHROPIC_* variable is
+// unset, so no backend leaks in from the shell.
+func applyClaudeBackend(cmd *cobra.Command, name string) error {
+	cfg, err := backend.Load()
+	if err != nil {
+		return err
+	}
+	if name == "" {
+		name = cfg.Default
+	}
+	if name == "" {
+		return nil
+	}
+	if name == backend.None {
+		if err := backend.Clear(); err != nil {
+			return fmt.Errorf("clear backend: %w", err)
+		}
+		fmt.Fprintln(cmd.ErrOrStderr(), "Backend: none (Anthropic)")
+		return nil
+	}
+
+	b, err := cfg.Resolve(name)
+	if err != nil {
+		return fmt.Errorf("%w. Run 'godo backend list'", err)
+	}
+	if err := b.Apply(); err != nil {
+		return fmt.Errorf("apply backend %s: %w", name, err)
+	}
+	fmt.Fprintf(cmd.ErrOrStderr(), "Backend: %s (%s)\n", name, b.BaseURL)
+	return nil
+}
+
 func runClaudeProfileCompat(cmd *cobra.Command, args []string) error {
 	if len(args) == 0 {
 		return fmt.Errorf("usage: godo claude profile <list|current|delete>")
@@ -0,0 +1,26 @@
+package cli
+
+import (
+	"errors"
+	"fmt"
+)
+
+// ExitError asks main to exit with Code. Commands return it instead of
+// calling os.Exit so they stay testable; the command has already reported
+// the failure, so Execute prints nothing more.
+type ExitError struct {
+	Code int
+}
+
+func (e *ExitError) Error() string {
+	return fmt.Sprintf("exit status %d", e.Code)
+}
+
+// ExitCode returns the process exit code for an error returned by Execute.
+func ExitCode(err error) int {
+	var exitErr *ExitError
+	if errors.As(err, &exitErr) {
+		return exitErr.Code
+	}
+	return 1
+}
@@ -6,14 +6,16 @@ import (
 	"strings"
 
 	"github.com/spf13/cobra"
+	"github.com/yejune/godo/internal/backend"
 	"github.com/yejune/godo/internal/glm"
 )
 
 var glmCmd = &cobra.Command{
 	Use:   "glm",
 	Short: "Launch Claude Code with GLM backend",
-	Long:  `Without subcommands, runs Claude Code using stored GLM credentials.`,
-	RunE:  runGLM,
+	Long: `Without subcommands, runs Claude Code using stored GLM credentials.
+Same as 'godo claude --backend glm'; see 'godo backend' for other backends.`,
+	RunE: runGLM,
 }
 
 var glmSetupCmd = &cobra.Command{
@@ -44,17 +46,24 @@ func init() {
 }
 
 func runGLM(cmd *cobra.Command, args []string) error {
-	creds, err := glm.LoadCredentials()
+	cfg, err := backend.Load()
 	if err != nil {
-		return fmt.Errorf("load GLM credentials: %w", err)
+		return err
 	}
-	if creds == nil || creds.APIKey == "" {
-		return fmt.Errorf("GLM API key is not configured. Run 'godo glm setup'")
+	// A registered glm backend brings its own token source; the preset
+	// reads the key stored by 'godo glm setup'.
+	if _, err := cfg.Get("glm"); err != nil {
+		creds, err := glm.LoadCredentials()
+		if err != nil {
+			return fmt.Errorf("load GLM credentials: %w", err)
+		}
+		if creds == nil || creds.APIKey == "" {
+			return fmt.Errorf("GLM API key is not configured. Run 'godo glm setup'")
+		}
 	}
 
-	glm.SetGLMEnv(creds.APIKey)
 	fmt.Fprintln(cmd.OutOrStdout(), "Launching Claude Code with GLM backend")
-	return runClaude(cmd, nil)
+	return runClaude(cmd, []string{"--backend", "glm"})
 }
 
 func runGLMSetup(cmd *cobra.Command, args []string) error {
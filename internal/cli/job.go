@@ -0,0 +1,326 @@
+package cli
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+	"time"
+
+	"github.com/spf13/cobra"
+	"github.com/yejune/godo/internal/hook"
+	"github.com/yejune/godo/internal/jobreport"
+)
+
+var jobCmd = &cobra.Command{
+	Use:   "job",
+	Short: "Inspect and manage .do/jobs workflow state",
+	Long: `Job works with the job directories under .do/jobs/{YY}/{MM}/{DD}/{title}/.
+A job may be referenced by its directory path or its title; without a
+reference the latest job is used.`,
+}
+
+var jobPhaseCmd = &cobra.Command{
+	Use:   "phase [job]",
+	Short: "Show the workflow phases of a job",
+	Args:  cobra.MaximumNArgs(1),
+	RunE:  runJobPhase,
+}
+
+var jobPhaseAdvanceCmd = &cobra.Command{
+	Use:   "advance [job]",
+	Short: "Complete the current phase and start the next one",
+	Long: `Advance validates the transition against the job's workflow definition
+(simple or complex), checks the entry criteria of the target phase
+(required artifacts, checklist completion), and timestamps the change in
+state.json. Use --to to take a non-forward path such as test -> develop.`,
+	Args: cobra.MaximumNArgs(1),
+	RunE: runJobPhaseAdvance,
+}
+
+var jobUseCmd = &cobra.Command{
+	Use:   "use [job]",
+	Short: "Set the current job pointer (.do/.current-job)",
+	Long: `Use marks a job as the current one. The Stop hook blocks only on the
+current session's job and treats other in-progress jobs as warnings.
+With --session the job is also bound to a Claude Code session ID.
+With --clear the pointer is removed.`,
+	Args: cobra.MaximumNArgs(1),
+	RunE: runJobUse,
+}
+
+var jobReportCmd = &cobra.Command{
+	Use:   "report [job]",
+	Short: "Generate a Markdown or HTML summary of a job",
+	Long: `Report combines the phase and agent timelines from state.json, checklist
+item history and the time-in-status metrics of 'godo job stats' (both from
+checklist-history.jsonl), git commits made during the job window and the
+files they changed, and token usage from the job's session transcript.`,
+	Args: cobra.MaximumNArgs(1),
+	RunE: runJobReport,
+}
+
+var jobHistoryCmd = &cobra.Command{
+	Use:   "history [job]",
+	Short: "Show checklist status transitions of a job",
+	Long: `History prints the append-only transition log (checklist-history.jsonl)
+kept beside checklist.md. Hooks record transitions as checklists are
+edited; the checklists are re-scanned first so manual edits are included.`,
+	Args: cobra.MaximumNArgs(1),
+	RunE: runJobHistory,
+}
+
+var jobStatsCmd = &cobra.Command{
+	Use:   "stats [job]",
+	Short: "Show cycle time, blocked time and rework per agent",
+	Long: `Stats computes time-in-status metrics from the checklist history:
+cycle time (first [~] to last [o]), time spent blocked in [!], and rework
+(items sent back to [~] after [o] or [*]), per agent and for the job.`,
+	Args: cobra.MaximumNArgs(1),
+	RunE: runJobStats,
+}
+
+var (
+	jobReportFormat     string
+	jobReportOutput     string
+	jobReportTranscript string
+)
+
+var (
+	jobPhaseTo   string
+	jobUseSessID string
+	jobUseClear  bool
+)
+
+func init() {
+	jobPhaseAdvanceCmd.Flags().StringVar(&jobPhaseTo, "to", "", "target phase (default: next phase in the workflow)")
+	jobPhaseCmd.AddCommand(jobPhaseAdvanceCmd)
+	jobUseCmd.Flags().StringVar(&jobUseSessID, "session", "", "bind the job to this session ID")
+	jobUseCmd.Flags().BoolVar(&jobUseClear, "clear", false, "clear the current job pointer")
+	jobReportCmd.Flags().StringVar(&jobReportFormat, "format", "md", "output format: md or html")
+	jobReportCmd.Flags().StringVarP(&jobReportOutput, "output", "o", "", "write the report to a file instead of stdout")
+	jobReportCmd.Flags().StringVar(&jobReportTranscript, "transcript", "", "session transcript path (default: looked up by the job's session ID)")
+	jobCmd.AddCommand(jobHistoryCmd)
+	jobCmd.AddCommand(jobPhaseCmd)
+	jobCmd.AddCommand(jobReportCmd)
+	jobCmd.AddCommand(jobStatsCmd)
+	jobCmd.AddCommand(jobUseCmd)
+	rootCmd.AddCommand(jobCmd)
+}
+
+// loadJob resolves a job reference from args and loads its state.json.
+func loadJob(args []string) (string, *hook.JobState, error) {
+	ref := ""
+	if len(args) > 0 {
+		ref = args[0]
+	}
+	jobDir, err := hook.ResolveJobDir(ref)
+	if err != nil {
+		return "", nil, err
+	}
+	state, err := hook.LoadJobState(filepath.Join(jobDir, "state.json"))
+	if err != nil {
+		return "", nil, fmt.Errorf("load job state: %w", err)
+	}
+	return jobDir, state, nil
+}
+
+func runJobPhase(cmd *cobra.Command, args []string) error {
+	jobDir, state, err := loadJob(args)
+	if err != nil {
+		return err
+	}
+	wf, err := hook.WorkflowFor(state.WorkflowType)
+	if err != nil {
+		return err
+	}
+
+	out := cmd.OutOrStdout()
+	fmt.Fprintf(out, "%s (%s workflow)\n", jobDir, wf.Type)
+	for _, name := range wf.PhaseNames() {
+		p := state.Phases[name]
+		status := p.Status
+		if status == "" {
+			status = hook.PhasePending
+		}
+		line := fmt.Sprintf("  %-13s %-12s", name, status)
+		if p.StartedAt != "" {
+			line += " started " + p.StartedAt
+		}
+		if p.CompletedAt != "" {
+			line += " completed " + p.CompletedAt
+		}
+		fmt.Fprintln(out, strings.TrimRight(line, " "))
+	}
+	return nil
+}
+
+func runJobPhaseAdvance(cmd *cobra.Command, args []string) error {
+	jobDir, state, err := loadJob(args)
+	if err != nil {
+		return err
+	}
+
+	tr, err := hook.AdvancePhase(jobDir, state, jobPhaseTo, time.Now())
+	if err != nil {
+		return fmt.Errorf("advance phase: %w", err)
+	}
+	if err := hook.SaveJobState(filepath.Join(jobDir, "state.json"), state); err != nil {
+		return fmt.Errorf("save job state: %w", err)
+	}
+
+	out := cmd.OutOrStdout()
+	switch {
+	case tr.From == "":
+		fmt.Fprintf(out, "Phase started: %s\n", tr.To)
+	case tr.To == "":
+		fmt.Fprintf(out, "Phase complete: %s (workflow finished)\n", tr.From)
+	default:
+		fmt.Fprintf(out, "Phase advanced: %s -> %s\n", tr.From, tr.To)
+	}
+	return nil
+}
+
+func runJobUse(cmd *cobra.Command, args []string) error {
+	if jobUseClear {
+		if err := hook.WriteCurrentJob(""); err != nil {
+			return fmt.Errorf("clear current job: %w", err)
+		}
+		fmt.Fprintln(cmd.OutOrStdout(), "Current job cleared")
+		return nil
+	}
+	if len(args) == 0 {
+		current := hook.ReadCurrentJob()
+		if current == "" {
+			current = "(none)"
+		}
+		fmt.Fprintln(cmd.OutOrStdout(), current)
+		return nil
+	}
+
+	jobDir, err := hook.ResolveJobDir(args[0])
+	if err != nil {
+		return err
+	}
+	if jobUseSessID != "" {
+		statePath := filepath.Join(jobDir, "state.json")
+		state, err := hook.LoadJobState(statePath)
+		if err != nil {
+			return fmt.Errorf("load job state: %w", err)
+		}
+		state.SessionID = jobUseSessID
+		if err := hook.SaveJobState(statePath, state); err != nil {
+			return fmt.Errorf("save job state: %w", err)
+		}
+	}
+	if err := hook.WriteCurrentJob(jobDir); err != nil {
+		return fmt.Errorf("set current job: %w", err)
+	}
+	fmt.Fprintf(cmd.OutOrStdout(), "Current job: %s\n", jobDir)
+	return nil
+}
+
+func runJobReport(cmd *cobra.Command, args []string) error {
+	jobDir, _, err := loadJob(args)
+	if err != nil {
+		return err
+	}
+
+	report, err := jobreport.Build(jobDir, jobreport.Options{TranscriptPath: jobReportTranscript})
+	if err != nil {
+		return fmt.Errorf("build report: %w", err)
+	}
+
+	var content string
+	switch strings.ToLower(jobReportFormat) {
+	case "md", "markdown":
+		content = report.Markdown()
+	case "html":
+		content, err = report.HTML()
+		if err != nil {
+			return err
+		}
+	default:
+		return fmt.Errorf("invalid format %q (valid: md, html)", jobReportFormat)
+	}
+
+	if jobReportOutput == "" {
+		fmt.Fprint(cmd.OutOrStdout(), content)
+		return nil
+	}
+	if err := os.WriteFile(jobReportOutput, []byte(content), 0644); err != nil {
+		return fmt.Errorf("write report: %w", err)
+	}
+	fmt.Fprintf(cmd.OutOrStdout(), "Report written: %s\n", jobReportOutput)
+	return nil
+}
+
+// loadJobHistory resolves a job, records any transitions not yet in the
+// log, and returns the full history.
+func loadJobHistory(args []string) (string, []hook.HistoryEntry, error) {
+	jobDir, _, err := loadJob(args)
+	if err != nil {
+		return "", nil, err
+	}
+	if _, err := hook.SyncJobHistory(jobDir, "", time.Now()); err != nil {
+		return "", nil, fmt.Errorf("sync checklist history: %w", err)
+	}
+	entries, err := hook.LoadHistory(jobDir)
+	if err != nil {
+		return "", nil, fmt.Errorf("load checklist history: %w", err)
+	}
+	return jobDir, entries, nil
+}
+
+func runJobHistory(cmd *cobra.Command, args []string) error {
+	jobDir, entries, err := loadJobHistory(args)
+	if err != nil {
+		return err
+	}
+
+	out := cmd.OutOrStdout()
+	fmt.Fprintf(out, "%s (%d transitions)\n", jobDir, len(entries))
+	for _, e := range entries {
+		from := "new"
+		if e.From != "" {
+			from = "[" + e.From + "]"
+		}
+		agent := e.Agent
+		if agent == "" {
+			agent = "(main)"
+		}
+		fmt.Fprintf(out, "  %s  %-20s %-6s %s -> [%s]\n", e.Time, agent, e.Item, from, e.To)
+	}
+	return nil
+}
+
+func runJobStats(cmd *cobra.Command, args []string) error {
+	jobDir, entries, err := loadJobHistory(args)
+	if err != nil {
+		return err
+	}
+	stats := hook.ComputeStats(entries, time.Now())
+
+	out := cmd.OutOrStdout()
+	fmt.Fprintln(out, jobDir)
+	fmt.Fprintf(out, "  %-20s %5s %5s %10s %10s %6s\n", "AGENT", "ITEMS", "DONE", "AVG CYCLE", "BLOCKED", "REWORK")
+	row := func(g hook.GroupStats) {
+		fmt.Fprintf(out, "  %-20s %5d %5d %10s %10s %6d\n",
+			g.Name, g.Items, g.Done, roundDuration(g.AvgCycleTime()), roundDuration(g.Blocked), g.Rework)
+	}
+	for _, g := range stats.Agents {
+		row(g)
+	}
+	job := stats.Job
+	job.Name = "(job)"
+	row(job)
+	return nil
+}
+
+// roundDuration trims a duration to whole seconds for display.
+func roundDuration(d time.Duration) string {
+	if d == 0 {
+		return "-"
+	}
+	return d.Round(time.Second).String()
+}
@@ -2,9 +2,12 @@ package cli
 
 import (
 	"bufio"
+	"errors"
 	"fmt"
 	"os"
+	"slices"
 	"strings"
+	"time"
 
 	"github.com/spf13/cobra"
 	"github.com/yejune/godo/internal/lint"
@@ -13,21 +16,123 @@ import (
 var lintCmd = &cobra.Command{
 	Use:   "lint [files...]",
 	Short: "Run language-aware linters on changed or specified files",
-	Long: `Lint detects languages from file extensions and runs the appropriate linter
-(go vet, ruff, tsc, eslint, cargo clippy). Without arguments, lints git-changed files.`,
+	Long: `Lint runs every linter whose file globs match the given files. The built-in
+linters (go vet, ruff, tsc, eslint, cargo clippy) can be overridden, disabled
+or extended in .do/lint.yaml. Without arguments, lints git-changed files.
+
+With --check the formatters (gofmt, ruff format, prettier, rustfmt) run
+instead of the linters. Every file they would reformat is reported as an
+error with rule "format", so CI fails on unformatted code; entries with
+kind: format in .do/lint.yaml add more formatters.
+
+Files are grouped by their nearest module root (go.mod, pyproject.toml,
+tsconfig.json, package.json, Cargo.toml); each linter runs once per root,
+in parallel, and the results are merged.
+
+With --fix the auto-fixers (ruff --fix, eslint --fix, cargo clippy --fix)
+and the formatters (goimports/gofmt, ruff format, prettier --write,
+rustfmt) rewrite the files, a diff is shown per changed
+file, and the files are linted again. Files with uncommitted changes are
+left alone unless --force is given, so every fix can be reviewed and undone
+with git.
+
+Results are cached in .do/cache/lint, keyed by file content, the linter's
+config files and its version, so unchanged files are not linted again;
+--no-cache bypasses the cache and "godo lint cache" shows its statistics.
+
+With --changed-lines only diagnostics on lines added or modified since HEAD
+are reported; --base <ref> compares against the merge base with ref instead,
+which suits pull request checks in CI.`,
 	RunE: runLint,
 }
 
 var lintSetupCmd = &cobra.Command{
 	Use:   "setup",
 	Short: "Interactive linter installation",
-	RunE:  runLintSetup,
+	Long: `Setup detects the project's languages and offers to install missing
+linters with the package managers found on the system.
+
+With --local the tools pinned in .do/tools.lock are installed into
+.do/tools instead, and linters run from .do/tools/bin in preference to
+PATH, so every teammate and CI runner uses the same versions. Without a
+lock file one is written with default pins for the detected languages.
+--mirror <dir> (or mirror: in the lock file) installs from a local mirror
+with no network access: pip/ holds wheels, npm/ is an npm cache, go/ is a
+GOPROXY file tree and archive/ holds release archives.`,
+	Args: cobra.NoArgs,
+	RunE: runLintSetup,
+}
+
+var lintListCmd = &cobra.Command{
+	Use:   "list",
+	Short: "List registered linters and formatters and whether they are installed",
+	RunE:  runLintList,
 }
 
-var lintAll bool
+var lintBaselineCmd = &cobra.Command{
+	Use:   "baseline",
+	Short: "Accept current diagnostics into .do/lint-baseline.json",
+	Long: `Baseline lints all tracked files and records every diagnostic, fingerprinted
+by file, rule and normalized source line, in .do/lint-baseline.json. Later
+lint runs report and fail only on diagnostics not in the baseline.`,
+	Args: cobra.NoArgs,
+	RunE: runLintBaseline,
+}
+
+var lintBaselinePruneCmd = &cobra.Command{
+	Use:   "prune",
+	Short: "Remove baseline entries that have been fixed",
+	Args:  cobra.NoArgs,
+	RunE:  runLintBaselinePrune,
+}
+
+var lintCacheCmd = &cobra.Command{
+	Use:   "cache",
+	Short: "Show lint cache size and hit statistics",
+	Args:  cobra.NoArgs,
+	RunE:  runLintCache,
+}
+
+var lintCacheClearCmd = &cobra.Command{
+	Use:   "clear",
+	Short: "Remove all cached lint results",
+	Args:  cobra.NoArgs,
+	RunE:  runLintCacheClear,
+}
+
+var (
+	lintAll        bool
+	lintFormat     string
+	lintNoBaseline bool
+	lintChanged    bool
+	lintBase       string
+	lintJobs       int
+	lintFix        bool
+	lintForce      bool
+	lintCheck      bool
+	lintNoCache    bool
+	lintLocal      bool
+	lintMirror     string
+)
 
 func init() {
 	lintCmd.Flags().BoolVar(&lintAll, "all", false, "lint all project files instead of only changed files")
+	lintCmd.Flags().StringVar(&lintFormat, "format", lint.OutputText, "output format: "+strings.Join(lint.OutputFormats(), ", "))
+	lintCmd.Flags().BoolVar(&lintNoBaseline, "no-baseline", false, "report diagnostics accepted in the baseline too")
+	lintCmd.Flags().BoolVar(&lintChanged, "changed-lines", false, "report only diagnostics on added or modified lines")
+	lintCmd.Flags().StringVar(&lintBase, "base", "", "with --changed-lines, compare against the merge base with this ref (e.g. origin/main)")
+	lintCmd.Flags().IntVarP(&lintJobs, "jobs", "j", 0, "parallel linter invocations (default: concurrency from .do/lint.yaml, else one per CPU)")
+	lintCmd.Flags().BoolVar(&lintFix, "fix", false, "apply linter auto-fixes, show the diff per file, then lint again")
+	lintCmd.Flags().BoolVar(&lintForce, "force", false, "with --fix, also rewrite files that have uncommitted changes")
+	lintCmd.Flags().BoolVar(&lintCheck, "check", false, "run the formatters and report unformatted files instead of linting")
+	lintCmd.PersistentFlags().BoolVar(&lintNoCache, "no-cache", false, "run every linter instead of reusing cached results")
+	lintSetupCmd.Flags().BoolVar(&lintLocal, "local", false, "install the versions pinned in .do/tools.lock into .do/tools")
+	lintSetupCmd.Flags().StringVar(&lintMirror, "mirror", "", "with --local, install offline from this mirror directory")
+	lintBaselineCmd.AddCommand(lintBaselinePruneCmd)
+	lintCacheCmd.AddCommand(lintCacheClearCmd)
+	lintCmd.AddCommand(lintCacheCmd)
+	lintCmd.AddCommand(lintBaselineCmd)
+	lintCmd.AddCommand(lintListCmd)
 	lintCmd.AddCommand(lintSetupCmd)
 	rootCmd.AddCommand(lintCmd)
 }
@@ -38,35 +143,281 @@ func runLint(cmd *cobra.Command, args []string) error {
 		return fmt.Errorf("get working directory: %w", err)
 	}
 
-	var files []string
-	if len(args) > 0 {
-		files = args
-	} else {
-		files = lint.GetChangedFiles(projectDir, lintAll)
+	if !slices.Contains(lint.OutputFormats(), lintFormat) {
+		return fmt.Errorf("invalid format %q (valid: %s)", lintFormat, strings.Join(lint.OutputFormats(), ", "))
+	}
+	full, err := lint.LoadRegistry(projectDir)
+	if err != nil {
+		return err
+	}
+	if lintJobs > 0 {
+		full.Concurrency = lintJobs
+	}
+	if !lintNoCache {
+		full.Cache = lint.NewCache(projectDir)
+		defer full.Cache.SaveStats()
+	}
+	// Plain --fix also formats; --check --fix only formats.
+	reg, fixReg := full.Only(lint.KindLint), full
+	if lintCheck {
+		reg = full.Only(lint.KindFormat)
+		fixReg = reg
 	}
 
-	if len(files) == 0 {
+	var changed lint.ChangedLines
+	if lintChanged || lintBase != "" {
+		changed, err = lint.GitChangedLines(projectDir, lintBase)
+		if err != nil {
+			return err
+		}
+	}
+
+	var files []string
+	switch {
+	case changed != nil && len(args) == 0:
+		for _, f := range changed.Files() {
+			if len(reg.ForFile(f)) > 0 {
+				files = append(files, f)
+			}
+		}
+	default:
+		files = lintFiles(reg, projectDir, args, lintAll)
+	}
+	if len(files) == 0 && lintFormat == lint.OutputText {
 		fmt.Fprintln(cmd.OutOrStdout(), "no files to lint")
 		return nil
 	}
 
-	grouped := lint.GroupFilesByLanguage(files)
+	if lintFix && len(files) > 0 {
+		if err := runLintFix(cmd, fixReg, projectDir, files); err != nil {
+			return err
+		}
+	}
+
 	var allDiags []lint.Diagnostic
+	if len(files) > 0 {
+		allDiags = reg.Run(files, projectDir)
+	}
+	if changed != nil {
+		allDiags = lint.FilterChanged(allDiags, changed)
+	}
+
+	reported := allDiags
+	if !lintNoBaseline {
+		base, err := lint.LoadBaseline(projectDir)
+		switch {
+		case err == nil:
+			var known []lint.Diagnostic
+			reported, known = base.Filter(allDiags, projectDir)
+			if len(known) > 0 {
+				fmt.Fprintf(cmd.ErrOrStderr(), "%d known issues hidden by %s (--no-baseline to show)\n", len(known), lint.BaselineFile)
+			}
+		case !errors.Is(err, os.ErrNotExist):
+			return err
+		}
+	}
+
+	if err := lint.WriteReport(cmd.OutOrStdout(), lintFormat, reported); err != nil {
+		return err
+	}
+	return lintExit(cmd, reported)
+}
+
+// runLintFix applies auto-fixes and prints a diff per changed file. Diffs
+// go to stderr for machine-readable formats so stdout stays parseable.
+func runLintFix(cmd *cobra.Command, reg *lint.Registry, projectDir string, files []string) error {
+	out := cmd.OutOrStdout()
+	if lintFormat != lint.OutputText {
+		out = cmd.ErrOrStderr()
+	}
+
+	fixable := files
+	if !lintForce {
+		dirty := lint.DirtyFiles(projectDir, files)
+		if len(dirty) > 0 {
+			fmt.Fprintf(cmd.ErrOrStderr(), "Skipping %d files with uncommitted changes (--force to fix anyway):\n", len(dirty))
+			for _, f := range dirty {
+				fmt.Fprintf(cmd.ErrOrStderr(), "  %s\n", f)
+			}
+			fixable = nil
+			for _, f := range files {
+				if !slices.Contains(dirty, f) {
+					fixable = append(fixable, f)
+				}
+			}
+		}
+	}
+	if len(fixable) == 0 {
+		return nil
+	}
+
+	changes, err := reg.Fix(fixable, projectDir)
+	var fixErr *lint.FixError
+	if err != nil && !errors.As(err, &fixErr) {
+		return fmt.Errorf("fix: %w", err)
+	}
+	for _, c := range changes {
+		fmt.Fprint(out, c.Diff())
+	}
+	// Fixers that rewrite a whole module may change files beyond fixable.
+	fmt.Fprintf(out, "Fixed %d files (%d requested)\n\n", len(changes), len(fixable))
+	if err != nil {
+		return fmt.Errorf("fix: %w", err)
+	}
+	return nil
+}
+
+// lintFiles returns the explicit args, or the git-changed (or all tracked)
+// files that some registered linter handles.
+func lintFiles(reg *lint.Registry, projectDir string, args []string, all bool) []string {
+	if len(args) > 0 {
+		return args
+	}
+	var files []string
+	for _, f := range lint.ListGitFiles(projectDir, all) {
+		if len(reg.ForFile(f)) > 0 {
+			files = append(files, f)
+		}
+	}
+	return files
+}
+
+// lintProject runs every registered linter and formatter over all tracked
+// files.
+func lintProject() (string, []lint.Diagnostic, error) {
+	projectDir, err := os.Getwd()
+	if err != nil {
+		return "", nil, fmt.Errorf("get working directory: %w", err)
+	}
+	reg, err := lint.LoadRegistry(projectDir)
+	if err != nil {
+		return "", nil, err
+	}
+	if !lintNoCache {
+		reg.Cache = lint.NewCache(projectDir)
+		defer reg.Cache.SaveStats()
+	}
+	files := lintFiles(reg, projectDir, nil, true)
+	if len(files) == 0 {
+		return projectDir, nil, nil
+	}
+	return projectDir, reg.Run(files, projectDir), nil
+}
+
+func runLintBaseline(cmd *cobra.Command, args []string) error {
+	projectDir, diags, err := lintProject()
+	if err != nil {
+		return err
+	}
+	base := lint.NewBaseline(diags, projectDir, time.Now())
+	if err := base.Save(projectDir); err != nil {
+		return err
+	}
+	fmt.Fprintf(cmd.OutOrStdout(), "Baseline written: %s (%d diagnostics in %d files)\n",
+		lint.BaselineFile, base.Total(), lint.CountUniqueFiles(diags))
+	return nil
+}
+
+func runLintBaselinePrune(cmd *cobra.Command, args []string) error {
+	projectDir, diags, err := lintProject()
+	if err != nil {
+		return err
+	}
+	base, err := lint.LoadBaseline(projectDir)
+	if err != nil {
+		return err
+	}
+	removed := base.Prune(diags, projectDir)
+	if err := base.Save(projectDir); err != nil {
+		return err
+	}
+	fmt.Fprintf(cmd.OutOrStdout(), "Baseline pruned: %d fixed diagnostics removed, %d remain\n", removed, base.Total())
+	return nil
+}
+
+func runLintCache(cmd *cobra.Command, args []string) error {
+	projectDir, err := os.Getwd()
+	if err != nil {
+		return fmt.Errorf("get working directory: %w", err)
+	}
+	u, err := lint.NewCache(projectDir).Usage()
+	if err != nil {
+		return err
+	}
+	out := cmd.OutOrStdout()
+	fmt.Fprintf(out, "Cache:    %s\n", lint.CacheDir)
+	fmt.Fprintf(out, "Entries:  %d (%s)\n", u.Entries, formatBytes(u.Bytes))
+	if u.LastRun.Hits+u.LastRun.Misses > 0 {
+		fmt.Fprintf(out, "Last run: %d hits, %d misses (%.0f%%) at %s\n",
+			u.LastRun.Hits, u.LastRun.Misses, u.LastRun.HitRate(), u.LastRun.Last.Local().Format("2006-01-02 15:04:05"))
+	}
+	fmt.Fprintf(out, "Total:    %d hits, %d misses (%.0f%%)\n", u.Total.Hits, u.Total.Misses, u.Total.HitRate())
+	return nil
+}
+
+func runLintCacheClear(cmd *cobra.Command, args []string) error {
+	projectDir, err := os.Getwd()
+	if err != nil {
+		return fmt.Errorf("get working directory: %w", err)
+	}
+	if err := lint.NewCache(projectDir).Clear(); err != nil {
+		return err
+	}
+	fmt.Fprintf(cmd.OutOrStdout(), "Cleared %s\n", lint.CacheDir)
+	return nil
+}
 
-	for lang, langFiles := range grouped {
-		diags := lint.RunLinter(lang, langFiles, projectDir)
-		allDiags = append(allDiags, diags...)
+// formatBytes renders a size with a binary unit.
+func formatBytes(n int64) string {
+	const unit = 1024
+	if n < unit {
+		return fmt.Sprintf("%d B", n)
 	}
+	div, exp := int64(unit), 0
+	for m := n / unit; m >= unit; m /= unit {
+		div *= unit
+		exp++
+	}
+	return fmt.Sprintf("%.1f %ciB", float64(n)/float64(div), "KMGTPE"[exp])
+}
 
-	if len(allDiags) > 0 {
-		fmt.Fprint(cmd.OutOrStdout(), lint.FormatDiagnostics(allDiags))
+// lintExit turns a failing lint gate into an ExitError. The report has
+// already been written, so cobra's error and usage output is silenced.
+func lintExit(cmd *cobra.Command, diags []lint.Diagnostic) error {
+	code := lint.EvaluateResults(diags)
+	if code == lint.ExitSuccess {
+		return nil
 	}
+	cmd.SilenceUsage = true
+	cmd.SilenceErrors = true
+	return &ExitError{Code: code}
+}
 
-	exitCode := lint.EvaluateResults(allDiags)
-	if exitCode != 0 {
-		os.Exit(exitCode)
+func runLintList(cmd *cobra.Command, args []string) error {
+	projectDir, err := os.Getwd()
+	if err != nil {
+		return fmt.Errorf("get working directory: %w", err)
+	}
+	reg, err := lint.LoadRegistry(projectDir)
+	if err != nil {
+		return err
 	}
 
+	out := cmd.OutOrStdout()
+	for _, d := range reg.Linters {
+		status := "[ok]"
+		switch {
+		case d.Disabled:
+			status = "[off]"
+		case !d.Installed(projectDir):
+			status = "[--]"
+		}
+		kind := lint.KindLint
+		if d.IsFormatter() {
+			kind = lint.KindFormat
+		}
+		fmt.Fprintf(out, "  %-5s %-16s %-6s %-10s %s\n", status, d.Name, kind, d.Format, strings.Join(d.Files, " "))
+	}
 	return nil
 }
 
@@ -91,7 +442,14 @@ func runLintSetup(cmd *cobra.Command, args []string) error {
 	}
 	fmt.Fprintf(cmd.OutOrStdout(), "Detected languages: %s\n\n", strings.Join(langNames, ", "))
 
-	status := lint.CheckSetupStatus(langs)
+	if lintLocal {
+		return runLintSetupLocal(cmd, projectDir, langs)
+	}
+	if lintMirror != "" {
+		return fmt.Errorf("--mirror requires --local")
+	}
+
+	status := lint.CheckSetupStatus(projectDir, langs)
 	lint.PrintSetupStatus(status)
 	if len(status.Missing) == 0 {
 		return nil
@@ -146,3 +504,51 @@ func runLintSetup(cmd *cobra.Command, args []string) error {
 	fmt.Fprintln(cmd.OutOrStdout(), "Setup complete.")
 	return nil
 }
+
+// runLintSetupLocal installs the pinned tools into .do/tools, writing a
+// lock file with default pins first when there is none.
+func runLintSetupLocal(cmd *cobra.Command, projectDir string, langs []lint.Language) error {
+	out := cmd.OutOrStdout()
+	lock, err := lint.LoadToolLock(projectDir)
+	switch {
+	case errors.Is(err, os.ErrNotExist):
+		lock = lint.NewToolLock(langs)
+		if err := lock.Save(projectDir); err != nil {
+			return err
+		}
+		fmt.Fprintf(out, "Wrote %s with %d pinned tools\n\n", lint.ToolsLockFile, len(lock.Tools))
+	case err != nil:
+		return err
+	}
+	if len(lock.Tools) == 0 {
+		fmt.Fprintf(out, "No tools pinned in %s.\n", lint.ToolsLockFile)
+		return nil
+	}
+
+	mirror := lintMirror
+	if mirror == "" {
+		mirror = lock.Mirror
+	}
+	opts := lint.InstallOptions{Mirror: mirror, Stdout: out, Stderr: cmd.ErrOrStderr()}
+
+	failed := 0
+	for _, pin := range lock.Tools {
+		if pin.Installed(projectDir) {
+			fmt.Fprintf(out, "  [ok] %s %s\n", pin.Name, pin.Version)
+			continue
+		}
+		fmt.Fprintf(out, "Installing %s %s (%s)\n", pin.Name, pin.Version, pin.Method)
+		if err := lint.InstallTool(projectDir, pin, opts); err != nil {
+			fmt.Fprintf(out, "  Failed: %v\n", err)
+			failed++
+			continue
+		}
+		fmt.Fprintf(out, "  [ok] %s %s\n", pin.Name, pin.Version)
+	}
+	fmt.Fprintln(out)
+	if failed > 0 {
+		return fmt.Errorf("%d of %d tools failed to install", failed, len(lock.Tools))
+	}
+	fmt.Fprintf(out, "Tools installed in %s\n", lint.ToolsBinDir)
+	return nil
+}
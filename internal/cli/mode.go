@@ -3,19 +3,43 @@ package cli
 import (
 	"fmt"
 	"strings"
+	"text/tabwriter"
 
 	"github.com/spf13/cobra"
 	"github.com/yejune/godo/internal/mode"
 )
 
 var modeCmd = &cobra.Command{
-	Use:   "mode [get|set <mode>|<mode>]",
-	Short: "Get or set the current execution/permission mode",
-	Long: `Compatible forms:
-  godo mode
+	Use:   "mode [list|get|set <mode>|<mode>]",
+	Short: "List, get or set the execution/permission mode",
+	Long: `Without arguments, lists the execution modes with their descriptions and
+marks the current one.
+
+Compatible forms:
   godo mode get
-  godo mode set <do|focus|team|bypass|accept|default|plan>
-  godo mode <do|focus|team|bypass|accept|default|plan>`,
+  godo mode set <mode|bypass|accept|default|plan>
+  godo mode <mode|bypass|accept|default|plan>
+
+The mode is read from the nearest .do/.current-mode between the working
+directory and the project root; "set" writes it at the project root, or in
+the working directory with --here. --session sets the mode of a single
+session (its session_id), overriding the directory's mode.
+
+Besides the built-in do, focus, team and review, modes can be defined in
+.do/modes.yaml (project) or ~/.do/modes.yaml:
+
+  modes:
+    - name: docs
+      description: Documentation only
+      prefix: "[Docs]"
+      instructions: Only change files under docs/.
+      allowed_tools: [Read, Grep, Glob, Write, Edit]
+      permission: accept
+
+The pre-tool hook enforces each mode's tools: denied_tools are refused,
+allowed_tools (when set) are the only ones permitted, and agent_only_tools
+may only be used by agents. Built-in policies: focus refuses Task, review
+refuses file edits, and team leaves file edits to agents.`,
 	Args: cobra.MaximumNArgs(2),
 	RunE: runMode,
 }
@@ -27,27 +51,42 @@ var modePermissionCmd = &cobra.Command{
 	RunE:  runModePermission,
 }
 
+var modeResetCmd = &cobra.Command{
+	Use:   "reset",
+	Short: "Remove a session's mode override",
+	Args:  cobra.NoArgs,
+	RunE:  runModeReset,
+}
+
+var (
+	modeSession string
+	modeHere    bool
+)
+
 func init() {
+	modeCmd.PersistentFlags().StringVar(&modeSession, "session", "", "session ID whose mode to get or set")
+	modeCmd.Flags().BoolVar(&modeHere, "here", false, "set the mode for the working directory instead of the project root")
 	rootCmd.AddCommand(modeCmd)
 	modeCmd.AddCommand(modePermissionCmd)
+	modeCmd.AddCommand(modeResetCmd)
 }
 
 func runMode(cmd *cobra.Command, args []string) error {
 	if len(args) == 0 {
-		fmt.Fprintln(cmd.OutOrStdout(), mode.ReadState())
-		return nil
+		return listModes(cmd)
 	}
 
 	arg0 := strings.ToLower(args[0])
 
-	if arg0 == "get" {
-		fmt.Fprintln(cmd.OutOrStdout(), mode.ReadState())
+	switch arg0 {
+	case "list":
+		return listModes(cmd)
+	case "get":
+		fmt.Fprintln(cmd.OutOrStdout(), mode.Current(".", modeSession))
 		return nil
-	}
-
-	if arg0 == "set" {
+	case "set":
 		if len(args) < 2 {
-			return fmt.Errorf("usage: godo mode set <do|focus|team|bypass|accept|default|plan>")
+			return fmt.Errorf("usage: godo mode set <mode|bypass|accept|default|plan>")
 		}
 		return applyMode(cmd, strings.ToLower(args[1]))
 	}
@@ -55,6 +94,26 @@ func runMode(cmd *cobra.Command, args []string) error {
 	return applyMode(cmd, arg0)
 }
 
+func listModes(cmd *cobra.Command) error {
+	set, err := mode.Load(".")
+	if err != nil {
+		return err
+	}
+	current := mode.Current(".", modeSession)
+	w := tabwriter.NewWriter(cmd.OutOrStdout(), 0, 0, 2, ' ', 0)
+	for _, m := range set.Modes {
+		marker := " "
+		if m.Name == current {
+			marker = "*"
+		}
+		fmt.Fprintf(w, "%s %s\t%s\t%s\n", marker, m.Name, m.Prefix, m.Description)
+	}
+	if _, ok := set.Get(current); !ok {
+		fmt.Fprintf(w, "* %s\t%s\t(not defined)\n", current, set.Prefix(current))
+	}
+	return w.Flush()
+}
+
 func applyMode(cmd *cobra.Command, value string) error {
 	if ccMode, ok := mode.PermissionModes[value]; ok {
 		if err := mode.SetDefaultMode(ccMode); err != nil {
@@ -64,13 +123,52 @@ func applyMode(cmd *cobra.Command, value string) error {
 		return nil
 	}
 
-	if mode.ExecutionModes[value] {
-		mode.WriteState(value)
-		fmt.Fprintf(cmd.OutOrStdout(), "Mode set: %s\n", value)
-		return nil
+	set, err := mode.Load(".")
+	if err != nil {
+		return err
+	}
+	m, ok := set.Get(value)
+	if !ok {
+		return fmt.Errorf("invalid mode %q (valid execution: %s, permission: bypass/accept/default/plan)",
+			value, strings.Join(set.Names(), "/"))
+	}
+
+	switch {
+	case modeSession != "":
+		err = mode.WriteSessionState(".", modeSession, m.Name)
+	case modeHere:
+		err = mode.WriteStateAt(".", m.Name)
+	default:
+		err = mode.WriteStateAt(mode.FindRoot("."), m.Name)
+	}
+	if err != nil {
+		return err
+	}
+	if modeSession != "" {
+		fmt.Fprintf(cmd.OutOrStdout(), "Mode set: %s (session %s)\n", m.Name, modeSession)
+	} else {
+		fmt.Fprintf(cmd.OutOrStdout(), "Mode set: %s\n", m.Name)
 	}
 
-	return fmt.Errorf("invalid mode %q (valid execution: do/focus/team, permission: bypass/accept/default/plan)", value)
+	if m.Permission != "" {
+		ccMode := mode.PermissionModes[m.Permission]
+		if err := mode.SetDefaultMode(ccMode); err != nil {
+			return fmt.Errorf("set permission mode: %w", err)
+		}
+		fmt.Fprintf(cmd.OutOrStdout(), "Permission mode set: %s (%s)\n", m.Permission, ccMode)
+	}
+	return nil
+}
+
+func runModeReset(cmd *cobra.Command, args []string) error {
+	if modeSession == "" {
+		return fmt.Errorf("--session is required")
+	}
+	if err := mode.ClearSessionState(".", modeSession); err != nil {
+		return err
+	}
+	fmt.Fprintf(cmd.OutOrStdout(), "Session %s follows the directory mode: %s\n", modeSession, mode.Current(".", ""))
+	return nil
 }
 
 func runModePermission(cmd *cobra.Command, args []string) error {
@@ -3,7 +3,10 @@ package cli
 import (
 	"context"
 	"fmt"
+	"io"
 	"os"
+	"sort"
+	"strings"
 	"time"
 
 	"github.com/yejune/godo/internal/rank"
@@ -20,7 +23,14 @@ Do Rank leaderboard system.`,
 var rankLoginCmd = &cobra.Command{
 	Use:   "login",
 	Short: "Authenticate with the Rank API via OAuth",
+	Long: `Login signs in through the browser (OAuth) and stores the API key in
+~/.do/rank/credentials.json. With --api-key a key issued by a self-hosted
+server ('godo-rank-server keys add') is checked against the server in
+RANK_API_URL and stored instead.`,
 	RunE: func(cmd *cobra.Command, args []string) error {
+		if rankLoginAPIKey != "" {
+			return loginWithAPIKey(cmd, rankLoginAPIKey)
+		}
 		baseURL := os.Getenv("RANK_API_URL")
 		if baseURL == "" {
 			baseURL = "https://rank.do.dev"
@@ -37,10 +47,39 @@ var rankLoginCmd = &cobra.Command{
 	},
 }
 
+var rankLoginAPIKey string
+
+// loginWithAPIKey verifies an issued API key by fetching the user's rank
+// and stores it as the credentials.
+func loginWithAPIKey(cmd *cobra.Command, key string) error {
+	ctx, cancel := context.WithTimeout(context.Background(), 15*time.Second)
+	defer cancel()
+	info, err := rank.NewClient(key).GetUserRank(ctx)
+	if err != nil {
+		return fmt.Errorf("verify API key: %w", err)
+	}
+	creds := &rank.Credentials{
+		APIKey:    key,
+		Username:  info.Username,
+		CreatedAt: time.Now().UTC().Format(time.RFC3339),
+	}
+	if err := rank.SaveCredentials(creds); err != nil {
+		return fmt.Errorf("save credentials: %w", err)
+	}
+	fmt.Fprintf(cmd.OutOrStdout(), "logged in as %s\n", info.Username)
+	return nil
+}
+
 var rankSubmitCmd = &cobra.Command{
 	Use:   "submit [session-id]",
 	Short: "Submit a session transcript to the leaderboard",
-	Args:  cobra.ExactArgs(1),
+	Long: `Submit sends the token usage, cache tokens, model, start time, duration
+and turn count of a session, plus an anonymous project ID (a SHA-256 hash of
+the project path). Fields listed in ~/.do/rank/privacy.yaml are withheld;
+see 'godo rank privacy'. A session the SessionEnd hook already queued is sent
+under its queued hash and leaves the queue, so 'godo rank sync' does not
+send it again. With --dry-run the signed request is printed instead of sent.`,
+	Args: cobra.ExactArgs(1),
 	RunE: func(cmd *cobra.Command, args []string) error {
 		sessionID := args[0]
 
@@ -48,6 +87,9 @@ var rankSubmitCmd = &cobra.Command{
 		if err != nil {
 			return fmt.Errorf("load credentials (run 'godo rank login' first): %w", err)
 		}
+		if creds == nil {
+			return fmt.Errorf("not logged in (run 'godo rank login' first)")
+		}
 
 		path := rank.FindTranscriptForSession(sessionID)
 		if path == "" {
@@ -59,27 +101,43 @@ var rankSubmitCmd = &cobra.Command{
 			return fmt.Errorf("parse transcript: %w", err)
 		}
 
+		submission, err := rank.BuildSubmission(usage)
+		if err != nil {
+			return err
+		}
+		privacy, err := rank.LoadPrivacy()
+		if err != nil {
+			return err
+		}
+
 		client := rank.NewClient(creds.APIKey)
 		ctx, cancel := context.WithTimeout(context.Background(), 30*time.Second)
 		defer cancel()
+		queue := rank.NewQueue(rank.GetQueueDir())
 
-		hash, err := rank.ComputeSessionHash(
-			usage.EndedAt,
-			rank.ClampTokens(usage.InputTokens),
-			rank.ClampTokens(usage.OutputTokens),
-		)
-		if err != nil {
-			return fmt.Errorf("compute hash: %w", err)
+		if rankSubmitDryRun {
+			if err := queue.Prepare(sessionID, submission); err != nil {
+				return err
+			}
+			payload := *submission
+			privacy.Apply(&payload)
+			req, err := client.PrepareSubmission(ctx, &payload)
+			if err != nil {
+				return err
+			}
+			writeSignedRequest(cmd.OutOrStdout(), req)
+			if len(privacy.Omit) > 0 {
+				fmt.Fprintf(cmd.ErrOrStderr(), "withheld by privacy config: %s\n", strings.Join(privacy.Omit, ", "))
+			}
+			return nil
 		}
 
-		submission := &rank.SessionSubmission{
-			SessionHash:  hash,
-			EndedAt:      usage.EndedAt,
-			InputTokens:  rank.ClampTokens(usage.InputTokens),
-			OutputTokens: rank.ClampTokens(usage.OutputTokens),
+		queued, err := queue.Submit(ctx, client, sessionID, submission, privacy)
+		if queued {
+			fmt.Fprintf(cmd.OutOrStdout(), "session %s queued (%v); run 'godo rank sync' to retry\n", sessionID, err)
+			return nil
 		}
-
-		if err := client.SubmitSession(ctx, submission); err != nil {
+		if err != nil {
 			return fmt.Errorf("submit session: %w", err)
 		}
 
@@ -88,6 +146,173 @@ var rankSubmitCmd = &cobra.Command{
 	},
 }
 
+var rankPrivacyCmd = &cobra.Command{
+	Use:   "privacy",
+	Short: "Choose which optional fields are sent with submissions",
+	Long: `Privacy shows, and with --omit or --send changes, which optional fields
+are withheld from session submissions. The session hash, end time and
+input/output token counts are always sent. The setting is stored in
+~/.do/rank/privacy.yaml and applies to queued sessions as well.`,
+	Args: cobra.NoArgs,
+	RunE: runRankPrivacy,
+}
+
+var (
+	rankSubmitDryRun bool
+	rankPrivacyOmit  []string
+	rankPrivacySend  []string
+)
+
+func runRankPrivacy(cmd *cobra.Command, args []string) error {
+	privacy, err := rank.LoadPrivacy()
+	if err != nil {
+		return err
+	}
+	if len(rankPrivacyOmit) > 0 || len(rankPrivacySend) > 0 {
+		omit := make(map[string]bool)
+		for _, f := range privacy.Omit {
+			omit[f] = true
+		}
+		for _, f := range rankPrivacyOmit {
+			omit[f] = true
+		}
+		for _, f := range rankPrivacySend {
+			delete(omit, f)
+		}
+		privacy.Omit = nil
+		for _, f := range rank.OptionalFields() {
+			if omit[f] {
+				privacy.Omit = append(privacy.Omit, f)
+				delete(omit, f)
+			}
+		}
+		for f := range omit {
+			privacy.Omit = append(privacy.Omit, f) // rejected by SavePrivacy
+		}
+		if err := rank.SavePrivacy(privacy); err != nil {
+			return err
+		}
+	}
+
+	out := cmd.OutOrStdout()
+	for _, f := range []string{"session_hash", "ended_at", "input_tokens", "output_tokens"} {
+		fmt.Fprintf(out, "%-22s always sent\n", f)
+	}
+	for _, f := range rank.OptionalFields() {
+		state := "sent"
+		if privacy.Omits(f) {
+			state = "withheld"
+		}
+		fmt.Fprintf(out, "%-22s %s\n", f, state)
+	}
+	return nil
+}
+
+// writeSignedRequest prints a request as it would go over the wire. The
+// API key is masked; the body is printed byte for byte as signed.
+func writeSignedRequest(w io.Writer, req *rank.SignedRequest) {
+	fmt.Fprintf(w, "%s %s\n", req.Method, req.URL)
+	keys := make([]string, 0, len(req.Headers))
+	for k := range req.Headers {
+		keys = append(keys, k)
+	}
+	sort.Strings(keys)
+	for _, k := range keys {
+		v := req.Headers.Get(k)
+		if k == "X-Api-Key" && len(v) > 4 {
+			v = strings.Repeat("*", len(v)-4) + v[len(v)-4:]
+		}
+		fmt.Fprintf(w, "%s: %s\n", k, v)
+	}
+	fmt.Fprintf(w, "\n%s\n", req.Body)
+}
+
+var rankSyncCmd = &cobra.Command{
+	Use:   "sync",
+	Short: "Submit queued sessions to the leaderboard",
+	Long: `Sync submits the sessions queued by the SessionEnd hook (and by
+'godo rank submit' when the API was unreachable) from ~/.do/rank/queue.
+Sessions are sent in batches; rate limits, server errors and network
+failures are retried with exponential backoff, and whatever cannot be sent
+stays queued for the next sync. Sessions already sent are skipped by their
+session hash.`,
+	Args: cobra.NoArgs,
+	RunE: runRankSync,
+}
+
+var rankQueueCmd = &cobra.Command{
+	Use:   "queue",
+	Short: "List sessions waiting to be submitted",
+	Args:  cobra.NoArgs,
+	RunE: func(cmd *cobra.Command, args []string) error {
+		entries, err := rank.NewQueue(rank.GetQueueDir()).List()
+		if err != nil {
+			return err
+		}
+		out := cmd.OutOrStdout()
+		if len(entries) == 0 {
+			fmt.Fprintln(out, "queue is empty")
+			return nil
+		}
+		for _, e := range entries {
+			fmt.Fprintf(out, "%s  queued %s  %d tokens", e.SessionID, e.QueuedAt,
+				e.Submission.InputTokens+e.Submission.OutputTokens)
+			if e.LastError != "" {
+				fmt.Fprintf(out, "  (%d attempts, last error: %s)", e.Attempts, e.LastError)
+			}
+			fmt.Fprintln(out)
+		}
+		return nil
+	},
+}
+
+var rankSyncBatchSize int
+
+func runRankSync(cmd *cobra.Command, args []string) error {
+	creds, err := rank.LoadCredentials()
+	if err != nil {
+		return fmt.Errorf("load credentials (run 'godo rank login' first): %w", err)
+	}
+	if creds == nil {
+		return fmt.Errorf("not logged in (run 'godo rank login' first)")
+	}
+
+	client := rank.NewClient(creds.APIKey)
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Minute)
+	defer cancel()
+
+	privacy, err := rank.LoadPrivacy()
+	if err != nil {
+		return err
+	}
+
+	report, err := rank.Sync(ctx, client, rank.NewQueue(rank.GetQueueDir()), rank.SyncOptions{
+		BatchSize: rankSyncBatchSize,
+		Privacy:   privacy,
+	})
+	if report != nil {
+		out := cmd.OutOrStdout()
+		for _, e := range report.Sent {
+			fmt.Fprintf(out, "sent       %s\n", e.SessionID)
+		}
+		for _, e := range report.Duplicates {
+			fmt.Fprintf(out, "duplicate  %s\n", e.SessionID)
+		}
+		for _, e := range report.Rejected {
+			fmt.Fprintf(out, "rejected   %s: %s\n", e.SessionID, e.LastError)
+		}
+		for _, e := range report.Pending {
+			fmt.Fprintf(out, "pending    %s\n", e.SessionID)
+		}
+		fmt.Fprintf(out, "%d sent, %d duplicate, %d rejected, %d still queued\n",
+			len(report.Sent), len(report.Duplicates), len(report.Rejected), len(report.Pending))
+	}
+	if err != nil {
+		return fmt.Errorf("sync: %w", err)
+	}
+	return nil
+}
+
 var rankStatusCmd = &cobra.Command{
 	Use:   "status",
 	Short: "Show current user rank",
@@ -129,8 +354,16 @@ var rankLogoutCmd = &cobra.Command{
 
 func init() {
 	rankCmd.AddCommand(rankLoginCmd)
+	rankLoginCmd.Flags().StringVar(&rankLoginAPIKey, "api-key", "", "store an API key issued by a self-hosted server instead of using OAuth")
+	rankSubmitCmd.Flags().BoolVar(&rankSubmitDryRun, "dry-run", false, "print the signed request instead of sending it")
 	rankCmd.AddCommand(rankSubmitCmd)
+	rankPrivacyCmd.Flags().StringSliceVar(&rankPrivacyOmit, "omit", nil, "withhold these fields: "+strings.Join(rank.OptionalFields(), ", "))
+	rankPrivacyCmd.Flags().StringSliceVar(&rankPrivacySend, "send", nil, "send these fields again")
+	rankCmd.AddCommand(rankPrivacyCmd)
 	rankCmd.AddCommand(rankStatusCmd)
+	rankSyncCmd.Flags().IntVar(&rankSyncBatchSize, "batch-size", 20, "sessions per request")
+	rankCmd.AddCommand(rankSyncCmd)
+	rankCmd.AddCommand(rankQueueCmd)
 	rankCmd.AddCommand(rankLogoutCmd)
 	rootCmd.AddCommand(rankCmd)
 }
@@ -1,6 +1,11 @@
 package cli
 
 import (
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+
 	"github.com/yejune/godo/internal/mode"
 	"github.com/yejune/godo/internal/profile"
 	"github.com/yejune/godo/internal/statusline"
@@ -11,16 +16,68 @@ var statuslineCmd = &cobra.Command{
 	Use:   "statusline",
 	Short: "Render the Claude Code status line from stdin JSON",
 	Long: `Statusline reads Claude Code's JSON status payload from stdin and prints
-a formatted status line with mode, model, context usage, and cost info.`,
+a formatted status line with mode, model, context usage, and cost info.
+
+The segments, their order, formats and colors come from .do/statusline.yaml
+(or ~/.do/statusline.yaml); run "godo statusline init" to write the default.`,
 	Run: func(cmd *cobra.Command, args []string) {
 		statusline.Render(statusline.Config{
 			Version:        rootCmd.Version,
-			ReadModeState:  mode.ReadState,
+			ReadMode:       readMode,
 			GetProfileName: profile.GetCurrentName,
 		})
 	},
 }
 
+var statuslineInitCmd = &cobra.Command{
+	Use:   "init",
+	Short: "Write the default statusline layout to .do/statusline.yaml",
+	Args:  cobra.NoArgs,
+	RunE:  runStatuslineInit,
+}
+
+var (
+	statuslineInitGlobal bool
+	statuslineInitForce  bool
+)
+
 func init() {
+	statuslineInitCmd.Flags().BoolVar(&statuslineInitGlobal, "global", false, "write ~/.do/statusline.yaml instead")
+	statuslineInitCmd.Flags().BoolVar(&statuslineInitForce, "force", false, "overwrite an existing layout")
+	statuslineCmd.AddCommand(statuslineInitCmd)
 	rootCmd.AddCommand(statuslineCmd)
 }
+
+// readMode resolves a session's mode in the working directory.
+func readMode(sessionID string) (string, string) {
+	name := mode.Current(".", sessionID)
+	set, err := mode.Load(".")
+	if err != nil {
+		set = &mode.Set{Modes: mode.Builtin()}
+	}
+	return name, set.Prefix(name)
+}
+
+func runStatuslineInit(cmd *cobra.Command, args []string) error {
+	path := statusline.LayoutFile
+	if statuslineInitGlobal {
+		home, err := os.UserHomeDir()
+		if err != nil {
+			return fmt.Errorf("find home directory: %w", err)
+		}
+		path = filepath.Join(home, statusline.LayoutFile)
+	}
+	if _, err := os.Stat(path); err == nil && !statuslineInitForce {
+		return fmt.Errorf("%s already exists (use --force to overwrite)", path)
+	} else if err != nil && !errors.Is(err, os.ErrNotExist) {
+		return fmt.Errorf("check layout: %w", err)
+	}
+	if err := os.MkdirAll(filepath.Dir(path), 0755); err != nil {
+		return fmt.Errorf("create layout directory: %w", err)
+	}
+	if err := os.WriteFile(path, []byte(statusline.ExampleLayout), 0644); err != nil {
+		return fmt.Errorf("write layout: %w", err)
+	}
+	fmt.Fprintf(cmd.OutOrStdout(), "Wrote %s\n", path)
+	return nil
+}
@@ -0,0 +1,198 @@
+package cli
+
+import (
+	"encoding/json"
+	"fmt"
+	"io"
+	"os"
+	"strings"
+
+	"github.com/spf13/cobra"
+	"github.com/yejune/godo/internal/rank"
+	"github.com/yejune/godo/internal/transcript"
+	"github.com/yejune/godo/internal/usage"
+)
+
+var transcriptCmd = &cobra.Command{
+	Use:   "transcript",
+	Short: "Inspect Claude Code session transcripts",
+}
+
+var transcriptShowCmd = &cobra.Command{
+	Use:   "show <session>",
+	Short: "Show per-turn, per-tool and per-subagent usage of a session",
+	Long: `Show breaks a session transcript down into turns: when each prompt ran,
+how long it took, the model and token usage of its responses, the tools it
+called and the subagents it launched. Summaries by tool and by subagent
+type follow, then any lines that could not be parsed.
+
+The session is a session ID (or its prefix) or a path to a .jsonl file.
+Costs use the same price table as 'godo usage'.`,
+	Args: cobra.ExactArgs(1),
+	RunE: runTranscriptShow,
+}
+
+var (
+	transcriptFormat string
+	transcriptCalls  bool
+	transcriptPrices string
+)
+
+func init() {
+	transcriptShowCmd.Flags().StringVar(&transcriptFormat, "format", "table", "output format: table or json")
+	transcriptShowCmd.Flags().BoolVar(&transcriptCalls, "calls", false, "list every tool call under its turn")
+	transcriptShowCmd.Flags().StringVar(&transcriptPrices, "prices", "", "price table file (default ~/"+usage.PricesFile+")")
+	transcriptCmd.AddCommand(transcriptShowCmd)
+	rootCmd.AddCommand(transcriptCmd)
+}
+
+func runTranscriptShow(cmd *cobra.Command, args []string) error {
+	if transcriptFormat != "table" && transcriptFormat != "json" {
+		return fmt.Errorf("invalid format %q (valid: table, json)", transcriptFormat)
+	}
+	path := args[0]
+	if _, err := os.Stat(path); err != nil {
+		path = rank.FindTranscriptForSession(args[0])
+		if path == "" {
+			return fmt.Errorf("transcript not found for session %s", args[0])
+		}
+	}
+	s, err := transcript.Load(path)
+	if err != nil {
+		return err
+	}
+
+	if transcriptFormat == "json" {
+		enc := json.NewEncoder(cmd.OutOrStdout())
+		enc.SetIndent("", "  ")
+		return enc.Encode(s)
+	}
+
+	pricesPath := transcriptPrices
+	if pricesPath == "" {
+		pricesPath = usage.DefaultPricesPath()
+	}
+	prices, err := usage.LoadPrices(pricesPath)
+	if err != nil {
+		return err
+	}
+	writeTranscript(cmd.OutOrStdout(), s, prices)
+	return nil
+}
+
+func writeTranscript(w io.Writer, s *transcript.Session, prices *usage.PriceTable) {
+	cost := func(model string, t usage.Tokens) float64 {
+		p, _ := prices.Lookup(model)
+		return p.Cost(t)
+	}
+	agentCost := func(a *transcript.Agent) float64 {
+		if len(a.Models) == 0 {
+			return 0
+		}
+		return cost(a.Models[0], a.Usage)
+	}
+
+	var total float64
+	for _, t := range s.Turns {
+		total += cost(t.Model, t.Usage)
+		for _, a := range t.Agents {
+			total += agentCost(a)
+		}
+	}
+	fmt.Fprintf(w, "Session  %s\n", s.SessionID)
+	fmt.Fprintf(w, "Project  %s\n", usage.ShortPath(s.Project))
+	fmt.Fprintf(w, "Time     %s - %s (%s)\n", s.Start.Local().Format("2006-01-02 15:04"),
+		s.End.Local().Format("15:04"), usage.FormatDuration(s.End.Sub(s.Start)))
+	fmt.Fprintf(w, "Usage    %d turns, %d requests, %s tokens, %s\n\n",
+		len(s.Turns), s.Requests(), usage.FormatTokens(s.Usage().Total()), usage.FormatCost(total))
+
+	fmt.Fprintf(w, "%4s %-8s %8s %-26s %4s %7s %9s %6s %6s  %s\n",
+		"TURN", "START", "DURATION", "MODEL", "REQ", "TOKENS", "COST", "TOOLS", "AGENTS", "PROMPT")
+	for _, t := range s.Turns {
+		tools := fmt.Sprint(len(t.Tools))
+		if n := failed(t.Tools); n > 0 {
+			tools += fmt.Sprintf("/%d!", n)
+		}
+		fmt.Fprintf(w, "%4d %-8s %8s %-26s %4d %7s %9s %6s %6d  %s\n",
+			t.Index, t.Start.Local().Format("15:04:05"), usage.FormatDuration(t.Duration()),
+			clip(t.Model, 26), t.Requests, usage.FormatTokens(t.Usage.Total()),
+			usage.FormatCost(cost(t.Model, t.Usage)), tools, len(t.Agents), clip(firstLine(t.Prompt), 50))
+		for _, sw := range t.Switches {
+			fmt.Fprintf(w, "       model %s -> %s at %s\n", sw.From, sw.To, sw.Time.Local().Format("15:04:05"))
+		}
+		if transcriptCalls {
+			for _, c := range t.Tools {
+				fmt.Fprintf(w, "       %-8s %-20s %8s %s\n", c.Start.Local().Format("15:04:05"), c.Name,
+					usage.FormatDuration(c.Duration()), callStatus(c))
+			}
+		}
+		for _, a := range t.Agents {
+			desc := a.Type
+			if a.Description != "" {
+				desc += fmt.Sprintf(" %q", a.Description)
+			}
+			fmt.Fprintf(w, "       agent %s: %d requests, %s tokens, %s, %d tools, %s\n",
+				strings.TrimSpace(desc), a.Requests, usage.FormatTokens(a.Usage.Total()),
+				usage.FormatCost(agentCost(a)), len(a.Tools), usage.FormatDuration(a.Duration()))
+		}
+	}
+
+	if stats := s.ToolStats(); len(stats) > 0 {
+		fmt.Fprintf(w, "\n%-24s %6s %6s %9s %9s\n", "TOOL", "CALLS", "FAILED", "TIME", "OUTPUT")
+		for _, st := range stats {
+			fmt.Fprintf(w, "%-24s %6d %6d %9s %9s\n", clip(st.Name, 24), st.Calls, st.Failed,
+				usage.FormatDuration(st.Duration), formatBytes(int64(st.ResultBytes)))
+		}
+	}
+	if stats := s.AgentStats(); len(stats) > 0 {
+		fmt.Fprintf(w, "\n%-24s %6s %8s %8s %9s\n", "AGENT TYPE", "RUNS", "REQUESTS", "TOKENS", "TIME")
+		for _, st := range stats {
+			fmt.Fprintf(w, "%-24s %6d %8d %8s %9s\n", clip(st.Type, 24), st.Runs, st.Requests,
+				usage.FormatTokens(st.Usage.Total()), usage.FormatDuration(st.Duration))
+		}
+	}
+	if len(s.Skipped) > 0 {
+		fmt.Fprintf(w, "\n%d unparseable line(s):\n", len(s.Skipped))
+		for i, e := range s.Skipped {
+			if i == 5 {
+				fmt.Fprintf(w, "  ... and %d more\n", len(s.Skipped)-i)
+				break
+			}
+			fmt.Fprintf(w, "  %s\n", clip(e.Error(), 100))
+		}
+	}
+}
+
+func failed(calls []*transcript.ToolCall) int {
+	n := 0
+	for _, c := range calls {
+		if c.Error {
+			n++
+		}
+	}
+	return n
+}
+
+func callStatus(c *transcript.ToolCall) string {
+	switch {
+	case !c.Done:
+		return "no result"
+	case c.Error:
+		return "failed"
+	}
+	return "ok"
+}
+
+func firstLine(s string) string {
+	s, _, _ = strings.Cut(s, "\n")
+	return s
+}
+
+// clip shortens s to n runes, marking the cut with an ellipsis.
+func clip(s string, n int) string {
+	r := []rune(s)
+	if len(r) <= n {
+		return s
+	}
+	return string(r[:n-1]) + "…"
+}
@@ -0,0 +1,103 @@
+package cli
+
+import (
+	"fmt"
+	"slices"
+	"strings"
+	"time"
+
+	"github.com/spf13/cobra"
+	"github.com/yejune/godo/internal/rank"
+	"github.com/yejune/godo/internal/usage"
+)
+
+var usageCmd = &cobra.Command{
+	Use:   "usage",
+	Short: "Report local token usage and estimated cost from transcripts",
+	Long: `Usage reads every Claude Code transcript on this machine and aggregates
+token usage by day, week, month, project, model or session. Nothing is sent
+anywhere and no account is needed.
+
+Cost is estimated from a price table (USD per million input, output,
+cache-write and cache-read tokens). Built-in prices cover the Claude model
+families; ~/.do/usage/prices.yaml (or --prices) overrides or extends them:
+
+  models:
+    opus-4-5: {input: 5, output: 25, cache_write: 6.25, cache_read: 0.5}
+    glm-4.6:  {input: 0.6, output: 2.2, cache_write: 0, cache_read: 0.11}
+
+A model uses the entry with the longest key contained in its name.`,
+	Args: cobra.NoArgs,
+	RunE: runUsage,
+}
+
+var (
+	usageBy      string
+	usageSince   string
+	usageUntil   string
+	usageProject string
+	usageFormat  string
+	usageTop     int
+	usagePrices  string
+)
+
+func init() {
+	usageCmd.Flags().StringVar(&usageBy, "by", usage.ByDay, "group by: "+strings.Join(usage.Groupings(), ", "))
+	usageCmd.Flags().StringVar(&usageSince, "since", "", "only usage on or after this date (YYYY-MM-DD)")
+	usageCmd.Flags().StringVar(&usageUntil, "until", "", "only usage on or before this date (YYYY-MM-DD)")
+	usageCmd.Flags().StringVar(&usageProject, "project", "", "only projects whose path contains this text")
+	usageCmd.Flags().StringVar(&usageFormat, "format", usage.FormatTable, "output format: "+strings.Join(usage.Formats(), ", "))
+	usageCmd.Flags().IntVar(&usageTop, "top", 5, "list the N most expensive sessions (0 to hide)")
+	usageCmd.Flags().StringVar(&usagePrices, "prices", "", "price table file (default ~/"+usage.PricesFile+")")
+	rootCmd.AddCommand(usageCmd)
+}
+
+func runUsage(cmd *cobra.Command, args []string) error {
+	if !slices.Contains(usage.Formats(), usageFormat) {
+		return fmt.Errorf("invalid format %q (valid: %s)", usageFormat, strings.Join(usage.Formats(), ", "))
+	}
+	since, err := parseDay(usageSince)
+	if err != nil {
+		return fmt.Errorf("invalid --since: %w", err)
+	}
+	until, err := parseDay(usageUntil)
+	if err != nil {
+		return fmt.Errorf("invalid --until: %w", err)
+	}
+	if !until.IsZero() {
+		until = until.AddDate(0, 0, 1)
+	}
+
+	pricesPath := usagePrices
+	if pricesPath == "" {
+		pricesPath = usage.DefaultPricesPath()
+	}
+	prices, err := usage.LoadPrices(pricesPath)
+	if err != nil {
+		return err
+	}
+
+	data, errs := usage.Load(rank.FindAllTranscripts())
+	for _, e := range errs {
+		fmt.Fprintf(cmd.ErrOrStderr(), "skipped: %v\n", e)
+	}
+	data = data.Filter(since, until, usageProject)
+
+	top := usageTop
+	if usageBy == usage.BySession {
+		top = 0
+	}
+	report, err := usage.Aggregate(data, usageBy, prices, time.Local, top)
+	if err != nil {
+		return err
+	}
+	return usage.WriteReport(cmd.OutOrStdout(), usageFormat, report)
+}
+
+// parseDay parses YYYY-MM-DD as local midnight; "" yields the zero time.
+func parseDay(s string) (time.Time, error) {
+	if s == "" {
+		return time.Time{}, nil
+	}
+	return time.ParseInLocation("2006-01-02", s, time.Local)
+}
@@ -90,15 +90,6 @@ func SetupCredentials(apiKey string) error {
 	return SaveCredentials(creds)
 }
 
-// SetGLMEnv sets the environment variables for GLM backend in the current process.
-func SetGLMEnv(apiKey string) {
-	os.Setenv("ANTHROPIC_AUTH_TOKEN", apiKey)
-	os.Setenv("ANTHROPIC_BASE_URL", "https://api.z.ai/api/anthropic")
-	os.Setenv("ANTHROPIC_DEFAULT_HAIKU_MODEL", "glm-4.7-flash")
-	os.Setenv("ANTHROPIC_DEFAULT_SONNET_MODEL", "glm-5")
-	os.Setenv("ANTHROPIC_DEFAULT_OPUS_MODEL", "glm-5")
-}
-
 // MaskAPIKey masks an API key for display, showing only prefix and suffix.
 func MaskAPIKey(key string) string {
 	if len(key) <= 8 {
@@ -54,6 +54,68 @@ func (s *ChecklistStats) Summary() string {
 
 var checklistItemRe = regexp.MustCompile(`^\s*-\s*\[(.)\]`)
 
+// checklistLineRe captures the status symbol and the text of an item line.
+var checklistLineRe = regexp.MustCompile(`^\s*-\s*\[(.)\]\s*(.*)$`)
+
+// checklistIDRe matches item IDs like #1, #12.
+var checklistIDRe = regexp.MustCompile(`#(\d+)`)
+
+// historyEntryRe matches status history sub-entries ("- [~] 2026-02-11 14:05:12 ...")
+// which record past transitions and are not items themselves.
+var historyEntryRe = regexp.MustCompile(`^\d{4}-\d{2}-\d{2} \d{2}:\d{2}:\d{2}`)
+
+// ChecklistItem is a single status-bearing line of a checklist.
+type ChecklistItem struct {
+	ID      string // "#3" when the item carries an ID, otherwise empty
+	Status  string // status symbol: " ", "~", "*", "!", "o", "x"
+	Text    string // item text without the status marker
+	Line    int    // 1-based line number
+	History []ChecklistHistoryEntry
+}
+
+// ChecklistHistoryEntry is a timestamped status history line nested under an
+// item, e.g. "    - [!] 2026-02-11 15:00:33 blocker: Redis not configured".
+type ChecklistHistoryEntry struct {
+	Status string
+	Time   string // "YYYY-MM-DD HH:MM:SS"
+	Note   string
+}
+
+// IsUnfinished returns true if the item is pending, in progress, or testing.
+func (it ChecklistItem) IsUnfinished() bool {
+	return it.Status == " " || it.Status == "~" || it.Status == "*"
+}
+
+// ParseChecklistItems parses checklist content into items. Timestamped status
+// history entries are attached to the preceding item instead of being items.
+func ParseChecklistItems(content string) []ChecklistItem {
+	var items []ChecklistItem
+	for i, line := range strings.Split(content, "\n") {
+		m := checklistLineRe.FindStringSubmatch(line)
+		if m == nil {
+			continue
+		}
+		text := strings.TrimSpace(m[2])
+		if ts := historyEntryRe.FindString(text); ts != "" {
+			if len(items) > 0 {
+				last := &items[len(items)-1]
+				last.History = append(last.History, ChecklistHistoryEntry{
+					Status: m[1],
+					Time:   ts,
+					Note:   strings.TrimSpace(text[len(ts):]),
+				})
+			}
+			continue
+		}
+		item := ChecklistItem{Status: m[1], Text: text, Line: i + 1}
+		if id := checklistIDRe.FindStringSubmatch(text); id != nil {
+			item.ID = "#" + id[1]
+		}
+		items = append(items, item)
+	}
+	return items
+}
+
 // ParseChecklistFile reads a checklist file and returns stats.
 func ParseChecklistFile(path string) (*ChecklistStats, error) {
 	data, err := os.ReadFile(path)
@@ -0,0 +1,300 @@
+package hook
+
+import (
+	"bufio"
+	"encoding/json"
+	"fmt"
+	"os"
+	"path/filepath"
+	"regexp"
+	"sort"
+	"strings"
+	"time"
+)
+
+// HistoryFile is the append-only checklist transition log kept in each job dir.
+const HistoryFile = "checklist-history.jsonl"
+
+// HistoryEntry records one checklist item status transition.
+type HistoryEntry struct {
+	Time      string `json:"ts"`
+	SessionID string `json:"session_id,omitempty"`
+	File      string `json:"file"`            // checklist path relative to the job dir
+	Agent     string `json:"agent,omitempty"` // owning agent, "" for the main checklist
+	Item      string `json:"item"`            // item ID ("#3") or item text when it has no ID
+	From      string `json:"from"`            // previous status symbol, "" when first seen
+	To        string `json:"to"`
+}
+
+// LoadHistory reads the job's transition log. A missing log yields no entries.
+func LoadHistory(jobDir string) ([]HistoryEntry, error) {
+	f, err := os.Open(filepath.Join(jobDir, HistoryFile))
+	if err != nil {
+		if os.IsNotExist(err) {
+			return nil, nil
+		}
+		return nil, err
+	}
+	defer f.Close()
+
+	var entries []HistoryEntry
+	scanner := bufio.NewScanner(f)
+	for scanner.Scan() {
+		line := strings.TrimSpace(scanner.Text())
+		if line == "" {
+			continue
+		}
+		var e HistoryEntry
+		if json.Unmarshal([]byte(line), &e) != nil {
+			continue
+		}
+		entries = append(entries, e)
+	}
+	return entries, scanner.Err()
+}
+
+// appendHistory appends entries to the job's transition log.
+func appendHistory(jobDir string, entries []HistoryEntry) error {
+	if len(entries) == 0 {
+		return nil
+	}
+	f, err := os.OpenFile(filepath.Join(jobDir, HistoryFile), os.O_CREATE|os.O_APPEND|os.O_WRONLY, 0644)
+	if err != nil {
+		return err
+	}
+	defer f.Close()
+	for _, e := range entries {
+		data, _ := json.Marshal(e)
+		if _, err := f.Write(append(data, '\n')); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// agentChecklistRe extracts the agent name from checklists/{order}_{agent}.md.
+var agentChecklistRe = regexp.MustCompile(`^\d+_(.+)\.md$`)
+
+// AgentForChecklist returns the agent owning a checklist file, or "" for the
+// main checklist.md.
+func AgentForChecklist(relFile string) string {
+	if m := agentChecklistRe.FindStringSubmatch(filepath.Base(relFile)); m != nil {
+		return m[1]
+	}
+	return ""
+}
+
+// HistoryKey returns the Item under which the transitions of item are
+// logged: its ID, or its text when it has none.
+func HistoryKey(item ChecklistItem) string {
+	if item.ID != "" {
+		return item.ID
+	}
+	return item.Text
+}
+
+// RecordChecklistTransitions compares the current statuses in the checklist
+// file relFile (relative to jobDir) with the last recorded status of each item
+// and appends a history entry for every change. Returns the new entries.
+func RecordChecklistTransitions(jobDir, relFile, sessionID string, now time.Time) ([]HistoryEntry, error) {
+	data, err := os.ReadFile(filepath.Join(jobDir, relFile))
+	if err != nil {
+		return nil, err
+	}
+	existing, err := LoadHistory(jobDir)
+	if err != nil {
+		return nil, fmt.Errorf("load history: %w", err)
+	}
+
+	relFile = filepath.ToSlash(relFile)
+	last := make(map[string]string)
+	for _, e := range existing {
+		if e.File == relFile {
+			last[e.Item] = e.To
+		}
+	}
+
+	ts := now.UTC().Format(time.RFC3339)
+	agent := AgentForChecklist(relFile)
+	var added []HistoryEntry
+	for _, item := range ParseChecklistItems(string(data)) {
+		key := HistoryKey(item)
+		prev, seen := last[key]
+		if seen && prev == item.Status {
+			continue
+		}
+		added = append(added, HistoryEntry{
+			Time: ts, SessionID: sessionID, File: relFile, Agent: agent,
+			Item: key, From: prev, To: item.Status,
+		})
+		last[key] = item.Status
+	}
+	if err := appendHistory(jobDir, added); err != nil {
+		return nil, fmt.Errorf("append history: %w", err)
+	}
+	return added, nil
+}
+
+// SyncJobHistory records transitions for checklist.md and every
+// checklists/*.md file of the job.
+func SyncJobHistory(jobDir, sessionID string, now time.Time) ([]HistoryEntry, error) {
+	files := []string{"checklist.md"}
+	subs, _ := filepath.Glob(filepath.Join(jobDir, "checklists", "*.md"))
+	sort.Strings(subs)
+	for _, sub := range subs {
+		files = append(files, filepath.Join("checklists", filepath.Base(sub)))
+	}
+
+	var added []HistoryEntry
+	for _, f := range files {
+		if _, err := os.Stat(filepath.Join(jobDir, f)); err != nil {
+			continue
+		}
+		entries, err := RecordChecklistTransitions(jobDir, f, sessionID, now)
+		if err != nil {
+			return added, err
+		}
+		added = append(added, entries...)
+	}
+	return added, nil
+}
+
+// ChecklistFileJob maps a checklist path (checklist.md or checklists/*.md
+// inside a .do/jobs job dir) to its job dir and path relative to it.
+// Returns ok=false for any other file.
+func ChecklistFileJob(path string) (jobDir, relFile string, ok bool) {
+	clean := filepath.Clean(path)
+	if !strings.Contains(filepath.ToSlash(clean), ".do/jobs/") {
+		return "", "", false
+	}
+	dir, base := filepath.Split(clean)
+	dir = filepath.Clean(dir)
+	switch {
+	case base == "checklist.md":
+		return dir, base, true
+	case filepath.Base(dir) == "checklists" && strings.HasSuffix(base, ".md"):
+		return filepath.Dir(dir), filepath.Join("checklists", base), true
+	}
+	return "", "", false
+}
+
+// ItemStats holds time-in-status metrics for one checklist item.
+type ItemStats struct {
+	File      string
+	Agent     string
+	Item      string
+	Status    string        // last recorded status
+	CycleTime time.Duration // first [~] to last [o]; 0 until done
+	Blocked   time.Duration // total time spent in [!]
+	Rework    int           // returns to [~] from [o] or [*]
+}
+
+// GroupStats aggregates item metrics for an agent or the whole job.
+type GroupStats struct {
+	Name      string
+	Items     int
+	Done      int
+	CycleTime time.Duration // sum over done items
+	Blocked   time.Duration
+	Rework    int
+}
+
+// AvgCycleTime returns the mean cycle time of done items.
+func (g GroupStats) AvgCycleTime() time.Duration {
+	if g.Done == 0 {
+		return 0
+	}
+	return g.CycleTime / time.Duration(g.Done)
+}
+
+// JobStats holds per-item, per-agent and whole-job metrics.
+type JobStats struct {
+	Items  []ItemStats
+	Agents []GroupStats // sorted by name; the main checklist is "(main)"
+	Job    GroupStats
+}
+
+// ComputeStats derives time-in-status metrics from the transition log.
+// Items still blocked accrue blocked time up to now.
+func ComputeStats(entries []HistoryEntry, now time.Time) *JobStats {
+	type itemState struct {
+		stats        ItemStats
+		firstStarted time.Time
+		blockedSince time.Time
+	}
+	byKey := make(map[string]*itemState)
+	var order []string
+
+	for _, e := range entries {
+		t, err := time.Parse(time.RFC3339, e.Time)
+		if err != nil {
+			continue
+		}
+		key := e.File + "\x00" + e.Item
+		st, ok := byKey[key]
+		if !ok {
+			st = &itemState{stats: ItemStats{File: e.File, Agent: e.Agent, Item: e.Item}}
+			byKey[key] = st
+			order = append(order, key)
+		}
+
+		if e.From == "!" && !st.blockedSince.IsZero() {
+			st.stats.Blocked += t.Sub(st.blockedSince)
+			st.blockedSince = time.Time{}
+		}
+		switch e.To {
+		case "~":
+			if st.firstStarted.IsZero() {
+				st.firstStarted = t
+			}
+			if e.From == "o" || e.From == "*" {
+				st.stats.Rework++
+			}
+		case "!":
+			st.blockedSince = t
+		case "o":
+			if !st.firstStarted.IsZero() {
+				st.stats.CycleTime = t.Sub(st.firstStarted)
+			}
+		}
+		st.stats.Status = e.To
+	}
+
+	js := &JobStats{Job: GroupStats{Name: "job"}}
+	groups := make(map[string]*GroupStats)
+	for _, key := range order {
+		st := byKey[key]
+		if !st.blockedSince.IsZero() {
+			st.stats.Blocked += now.Sub(st.blockedSince)
+		}
+		if st.stats.Status != "o" {
+			st.stats.CycleTime = 0
+		}
+		js.Items = append(js.Items, st.stats)
+
+		name := st.stats.Agent
+		if name == "" {
+			name = "(main)"
+		}
+		g, ok := groups[name]
+		if !ok {
+			g = &GroupStats{Name: name}
+			groups[name] = g
+		}
+		for _, target := range []*GroupStats{g, &js.Job} {
+			target.Items++
+			target.Blocked += st.stats.Blocked
+			target.Rework += st.stats.Rework
+			if st.stats.Status == "o" {
+				target.Done++
+				target.CycleTime += st.stats.CycleTime
+			}
+		}
+	}
+
+	for _, g := range groups {
+		js.Agents = append(js.Agents, *g)
+	}
+	sort.Slice(js.Agents, func(i, j int) bool { return js.Agents[i].Name < js.Agents[j].Name })
+	return js
+}
@@ -0,0 +1,107 @@
+package hook
+
+import (
+	"encoding/json"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+)
+
+func Test_RecordChecklistTransitions_appends_only_changes(t *testing.T) {
+	jobDir := t.TempDir()
+	path := filepath.Join(jobDir, "checklist.md")
+	t0 := time.Date(2026, 2, 18, 10, 0, 0, 0, time.UTC)
+
+	if err := os.WriteFile(path, []byte("- [ ] #1 schema\n- [ ] #2 api\n"), 0644); err != nil {
+		t.Fatal(err)
+	}
+	added, err := RecordChecklistTransitions(jobDir, "checklist.md", "sess-1", t0)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if len(added) != 2 || added[0].From != "" || added[0].To != " " {
+		t.Fatalf("first record: got %+v", added)
+	}
+
+	if err := os.WriteFile(path, []byte("- [~] #1 schema\n- [ ] #2 api\n"), 0644); err != nil {
+		t.Fatal(err)
+	}
+	added, _ = RecordChecklistTransitions(jobDir, "checklist.md", "sess-1", t0.Add(time.Minute))
+	if len(added) != 1 || added[0].Item != "#1" || added[0].From != " " || added[0].To != "~" || added[0].SessionID != "sess-1" {
+		t.Fatalf("second record: got %+v", added)
+	}
+
+	entries, err := LoadHistory(jobDir)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(entries) != 3 {
+		t.Errorf("log should hold 3 entries, got %d", len(entries))
+	}
+}
+
+func Test_HandlePostToolUse_records_checklist_edit(t *testing.T) {
+	root := t.TempDir()
+	jobDir := filepath.Join(root, ".do", "jobs", "26", "02", "18", "task")
+	if err := os.MkdirAll(filepath.Join(jobDir, "checklists"), 0755); err != nil {
+		t.Fatal(err)
+	}
+	path := filepath.Join(jobDir, "checklists", "01_expert-backend.md")
+	if err := os.WriteFile(path, []byte("- [~] handler\n"), 0644); err != nil {
+		t.Fatal(err)
+	}
+	toolInput, _ := json.Marshal(map[string]string{"file_path": path})
+
+	HandlePostToolUse(&Input{ToolName: "Edit", ToolInput: toolInput, SessionID: "s"})
+
+	entries, _ := LoadHistory(jobDir)
+	if len(entries) != 1 {
+		t.Fatalf("expected 1 history entry, got %+v", entries)
+	}
+	if entries[0].File != "checklists/01_expert-backend.md" || entries[0].Agent != "expert-backend" {
+		t.Errorf("entry: got %+v", entries[0])
+	}
+}
+
+func Test_ComputeStats_cycle_blocked_and_rework(t *testing.T) {
+	at := func(min int) string {
+		return time.Date(2026, 2, 18, 10, min, 0, 0, time.UTC).Format(time.RFC3339)
+	}
+	f := "checklists/01_expert-backend.md"
+	entries := []HistoryEntry{
+		{Time: at(0), File: f, Agent: "expert-backend", Item: "#1", From: "", To: " "},
+		{Time: at(5), File: f, Agent: "expert-backend", Item: "#1", From: " ", To: "~"},
+		{Time: at(10), File: f, Agent: "expert-backend", Item: "#1", From: "~", To: "!"},
+		{Time: at(25), File: f, Agent: "expert-backend", Item: "#1", From: "!", To: "~"},
+		{Time: at(30), File: f, Agent: "expert-backend", Item: "#1", From: "~", To: "o"},
+		{Time: at(35), File: f, Agent: "expert-backend", Item: "#1", From: "o", To: "~"},
+		{Time: at(45), File: f, Agent: "expert-backend", Item: "#1", From: "~", To: "o"},
+		{Time: at(0), File: "checklist.md", Item: "#2", From: "", To: "!"},
+	}
+	now := time.Date(2026, 2, 18, 11, 0, 0, 0, time.UTC)
+
+	stats := ComputeStats(entries, now)
+	if len(stats.Items) != 2 {
+		t.Fatalf("items: got %d, want 2", len(stats.Items))
+	}
+	item := stats.Items[0]
+	if item.CycleTime != 40*time.Minute {
+		t.Errorf("cycle time: got %v, want 40m", item.CycleTime)
+	}
+	if item.Blocked != 15*time.Minute {
+		t.Errorf("blocked: got %v, want 15m", item.Blocked)
+	}
+	if item.Rework != 1 {
+		t.Errorf("rework: got %d, want 1", item.Rework)
+	}
+	if still := stats.Items[1]; still.Blocked != time.Hour {
+		t.Errorf("open blocker should accrue until now: got %v", still.Blocked)
+	}
+	if len(stats.Agents) != 2 || stats.Agents[0].Name != "(main)" || stats.Agents[1].Done != 1 {
+		t.Errorf("agents: got %+v", stats.Agents)
+	}
+	if stats.Job.Items != 2 || stats.Job.Rework != 1 || stats.Job.Blocked != 75*time.Minute {
+		t.Errorf("job: got %+v", stats.Job)
+	}
+}
@@ -285,3 +285,32 @@ func Test_ChecklistStats_Summary_with_blocked(t *testing.T) {
 		t.Errorf("Summary: got %q, want %q", got, "[!]1")
 	}
 }
+
+func Test_ParseChecklistItems_ids_and_history(t *testing.T) {
+	content := `# Checklist
+- [o] #1 DB schema
+- [~] #2 login API (depends on: #1)
+    - [ ] 2026-02-11 14:00:00 created
+    - [~] 2026-02-11 14:05:12 started
+- [ ] write docs
+`
+	items := ParseChecklistItems(content)
+	if len(items) != 3 {
+		t.Fatalf("expected 3 items (history entries skipped), got %d: %+v", len(items), items)
+	}
+	if items[0].ID != "#1" || items[0].Status != "o" {
+		t.Errorf("item 0: got %+v", items[0])
+	}
+	if items[1].ID != "#2" || items[1].Line != 3 {
+		t.Errorf("item 1: got %+v", items[1])
+	}
+	if items[2].ID != "" || items[2].Text != "write docs" {
+		t.Errorf("item 2: got %+v", items[2])
+	}
+	if len(items[1].History) != 2 || items[1].History[1].Status != "~" || items[1].History[1].Note != "started" {
+		t.Errorf("item 1 history: got %+v", items[1].History)
+	}
+	if !items[1].IsUnfinished() || items[0].IsUnfinished() {
+		t.Error("IsUnfinished: expected [~] unfinished and [o] finished")
+	}
+}
@@ -2,32 +2,38 @@ package hook
 
 import (
 	"encoding/json"
+	"fmt"
 	"os"
+	"path/filepath"
+	"sort"
+	"strings"
 )
 
 // JobState represents the workflow state tracked in state.json.
 type JobState struct {
 	JobID               string                `json:"job_id"`
 	CreatedAt           string                `json:"created_at"`
-	WorkflowType        string                `json:"workflow_type"`                   // "simple" or "complex"
-	Phases              map[string]PhaseState  `json:"phases"`
-	Agents              map[string]AgentState  `json:"agents"`
-	AutoResolveAttempts map[string]bool        `json:"auto_resolve_attempts,omitempty"` // tracks auto-resolve attempts (max 1 per dep)
+	WorkflowType        string                `json:"workflow_type"`        // "simple" or "complex"
+	SessionID           string                `json:"session_id,omitempty"` // Claude Code session that owns the job
+	Phases              map[string]PhaseState `json:"phases"`
+	Agents              map[string]AgentState `json:"agents"`
+	AutoResolveAttempts map[string]bool       `json:"auto_resolve_attempts,omitempty"` // tracks auto-resolve attempts (max 1 per dep)
 }
 
 // PhaseState tracks the status of a workflow phase.
 type PhaseState struct {
-	Status      string `json:"status"`                 // pending, in_progress, complete
+	Status      string `json:"status"` // pending, in_progress, complete
 	StartedAt   string `json:"started_at,omitempty"`
 	CompletedAt string `json:"completed_at,omitempty"`
 }
 
 // AgentState tracks the status of an agent's work.
 type AgentState struct {
-	Status      string   `json:"status"`                 // pending, in_progress, complete, failed, blocked
+	Status      string   `json:"status"` // pending, in_progress, complete, failed, blocked
 	Checklist   string   `json:"checklist,omitempty"`
 	CompletedAt string   `json:"completed_at,omitempty"`
 	BlockedBy   []string `json:"blocked_by,omitempty"`
+	Artifacts   []string `json:"artifacts,omitempty"` // output files (relative to the job dir) the agent must produce
 }
 
 // LoadJobState reads and parses a state.json file.
@@ -49,3 +55,85 @@ func SaveJobState(path string, state *JobState) error {
 	data = append(data, '\n')
 	return os.WriteFile(path, data, 0644)
 }
+
+// FindJobDirs returns the job directories .do/jobs/{YY}/{MM}/{DD}/{title}/
+// that contain a state.json, oldest first.
+func FindJobDirs() []string {
+	states, _ := filepath.Glob(filepath.Join(".do", "jobs", "*", "*", "*", "*", "state.json"))
+	var dirs []string
+	for _, path := range states {
+		if dir := filepath.Dir(path); isDatedJobDir(dir) {
+			dirs = append(dirs, dir)
+		}
+	}
+	sort.Strings(dirs)
+	return dirs
+}
+
+// FindLatestJobDir returns the most recent job directory under .do/jobs/
+// that contains a state.json, or "" if none exists.
+func FindLatestJobDir() string {
+	dirs := FindJobDirs()
+	if len(dirs) == 0 {
+		return ""
+	}
+	return dirs[len(dirs)-1]
+}
+
+// CurrentJobFile is the path of the explicit current-job pointer relative to project root.
+const CurrentJobFile = ".do/.current-job"
+
+// ReadCurrentJob returns the job directory named by the current-job pointer,
+// or "" if no pointer is set or it no longer points at a directory.
+func ReadCurrentJob() string {
+	data, err := os.ReadFile(CurrentJobFile)
+	if err != nil {
+		return ""
+	}
+	dir := strings.TrimSpace(string(data))
+	if info, err := os.Stat(dir); err != nil || !info.IsDir() {
+		return ""
+	}
+	return dir
+}
+
+// WriteCurrentJob sets the current-job pointer. An empty dir clears it.
+func WriteCurrentJob(dir string) error {
+	if dir == "" {
+		err := os.Remove(CurrentJobFile)
+		if os.IsNotExist(err) {
+			return nil
+		}
+		return err
+	}
+	if err := os.MkdirAll(filepath.Dir(CurrentJobFile), 0755); err != nil {
+		return err
+	}
+	return os.WriteFile(CurrentJobFile, []byte(filepath.Clean(dir)+"\n"), 0644)
+}
+
+// ResolveJobDir resolves a job reference to its directory. The reference may be
+// a path to a job directory or a job title (the kebab-case directory name under
+// .do/jobs/{YY}/{MM}/{DD}/). An empty reference resolves to the current-job
+// pointer, falling back to the latest job.
+func ResolveJobDir(ref string) (string, error) {
+	if ref == "" {
+		if dir := ReadCurrentJob(); dir != "" {
+			return dir, nil
+		}
+		dir := FindLatestJobDir()
+		if dir == "" {
+			return "", fmt.Errorf("no job with state.json found under .do/jobs")
+		}
+		return dir, nil
+	}
+	if info, err := os.Stat(ref); err == nil && info.IsDir() {
+		return ref, nil
+	}
+	matches, _ := filepath.Glob(filepath.Join(".do", "jobs", "*", "*", "*", ref))
+	if len(matches) == 0 {
+		return "", fmt.Errorf("job %q not found under .do/jobs", ref)
+	}
+	sort.Strings(matches)
+	return matches[len(matches)-1], nil
+}
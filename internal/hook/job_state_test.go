@@ -107,3 +107,25 @@ func Test_SaveJobState_invalid_path(t *testing.T) {
 		t.Fatal("expected error for invalid path")
 	}
 }
+
+func Test_FindLatestJobDir_ignores_nested_state_files(t *testing.T) {
+	origDir, _ := os.Getwd()
+	if err := os.Chdir(t.TempDir()); err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(func() { os.Chdir(origDir) })
+
+	job := filepath.Join(".do", "jobs", "26", "02", "18", "task")
+	for _, dir := range []string{job, filepath.Join(job, "artifacts", "zz")} {
+		if err := os.MkdirAll(dir, 0755); err != nil {
+			t.Fatal(err)
+		}
+		if err := SaveJobState(filepath.Join(dir, "state.json"), &JobState{JobID: "task"}); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	if got := FindLatestJobDir(); got != job {
+		t.Errorf("FindLatestJobDir() = %q, want %q", got, job)
+	}
+}
@@ -1,7 +1,57 @@
 package hook
 
+import (
+	"os"
+	"time"
+
+	"github.com/yejune/godo/internal/lint"
+)
+
 // HandlePostToolUse handles the PostToolUse hook event.
-// Keep it non-intrusive for baseline compatibility.
+// Keep it non-intrusive for baseline compatibility: checklist edits are
+// recorded in the job history, and edited files are linted only when
+// .do/lint.yaml enables hook.on_edit.
 func HandlePostToolUse(input *Input) *Output {
+	if input == nil || (input.ToolName != "Write" && input.ToolName != "Edit") {
+		return &Output{}
+	}
+	recordChecklistEdit(input)
+	if report := lintEditedFile(input); report != "" {
+		return NewPostToolOutput(report)
+	}
 	return &Output{}
 }
+
+// recordChecklistEdit appends status transitions to the job's history log
+// if the tool wrote a job checklist file. Errors are ignored.
+func recordChecklistEdit(input *Input) {
+	jobDir, relFile, ok := ChecklistFileJob(extractFilePath(input.ToolInput))
+	if !ok {
+		return
+	}
+	_, _ = RecordChecklistTransitions(jobDir, relFile, input.SessionID, time.Now())
+}
+
+// lintEditedFile lints the written file when the project opts in. Results
+// are cached in .do/cache/lint, so an unchanged file is not linted again.
+func lintEditedFile(input *Input) string {
+	filePath := extractFilePath(input.ToolInput)
+	if filePath == "" {
+		return ""
+	}
+	projectDir := os.Getenv("CLAUDE_PROJECT_DIR")
+	if projectDir == "" {
+		projectDir = input.CWD
+	}
+	if projectDir == "" {
+		return ""
+	}
+	reg, err := lint.LoadRegistry(projectDir)
+	if err != nil || !reg.Hook.OnEdit || len(reg.ForFile(filePath)) == 0 {
+		return ""
+	}
+	reg.Cache = lint.NewCache(projectDir)
+	report := reg.RunForHook(filePath, projectDir)
+	_ = reg.Cache.SaveStats()
+	return report
+}
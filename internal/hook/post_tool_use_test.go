@@ -6,6 +6,8 @@ import (
 	"path/filepath"
 	"strings"
 	"testing"
+
+	"github.com/yejune/godo/internal/lint"
 )
 
 func TestHandlePostToolUse_ReturnsEmptyOutputWhenNoPersonaDir(t *testing.T) {
@@ -218,3 +220,92 @@ func TestHandlePostToolUse_WithTempPersonaFixture(t *testing.T) {
 		t.Errorf("HookEventName: got %q, want %q", out.HookSpecificOutput.HookEventName, "PostToolUse")
 	}
 }
+
+func TestHandlePostToolUse_LintsEditedFileWhenEnabled(t *testing.T) {
+	tmpDir := t.TempDir()
+	if err := os.MkdirAll(filepath.Join(tmpDir, ".do"), 0755); err != nil {
+		t.Fatal(err)
+	}
+	registry := `hook:
+  on_edit: true
+linters:
+  - name: fake
+    files: ["*.txt"]
+    command: 'sh -c "echo notes.txt:1:1: bad wording"'
+    format: regex
+    pattern: '^(?P<file>[^:]+):(?P<line>\d+):(?P<column>\d+): (?P<message>.+)$'
+`
+	if err := os.WriteFile(filepath.Join(tmpDir, ".do", "lint.yaml"), []byte(registry), 0644); err != nil {
+		t.Fatal(err)
+	}
+	file := filepath.Join(tmpDir, "notes.txt")
+	if err := os.WriteFile(file, []byte("draft\n"), 0644); err != nil {
+		t.Fatal(err)
+	}
+
+	origProjectDir := os.Getenv("CLAUDE_PROJECT_DIR")
+	os.Setenv("CLAUDE_PROJECT_DIR", tmpDir)
+	defer os.Setenv("CLAUDE_PROJECT_DIR", origProjectDir)
+
+	toolInput, _ := json.Marshal(map[string]string{"file_path": file})
+	out := HandlePostToolUse(&Input{ToolName: "Write", ToolInput: toolInput})
+	if out.HookSpecificOutput == nil || !strings.Contains(out.HookSpecificOutput.AdditionalContext, "bad wording") {
+		t.Fatalf("expected lint report in additional context, got %+v", out.HookSpecificOutput)
+	}
+
+	// Without hook.on_edit the hook stays silent.
+	if err := os.WriteFile(filepath.Join(tmpDir, ".do", "lint.yaml"), []byte(strings.Replace(registry, "on_edit: true", "on_edit: false", 1)), 0644); err != nil {
+		t.Fatal(err)
+	}
+	if out := HandlePostToolUse(&Input{ToolName: "Write", ToolInput: toolInput}); out.HookSpecificOutput != nil {
+		t.Errorf("expected no output when on_edit is off, got %+v", out.HookSpecificOutput)
+	}
+}
+
+func Test_HandlePostToolUse_lint_reuses_cache_for_unchanged_file(t *testing.T) {
+	tmpDir := t.TempDir()
+	if err := os.MkdirAll(filepath.Join(tmpDir, ".do"), 0755); err != nil {
+		t.Fatal(err)
+	}
+	registry := `hook:
+  on_edit: true
+linters:
+  - name: fake
+    files: ["*.txt"]
+    command: 'sh -c "echo run >> calls.log; echo notes.txt:1:1: bad wording" {files}'
+    version_command: 'sh -c "echo v1"'
+    format: regex
+    pattern: '^(?P<file>[^:]+):(?P<line>\d+):(?P<column>\d+): (?P<message>.+)$'
+`
+	if err := os.WriteFile(filepath.Join(tmpDir, ".do", "lint.yaml"), []byte(registry), 0644); err != nil {
+		t.Fatal(err)
+	}
+	file := filepath.Join(tmpDir, "notes.txt")
+	if err := os.WriteFile(file, []byte("draft\n"), 0644); err != nil {
+		t.Fatal(err)
+	}
+	t.Setenv("CLAUDE_PROJECT_DIR", tmpDir)
+
+	toolInput, _ := json.Marshal(map[string]string{"file_path": file})
+	for i := 0; i < 2; i++ {
+		out := HandlePostToolUse(&Input{ToolName: "Write", ToolInput: toolInput})
+		if out.HookSpecificOutput == nil || !strings.Contains(out.HookSpecificOutput.AdditionalContext, "bad wording") {
+			t.Fatalf("run %d: expected lint report in additional context, got %+v", i+1, out.HookSpecificOutput)
+		}
+	}
+
+	calls, err := os.ReadFile(filepath.Join(tmpDir, "calls.log"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if n := len(strings.Fields(string(calls))); n != 1 {
+		t.Errorf("linter ran %d times, want 1", n)
+	}
+	usage, err := lint.NewCache(tmpDir).Usage()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if usage.LastRun.Hits != 1 || usage.Total.Misses != 1 {
+		t.Errorf("cache stats: last run %+v, total %+v; want a hit after one miss", usage.LastRun, usage.Total)
+	}
+}
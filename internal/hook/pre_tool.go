@@ -2,12 +2,20 @@ package hook
 
 import (
 	"encoding/json"
+	"fmt"
 	"strings"
+
+	"github.com/yejune/godo/internal/mode"
 )
 
 // HandlePreTool handles the PreToolUse hook event.
-// It checks file paths and bash commands against security policies.
+// It checks the tool against the current execution mode's tool policy,
+// then file paths and bash commands against security policies.
 func HandlePreTool(input *Input) *Output {
+	if out := checkModePolicy(input); out != nil {
+		return out
+	}
+
 	policy := DefaultSecurityPolicy()
 	toolName := input.ToolName
 
@@ -21,6 +29,49 @@ func HandlePreTool(input *Input) *Output {
 	}
 }
 
+// checkModePolicy denies tools the current execution mode does not allow.
+// Calls from a subagent carry an agent_id. Unreadable mode config falls
+// back to the built-in modes, so a broken modes.yaml cannot turn off
+// their policies.
+func checkModePolicy(input *Input) *Output {
+	if input.ToolName == "" {
+		return nil
+	}
+	modes, err := mode.Load(input.Dir())
+	if err != nil {
+		modes = &mode.Set{Modes: mode.Builtin()}
+	}
+	current := mode.Current(input.Dir(), input.SessionID)
+	m, ok := modes.Get(current)
+	if !ok {
+		return nil
+	}
+	denial := m.Check(input.ToolName, input.AgentID != "")
+	if denial == nil {
+		return nil
+	}
+	return NewDenyOutput(modeDenyReason(denial, modes))
+}
+
+// modeDenyReason names the mode and how to leave it.
+func modeDenyReason(denial *mode.Denial, modes *mode.Set) string {
+	reason := "Blocked: " + denial.Error() + "."
+	if denial.AgentOnly {
+		reason += " Launch an agent with Task to make this change."
+	}
+	var others []string
+	for _, name := range modes.Allowing(denial.Tool) {
+		if name != denial.Mode {
+			others = append(others, name)
+		}
+	}
+	if len(others) == 0 {
+		return reason
+	}
+	return reason + fmt.Sprintf(" To use %s here, switch modes with `godo mode %s` (allowed in: %s).",
+		denial.Tool, others[0], strings.Join(others, ", "))
+}
+
 // checkFileAccess validates file tool access against security patterns.
 func checkFileAccess(policy *SecurityPolicy, input *Input) *Output {
 	filePath := extractFilePath(input.ToolInput)
@@ -2,8 +2,12 @@ package hook
 
 import (
 	"encoding/json"
+	"os"
+	"path/filepath"
 	"strings"
 	"testing"
+
+	"github.com/yejune/godo/internal/mode"
 )
 
 func TestHandlePreTool_AllowsNormalFileWrite(t *testing.T) {
@@ -287,3 +291,69 @@ func TestHandlePreTool_WriteEmptyInput(t *testing.T) {
 		t.Errorf("expected decision %q for empty input, got %q", DecisionAllow, output.HookSpecificOutput.PermissionDecision)
 	}
 }
+
+func TestHandlePreTool_EnforcesModeToolPolicy(t *testing.T) {
+	t.Setenv("HOME", t.TempDir())
+	t.Setenv("CLAUDE_PROJECT_DIR", "")
+	t.Setenv("DO_MODE", "")
+	dir := t.TempDir()
+	os.MkdirAll(filepath.Join(dir, ".git"), 0755)
+	write := json.RawMessage(`{"file_path": "/project/main.go"}`)
+
+	decide := func(in *Input) *SpecificOutput {
+		t.Helper()
+		in.CWD = dir
+		return HandlePreTool(in).HookSpecificOutput
+	}
+
+	mode.WriteStateAt(dir, "focus")
+	out := decide(&Input{ToolName: "Task"})
+	if out.PermissionDecision != DecisionDeny || !strings.Contains(out.PermissionDecisionReason, "focus mode") ||
+		!strings.Contains(out.PermissionDecisionReason, "godo mode do") {
+		t.Errorf("focus/Task: %+v", out)
+	}
+	if out := decide(&Input{ToolName: "Write", ToolInput: write}); out.PermissionDecision != DecisionAllow {
+		t.Errorf("focus/Write: %+v", out)
+	}
+
+	mode.WriteStateAt(dir, "team")
+	out = decide(&Input{ToolName: "Edit", ToolInput: write})
+	if out.PermissionDecision != DecisionDeny || !strings.Contains(out.PermissionDecisionReason, "delegated to an agent") {
+		t.Errorf("team/Edit from main conversation: %+v", out)
+	}
+	if out := decide(&Input{ToolName: "Edit", ToolInput: write, AgentID: "a1"}); out.PermissionDecision != DecisionAllow {
+		t.Errorf("team/Edit from agent: %+v", out)
+	}
+
+	// A session override wins over the directory mode.
+	mode.WriteSessionState(dir, "s1", "review")
+	out = decide(&Input{ToolName: "Write", ToolInput: write, SessionID: "s1"})
+	if out.PermissionDecision != DecisionDeny || !strings.Contains(out.PermissionDecisionReason, "review mode") {
+		t.Errorf("review/Write: %+v", out)
+	}
+
+	// Security checks still apply to tools the mode allows.
+	mode.WriteStateAt(dir, "do")
+	if out := decide(&Input{ToolName: "Bash", ToolInput: json.RawMessage(`{"command": "rm -rf /"}`)}); out.PermissionDecision != DecisionDeny {
+		t.Errorf("do/dangerous Bash: %+v", out)
+	}
+}
+
+func TestHandlePreTool_MalformedModeConfigKeepsBuiltinPolicy(t *testing.T) {
+	t.Setenv("HOME", t.TempDir())
+	t.Setenv("CLAUDE_PROJECT_DIR", "")
+	t.Setenv("DO_MODE", "")
+	dir := t.TempDir()
+	os.MkdirAll(filepath.Join(dir, ".git"), 0755)
+	os.MkdirAll(filepath.Join(dir, ".do"), 0755)
+	os.WriteFile(filepath.Join(dir, mode.ConfigFile), []byte("modes: [unclosed\n"), 0644)
+	if _, err := mode.Load(dir); err == nil {
+		t.Fatal("expected the malformed modes.yaml to fail to load")
+	}
+	mode.WriteStateAt(dir, "review")
+
+	out := HandlePreTool(&Input{ToolName: "Write", ToolInput: json.RawMessage(`{"file_path": "/project/main.go"}`), CWD: dir}).HookSpecificOutput
+	if out.PermissionDecision != DecisionDeny || !strings.Contains(out.PermissionDecisionReason, "review mode") {
+		t.Errorf("review/Write with malformed modes.yaml: %+v", out)
+	}
+}
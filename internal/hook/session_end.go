@@ -1,7 +1,44 @@
 package hook
 
+import (
+	"github.com/yejune/godo/internal/mode"
+	"github.com/yejune/godo/internal/rank"
+)
+
 // HandleSessionEnd handles the SessionEnd hook event.
-// Minimal implementation - allows session to end normally.
+// When the user is logged in to Rank, the finished session is added to the
+// submission queue (~/.do/rank/queue) for the next 'godo rank sync'. Queue
+// failures never keep the session from ending. A mode override set for
+// the session is removed.
 func HandleSessionEnd(input *Input) *Output {
+	if input.SessionID != "" && rank.HasCredentials() {
+		_ = queueSession(rank.NewQueue(rank.GetQueueDir()), input)
+	}
+	if input.SessionID != "" {
+		_ = mode.ClearSessionState(input.Dir(), input.SessionID)
+	}
 	return &Output{Continue: true}
 }
+
+// queueSession parses the session transcript and queues its usage.
+func queueSession(q *rank.Queue, input *Input) error {
+	path := input.TranscriptPath
+	if path == "" {
+		path = rank.FindTranscriptForSession(input.SessionID)
+	}
+	if path == "" {
+		return nil
+	}
+	usage, err := rank.ParseTranscript(path)
+	if err != nil {
+		return err
+	}
+	if usage.InputTokens == 0 && usage.OutputTokens == 0 {
+		return nil
+	}
+	sub, err := rank.BuildSubmission(usage)
+	if err != nil {
+		return err
+	}
+	return q.Add(input.SessionID, sub)
+}
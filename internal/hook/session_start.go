@@ -9,7 +9,7 @@ import (
 // HandleSessionStart handles the SessionStart hook event.
 // Keep startup message minimal and compatible with the original godo behavior.
 func HandleSessionStart(input *Input) *Output {
-	currentMode := mode.ReadState()
+	currentMode := mode.Current(input.Dir(), input.SessionID)
 	message := fmt.Sprintf("current_mode: %s", currentMode)
 	return NewSessionOutput(true, message)
 }
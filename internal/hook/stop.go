@@ -7,80 +7,147 @@ import (
 	"path/filepath"
 	"sort"
 	"strings"
+	"time"
 )
 
+// ActiveJobMaxAge bounds how old a job's checklist may be (by modification
+// time) and still count as active. The current-job pointer is exempt.
+const ActiveJobMaxAge = 7 * 24 * time.Hour
+
 // HandleStop handles the Stop hook event.
-// Match original behavior:
-// 1) If stop_hook_active is true, allow stop to prevent loops.
-// 2) Block only when the most recent active checklist is in-progress/blocked.
+//  1. If stop_hook_active is true, allow stop to prevent loops.
+//  2. Block only when the job belonging to this session has in-progress/blocked
+//     checklist items. Other active jobs are listed as a warning.
+//
+// The session's job is the one whose state.json is bound to the session ID;
+// otherwise the current-job pointer (.do/.current-job); otherwise, when no
+// pointer is set, the newest unbound active job.
 func HandleStop(input *Input) *Output {
 	if input != nil && input.StopHookActive {
 		return &Output{}
 	}
-	if reason := checkActiveChecklist(); reason != "" {
+
+	sessionID := ""
+	if input != nil {
+		sessionID = input.SessionID
+	}
+	jobs := FindActiveJobs(ActiveJobMaxAge, time.Now())
+	own, others := selectSessionJob(jobs, sessionID, ReadCurrentJob())
+
+	warning := otherJobsWarning(others)
+	if own != nil {
+		reason := own.Summary
+		if warning != "" {
+			reason += "\n" + warning
+		}
 		return NewStopBlockOutput(reason)
 	}
+	if warning != "" {
+		return &Output{SystemMessage: warning}
+	}
 	return &Output{}
 }
 
-func checkActiveChecklist() string {
-	jobsDir := ".do/jobs"
-	if _, err := os.Stat(jobsDir); os.IsNotExist(err) {
-		return ""
-	}
+// ActiveJob is a job whose checklist has in-progress or blocked items.
+type ActiveJob struct {
+	Dir       string
+	SessionID string // session bound in state.json, if any
+	ModTime   time.Time
+	Summary   string // human-readable checklist summary
+}
 
-	yearDirs, err := os.ReadDir(jobsDir)
-	if err != nil || len(yearDirs) == 0 {
-		return ""
-	}
-	sort.Slice(yearDirs, func(i, j int) bool { return yearDirs[i].Name() > yearDirs[j].Name() })
+// FindActiveJobs returns all jobs under .do/jobs/{YY}/{MM}/{DD}/{title}/ with
+// in-progress or blocked checklist items, most recently modified checklist
+// first. Jobs whose checklist was last modified more than maxAge before now
+// are skipped unless they are the current-job pointer.
+func FindActiveJobs(maxAge time.Duration, now time.Time) []ActiveJob {
+	current := ReadCurrentJob()
+	matches, _ := filepath.Glob(filepath.Join(".do", "jobs", "*", "*", "*", "*", "checklist.md"))
 
-	for _, yearDir := range yearDirs {
-		if !yearDir.IsDir() || !stopIsDigits(yearDir.Name()) {
+	var jobs []ActiveJob
+	for _, checklistPath := range matches {
+		jobDir := filepath.Dir(checklistPath)
+		if !isDatedJobDir(jobDir) {
 			continue
 		}
-		monthPath := filepath.Join(jobsDir, yearDir.Name())
-		monthDirs, err := os.ReadDir(monthPath)
+		info, err := os.Stat(checklistPath)
 		if err != nil {
 			continue
 		}
-		sort.Slice(monthDirs, func(i, j int) bool { return monthDirs[i].Name() > monthDirs[j].Name() })
-		for _, monthDir := range monthDirs {
-			if !monthDir.IsDir() || !stopIsDigits(monthDir.Name()) {
-				continue
+		if maxAge > 0 && now.Sub(info.ModTime()) > maxAge && !sameDir(jobDir, current) {
+			continue
+		}
+		summary := parseChecklistSummary(checklistPath)
+		if summary == "" {
+			continue
+		}
+		job := ActiveJob{Dir: jobDir, ModTime: info.ModTime(), Summary: summary}
+		if state, err := LoadJobState(filepath.Join(jobDir, "state.json")); err == nil {
+			job.SessionID = state.SessionID
+		}
+		jobs = append(jobs, job)
+	}
+	sort.SliceStable(jobs, func(i, j int) bool { return jobs[i].ModTime.After(jobs[j].ModTime) })
+	return jobs
+}
+
+// selectSessionJob picks the job the stopping session is responsible for and
+// returns the remaining jobs separately.
+func selectSessionJob(jobs []ActiveJob, sessionID, current string) (*ActiveJob, []ActiveJob) {
+	idx := -1
+	if sessionID != "" {
+		for i, j := range jobs {
+			if j.SessionID == sessionID {
+				idx = i
+				break
 			}
-			dayPath := filepath.Join(monthPath, monthDir.Name())
-			dayDirs, err := os.ReadDir(dayPath)
-			if err != nil {
+		}
+	}
+	if idx < 0 {
+		for i, j := range jobs {
+			if j.SessionID != "" {
 				continue
 			}
-			sort.Slice(dayDirs, func(i, j int) bool { return dayDirs[i].Name() > dayDirs[j].Name() })
-			for _, dayDir := range dayDirs {
-				if !dayDir.IsDir() || !stopIsDigits(dayDir.Name()) {
-					continue
-				}
-				taskRoot := filepath.Join(dayPath, dayDir.Name())
-				taskDirs, err := os.ReadDir(taskRoot)
-				if err != nil {
-					continue
-				}
-				sort.Slice(taskDirs, func(i, j int) bool { return taskDirs[i].Name() > taskDirs[j].Name() })
-				for _, taskDir := range taskDirs {
-					if !taskDir.IsDir() {
-						continue
-					}
-					checklistPath := filepath.Join(taskRoot, taskDir.Name(), "checklist.md")
-					if summary := parseChecklistSummary(checklistPath); summary != "" {
-						return summary
-					}
-				}
-				break
+			if current != "" && !sameDir(j.Dir, current) {
+				continue
 			}
+			idx = i
 			break
 		}
-		break
 	}
-	return ""
+	if idx < 0 {
+		return nil, jobs
+	}
+	own := jobs[idx]
+	others := append(append([]ActiveJob{}, jobs[:idx]...), jobs[idx+1:]...)
+	return &own, others
+}
+
+func otherJobsWarning(jobs []ActiveJob) string {
+	if len(jobs) == 0 {
+		return ""
+	}
+	var sb strings.Builder
+	sb.WriteString("다른 진행중인 작업이 있습니다 (이 세션의 종료는 막지 않음):")
+	for _, j := range jobs {
+		fmt.Fprintf(&sb, "\n  - %s (최종 수정 %s)", j.Dir, j.ModTime.Format("2006-01-02 15:04"))
+		if j.SessionID != "" {
+			fmt.Fprintf(&sb, " [session %s]", j.SessionID)
+		}
+	}
+	return sb.String()
+}
+
+// isDatedJobDir reports whether dir is .do/jobs/{YY}/{MM}/{DD}/{title}.
+func isDatedJobDir(dir string) bool {
+	day := filepath.Dir(dir)
+	month := filepath.Dir(day)
+	year := filepath.Dir(month)
+	return stopIsDigits(filepath.Base(year)) && stopIsDigits(filepath.Base(month)) && stopIsDigits(filepath.Base(day))
+}
+
+func sameDir(a, b string) bool {
+	return a != "" && b != "" && filepath.Clean(a) == filepath.Clean(b)
 }
 
 func parseChecklistSummary(path string) string {
@@ -3,7 +3,9 @@ package hook
 import (
 	"os"
 	"path/filepath"
+	"strings"
 	"testing"
+	"time"
 )
 
 func Test_HandleStop_no_checklist(t *testing.T) {
@@ -244,3 +246,135 @@ func Test_HandleStop_allows_clean_state(t *testing.T) {
 		t.Errorf("expected empty Decision when clean, got %q", output.Decision)
 	}
 }
+
+// writeJob creates .do/jobs/{date}/{title}/ with a checklist and, when
+// sessionID is non-empty, a state.json bound to that session.
+func writeJob(t *testing.T, date, title, checklist, sessionID string) string {
+	t.Helper()
+	dir := filepath.Join(".do", "jobs", date, title)
+	if err := os.MkdirAll(dir, 0755); err != nil {
+		t.Fatal(err)
+	}
+	if err := os.WriteFile(filepath.Join(dir, "checklist.md"), []byte(checklist), 0644); err != nil {
+		t.Fatal(err)
+	}
+	if sessionID != "" {
+		if err := SaveJobState(filepath.Join(dir, "state.json"), &JobState{JobID: title, SessionID: sessionID}); err != nil {
+			t.Fatal(err)
+		}
+	}
+	return dir
+}
+
+func Test_HandleStop_finds_job_from_previous_day(t *testing.T) {
+	origDir, _ := os.Getwd()
+	os.Chdir(t.TempDir())
+	defer os.Chdir(origDir)
+
+	writeJob(t, "26/02/17", "yesterday-task", "- [~] still going\n", "")
+	writeJob(t, "26/02/18", "today-done", "- [o] finished\n", "")
+
+	output := HandleStop(&Input{})
+	if output.Decision != DecisionBlock || !strings.Contains(output.Reason, "yesterday-task") {
+		t.Errorf("expected block on yesterday's job, got %+v", output)
+	}
+}
+
+func Test_HandleStop_scopes_to_session_job(t *testing.T) {
+	origDir, _ := os.Getwd()
+	os.Chdir(t.TempDir())
+	defer os.Chdir(origDir)
+
+	writeJob(t, "26/02/18", "mine", "- [~] my item\n", "sess-a")
+	writeJob(t, "26/02/18", "theirs", "- [!] their item\n", "sess-b")
+
+	output := HandleStop(&Input{SessionID: "sess-a"})
+	if output.Decision != DecisionBlock {
+		t.Fatalf("expected block, got %+v", output)
+	}
+	if !strings.Contains(output.Reason, "mine") {
+		t.Errorf("reason should name own job, got %q", output.Reason)
+	}
+	if !strings.Contains(output.Reason, "theirs") {
+		t.Errorf("reason should list the other job as a warning, got %q", output.Reason)
+	}
+}
+
+func Test_HandleStop_other_session_job_only_warns(t *testing.T) {
+	origDir, _ := os.Getwd()
+	os.Chdir(t.TempDir())
+	defer os.Chdir(origDir)
+
+	writeJob(t, "26/02/18", "theirs", "- [~] their item\n", "sess-b")
+
+	output := HandleStop(&Input{SessionID: "sess-a"})
+	if output.Decision != "" {
+		t.Errorf("should not block on another session's job, got %q", output.Decision)
+	}
+	if !strings.Contains(output.SystemMessage, "theirs") {
+		t.Errorf("expected warning naming the other job, got %q", output.SystemMessage)
+	}
+}
+
+func Test_HandleStop_respects_current_job_pointer(t *testing.T) {
+	origDir, _ := os.Getwd()
+	os.Chdir(t.TempDir())
+	defer os.Chdir(origDir)
+
+	older := writeJob(t, "26/02/17", "pointed", "- [~] pointed item\n", "")
+	writeJob(t, "26/02/18", "newer", "- [~] newer item\n", "")
+	if err := WriteCurrentJob(older); err != nil {
+		t.Fatal(err)
+	}
+
+	output := HandleStop(&Input{})
+	if output.Decision != DecisionBlock {
+		t.Fatalf("expected block, got %+v", output)
+	}
+	if !strings.HasPrefix(output.Reason, "활성 체크리스트") || !strings.Contains(output.Reason, "pointed/checklist.md") {
+		t.Errorf("expected block on pointed job, got %q", output.Reason)
+	}
+}
+
+func Test_FindActiveJobs_skips_stale_jobs(t *testing.T) {
+	origDir, _ := os.Getwd()
+	os.Chdir(t.TempDir())
+	defer os.Chdir(origDir)
+
+	dir := writeJob(t, "25/01/01", "ancient", "- [~] forgotten\n", "")
+	old := time.Now().Add(-30 * 24 * time.Hour)
+	if err := os.Chtimes(filepath.Join(dir, "checklist.md"), old, old); err != nil {
+		t.Fatal(err)
+	}
+
+	if jobs := FindActiveJobs(ActiveJobMaxAge, time.Now()); len(jobs) != 0 {
+		t.Errorf("expected stale job to be skipped, got %+v", jobs)
+	}
+
+	// The current-job pointer is exempt from the age bound.
+	if err := WriteCurrentJob(dir); err != nil {
+		t.Fatal(err)
+	}
+	if jobs := FindActiveJobs(ActiveJobMaxAge, time.Now()); len(jobs) != 1 {
+		t.Errorf("expected pointed stale job to be active, got %+v", jobs)
+	}
+}
+
+func Test_FindActiveJobs_orders_by_modification_time(t *testing.T) {
+	origDir, _ := os.Getwd()
+	os.Chdir(t.TempDir())
+	defer os.Chdir(origDir)
+
+	// The job dated later was touched earlier.
+	later := writeJob(t, "26/02/19", "later", "- [~] waiting\n", "")
+	earlier := writeJob(t, "26/02/18", "earlier", "- [~] working\n", "")
+	old := time.Now().Add(-time.Hour)
+	if err := os.Chtimes(filepath.Join(later, "checklist.md"), old, old); err != nil {
+		t.Fatal(err)
+	}
+
+	jobs := FindActiveJobs(ActiveJobMaxAge, time.Now())
+	if len(jobs) != 2 || jobs[0].Dir != earlier || jobs[1].Dir != later {
+		t.Errorf("expected most recently modified first, got %+v", jobs)
+	}
+}
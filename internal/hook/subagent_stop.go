@@ -1,7 +1,192 @@
 package hook
 
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+	"time"
+)
+
 // HandleSubagentStop handles the SubagentStop hook event.
-// Baseline compatibility: continue without injecting extra warnings.
+// It verifies the finishing agent against its entry in the job's state.json:
+// the agent's checklist must have no pending/in-progress/testing items and
+// every declared artifact must exist. The agent state is updated either way.
+// The job is the current-job pointer, else the latest job not bound to
+// another session. Only an unbound job named by the pointer is bound to the
+// session, so the Stop hook can scope to it. No other context is injected.
+// When stop_hook_active is set the agent is never blocked again, to avoid loops.
 func HandleSubagentStop(input *Input) *Output {
-	return &Output{Continue: true}
+	output := &Output{Continue: true}
+
+	name := subagentName(input)
+	jobDir, pointed := ReadCurrentJob(), true
+	if jobDir == "" {
+		jobDir, pointed = latestJobFor(input.SessionID), false
+	}
+	if name == "" || jobDir == "" {
+		return output
+	}
+
+	statePath := filepath.Join(jobDir, "state.json")
+	state, err := LoadJobState(statePath)
+	if err != nil {
+		return output
+	}
+	agent, ok := state.Agents[name]
+	if !ok {
+		return output
+	}
+
+	v := VerifyAgent(jobDir, name, agent)
+	if rel, err := filepath.Rel(jobDir, v.ChecklistPath); err == nil && v.ChecklistPath != "" {
+		_, _ = RecordChecklistTransitions(jobDir, rel, input.SessionID, time.Now())
+	}
+	state.Agents[name] = v.Apply(agent, time.Now().UTC())
+	if pointed && state.SessionID == "" && input.SessionID != "" {
+		state.SessionID = input.SessionID
+	}
+	_ = SaveJobState(statePath, state)
+
+	if reason := v.BlockReason(name); reason != "" && !input.StopHookActive {
+		return NewStopBlockOutput(reason)
+	}
+	return output
+}
+
+// latestJobFor returns the newest job that is unbound or bound to sessionID.
+func latestJobFor(sessionID string) string {
+	dirs := FindJobDirs()
+	for i := len(dirs) - 1; i >= 0; i-- {
+		state, err := LoadJobState(filepath.Join(dirs[i], "state.json"))
+		if err != nil {
+			continue
+		}
+		if state.SessionID == "" || state.SessionID == sessionID {
+			return dirs[i]
+		}
+	}
+	return ""
+}
+
+// subagentName returns the agent name used as the key in JobState.Agents.
+func subagentName(input *Input) string {
+	if input == nil {
+		return ""
+	}
+	if input.AgentType != "" {
+		return input.AgentType
+	}
+	return input.AgentID
+}
+
+// AgentVerification is the result of checking an agent's checklist and artifacts.
+type AgentVerification struct {
+	ChecklistPath    string
+	Unfinished       []ChecklistItem // [ ], [~], [*]
+	Blocked          []ChecklistItem // [!]
+	Failed           []ChecklistItem // [x]
+	MissingArtifacts []string
+	ChecklistMissing bool
+}
+
+// VerifyAgent inspects the agent's checklist and declared artifacts under jobDir.
+// If the state does not name a checklist, checklists/*_{name}.md is used.
+func VerifyAgent(jobDir, name string, agent AgentState) *AgentVerification {
+	v := &AgentVerification{ChecklistPath: agentChecklistPath(jobDir, name, agent)}
+
+	if v.ChecklistPath == "" {
+		v.ChecklistMissing = true
+	} else if data, err := os.ReadFile(v.ChecklistPath); err != nil {
+		v.ChecklistMissing = true
+	} else {
+		for _, item := range ParseChecklistItems(string(data)) {
+			switch {
+			case item.IsUnfinished():
+				v.Unfinished = append(v.Unfinished, item)
+			case item.Status == "!":
+				v.Blocked = append(v.Blocked, item)
+			case item.Status == "x":
+				v.Failed = append(v.Failed, item)
+			}
+		}
+	}
+
+	for _, artifact := range agent.Artifacts {
+		if _, err := os.Stat(filepath.Join(jobDir, artifact)); err != nil {
+			v.MissingArtifacts = append(v.MissingArtifacts, artifact)
+		}
+	}
+	return v
+}
+
+func agentChecklistPath(jobDir, name string, agent AgentState) string {
+	if agent.Checklist != "" {
+		return filepath.Join(jobDir, agent.Checklist)
+	}
+	matches, _ := filepath.Glob(filepath.Join(jobDir, "checklists", fmt.Sprintf("*_%s.md", name)))
+	if len(matches) == 0 {
+		return ""
+	}
+	return matches[0]
+}
+
+// Status derives the agent status from the verification result.
+func (v *AgentVerification) Status() string {
+	switch {
+	case len(v.Failed) > 0:
+		return "failed"
+	case len(v.Blocked) > 0:
+		return "blocked"
+	case v.ChecklistMissing, len(v.Unfinished) > 0, len(v.MissingArtifacts) > 0:
+		return "in_progress"
+	default:
+		return "complete"
+	}
+}
+
+// Apply returns the agent state updated with the derived status.
+// CompletedAt is set only on completion; BlockedBy lists the blocking items.
+func (v *AgentVerification) Apply(agent AgentState, now time.Time) AgentState {
+	agent.Status = v.Status()
+	agent.BlockedBy = nil
+	for _, item := range v.Blocked {
+		agent.BlockedBy = append(agent.BlockedBy, item.Text)
+	}
+	if agent.Status == "complete" {
+		if agent.CompletedAt == "" {
+			agent.CompletedAt = now.Format(time.RFC3339)
+		}
+	} else {
+		agent.CompletedAt = ""
+	}
+	return agent
+}
+
+// BlockReason returns why the agent may not stop yet, or "" if it may.
+// Blocked and failed agents are allowed to stop: they have reported an outcome.
+func (v *AgentVerification) BlockReason(name string) string {
+	if len(v.Blocked) > 0 || len(v.Failed) > 0 || v.ChecklistMissing {
+		return ""
+	}
+	if len(v.Unfinished) == 0 && len(v.MissingArtifacts) == 0 {
+		return ""
+	}
+
+	var sb strings.Builder
+	fmt.Fprintf(&sb, "에이전트 %s 의 작업이 완료되지 않았습니다.", name)
+	if len(v.Unfinished) > 0 {
+		fmt.Fprintf(&sb, "\n체크리스트(%s)에 미완료 항목 %d개:", v.ChecklistPath, len(v.Unfinished))
+		for _, item := range v.Unfinished {
+			fmt.Fprintf(&sb, "\n  - [%s] %s (line %d)", item.Status, item.Text, item.Line)
+		}
+	}
+	if len(v.MissingArtifacts) > 0 {
+		sb.WriteString("\n누락된 산출물:")
+		for _, a := range v.MissingArtifacts {
+			fmt.Fprintf(&sb, "\n  - %s", a)
+		}
+	}
+	sb.WriteString("\n남은 항목을 완료하거나, 진행할 수 없으면 [!] 블로커로 표시하고 사유를 기록한 뒤 종료하세요.")
+	return sb.String()
 }
@@ -1,6 +1,11 @@
 package hook
 
-import "testing"
+import (
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
 
 func Test_HandleSubagentStop_clean_repo_returns_continue(t *testing.T) {
 	original := GitStatus
@@ -18,32 +23,187 @@ func Test_HandleSubagentStop_clean_repo_returns_continue(t *testing.T) {
 	}
 }
 
-func Test_HandleSubagentStop_uncommitted_changes_warns(t *testing.T) {
+func Test_HandleSubagentStop_returns_non_nil(t *testing.T) {
 	original := GitStatus
 	defer func() { GitStatus = original }()
-	GitStatus = func() (bool, string) { return true, "M dirty.go" }
+	GitStatus = func() (bool, string) { return false, "" }
 
-	input := &Input{}
-	output := HandleSubagentStop(input)
+	output := HandleSubagentStop(&Input{})
+	if output == nil {
+		t.Fatal("expected non-nil output")
+	}
+}
 
-	if !output.Continue {
-		t.Error("expected Continue=true even with warning")
+// setupAgentJob creates .do/jobs/26/02/18/task/ with a state.json for one agent
+// and chdirs into the temp project. Returns the job directory.
+func setupAgentJob(t *testing.T, checklist string, artifacts []string) string {
+	t.Helper()
+	origDir, _ := os.Getwd()
+	tmpDir := t.TempDir()
+	if err := os.Chdir(tmpDir); err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(func() { os.Chdir(origDir) })
+
+	jobDir := filepath.Join(".do", "jobs", "26", "02", "18", "task")
+	if err := os.MkdirAll(filepath.Join(jobDir, "checklists"), 0755); err != nil {
+		t.Fatal(err)
 	}
-	if output.HookSpecificOutput == nil {
-		t.Fatal("expected HookSpecificOutput with warning")
+	if err := os.WriteFile(filepath.Join(jobDir, "checklists", "01_expert-backend.md"), []byte(checklist), 0644); err != nil {
+		t.Fatal(err)
 	}
-	if output.HookSpecificOutput.AdditionalContext == "" {
-		t.Error("expected non-empty AdditionalContext")
+	state := &JobState{
+		JobID: "task",
+		Agents: map[string]AgentState{
+			"expert-backend": {Status: "in_progress", Checklist: "checklists/01_expert-backend.md", Artifacts: artifacts},
+		},
 	}
+	if err := SaveJobState(filepath.Join(jobDir, "state.json"), state); err != nil {
+		t.Fatal(err)
+	}
+	return jobDir
 }
 
-func Test_HandleSubagentStop_returns_non_nil(t *testing.T) {
+func Test_HandleSubagentStop_blocks_on_unfinished_items(t *testing.T) {
 	original := GitStatus
 	defer func() { GitStatus = original }()
 	GitStatus = func() (bool, string) { return false, "" }
 
-	output := HandleSubagentStop(&Input{})
-	if output == nil {
-		t.Fatal("expected non-nil output")
+	jobDir := setupAgentJob(t, "- [o] #1 handler\n- [~] #2 validation\n- [ ] #3 tests\n", nil)
+
+	output := HandleSubagentStop(&Input{AgentType: "expert-backend"})
+	if output.Decision != DecisionBlock {
+		t.Fatalf("expected block, got %+v", output)
+	}
+	for _, want := range []string{"#2 validation", "#3 tests"} {
+		if !strings.Contains(output.Reason, want) {
+			t.Errorf("reason should mention %q, got %q", want, output.Reason)
+		}
+	}
+
+	state, err := LoadJobState(filepath.Join(jobDir, "state.json"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if got := state.Agents["expert-backend"].Status; got != "in_progress" {
+		t.Errorf("status: got %q, want in_progress", got)
+	}
+}
+
+func Test_HandleSubagentStop_stop_hook_active_does_not_block(t *testing.T) {
+	original := GitStatus
+	defer func() { GitStatus = original }()
+	GitStatus = func() (bool, string) { return false, "" }
+
+	setupAgentJob(t, "- [~] #1 handler\n", nil)
+
+	output := HandleSubagentStop(&Input{AgentType: "expert-backend", StopHookActive: true})
+	if output.Decision == DecisionBlock {
+		t.Error("should not block when stop_hook_active is set")
+	}
+}
+
+func Test_HandleSubagentStop_blocks_on_missing_artifact(t *testing.T) {
+	original := GitStatus
+	defer func() { GitStatus = original }()
+	GitStatus = func() (bool, string) { return false, "" }
+
+	setupAgentJob(t, "- [o] #1 report\n", []string{"report.md"})
+
+	output := HandleSubagentStop(&Input{AgentType: "expert-backend"})
+	if output.Decision != DecisionBlock || !strings.Contains(output.Reason, "report.md") {
+		t.Errorf("expected block naming report.md, got %+v", output)
+	}
+}
+
+func Test_HandleSubagentStop_completes_agent(t *testing.T) {
+	original := GitStatus
+	defer func() { GitStatus = original }()
+	GitStatus = func() (bool, string) { return false, "" }
+
+	jobDir := setupAgentJob(t, "- [o] #1 handler\n- [o] #2 tests\n", []string{"report.md"})
+	if err := os.WriteFile(filepath.Join(jobDir, "report.md"), []byte("done"), 0644); err != nil {
+		t.Fatal(err)
+	}
+
+	output := HandleSubagentStop(&Input{AgentType: "expert-backend"})
+	if output.Decision != "" {
+		t.Errorf("expected no block, got %q: %s", output.Decision, output.Reason)
+	}
+
+	state, _ := LoadJobState(filepath.Join(jobDir, "state.json"))
+	agent := state.Agents["expert-backend"]
+	if agent.Status != "complete" || agent.CompletedAt == "" {
+		t.Errorf("expected complete with timestamp, got %+v", agent)
+	}
+}
+
+func Test_HandleSubagentStop_records_blockers(t *testing.T) {
+	original := GitStatus
+	defer func() { GitStatus = original }()
+	GitStatus = func() (bool, string) { return false, "" }
+
+	jobDir := setupAgentJob(t, "- [o] #1 handler\n- [!] #2 OAuth key not issued\n", nil)
+
+	output := HandleSubagentStop(&Input{AgentType: "expert-backend"})
+	if output.Decision != "" {
+		t.Errorf("blocked agent should be allowed to stop, got %q", output.Decision)
+	}
+
+	state, _ := LoadJobState(filepath.Join(jobDir, "state.json"))
+	agent := state.Agents["expert-backend"]
+	if agent.Status != "blocked" || len(agent.BlockedBy) != 1 {
+		t.Errorf("expected blocked with 1 blocker, got %+v", agent)
+	}
+}
+
+func Test_HandleSubagentStop_binds_session_only_through_current_job_pointer(t *testing.T) {
+	original := GitStatus
+	defer func() { GitStatus = original }()
+	GitStatus = func() (bool, string) { return false, "" }
+
+	jobDir := setupAgentJob(t, "- [~] #1 handler\n", nil)
+	statePath := filepath.Join(jobDir, "state.json")
+
+	// Found only as the latest job: verified, but left unbound.
+	HandleSubagentStop(&Input{AgentType: "expert-backend", SessionID: "s1"})
+	if state, _ := LoadJobState(statePath); state.SessionID != "" {
+		t.Errorf("latest-job fallback bound session %q", state.SessionID)
+	}
+
+	if err := WriteCurrentJob(jobDir); err != nil {
+		t.Fatal(err)
+	}
+	HandleSubagentStop(&Input{AgentType: "expert-backend", SessionID: "s1"})
+	if state, _ := LoadJobState(statePath); state.SessionID != "s1" {
+		t.Errorf("pointer job: session %q, want s1", state.SessionID)
+	}
+}
+
+func Test_HandleSubagentStop_fallback_skips_jobs_of_other_sessions(t *testing.T) {
+	original := GitStatus
+	defer func() { GitStatus = original }()
+	GitStatus = func() (bool, string) { return false, "" }
+
+	older := setupAgentJob(t, "- [o] #1 handler\n", nil)
+	newer := filepath.Join(".do", "jobs", "26", "02", "19", "other")
+	if err := os.MkdirAll(newer, 0755); err != nil {
+		t.Fatal(err)
+	}
+	state := &JobState{
+		JobID:     "other",
+		SessionID: "s2",
+		Agents:    map[string]AgentState{"expert-backend": {Status: "in_progress"}},
+	}
+	if err := SaveJobState(filepath.Join(newer, "state.json"), state); err != nil {
+		t.Fatal(err)
+	}
+
+	HandleSubagentStop(&Input{AgentType: "expert-backend", SessionID: "s1"})
+	if got, _ := LoadJobState(filepath.Join(newer, "state.json")); got.Agents["expert-backend"].Status != "in_progress" {
+		t.Errorf("job of session s2 was updated: %+v", got.Agents["expert-backend"])
+	}
+	if got, _ := LoadJobState(filepath.Join(older, "state.json")); got.Agents["expert-backend"].Status != "complete" {
+		t.Errorf("unbound job not verified: %+v", got.Agents["expert-backend"])
 	}
 }
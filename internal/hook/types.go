@@ -104,6 +104,15 @@ type Input struct {
 	ProjectDir string `json:"project_dir,omitempty"`
 }
 
+// Dir returns the session's working directory, or "." when the event
+// carries none.
+func (in *Input) Dir() string {
+	if in.CWD != "" {
+		return in.CWD
+	}
+	return "."
+}
+
 // SpecificOutput represents the hookSpecificOutput field for PreToolUse/PostToolUse.
 type SpecificOutput struct {
 	HookEventName            string `json:"hookEventName,omitempty"`
@@ -4,15 +4,18 @@ import (
 	"fmt"
 	"os"
 	"strings"
+	"time"
 
+	"github.com/yejune/godo/internal/budget"
 	"github.com/yejune/godo/internal/mode"
 	"github.com/yejune/godo/internal/persona"
 )
 
 // HandleUserPromptSubmit handles the UserPromptSubmit hook event.
-// It injects mode and persona reminders as additionalContext.
+// It injects mode and persona reminders as additionalContext, plus a
+// warning when a budget with warn enabled is exceeded.
 func HandleUserPromptSubmit(input *Input) *Output {
-	currentMode := mode.ReadState()
+	currentMode := mode.Current(input.Dir(), input.SessionID)
 	userName := os.Getenv("DO_USER_NAME")
 	personaType := os.Getenv("DO_PERSONA")
 	if personaType == "" {
@@ -21,9 +24,15 @@ func HandleUserPromptSubmit(input *Input) *Output {
 
 	var parts []string
 
-	// Mode reminder
-	modePrefix := strings.ToUpper(currentMode[:1]) + currentMode[1:]
-	parts = append(parts, fmt.Sprintf("현재 실행 모드: %s (응답 접두사: [%s])", currentMode, modePrefix))
+	// Mode reminder, with the mode's own instructions
+	modes, err := mode.Load(input.Dir())
+	if err != nil {
+		modes = &mode.Set{Modes: mode.Builtin()}
+	}
+	parts = append(parts, fmt.Sprintf("현재 실행 모드: %s (응답 접두사: %s)", currentMode, modes.Prefix(currentMode)))
+	if m, ok := modes.Get(currentMode); ok && m.Instructions != "" {
+		parts = append(parts, strings.TrimSpace(m.Instructions))
+	}
 
 	// Persona reminder
 	personaDir := persona.ResolveDir()
@@ -36,6 +45,10 @@ func HandleUserPromptSubmit(input *Input) *Output {
 		}
 	}
 
+	if warning := budgetWarning(input); warning != "" {
+		parts = append(parts, warning)
+	}
+
 	return &Output{
 		HookSpecificOutput: &SpecificOutput{
 			HookEventName:    "UserPromptSubmit",
@@ -43,3 +56,36 @@ func HandleUserPromptSubmit(input *Input) *Output {
 		},
 	}
 }
+
+// budgetWarning describes the exceeded budgets, or returns "" when none is
+// exceeded or warnings are off. Spend is measured whenever budgets are set,
+// even with warnings off: the status line only waits for the measurement
+// until its render deadline and otherwise shows the result cached here.
+func budgetWarning(input *Input) string {
+	cfg, err := budget.Load(input.Dir())
+	if err != nil || !cfg.Enabled() {
+		return ""
+	}
+
+	var session, daily budget.Spend
+	if cfg.Session.Set() && input.TranscriptPath != "" {
+		session, _ = budget.Session(input.TranscriptPath)
+	}
+	if cfg.Daily.Set() {
+		daily = budget.Today(time.Now())
+	}
+	if !cfg.Warn {
+		return ""
+	}
+
+	var over []string
+	for _, st := range cfg.Check(session, daily) {
+		if st.Exceeded() {
+			over = append(over, st.String())
+		}
+	}
+	if len(over) == 0 {
+		return ""
+	}
+	return fmt.Sprintf("⚠️ 예산 초과 (%s). 사용자에게 알리고, 계속 진행할지 확인한 뒤 작업하세요.", strings.Join(over, ", "))
+}
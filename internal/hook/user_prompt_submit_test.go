@@ -1,8 +1,13 @@
 package hook
 
 import (
+	"os"
+	"path/filepath"
 	"strings"
 	"testing"
+	"time"
+
+	"github.com/yejune/godo/internal/budget"
 )
 
 func Test_HandleUserPromptSubmit_returns_output(t *testing.T) {
@@ -65,3 +70,27 @@ func Test_HandleUserPromptSubmit_with_persona(t *testing.T) {
 		t.Errorf("expected persona reminder in context, got: %s", ctx)
 	}
 }
+
+func Test_HandleUserPromptSubmit_warns_when_session_budget_exceeded(t *testing.T) {
+	t.Setenv("HOME", t.TempDir())
+	dir := t.TempDir()
+	os.MkdirAll(filepath.Join(dir, ".do"), 0755)
+	transcript := filepath.Join(dir, "s.jsonl")
+	os.WriteFile(transcript, []byte(`{"type":"assistant","timestamp":"2026-01-05T10:00:00Z","requestId":"r1","message":{"id":"m1","model":"claude-sonnet-4-5","usage":{"input_tokens":900,"output_tokens":200}}}`+"\n"), 0644)
+	input := &Input{CWD: dir, TranscriptPath: transcript}
+
+	os.WriteFile(filepath.Join(dir, budget.ConfigFile), []byte("session: {tokens: 1000}\n"), 0644)
+	if ctx := HandleUserPromptSubmit(input).HookSpecificOutput.AdditionalContext; strings.Contains(ctx, "예산") {
+		t.Errorf("warn is off, got: %s", ctx)
+	}
+	// Even with warn off the spend is measured for the status line.
+	if got, ok := budget.SessionBy(transcript, time.Now().Add(-time.Second)); !ok || got.Tokens != 1100 {
+		t.Errorf("session spend not cached: %+v, %v", got, ok)
+	}
+
+	os.WriteFile(filepath.Join(dir, budget.ConfigFile), []byte("session: {tokens: 1000}\nwarn: true\n"), 0644)
+	ctx := HandleUserPromptSubmit(input).HookSpecificOutput.AdditionalContext
+	if !strings.Contains(ctx, "예산 초과") || !strings.Contains(ctx, "session 1100/1000 tok") {
+		t.Errorf("expected budget warning, got: %s", ctx)
+	}
+}
@@ -0,0 +1,260 @@
+package hook
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+	"time"
+)
+
+// Phase status values stored in PhaseState.Status.
+const (
+	PhasePending    = "pending"
+	PhaseInProgress = "in_progress"
+	PhaseComplete   = "complete"
+)
+
+// Workflow type values stored in JobState.WorkflowType.
+const (
+	WorkflowSimple  = "simple"
+	WorkflowComplex = "complex"
+)
+
+// PhaseDef describes one phase of a workflow and what it takes to enter it.
+type PhaseDef struct {
+	Name string
+	// Next lists the phases this phase may transition to. The first entry is
+	// the forward path; later entries are rework paths (e.g. test -> develop).
+	Next []string
+	// Artifacts are files (relative to the job dir) that must exist before entry.
+	Artifacts []string
+	// RequireChecklist requires every checklist.md item to be [o] before entry.
+	RequireChecklist bool
+}
+
+// Workflow is the ordered phase definition for a workflow type.
+type Workflow struct {
+	Type   string
+	Phases []PhaseDef
+}
+
+// Workflows holds the built-in workflow definitions keyed by workflow type.
+// They follow dev-workflow.md:
+//
+//	complex: analysis -> architecture -> plan -> checklist -> develop -> test -> report
+//	simple:  plan -> checklist -> develop -> test -> report
+var Workflows = map[string]*Workflow{
+	WorkflowComplex: {
+		Type: WorkflowComplex,
+		Phases: []PhaseDef{
+			{Name: "analysis", Next: []string{"architecture"}},
+			{Name: "architecture", Next: []string{"plan"}, Artifacts: []string{"analysis.md"}},
+			{Name: "plan", Next: []string{"checklist"}, Artifacts: []string{"analysis.md", "architecture.md"}},
+			{Name: "checklist", Next: []string{"develop"}, Artifacts: []string{"plan.md"}},
+			{Name: "develop", Next: []string{"test"}, Artifacts: []string{"checklist.md"}},
+			{Name: "test", Next: []string{"report", "develop"}},
+			{Name: "report", RequireChecklist: true},
+		},
+	},
+	WorkflowSimple: {
+		Type: WorkflowSimple,
+		Phases: []PhaseDef{
+			{Name: "plan", Next: []string{"checklist"}},
+			{Name: "checklist", Next: []string{"develop"}, Artifacts: []string{"plan.md"}},
+			{Name: "develop", Next: []string{"test"}, Artifacts: []string{"checklist.md"}},
+			{Name: "test", Next: []string{"report", "develop"}},
+			{Name: "report", RequireChecklist: true},
+		},
+	},
+}
+
+// WorkflowFor returns the workflow definition for a workflow type.
+// An empty type falls back to complex, whose phases are a superset of simple.
+func WorkflowFor(workflowType string) (*Workflow, error) {
+	if workflowType == "" {
+		workflowType = WorkflowComplex
+	}
+	wf, ok := Workflows[workflowType]
+	if !ok {
+		return nil, fmt.Errorf("unknown workflow type %q (valid: simple, complex)", workflowType)
+	}
+	return wf, nil
+}
+
+// Phase returns the definition of the named phase.
+func (w *Workflow) Phase(name string) (PhaseDef, bool) {
+	for _, p := range w.Phases {
+		if p.Name == name {
+			return p, true
+		}
+	}
+	return PhaseDef{}, false
+}
+
+// PhaseNames returns the phase names in workflow order.
+func (w *Workflow) PhaseNames() []string {
+	names := make([]string, len(w.Phases))
+	for i, p := range w.Phases {
+		names[i] = p.Name
+	}
+	return names
+}
+
+// CurrentPhase returns the phase that is in progress, or "" if none is.
+// More than one in-progress phase is an invalid state.
+func (w *Workflow) CurrentPhase(state *JobState) (string, error) {
+	var current []string
+	for _, name := range w.PhaseNames() {
+		if state.Phases[name].Status == PhaseInProgress {
+			current = append(current, name)
+		}
+	}
+	switch len(current) {
+	case 0:
+		return "", nil
+	case 1:
+		return current[0], nil
+	default:
+		return "", fmt.Errorf("multiple phases in progress: %s", strings.Join(current, ", "))
+	}
+}
+
+// CanTransition reports whether from -> to is an allowed transition.
+// From "" (nothing started) only the first phase may be entered.
+func (w *Workflow) CanTransition(from, to string) bool {
+	if from == "" {
+		return len(w.Phases) > 0 && w.Phases[0].Name == to
+	}
+	def, ok := w.Phase(from)
+	if !ok {
+		return false
+	}
+	for _, next := range def.Next {
+		if next == to {
+			return true
+		}
+	}
+	return false
+}
+
+// CheckEntry verifies the entry criteria of a phase against the job directory.
+func (w *Workflow) CheckEntry(jobDir, phase string) error {
+	def, ok := w.Phase(phase)
+	if !ok {
+		return fmt.Errorf("phase %q: not defined in %s workflow", phase, w.Type)
+	}
+	var missing []string
+	for _, artifact := range def.Artifacts {
+		if _, err := os.Stat(filepath.Join(jobDir, artifact)); err != nil {
+			missing = append(missing, artifact)
+		}
+	}
+	if len(missing) > 0 {
+		return fmt.Errorf("phase %q: missing artifacts: %s", phase, strings.Join(missing, ", "))
+	}
+	if def.RequireChecklist {
+		data, err := os.ReadFile(filepath.Join(jobDir, "checklist.md"))
+		if err != nil {
+			return fmt.Errorf("phase %q: cannot read checklist.md: %w", phase, err)
+		}
+		var open []string
+		for _, item := range ParseChecklistItems(string(data)) {
+			if item.Status != "o" {
+				open = append(open, fmt.Sprintf("[%s] %s", item.Status, item.Text))
+			}
+		}
+		if len(open) > 0 {
+			return fmt.Errorf("phase %q: checklist not complete (%d open): %s", phase, len(open), strings.Join(open, "; "))
+		}
+	}
+	return nil
+}
+
+// NextPhase returns the forward successor of the current phase.
+// With nothing started it returns the first phase; after the last phase "".
+func (w *Workflow) NextPhase(current string) string {
+	if current == "" {
+		if len(w.Phases) == 0 {
+			return ""
+		}
+		return w.Phases[0].Name
+	}
+	def, ok := w.Phase(current)
+	if !ok || len(def.Next) == 0 {
+		return ""
+	}
+	return def.Next[0]
+}
+
+// PhaseTransition describes a validated, applied phase transition.
+type PhaseTransition struct {
+	From string // phase completed by this transition ("" if none)
+	To   string // phase started by this transition ("" if the workflow finished)
+}
+
+// AdvancePhase completes the current phase and starts the next one, after
+// validating the transition and the target's entry criteria. If to is empty
+// the forward successor is used. Nothing is modified when validation fails.
+// The caller is responsible for saving the state.
+func AdvancePhase(jobDir string, state *JobState, to string, now time.Time) (*PhaseTransition, error) {
+	wf, err := WorkflowFor(state.WorkflowType)
+	if err != nil {
+		return nil, err
+	}
+	current, err := wf.CurrentPhase(state)
+	if err != nil {
+		return nil, err
+	}
+	if current == "" && allPhasesComplete(wf, state) {
+		return nil, fmt.Errorf("all %s workflow phases are already complete", wf.Type)
+	}
+	if current == "" && to == "" {
+		to = firstIncompletePhase(wf, state)
+	} else if to == "" {
+		to = wf.NextPhase(current)
+	}
+
+	if to != "" {
+		if _, ok := wf.Phase(to); !ok {
+			return nil, fmt.Errorf("phase %q: not defined in %s workflow (phases: %s)", to, wf.Type, strings.Join(wf.PhaseNames(), ", "))
+		}
+		if current != "" && !wf.CanTransition(current, to) {
+			return nil, fmt.Errorf("transition %s -> %s is not allowed in %s workflow", current, to, wf.Type)
+		}
+		if current == "" && to != firstIncompletePhase(wf, state) {
+			return nil, fmt.Errorf("phase %q cannot start before %q is complete", to, firstIncompletePhase(wf, state))
+		}
+		if err := wf.CheckEntry(jobDir, to); err != nil {
+			return nil, err
+		}
+	}
+
+	if state.Phases == nil {
+		state.Phases = make(map[string]PhaseState)
+	}
+	ts := now.UTC().Format(time.RFC3339)
+	if current != "" {
+		p := state.Phases[current]
+		p.Status = PhaseComplete
+		p.CompletedAt = ts
+		state.Phases[current] = p
+	}
+	if to != "" {
+		state.Phases[to] = PhaseState{Status: PhaseInProgress, StartedAt: ts}
+	}
+	return &PhaseTransition{From: current, To: to}, nil
+}
+
+func firstIncompletePhase(wf *Workflow, state *JobState) string {
+	for _, name := range wf.PhaseNames() {
+		if state.Phases[name].Status != PhaseComplete {
+			return name
+		}
+	}
+	return ""
+}
+
+func allPhasesComplete(wf *Workflow, state *JobState) bool {
+	return firstIncompletePhase(wf, state) == ""
+}
@@ -0,0 +1,132 @@
+package hook
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+)
+
+func Test_WorkflowFor_types(t *testing.T) {
+	simple, err := WorkflowFor("simple")
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if simple.Phases[0].Name != "plan" {
+		t.Errorf("simple first phase: got %q, want plan", simple.Phases[0].Name)
+	}
+	complexWF, _ := WorkflowFor("")
+	if complexWF.Type != WorkflowComplex {
+		t.Errorf("empty type should fall back to complex, got %q", complexWF.Type)
+	}
+	if _, err := WorkflowFor("bogus"); err == nil {
+		t.Error("expected error for unknown workflow type")
+	}
+}
+
+func Test_Workflow_CanTransition(t *testing.T) {
+	wf, _ := WorkflowFor("simple")
+	cases := []struct {
+		from, to string
+		want     bool
+	}{
+		{"", "plan", true},
+		{"", "develop", false},
+		{"plan", "checklist", true},
+		{"plan", "develop", false},
+		{"test", "report", true},
+		{"test", "develop", true},
+		{"report", "plan", false},
+	}
+	for _, c := range cases {
+		if got := wf.CanTransition(c.from, c.to); got != c.want {
+			t.Errorf("%q -> %q: got %v, want %v", c.from, c.to, got, c.want)
+		}
+	}
+}
+
+func Test_AdvancePhase_starts_first_phase(t *testing.T) {
+	dir := t.TempDir()
+	state := &JobState{WorkflowType: "simple"}
+	now := time.Date(2026, 2, 18, 10, 0, 0, 0, time.UTC)
+
+	tr, err := AdvancePhase(dir, state, "", now)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if tr.From != "" || tr.To != "plan" {
+		t.Errorf("transition: got %+v", tr)
+	}
+	if p := state.Phases["plan"]; p.Status != PhaseInProgress || p.StartedAt != "2026-02-18T10:00:00Z" {
+		t.Errorf("plan phase: got %+v", p)
+	}
+}
+
+func Test_AdvancePhase_requires_entry_artifacts(t *testing.T) {
+	dir := t.TempDir()
+	state := &JobState{
+		WorkflowType: "simple",
+		Phases:       map[string]PhaseState{"plan": {Status: PhaseInProgress}},
+	}
+
+	_, err := AdvancePhase(dir, state, "", time.Now())
+	if err == nil || !strings.Contains(err.Error(), "plan.md") {
+		t.Fatalf("expected missing plan.md error, got %v", err)
+	}
+	if state.Phases["plan"].Status != PhaseInProgress {
+		t.Error("state must not change when validation fails")
+	}
+
+	if err := os.WriteFile(filepath.Join(dir, "plan.md"), []byte("# plan"), 0644); err != nil {
+		t.Fatal(err)
+	}
+	tr, err := AdvancePhase(dir, state, "", time.Now())
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if tr.From != "plan" || tr.To != "checklist" {
+		t.Errorf("transition: got %+v", tr)
+	}
+	if state.Phases["plan"].Status != PhaseComplete || state.Phases["plan"].CompletedAt == "" {
+		t.Errorf("plan should be complete with timestamp, got %+v", state.Phases["plan"])
+	}
+}
+
+func Test_AdvancePhase_rejects_disallowed_transition(t *testing.T) {
+	dir := t.TempDir()
+	state := &JobState{
+		WorkflowType: "simple",
+		Phases:       map[string]PhaseState{"plan": {Status: PhaseInProgress}},
+	}
+	if _, err := AdvancePhase(dir, state, "report", time.Now()); err == nil {
+		t.Fatal("expected plan -> report to be rejected")
+	}
+}
+
+func Test_AdvancePhase_report_requires_complete_checklist(t *testing.T) {
+	dir := t.TempDir()
+	state := &JobState{
+		WorkflowType: "simple",
+		Phases: map[string]PhaseState{
+			"plan": {Status: PhaseComplete}, "checklist": {Status: PhaseComplete},
+			"develop": {Status: PhaseComplete}, "test": {Status: PhaseInProgress},
+		},
+	}
+	if err := os.WriteFile(filepath.Join(dir, "checklist.md"), []byte("- [o] #1 a\n- [~] #2 b\n"), 0644); err != nil {
+		t.Fatal(err)
+	}
+	_, err := AdvancePhase(dir, state, "", time.Now())
+	if err == nil || !strings.Contains(err.Error(), "#2 b") {
+		t.Fatalf("expected checklist error naming #2, got %v", err)
+	}
+
+	// Rework path back to develop is allowed regardless of the checklist.
+	tr, err := AdvancePhase(dir, state, "develop", time.Now())
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if tr.To != "develop" || state.Phases["develop"].Status != PhaseInProgress {
+		t.Errorf("expected develop reopened, got %+v", state.Phases["develop"])
+	}
+}
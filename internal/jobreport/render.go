@@ -0,0 +1,215 @@
+package jobreport
+
+import (
+	"fmt"
+	"html/template"
+	"strings"
+	"time"
+
+	"github.com/yejune/godo/internal/hook"
+)
+
+const timeLayout = "2006-01-02 15:04:05"
+
+// Markdown renders the report as Markdown suitable for a PR description.
+func (r *Report) Markdown() string {
+	var sb strings.Builder
+
+	fmt.Fprintf(&sb, "# Job Report: %s\n\n", r.JobID)
+	sb.WriteString("## Summary\n\n")
+	if r.WorkflowType != "" {
+		fmt.Fprintf(&sb, "- Workflow: %s\n", r.WorkflowType)
+	}
+	fmt.Fprintf(&sb, "- Period: %s ~ %s (%s)\n", formatTime(r.Start), formatTime(r.End), formatDuration(r.End.Sub(r.Start)))
+	if r.Stats != nil {
+		fmt.Fprintf(&sb, "- Checklist: %d/%d done (%s)\n", r.Stats.Done, r.Stats.Total, r.Stats.Summary())
+	}
+	fmt.Fprintf(&sb, "- Commits: %d, files changed: %d\n", len(r.Commits), len(r.Files))
+	if r.Usage != nil {
+		fmt.Fprintf(&sb, "- Tokens: %d in / %d out\n", r.Usage.InputTokens, r.Usage.OutputTokens)
+	}
+
+	if len(r.Phases) > 0 {
+		sb.WriteString("\n## Phases\n\n| Phase | Status | Started | Completed | Duration |\n|---|---|---|---|---|\n")
+		for _, p := range r.Phases {
+			fmt.Fprintf(&sb, "| %s | %s | %s | %s | %s |\n",
+				p.Name, p.Status, formatTime(p.StartedAt), formatTime(p.CompletedAt), formatDuration(p.Duration()))
+		}
+	}
+
+	if len(r.Agents) > 0 {
+		sb.WriteString("\n## Agents\n\n| Agent | Status | Checklist | Completed | Blocked by |\n|---|---|---|---|---|\n")
+		for _, a := range r.Agents {
+			fmt.Fprintf(&sb, "| %s | %s | %s | %s | %s |\n",
+				a.Name, a.Status, a.Checklist, formatTime(a.CompletedAt), mdEscape(strings.Join(a.BlockedBy, "; ")))
+		}
+	}
+
+	if len(r.Checklist) > 0 {
+		sb.WriteString("\n## Checklist\n\n")
+		for _, item := range r.Checklist {
+			fmt.Fprintf(&sb, "- [%s] %s\n", item.Status, item.Text)
+			for _, h := range item.History {
+				fmt.Fprintf(&sb, "    - [%s] %s %s\n", h.Status, h.Time, h.Note)
+			}
+		}
+	}
+
+	if r.Timing != nil {
+		sb.WriteString("\n## Checklist Timing\n\n| Agent | Items | Done | Avg cycle | Blocked | Rework |\n|---|---|---|---|---|---|\n")
+		for _, g := range r.TimingRows() {
+			fmt.Fprintf(&sb, "| %s | %d | %d | %s | %s | %d |\n",
+				g.Name, g.Items, g.Done, formatDuration(g.AvgCycleTime()), formatDuration(g.Blocked), g.Rework)
+		}
+	}
+
+	if len(r.Commits) > 0 {
+		sb.WriteString("\n## Commits\n\n")
+		for _, c := range r.Commits {
+			fmt.Fprintf(&sb, "- `%s` %s (%s, %s)\n", shortHash(c.Hash), c.Subject, c.Author, formatTime(c.Date))
+		}
+	}
+
+	if len(r.Files) > 0 {
+		sb.WriteString("\n## Files Changed\n\n")
+		for _, f := range r.Files {
+			fmt.Fprintf(&sb, "- `%s` +%d -%d\n", f.Path, f.Added, f.Deleted)
+		}
+	}
+
+	if r.Usage != nil {
+		u := r.Usage
+		sb.WriteString("\n## Token Usage\n\n| Input | Output | Cache creation | Cache read | Turns | Model |\n|---|---|---|---|---|---|\n")
+		fmt.Fprintf(&sb, "| %d | %d | %d | %d | %d | %s |\n",
+			u.InputTokens, u.OutputTokens, u.CacheCreationTokens, u.CacheReadTokens, u.TurnCount, u.ModelName)
+	}
+
+	return sb.String()
+}
+
+// HTML renders the report as a standalone HTML document.
+func (r *Report) HTML() (string, error) {
+	var sb strings.Builder
+	if err := htmlTemplate.Execute(&sb, r); err != nil {
+		return "", fmt.Errorf("render html: %w", err)
+	}
+	return sb.String(), nil
+}
+
+var htmlTemplate = template.Must(template.New("report").Funcs(template.FuncMap{
+	"time":     formatTime,
+	"duration": formatDuration,
+	"short":    shortHash,
+	"join":     strings.Join,
+	"elapsed":  func(a, b time.Time) string { return formatDuration(b.Sub(a)) },
+}).Parse(`<!DOCTYPE html>
+<html><head><meta charset="utf-8"><title>Job Report: {{.JobID}}</title>
+<style>body{font-family:system-ui,sans-serif;max-width:960px;margin:2rem auto;padding:0 1rem;color:#222}
+table{border-collapse:collapse;width:100%;margin-bottom:1.5rem}th,td{border:1px solid #ddd;padding:0.4rem 0.6rem;text-align:left}
+th{background:#f5f5f5}code{background:#f5f5f5;padding:0 0.2rem;border-radius:3px}ul.history{color:#666;font-size:0.9em}</style></head>
+<body>
+<h1>Job Report: {{.JobID}}</h1>
+<ul>
+{{- if .WorkflowType}}<li>Workflow: {{.WorkflowType}}</li>{{end}}
+<li>Period: {{time .Start}} ~ {{time .End}} ({{elapsed .Start .End}})</li>
+{{- with .Stats}}<li>Checklist: {{.Done}}/{{.Total}} done ({{.Summary}})</li>{{end}}
+<li>Commits: {{len .Commits}}, files changed: {{len .Files}}</li>
+</ul>
+{{- if .Phases}}
+<h2>Phases</h2>
+<table><tr><th>Phase</th><th>Status</th><th>Started</th><th>Completed</th><th>Duration</th></tr>
+{{- range .Phases}}
+<tr><td>{{.Name}}</td><td>{{.Status}}</td><td>{{time .StartedAt}}</td><td>{{time .CompletedAt}}</td><td>{{duration .Duration}}</td></tr>
+{{- end}}
+</table>
+{{- end}}
+{{- if .Agents}}
+<h2>Agents</h2>
+<table><tr><th>Agent</th><th>Status</th><th>Checklist</th><th>Completed</th><th>Blocked by</th></tr>
+{{- range .Agents}}
+<tr><td>{{.Name}}</td><td>{{.Status}}</td><td>{{.Checklist}}</td><td>{{time .CompletedAt}}</td><td>{{join .BlockedBy "; "}}</td></tr>
+{{- end}}
+</table>
+{{- end}}
+{{- if .Checklist}}
+<h2>Checklist</h2>
+<ul>
+{{- range .Checklist}}
+<li><code>[{{.Status}}]</code> {{.Text}}{{if .History}}<ul class="history">{{range .History}}<li><code>[{{.Status}}]</code> {{.Time}} {{.Note}}</li>{{end}}</ul>{{end}}</li>
+{{- end}}
+</ul>
+{{- end}}
+{{- if .Timing}}
+<h2>Checklist Timing</h2>
+<table><tr><th>Agent</th><th>Items</th><th>Done</th><th>Avg cycle</th><th>Blocked</th><th>Rework</th></tr>
+{{- range .TimingRows}}
+<tr><td>{{.Name}}</td><td>{{.Items}}</td><td>{{.Done}}</td><td>{{duration .AvgCycleTime}}</td><td>{{duration .Blocked}}</td><td>{{.Rework}}</td></tr>
+{{- end}}
+</table>
+{{- end}}
+{{- if .Commits}}
+<h2>Commits</h2>
+<ul>
+{{- range .Commits}}
+<li><code>{{short .Hash}}</code> {{.Subject}} ({{.Author}}, {{time .Date}})</li>
+{{- end}}
+</ul>
+{{- end}}
+{{- if .Files}}
+<h2>Files Changed</h2>
+<table><tr><th>File</th><th>Added</th><th>Deleted</th></tr>
+{{- range .Files}}
+<tr><td><code>{{.Path}}</code></td><td>+{{.Added}}</td><td>-{{.Deleted}}</td></tr>
+{{- end}}
+</table>
+{{- end}}
+{{- with .Usage}}
+<h2>Token Usage</h2>
+<table><tr><th>Input</th><th>Output</th><th>Cache creation</th><th>Cache read</th><th>Turns</th><th>Model</th></tr>
+<tr><td>{{.InputTokens}}</td><td>{{.OutputTokens}}</td><td>{{.CacheCreationTokens}}</td><td>{{.CacheReadTokens}}</td><td>{{.TurnCount}}</td><td>{{.ModelName}}</td></tr>
+</table>
+{{- end}}
+</body></html>
+`))
+
+// TimingRows returns the per-agent rows of the checklist timing table
+// followed by the whole job, in the order godo job stats prints them.
+func (r *Report) TimingRows() []hook.GroupStats {
+	if r.Timing == nil {
+		return nil
+	}
+	job := r.Timing.Job
+	job.Name = "(job)"
+	return append(append([]hook.GroupStats{}, r.Timing.Agents...), job)
+}
+
+func formatTime(t time.Time) string {
+	if t.IsZero() {
+		return "-"
+	}
+	return t.Local().Format(timeLayout)
+}
+
+func formatDuration(d time.Duration) string {
+	if d <= 0 {
+		return "-"
+	}
+	d = d.Round(time.Minute)
+	h := int(d.Hours())
+	m := int(d.Minutes()) % 60
+	if h > 0 {
+		return fmt.Sprintf("%dh%dm", h, m)
+	}
+	return fmt.Sprintf("%dm", m)
+}
+
+func shortHash(hash string) string {
+	if len(hash) > 7 {
+		return hash[:7]
+	}
+	return hash
+}
+
+func mdEscape(s string) string {
+	return strings.ReplaceAll(s, "|", "\\|")
+}
@@ -0,0 +1,330 @@
+// Package jobreport builds a summary of a .do/jobs job from its state.json,
+// checklists and their transition log, the git history of the job window,
+// and the session transcript.
+package jobreport
+
+import (
+	"fmt"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/yejune/godo/internal/hook"
+	"github.com/yejune/godo/internal/rank"
+)
+
+// Report is the assembled summary of a job.
+type Report struct {
+	JobDir       string
+	JobID        string
+	WorkflowType string
+	Start        time.Time
+	End          time.Time
+	Finished     bool // every workflow phase is complete
+
+	Phases    []PhaseEntry
+	Agents    []AgentEntry
+	Checklist []hook.ChecklistItem
+	Stats     *hook.ChecklistStats
+	History   []hook.HistoryEntry // checklist transition log
+	Timing    *hook.JobStats      // time-in-status metrics, as godo job stats shows them
+	Commits   []Commit
+	Files     []FileChange
+	Usage     *rank.TranscriptUsage
+}
+
+// PhaseEntry is one row of the phase timeline.
+type PhaseEntry struct {
+	Name        string
+	Status      string
+	StartedAt   time.Time
+	CompletedAt time.Time
+}
+
+// Duration returns how long the phase took, or 0 if it has not completed.
+func (p PhaseEntry) Duration() time.Duration {
+	if p.StartedAt.IsZero() || p.CompletedAt.IsZero() {
+		return 0
+	}
+	return p.CompletedAt.Sub(p.StartedAt)
+}
+
+// AgentEntry is one row of the agent timeline.
+type AgentEntry struct {
+	Name        string
+	Status      string
+	CompletedAt time.Time
+	BlockedBy   []string
+	Checklist   string // checklist summary, e.g. "[o]3 [~]1"
+}
+
+// Commit is a git commit made during the job window.
+type Commit struct {
+	Hash    string
+	Author  string
+	Date    time.Time
+	Subject string
+}
+
+// FileChange aggregates line changes to a file across the job's commits.
+type FileChange struct {
+	Path    string
+	Added   int
+	Deleted int
+}
+
+// Options controls where Build looks for git history and the transcript.
+type Options struct {
+	// RepoDir is the git working directory (default ".").
+	RepoDir string
+	// TranscriptPath overrides the transcript lookup by the job's session ID.
+	TranscriptPath string
+	// Now is the end of the window for unfinished jobs (default time.Now()).
+	Now time.Time
+}
+
+// runGit runs git in dir and returns its stdout. Replaced in tests.
+var runGit = func(dir string, args ...string) (string, error) {
+	cmd := exec.Command("git", args...)
+	cmd.Dir = dir
+	out, err := cmd.Output()
+	return string(out), err
+}
+
+// Build assembles the report for the job in jobDir.
+func Build(jobDir string, opts Options) (*Report, error) {
+	if opts.RepoDir == "" {
+		opts.RepoDir = "."
+	}
+	if opts.Now.IsZero() {
+		opts.Now = time.Now()
+	}
+
+	state, err := hook.LoadJobState(filepath.Join(jobDir, "state.json"))
+	if err != nil {
+		return nil, fmt.Errorf("load job state: %w", err)
+	}
+
+	r := &Report{
+		JobDir:       jobDir,
+		JobID:        state.JobID,
+		WorkflowType: state.WorkflowType,
+		Start:        parseTime(state.CreatedAt),
+	}
+	if r.JobID == "" {
+		r.JobID = filepath.Base(jobDir)
+	}
+
+	r.addPhases(state)
+	r.addAgents(jobDir, state)
+
+	if data, err := os.ReadFile(filepath.Join(jobDir, "checklist.md")); err == nil {
+		r.Checklist = hook.ParseChecklistItems(string(data))
+		r.Stats = hook.ParseChecklistContent(string(data))
+	}
+	if err := r.addHistory(jobDir, opts.Now); err != nil {
+		return nil, err
+	}
+
+	if r.End.IsZero() || !r.Finished {
+		r.End = opts.Now
+	}
+	if !r.Start.IsZero() {
+		r.Commits, r.Files = gitActivity(opts.RepoDir, r.Start, r.End)
+	}
+
+	transcript := opts.TranscriptPath
+	if transcript == "" && state.SessionID != "" {
+		transcript = rank.FindTranscriptForSession(state.SessionID)
+	}
+	if transcript != "" {
+		usage, err := rank.ParseTranscript(transcript)
+		if err != nil {
+			return nil, fmt.Errorf("parse transcript: %w", err)
+		}
+		r.Usage = usage
+	}
+
+	return r, nil
+}
+
+func (r *Report) addPhases(state *hook.JobState) {
+	wf, err := hook.WorkflowFor(state.WorkflowType)
+	names := make([]string, 0, len(state.Phases))
+	if err == nil {
+		names = wf.PhaseNames()
+	} else {
+		for name := range state.Phases {
+			names = append(names, name)
+		}
+		sort.Strings(names)
+	}
+
+	r.Finished = len(names) > 0
+	for _, name := range names {
+		ps := state.Phases[name]
+		p := PhaseEntry{
+			Name:        name,
+			Status:      ps.Status,
+			StartedAt:   parseTime(ps.StartedAt),
+			CompletedAt: parseTime(ps.CompletedAt),
+		}
+		if p.Status == "" {
+			p.Status = hook.PhasePending
+		}
+		if p.Status != hook.PhaseComplete {
+			r.Finished = false
+		}
+		r.extendWindow(p.StartedAt)
+		r.extendWindow(p.CompletedAt)
+		r.Phases = append(r.Phases, p)
+	}
+}
+
+func (r *Report) addAgents(jobDir string, state *hook.JobState) {
+	names := make([]string, 0, len(state.Agents))
+	for name := range state.Agents {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+
+	for _, name := range names {
+		as := state.Agents[name]
+		a := AgentEntry{
+			Name:        name,
+			Status:      as.Status,
+			CompletedAt: parseTime(as.CompletedAt),
+			BlockedBy:   as.BlockedBy,
+		}
+		if as.Checklist != "" {
+			if stats, err := hook.ParseChecklistFile(filepath.Join(jobDir, as.Checklist)); err == nil {
+				a.Checklist = stats.Summary()
+			}
+		}
+		r.extendWindow(a.CompletedAt)
+		r.Agents = append(r.Agents, a)
+	}
+}
+
+// addHistory records any checklist transitions not yet logged, as godo job
+// stats does, and computes the time-in-status metrics from the log. Items
+// of checklist.md without inline history lines get theirs from the log.
+func (r *Report) addHistory(jobDir string, now time.Time) error {
+	if _, err := hook.SyncJobHistory(jobDir, "", now); err != nil {
+		return fmt.Errorf("sync checklist history: %w", err)
+	}
+	history, err := hook.LoadHistory(jobDir)
+	if err != nil {
+		return fmt.Errorf("load checklist history: %w", err)
+	}
+	if len(history) == 0 {
+		return nil
+	}
+	r.History = history
+	r.Timing = hook.ComputeStats(history, now)
+
+	byItem := make(map[string][]hook.ChecklistHistoryEntry)
+	for _, e := range history {
+		if e.File != "checklist.md" {
+			continue
+		}
+		t, err := time.Parse(time.RFC3339, e.Time)
+		if err != nil {
+			continue
+		}
+		byItem[e.Item] = append(byItem[e.Item], hook.ChecklistHistoryEntry{Status: e.To, Time: t.Local().Format(timeLayout)})
+	}
+	for i, item := range r.Checklist {
+		if len(item.History) == 0 {
+			r.Checklist[i].History = byItem[hook.HistoryKey(item)]
+		}
+	}
+	return nil
+}
+
+// extendWindow widens [Start, End] to include t.
+func (r *Report) extendWindow(t time.Time) {
+	if t.IsZero() {
+		return
+	}
+	if r.Start.IsZero() || t.Before(r.Start) {
+		r.Start = t
+	}
+	if t.After(r.End) {
+		r.End = t
+	}
+}
+
+// gitActivity returns the commits made in [start, end] and the files they touched.
+func gitActivity(repoDir string, start, end time.Time) ([]Commit, []FileChange) {
+	out, err := runGit(repoDir, "log",
+		"--since="+start.Format(time.RFC3339),
+		"--until="+end.Format(time.RFC3339),
+		"--numstat", "--format=%x1e%H%x1f%an%x1f%aI%x1f%s")
+	if err != nil {
+		return nil, nil
+	}
+	return parseGitLog(out)
+}
+
+// parseGitLog parses `git log --numstat` output whose records start with \x1e
+// and whose header fields are separated by \x1f.
+func parseGitLog(out string) ([]Commit, []FileChange) {
+	var commits []Commit
+	files := make(map[string]*FileChange)
+
+	for _, record := range strings.Split(out, "\x1e") {
+		lines := strings.Split(strings.TrimSpace(record), "\n")
+		if len(lines) == 0 || lines[0] == "" {
+			continue
+		}
+		fields := strings.Split(lines[0], "\x1f")
+		if len(fields) != 4 {
+			continue
+		}
+		commits = append(commits, Commit{
+			Hash:    fields[0],
+			Author:  fields[1],
+			Date:    parseTime(fields[2]),
+			Subject: fields[3],
+		})
+		for _, line := range lines[1:] {
+			parts := strings.SplitN(line, "\t", 3)
+			if len(parts) != 3 {
+				continue
+			}
+			fc, ok := files[parts[2]]
+			if !ok {
+				fc = &FileChange{Path: parts[2]}
+				files[parts[2]] = fc
+			}
+			// Binary files report "-" for both counts.
+			added, _ := strconv.Atoi(parts[0])
+			deleted, _ := strconv.Atoi(parts[1])
+			fc.Added += added
+			fc.Deleted += deleted
+		}
+	}
+
+	changes := make([]FileChange, 0, len(files))
+	for _, fc := range files {
+		changes = append(changes, *fc)
+	}
+	sort.Slice(changes, func(i, j int) bool { return changes[i].Path < changes[j].Path })
+	return commits, changes
+}
+
+func parseTime(s string) time.Time {
+	if s == "" {
+		return time.Time{}
+	}
+	t, err := time.Parse(time.RFC3339Nano, s)
+	if err != nil {
+		return time.Time{}
+	}
+	return t
+}
@@ -0,0 +1,173 @@
+package jobreport
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/yejune/godo/internal/hook"
+)
+
+func writeJobFixture(t *testing.T) string {
+	t.Helper()
+	dir := t.TempDir()
+	files := map[string]string{
+		"state.json": `{
+  "job_id": "login-api",
+  "created_at": "2026-02-18T10:00:00Z",
+  "workflow_type": "simple",
+  "phases": {
+    "plan": {"status": "complete", "started_at": "2026-02-18T10:00:00Z", "completed_at": "2026-02-18T10:30:00Z"},
+    "checklist": {"status": "complete", "started_at": "2026-02-18T10:30:00Z", "completed_at": "2026-02-18T10:40:00Z"},
+    "develop": {"status": "complete", "started_at": "2026-02-18T10:40:00Z", "completed_at": "2026-02-18T12:00:00Z"},
+    "test": {"status": "complete", "started_at": "2026-02-18T12:00:00Z", "completed_at": "2026-02-18T12:30:00Z"},
+    "report": {"status": "complete", "started_at": "2026-02-18T12:30:00Z", "completed_at": "2026-02-18T13:00:00Z"}
+  },
+  "agents": {
+    "expert-backend": {"status": "complete", "checklist": "checklists/01_expert-backend.md", "completed_at": "2026-02-18T12:00:00Z"}
+  }
+}`,
+		"checklist.md":                    "- [o] #1 login handler\n    - [~] 2026-02-18 10:45:00 started\n    - [o] 2026-02-18 11:50:00 done\n",
+		"checklists/01_expert-backend.md": "- [o] handler\n- [o] tests\n",
+	}
+	for name, content := range files {
+		path := filepath.Join(dir, name)
+		if err := os.MkdirAll(filepath.Dir(path), 0755); err != nil {
+			t.Fatal(err)
+		}
+		if err := os.WriteFile(path, []byte(content), 0644); err != nil {
+			t.Fatal(err)
+		}
+	}
+	return dir
+}
+
+func TestBuild_collects_timeline_and_git_activity(t *testing.T) {
+	orig := runGit
+	defer func() { runGit = orig }()
+	var gotArgs []string
+	runGit = func(dir string, args ...string) (string, error) {
+		gotArgs = args
+		return "\x1eabc1234567\x1fdev\x1f2026-02-18T11:50:00Z\x1fAdd login handler\n\n10\t2\tapi/login.go\n5\t0\tapi/login_test.go\n" +
+			"\x1edef7654321\x1fdev\x1f2026-02-18T12:20:00Z\x1fFix token expiry\n\n3\t1\tapi/login.go\n", nil
+	}
+
+	dir := writeJobFixture(t)
+	r, err := Build(dir, Options{})
+	if err != nil {
+		t.Fatalf("Build: %v", err)
+	}
+
+	if !r.Finished {
+		t.Error("expected Finished with all phases complete")
+	}
+	if want := time.Date(2026, 2, 18, 13, 0, 0, 0, time.UTC); !r.End.Equal(want) {
+		t.Errorf("End: got %v, want %v", r.End, want)
+	}
+	if !strings.Contains(strings.Join(gotArgs, " "), "--since=2026-02-18T10:00:00Z") {
+		t.Errorf("git log should be bounded by the job window, args: %v", gotArgs)
+	}
+	if len(r.Commits) != 2 {
+		t.Fatalf("commits: got %d, want 2", len(r.Commits))
+	}
+	if len(r.Files) != 2 || r.Files[0].Path != "api/login.go" || r.Files[0].Added != 13 || r.Files[0].Deleted != 3 {
+		t.Errorf("files: got %+v", r.Files)
+	}
+	if len(r.Agents) != 1 || r.Agents[0].Checklist != "[o]2" {
+		t.Errorf("agents: got %+v", r.Agents)
+	}
+	if len(r.Checklist) != 1 || len(r.Checklist[0].History) != 2 {
+		t.Errorf("checklist history: got %+v", r.Checklist)
+	}
+}
+
+func TestBuild_reads_transcript_usage(t *testing.T) {
+	orig := runGit
+	defer func() { runGit = orig }()
+	runGit = func(dir string, args ...string) (string, error) { return "", nil }
+
+	dir := writeJobFixture(t)
+	transcript := filepath.Join(t.TempDir(), "session.jsonl")
+	lines := `{"type":"user","timestamp":"2026-02-18T10:00:00Z"}
+{"type":"assistant","timestamp":"2026-02-18T10:01:00Z","message":{"model":"claude-sonnet","usage":{"input_tokens":100,"output_tokens":50}}}
+`
+	if err := os.WriteFile(transcript, []byte(lines), 0644); err != nil {
+		t.Fatal(err)
+	}
+
+	r, err := Build(dir, Options{TranscriptPath: transcript})
+	if err != nil {
+		t.Fatalf("Build: %v", err)
+	}
+	if r.Usage == nil || r.Usage.InputTokens != 100 || r.Usage.OutputTokens != 50 {
+		t.Errorf("usage: got %+v", r.Usage)
+	}
+	if !strings.Contains(r.Markdown(), "## Token Usage") {
+		t.Error("markdown should include a token usage section")
+	}
+}
+
+func TestBuild_reads_checklist_history_log(t *testing.T) {
+	orig := runGit
+	defer func() { runGit = orig }()
+	runGit = func(dir string, args ...string) (string, error) { return "", nil }
+
+	dir := writeJobFixture(t)
+	os.WriteFile(filepath.Join(dir, "checklist.md"), []byte("- [o] #1 login handler\n- [~] #2 refresh token\n"), 0644)
+	log := `{"ts":"2026-02-18T10:45:00Z","file":"checklist.md","item":"#1","from":"","to":"~"}
+{"ts":"2026-02-18T11:15:00Z","file":"checklist.md","item":"#1","from":"~","to":"o"}
+{"ts":"2026-02-18T11:15:00Z","file":"checklist.md","item":"#2","from":"","to":"~"}
+`
+	os.WriteFile(filepath.Join(dir, hook.HistoryFile), []byte(log), 0644)
+
+	now := time.Date(2026, 2, 18, 14, 0, 0, 0, time.UTC)
+	r, err := Build(dir, Options{Now: now})
+	if err != nil {
+		t.Fatalf("Build: %v", err)
+	}
+	if len(r.Checklist) != 2 || len(r.Checklist[0].History) != 2 || r.Checklist[0].History[1].Status != "o" {
+		t.Errorf("item history from the log: got %+v", r.Checklist)
+	}
+
+	entries, _ := hook.LoadHistory(dir)
+	want := hook.ComputeStats(entries, now)
+	if r.Timing == nil || r.Timing.Job != want.Job {
+		t.Fatalf("timing: got %+v, want %+v", r.Timing, want)
+	}
+	if main := r.Timing.Agents[0]; main.Name != "(main)" || main.Items != 2 || main.AvgCycleTime() != 30*time.Minute {
+		t.Errorf("main checklist timing: got %+v", main)
+	}
+	if md := r.Markdown(); !strings.Contains(md, "## Checklist Timing") || !strings.Contains(md, "| (job) |") {
+		t.Errorf("markdown should include the timing table:\n%s", md)
+	}
+}
+
+func TestReport_renders_markdown_and_html(t *testing.T) {
+	r := &Report{
+		JobID:   "demo",
+		Start:   time.Date(2026, 2, 18, 10, 0, 0, 0, time.UTC),
+		End:     time.Date(2026, 2, 18, 11, 30, 0, 0, time.UTC),
+		Phases:  []PhaseEntry{{Name: "plan", Status: "complete"}},
+		Commits: []Commit{{Hash: "abcdef123456", Subject: "Add <feature>"}},
+	}
+
+	md := r.Markdown()
+	for _, want := range []string{"# Job Report: demo", "(1h30m)", "| plan | complete |", "`abcdef1` Add <feature>"} {
+		if !strings.Contains(md, want) {
+			t.Errorf("markdown missing %q:\n%s", want, md)
+		}
+	}
+
+	html, err := r.HTML()
+	if err != nil {
+		t.Fatalf("HTML: %v", err)
+	}
+	if !strings.HasPrefix(html, "<!DOCTYPE html>") {
+		t.Error("html should be a standalone document")
+	}
+	if !strings.Contains(html, "Add &lt;feature&gt;") {
+		t.Error("html should escape commit subjects")
+	}
+}
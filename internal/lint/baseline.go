@@ -0,0 +1,196 @@
+package lint
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+	"time"
+)
+
+// BaselineFile is the accepted-diagnostics snapshot, relative to the project root.
+const BaselineFile = ".do/lint-baseline.json"
+
+// Baseline records diagnostics that existed when it was taken, so lint
+// only fails on new ones.
+type Baseline struct {
+	Version   int             `json:"version"`
+	CreatedAt string          `json:"created_at"`
+	Entries   []BaselineEntry `json:"entries"`
+}
+
+// BaselineEntry is one accepted diagnostic. Count covers identical
+// fingerprints (the same finding on identical lines of one file).
+type BaselineEntry struct {
+	Fingerprint string `json:"fingerprint"`
+	File        string `json:"file"`
+	Rule        string `json:"rule,omitempty"`
+	Source      string `json:"source"`
+	Message     string `json:"message"`
+	Line        int    `json:"line"` // informational; not part of the fingerprint
+	Count       int    `json:"count"`
+}
+
+// Fingerprinter computes line-independent diagnostic fingerprints from the
+// file, the rule and the whitespace-normalized source line. File contents
+// are read once per path.
+type Fingerprinter struct {
+	projectDir string
+	lines      map[string][]string
+}
+
+// NewFingerprinter creates a Fingerprinter reading files under projectDir.
+func NewFingerprinter(projectDir string) *Fingerprinter {
+	return &Fingerprinter{projectDir: projectDir, lines: make(map[string][]string)}
+}
+
+// Fingerprint returns the baseline key for d. Diagnostics without a rule
+// are keyed by message, so shifting the code up or down keeps the key.
+func (f *Fingerprinter) Fingerprint(d Diagnostic) string {
+	rule := d.Rule
+	if rule == "" {
+		rule = d.Message
+	}
+	h := sha256.Sum256([]byte(strings.Join([]string{
+		filepath.ToSlash(d.File), d.Source, rule, f.context(d.File, d.Line),
+	}, "\x00")))
+	return hex.EncodeToString(h[:16])
+}
+
+// context returns the flagged line with whitespace collapsed.
+func (f *Fingerprinter) context(file string, line int) string {
+	lines, ok := f.lines[file]
+	if !ok {
+		p := file
+		if !filepath.IsAbs(p) {
+			p = filepath.Join(f.projectDir, p)
+		}
+		data, err := os.ReadFile(p)
+		if err == nil {
+			lines = strings.Split(string(data), "\n")
+		}
+		f.lines[file] = lines
+	}
+	if line < 1 || line > len(lines) {
+		return ""
+	}
+	return strings.Join(strings.Fields(lines[line-1]), " ")
+}
+
+// NewBaseline snapshots diags.
+func NewBaseline(diags []Diagnostic, projectDir string, now time.Time) *Baseline {
+	b := &Baseline{Version: 1, CreatedAt: now.UTC().Format(time.RFC3339)}
+	fp := NewFingerprinter(projectDir)
+	index := make(map[string]int)
+	for _, d := range SortDiagnostics(diags) {
+		key := fp.Fingerprint(d)
+		if i, ok := index[key]; ok {
+			b.Entries[i].Count++
+			continue
+		}
+		index[key] = len(b.Entries)
+		b.Entries = append(b.Entries, BaselineEntry{
+			Fingerprint: key, File: d.File, Rule: d.Rule, Source: d.Source,
+			Message: d.Message, Line: d.Line, Count: 1,
+		})
+	}
+	return b
+}
+
+// LoadBaseline reads {projectDir}/.do/lint-baseline.json. The returned
+// error wraps os.ErrNotExist when there is no baseline.
+func LoadBaseline(projectDir string) (*Baseline, error) {
+	p := filepath.Join(projectDir, BaselineFile)
+	data, err := os.ReadFile(p)
+	if err != nil {
+		return nil, fmt.Errorf("read lint baseline: %w", err)
+	}
+	var b Baseline
+	if err := json.Unmarshal(data, &b); err != nil {
+		return nil, fmt.Errorf("parse lint baseline %s: %w", p, err)
+	}
+	return &b, nil
+}
+
+// Save writes the baseline atomically.
+func (b *Baseline) Save(projectDir string) error {
+	p := filepath.Join(projectDir, BaselineFile)
+	if err := os.MkdirAll(filepath.Dir(p), 0755); err != nil {
+		return fmt.Errorf("create baseline dir: %w", err)
+	}
+	if b.Entries == nil {
+		b.Entries = []BaselineEntry{}
+	}
+	data, err := json.MarshalIndent(b, "", "  ")
+	if err != nil {
+		return fmt.Errorf("marshal lint baseline: %w", err)
+	}
+	tmp := p + ".tmp"
+	if err := os.WriteFile(tmp, append(data, '\n'), 0644); err != nil {
+		return fmt.Errorf("write lint baseline: %w", err)
+	}
+	if err := os.Rename(tmp, p); err != nil {
+		os.Remove(tmp)
+		return fmt.Errorf("rename lint baseline: %w", err)
+	}
+	return nil
+}
+
+// Total returns the number of accepted diagnostics.
+func (b *Baseline) Total() int {
+	n := 0
+	for _, e := range b.Entries {
+		n += e.Count
+	}
+	return n
+}
+
+// Filter splits diags into new ones and ones already in the baseline.
+func (b *Baseline) Filter(diags []Diagnostic, projectDir string) (fresh, known []Diagnostic) {
+	remaining := make(map[string]int, len(b.Entries))
+	for _, e := range b.Entries {
+		remaining[e.Fingerprint] += e.Count
+	}
+	fp := NewFingerprinter(projectDir)
+	for _, d := range SortDiagnostics(diags) {
+		key := fp.Fingerprint(d)
+		if remaining[key] > 0 {
+			remaining[key]--
+			known = append(known, d)
+			continue
+		}
+		fresh = append(fresh, d)
+	}
+	return fresh, known
+}
+
+// Prune drops entries that no longer occur in diags (a full-project run)
+// and lowers counts to the current occurrences. Returns how many accepted
+// diagnostics were removed.
+func (b *Baseline) Prune(diags []Diagnostic, projectDir string) int {
+	current := make(map[string]int)
+	fp := NewFingerprinter(projectDir)
+	for _, d := range diags {
+		current[fp.Fingerprint(d)]++
+	}
+
+	removed := 0
+	kept := b.Entries[:0]
+	for _, e := range b.Entries {
+		n := current[e.Fingerprint]
+		if n < e.Count {
+			removed += e.Count - n
+			e.Count = n
+		}
+		if e.Count > 0 {
+			kept = append(kept, e)
+		}
+	}
+	b.Entries = kept
+	sort.SliceStable(b.Entries, func(i, j int) bool { return b.Entries[i].File < b.Entries[j].File })
+	return removed
+}
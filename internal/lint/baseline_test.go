@@ -0,0 +1,93 @@
+package lint
+
+import (
+	"errors"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+)
+
+func writeSource(t *testing.T, dir, name, content string) {
+	t.Helper()
+	if err := os.WriteFile(filepath.Join(dir, name), []byte(content), 0644); err != nil {
+		t.Fatal(err)
+	}
+}
+
+func Test_Fingerprint_survives_line_shift(t *testing.T) {
+	dir := t.TempDir()
+	writeSource(t, dir, "a.py", "import os\n")
+	before := NewFingerprinter(dir).Fingerprint(Diagnostic{File: "a.py", Line: 1, Rule: "F401", Source: "ruff"})
+
+	writeSource(t, dir, "a.py", "# header\n\n   import   os\n")
+	after := NewFingerprinter(dir).Fingerprint(Diagnostic{File: "a.py", Line: 3, Rule: "F401", Source: "ruff"})
+
+	if before != after {
+		t.Error("fingerprint should ignore line numbers and whitespace")
+	}
+	other := NewFingerprinter(dir).Fingerprint(Diagnostic{File: "a.py", Line: 3, Rule: "E501", Source: "ruff"})
+	if other == after {
+		t.Error("different rules should have different fingerprints")
+	}
+}
+
+func Test_Baseline_Filter_reports_only_new(t *testing.T) {
+	dir := t.TempDir()
+	writeSource(t, dir, "a.py", "x = 1\nx = 1\ny = 2\n")
+	old := []Diagnostic{
+		{File: "a.py", Line: 1, Rule: "R1", Source: "ruff"},
+		{File: "a.py", Line: 2, Rule: "R1", Source: "ruff"},
+	}
+	base := NewBaseline(old, dir, time.Now())
+	if len(base.Entries) != 1 || base.Total() != 2 {
+		t.Fatalf("identical lines should share one entry with count 2: %+v", base.Entries)
+	}
+	if err := base.Save(dir); err != nil {
+		t.Fatal(err)
+	}
+	loaded, err := LoadBaseline(dir)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	current := append(old,
+		Diagnostic{File: "a.py", Line: 3, Rule: "R1", Source: "ruff"}, // new line content
+	)
+	fresh, known := loaded.Filter(current, dir)
+	if len(fresh) != 1 || fresh[0].Line != 3 || len(known) != 2 {
+		t.Errorf("fresh=%+v known=%+v", fresh, known)
+	}
+
+	// A third identical occurrence exceeds the accepted count.
+	writeSource(t, dir, "a.py", "x = 1\nx = 1\nx = 1\n")
+	fresh, _ = loaded.Filter(current, dir)
+	if len(fresh) != 1 {
+		t.Errorf("extra occurrence should be new, got %+v", fresh)
+	}
+}
+
+func Test_Baseline_Prune_removes_fixed(t *testing.T) {
+	dir := t.TempDir()
+	writeSource(t, dir, "a.py", "x = 1\nx = 1\nimport os\n")
+	base := NewBaseline([]Diagnostic{
+		{File: "a.py", Line: 1, Rule: "R1", Source: "ruff"},
+		{File: "a.py", Line: 2, Rule: "R1", Source: "ruff"},
+		{File: "a.py", Line: 3, Rule: "F401", Source: "ruff"},
+	}, dir, time.Now())
+
+	removed := base.Prune([]Diagnostic{{File: "a.py", Line: 1, Rule: "R1", Source: "ruff"}}, dir)
+	if removed != 2 {
+		t.Errorf("removed: got %d, want 2", removed)
+	}
+	if len(base.Entries) != 1 || base.Entries[0].Count != 1 {
+		t.Errorf("entries: got %+v", base.Entries)
+	}
+}
+
+func Test_LoadBaseline_missing(t *testing.T) {
+	_, err := LoadBaseline(t.TempDir())
+	if !errors.Is(err, os.ErrNotExist) {
+		t.Errorf("expected os.ErrNotExist, got %v", err)
+	}
+}
@@ -0,0 +1,444 @@
+package lint
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io/fs"
+	"os"
+	"path/filepath"
+	"strings"
+	"sync"
+	"sync/atomic"
+	"time"
+)
+
+// CacheDir holds cached lint results, relative to the project root.
+const CacheDir = ".do/cache/lint"
+
+// cacheFormat is part of every key; bump it when the entry layout or the
+// key derivation changes.
+const cacheFormat = "1"
+
+// Cache stores diagnostics keyed by the linted content, the linter
+// definition, the tool version and the linter's config files.
+//
+// Linters whose command takes {files} or {file} are cached per file, so
+// only changed files are passed to the tool again. Linters that analyze
+// a whole directory (go vet ./..., tsc, cargo clippy) are cached per
+// working directory, keyed by every file under it the linter handles.
+type Cache struct {
+	projectDir string
+	dir        string
+
+	hits   atomic.Int64
+	misses atomic.Int64
+
+	mu       sync.Mutex
+	versions map[string]string
+	hashes   map[string]fileHash
+
+	listOnce sync.Once
+	listed   []string
+}
+
+type fileHash struct {
+	size    int64
+	modTime time.Time
+	sum     string
+}
+
+// CacheStats counts cache lookups.
+type CacheStats struct {
+	Hits   int64     `json:"hits"`
+	Misses int64     `json:"misses"`
+	Last   time.Time `json:"last,omitempty"`
+}
+
+// HitRate returns hits as a percentage of lookups.
+func (s CacheStats) HitRate() float64 {
+	if s.Hits+s.Misses == 0 {
+		return 0
+	}
+	return float64(s.Hits) * 100 / float64(s.Hits+s.Misses)
+}
+
+// CacheUsage describes the on-disk cache.
+type CacheUsage struct {
+	Entries int
+	Bytes   int64
+	Total   CacheStats // accumulated over all runs
+	LastRun CacheStats // the most recent run
+}
+
+// NewCache opens the cache of projectDir. Nothing is written until a
+// result is stored.
+func NewCache(projectDir string) *Cache {
+	return &Cache{
+		projectDir: projectDir,
+		dir:        filepath.Join(projectDir, CacheDir),
+		versions:   make(map[string]string),
+		hashes:     make(map[string]fileHash),
+	}
+}
+
+// Stats returns the lookups made through this Cache.
+func (c *Cache) Stats() CacheStats {
+	return CacheStats{Hits: c.hits.Load(), Misses: c.misses.Load()}
+}
+
+// run returns the diagnostics of one invocation, running the linter only
+// for what is not cached.
+func (c *Cache) run(d LinterDef, b runBatch, projectDir string) []Diagnostic {
+	base := c.baseKey(d, b.dir)
+	if !d.takesFiles() {
+		key := c.dirKey(base, d, b)
+		if diags, ok := c.get(key); ok {
+			c.hits.Add(1)
+			return diags
+		}
+		c.misses.Add(1)
+		diags, ok := d.runBatchChecked(b, projectDir)
+		if ok {
+			c.put(key, diags)
+		}
+		return diags
+	}
+
+	keys := make(map[string]string, len(b.files))
+	byFile := make(map[string][]Diagnostic, len(b.files))
+	var todo []string
+	for _, f := range b.files {
+		sum, err := c.fileSum(filepath.Join(b.dir, f))
+		if err != nil {
+			todo = append(todo, f)
+			continue
+		}
+		key := hashKey(base, filepath.ToSlash(f), sum)
+		if diags, ok := c.get(key); ok {
+			c.hits.Add(1)
+			byFile[f] = diags
+			continue
+		}
+		c.misses.Add(1)
+		keys[f] = key
+		todo = append(todo, f)
+	}
+
+	var extra []Diagnostic
+	if len(todo) > 0 {
+		fresh, ok := d.runBatchChecked(runBatch{dir: b.dir, files: todo}, projectDir)
+		rel := make(map[string]string, len(todo))
+		for _, f := range todo {
+			rel[normalizeDiagPath(f, b.dir, projectDir)] = f
+		}
+		for _, diag := range fresh {
+			if f, found := rel[diag.File]; found {
+				byFile[f] = append(byFile[f], diag)
+			} else {
+				extra = append(extra, diag)
+			}
+		}
+		if ok {
+			for _, f := range todo {
+				if key, found := keys[f]; found {
+					c.put(key, byFile[f])
+				}
+			}
+		}
+	}
+
+	var diags []Diagnostic
+	for _, f := range b.files {
+		diags = append(diags, byFile[f]...)
+	}
+	return append(diags, extra...)
+}
+
+// takesFiles reports whether the command receives the files to lint.
+func (d LinterDef) takesFiles() bool {
+	return strings.Contains(d.Command, "{files}") || strings.Contains(d.Command, "{file}")
+}
+
+// baseKey hashes everything but the linted files: the definition, the
+// tool version and the config files that apply in dir.
+func (c *Cache) baseKey(d LinterDef, dir string) string {
+	def, _ := json.Marshal(struct {
+		Name, Kind, Command, Format, Pattern, Severity string
+		JSON                                           *JSONMapping
+	}{d.Name, d.Kind, d.Command, d.Format, d.Pattern, d.Severity, d.JSON})
+
+	parts := []string{cacheFormat, string(def), c.version(d)}
+	for _, p := range configPaths(dir, c.projectDir, d.ConfigFiles) {
+		sum, err := c.fileSum(p)
+		if err != nil {
+			continue
+		}
+		rel, _ := filepath.Rel(c.projectDir, p)
+		parts = append(parts, filepath.ToSlash(rel), sum)
+	}
+	return hashKey(parts...)
+}
+
+// dirKey extends base with every file under the batch directory the
+// linter handles. Outside git only the batch's own files are known.
+func (c *Cache) dirKey(base string, d LinterDef, b runBatch) string {
+	parts := []string{base}
+	if rel, err := filepath.Rel(c.projectDir, b.dir); err == nil {
+		parts = append(parts, filepath.ToSlash(rel))
+	}
+	files := c.projectFiles()
+	root := c.projectDir
+	if files == nil {
+		files, root = b.files, b.dir
+	}
+	for _, f := range files {
+		abs := filepath.Join(root, f)
+		if !d.Matches(f) || !within(abs, b.dir) {
+			continue
+		}
+		sum, err := c.fileSum(abs)
+		if err != nil {
+			continue
+		}
+		parts = append(parts, f, sum)
+	}
+	return hashKey(parts...)
+}
+
+// version returns the output of the linter's version command, run once
+// per Cache.
+func (c *Cache) version(d LinterDef) string {
+	cmd := d.VersionCommand
+	if cmd == "" {
+		cmd = d.Binary() + " --version"
+	}
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	if v, ok := c.versions[cmd]; ok {
+		return v
+	}
+	v := ""
+	if args := splitCommand(cmd); len(args) > 0 {
+		args[0] = ToolPath(c.projectDir, args[0])
+		if out, err := runCommand(args, c.projectDir); err == nil {
+			v = strings.TrimSpace(string(out))
+		}
+	}
+	c.versions[cmd] = v
+	return v
+}
+
+// projectFiles lists tracked and untracked, non-ignored files once.
+func (c *Cache) projectFiles() []string {
+	c.listOnce.Do(func() {
+		out, err := gitOutput(c.projectDir, "ls-files", "--cached", "--others", "--exclude-standard")
+		if err != nil {
+			return
+		}
+		for _, f := range strings.Split(out, "\n") {
+			if f = strings.TrimSpace(f); f != "" {
+				c.listed = append(c.listed, f)
+			}
+		}
+	})
+	return c.listed
+}
+
+// fileSum returns the content hash of a file, reusing it while the size
+// and modification time are unchanged.
+func (c *Cache) fileSum(path string) (string, error) {
+	info, err := os.Stat(path)
+	if err != nil {
+		return "", err
+	}
+	c.mu.Lock()
+	h, ok := c.hashes[path]
+	c.mu.Unlock()
+	if ok && h.size == info.Size() && h.modTime.Equal(info.ModTime()) {
+		return h.sum, nil
+	}
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return "", err
+	}
+	sum := sha256.Sum256(data)
+	h = fileHash{size: info.Size(), modTime: info.ModTime(), sum: hex.EncodeToString(sum[:])}
+	c.mu.Lock()
+	c.hashes[path] = h
+	c.mu.Unlock()
+	return h.sum, nil
+}
+
+func (c *Cache) entryPath(key string) string {
+	return filepath.Join(c.dir, key[:2], key+".json")
+}
+
+func (c *Cache) get(key string) ([]Diagnostic, bool) {
+	data, err := os.ReadFile(c.entryPath(key))
+	if err != nil {
+		return nil, false
+	}
+	var diags []Diagnostic
+	if err := json.Unmarshal(data, &diags); err != nil {
+		return nil, false
+	}
+	return diags, true
+}
+
+// put stores an entry. Failures only cost a future cache miss.
+func (c *Cache) put(key string, diags []Diagnostic) {
+	if diags == nil {
+		diags = []Diagnostic{}
+	}
+	data, err := json.Marshal(diags)
+	if err != nil {
+		return
+	}
+	p := c.entryPath(key)
+	if err := os.MkdirAll(filepath.Dir(p), 0755); err != nil {
+		return
+	}
+	c.ignoreInGit()
+	tmp, err := os.CreateTemp(filepath.Dir(p), key+".*.tmp")
+	if err != nil {
+		return
+	}
+	_, werr := tmp.Write(data)
+	cerr := tmp.Close()
+	if werr != nil || cerr != nil || os.Rename(tmp.Name(), p) != nil {
+		os.Remove(tmp.Name())
+	}
+}
+
+// ignoreInGit keeps the cache out of git status.
+func (c *Cache) ignoreInGit() {
+	p := filepath.Join(c.dir, ".gitignore")
+	if _, err := os.Stat(p); err == nil {
+		return
+	}
+	_ = os.WriteFile(p, []byte("*\n"), 0644)
+}
+
+const cacheStatsFile = "stats.json"
+
+type cacheStatsRecord struct {
+	Total   CacheStats `json:"total"`
+	LastRun CacheStats `json:"last_run"`
+}
+
+func (c *Cache) loadStats() cacheStatsRecord {
+	var rec cacheStatsRecord
+	data, err := os.ReadFile(filepath.Join(c.dir, cacheStatsFile))
+	if err == nil {
+		_ = json.Unmarshal(data, &rec)
+	}
+	return rec
+}
+
+// SaveStats adds this Cache's lookups to the totals in stats.json.
+// Runs without lookups are not recorded.
+func (c *Cache) SaveStats() error {
+	run := c.Stats()
+	if run.Hits+run.Misses == 0 {
+		return nil
+	}
+	run.Last = time.Now().UTC()
+	rec := c.loadStats()
+	rec.Total.Hits += run.Hits
+	rec.Total.Misses += run.Misses
+	rec.Total.Last = run.Last
+	rec.LastRun = run
+
+	if err := os.MkdirAll(c.dir, 0755); err != nil {
+		return fmt.Errorf("create lint cache dir: %w", err)
+	}
+	c.ignoreInGit()
+	data, err := json.MarshalIndent(rec, "", "  ")
+	if err != nil {
+		return fmt.Errorf("marshal lint cache stats: %w", err)
+	}
+	p := filepath.Join(c.dir, cacheStatsFile)
+	tmp := p + ".tmp"
+	if err := os.WriteFile(tmp, append(data, '\n'), 0644); err != nil {
+		return fmt.Errorf("write lint cache stats: %w", err)
+	}
+	if err := os.Rename(tmp, p); err != nil {
+		os.Remove(tmp)
+		return fmt.Errorf("rename lint cache stats: %w", err)
+	}
+	return nil
+}
+
+// Usage reports the number and size of cached entries and the recorded
+// lookup statistics.
+func (c *Cache) Usage() (CacheUsage, error) {
+	var u CacheUsage
+	err := filepath.WalkDir(c.dir, func(p string, e fs.DirEntry, err error) error {
+		if err != nil {
+			return err
+		}
+		if e.IsDir() || filepath.Dir(p) == c.dir || !strings.HasSuffix(p, ".json") {
+			return nil
+		}
+		info, err := e.Info()
+		if err != nil {
+			return nil
+		}
+		u.Entries++
+		u.Bytes += info.Size()
+		return nil
+	})
+	if err != nil && !errors.Is(err, fs.ErrNotExist) {
+		return u, fmt.Errorf("scan lint cache: %w", err)
+	}
+	rec := c.loadStats()
+	u.Total, u.LastRun = rec.Total, rec.LastRun
+	return u, nil
+}
+
+// Clear removes every cached entry and the statistics.
+func (c *Cache) Clear() error {
+	if err := os.RemoveAll(c.dir); err != nil {
+		return fmt.Errorf("clear lint cache: %w", err)
+	}
+	return nil
+}
+
+func hashKey(parts ...string) string {
+	h := sha256.Sum256([]byte(strings.Join(parts, "\x00")))
+	return hex.EncodeToString(h[:])
+}
+
+// configPaths returns the existing config files named in names, looking in
+// dir and each parent up to projectDir, nearest first.
+func configPaths(dir, projectDir string, names []string) []string {
+	if len(names) == 0 {
+		return nil
+	}
+	var paths []string
+	for {
+		for _, n := range names {
+			p := filepath.Join(dir, n)
+			if info, err := os.Stat(p); err == nil && !info.IsDir() {
+				paths = append(paths, p)
+			}
+		}
+		if dir == projectDir || !within(dir, projectDir) {
+			return paths
+		}
+		parent := filepath.Dir(dir)
+		if parent == dir {
+			return paths
+		}
+		dir = parent
+	}
+}
+
+// within reports whether path is dir or below it.
+func within(path, dir string) bool {
+	rel, err := filepath.Rel(dir, path)
+	return err == nil && rel != ".." && !strings.HasPrefix(rel, ".."+string(filepath.Separator))
+}
@@ -0,0 +1,170 @@
+package lint
+
+import (
+	"os"
+	"os/exec"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+// countingLinter reports one diagnostic per file and logs every file it is
+// given to calls.log, so tests can see what was actually linted.
+func countingLinter(command string) LinterDef {
+	return LinterDef{
+		Name:           "count",
+		Files:          []string{"*.txt"},
+		Command:        command,
+		Format:         FormatRegex,
+		Pattern:        `^(?P<file>[^:]+):(?P<line>\d+): (?P<message>.+)$`,
+		VersionCommand: "sh -c 'echo v1'",
+		ConfigFiles:    []string{"count.cfg"},
+	}
+}
+
+func readCalls(t *testing.T, dir string) []string {
+	t.Helper()
+	data, err := os.ReadFile(filepath.Join(dir, "calls.log"))
+	if err != nil && !os.IsNotExist(err) {
+		t.Fatal(err)
+	}
+	os.Remove(filepath.Join(dir, "calls.log"))
+	return strings.Fields(string(data))
+}
+
+func Test_Cache_per_file_reuses_unchanged_files(t *testing.T) {
+	if _, err := exec.LookPath("sh"); err != nil {
+		t.Skip("sh not available")
+	}
+	dir := t.TempDir()
+	writeSource(t, dir, "a.txt", "a\n")
+	writeSource(t, dir, "b.txt", "b\n")
+	writeSource(t, dir, "count.cfg", "strict\n")
+	def := countingLinter(`sh -c 'for f in "$@"; do echo "$f" >> calls.log; echo "$f:1: seen"; done' sh {files}`)
+	files := []string{"a.txt", "b.txt"}
+
+	run := func() ([]Diagnostic, CacheStats) {
+		reg := &Registry{Linters: []LinterDef{def}, Cache: NewCache(dir)}
+		diags := reg.Run(files, dir)
+		return diags, reg.Cache.Stats()
+	}
+
+	if diags, st := run(); len(diags) != 2 || st.Misses != 2 || st.Hits != 0 {
+		t.Fatalf("first run: diags=%+v stats=%+v", diags, st)
+	}
+	readCalls(t, dir)
+
+	diags, st := run()
+	if len(diags) != 2 || diags[0].File != "a.txt" || st.Hits != 2 {
+		t.Errorf("cached run: diags=%+v stats=%+v", diags, st)
+	}
+	if calls := readCalls(t, dir); len(calls) != 0 {
+		t.Errorf("cached run invoked the linter on %v", calls)
+	}
+
+	writeSource(t, dir, "b.txt", "changed\n")
+	run()
+	if calls := readCalls(t, dir); len(calls) != 1 || calls[0] != "b.txt" {
+		t.Errorf("after edit: linted %v, want only b.txt", calls)
+	}
+
+	writeSource(t, dir, "count.cfg", "lenient\n")
+	run()
+	if calls := readCalls(t, dir); len(calls) != 2 {
+		t.Errorf("after config change: linted %v, want both files", calls)
+	}
+}
+
+func Test_Cache_whole_directory_linter_sees_other_files(t *testing.T) {
+	if _, err := exec.LookPath("sh"); err != nil {
+		t.Skip("sh not available")
+	}
+	if _, err := exec.LookPath("git"); err != nil {
+		t.Skip("git not available")
+	}
+	dir := t.TempDir()
+	if out, err := exec.Command("git", "-C", dir, "init", "-q").CombinedOutput(); err != nil {
+		t.Fatalf("git init: %v\n%s", err, out)
+	}
+	writeSource(t, dir, "a.txt", "a\n")
+	writeSource(t, dir, "other.txt", "x\n")
+	def := countingLinter(`sh -c 'echo run >> calls.log; echo "a.txt:1: whole"'`)
+
+	run := func() CacheStats {
+		reg := &Registry{Linters: []LinterDef{def}, Cache: NewCache(dir)}
+		if diags := reg.Run([]string{"a.txt"}, dir); len(diags) != 1 {
+			t.Fatalf("diags: %+v", diags)
+		}
+		return reg.Cache.Stats()
+	}
+
+	run()
+	if st := run(); st.Hits != 1 {
+		t.Errorf("unchanged tree should hit: %+v", st)
+	}
+	writeSource(t, dir, "other.txt", "y\n")
+	if st := run(); st.Misses != 1 {
+		t.Errorf("editing a file outside the batch should miss: %+v", st)
+	}
+}
+
+func Test_Cache_stats_and_clear(t *testing.T) {
+	dir := t.TempDir()
+	c := NewCache(dir)
+	c.put(hashKey("k"), []Diagnostic{{File: "a.go", Line: 1}})
+	c.hits.Add(3)
+	c.misses.Add(1)
+	if err := c.SaveStats(); err != nil {
+		t.Fatal(err)
+	}
+
+	u, err := NewCache(dir).Usage()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if u.Entries != 1 || u.Total.Hits != 3 || u.LastRun.Misses != 1 || u.Total.HitRate() != 75 {
+		t.Errorf("usage: %+v", u)
+	}
+	if err := c.Clear(); err != nil {
+		t.Fatal(err)
+	}
+	if u, _ := NewCache(dir).Usage(); u.Entries != 0 || u.Total.Hits != 0 {
+		t.Errorf("after clear: %+v", u)
+	}
+}
+
+func Test_Cache_skips_tool_failures(t *testing.T) {
+	if _, err := exec.LookPath("sh"); err != nil {
+		t.Skip("sh not available")
+	}
+	dir := t.TempDir()
+	writeSource(t, dir, "a.js", "a\n")
+
+	tests := []struct {
+		name    string
+		command string
+		cached  bool
+	}{
+		{"crash with garbage", `sh -c 'echo "Oops! Something went wrong"; exit 2' sh {files}`, false},
+		{"failure exit code", `sh -c 'echo "[]"; exit 2' sh {files}`, false},
+		{"unparseable output", `sh -c 'echo "Cannot find module"' sh {files}`, false},
+		{"findings exit code", `sh -c 'echo "[]"; exit 1' sh {files}`, true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			def := LinterDef{Name: "stub " + tt.name, Files: []string{"*.js"}, Command: tt.command,
+				Format: "eslint", VersionCommand: "sh -c 'echo v1'"}
+			run := func() CacheStats {
+				reg := &Registry{Linters: []LinterDef{def}, Cache: NewCache(dir)}
+				if diags := reg.Run([]string{"a.js"}, dir); len(diags) != 0 {
+					t.Fatalf("diags: %+v", diags)
+				}
+				return reg.Cache.Stats()
+			}
+			run()
+			if st := run(); (st.Hits == 1) != tt.cached {
+				t.Errorf("second run: %+v, want cached %v", st, tt.cached)
+			}
+		})
+	}
+}
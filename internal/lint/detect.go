@@ -4,6 +4,7 @@ import (
 	"os/exec"
 	"path/filepath"
 	"strings"
+	"sync"
 )
 
 // Language represents a detected programming language.
@@ -25,15 +26,13 @@ type LinterInfo struct {
 	DisplayName string
 }
 
-// AllLinters returns the linter info for each supported language.
+// AllLinters returns the linter info for each built-in linter.
 func AllLinters() []LinterInfo {
-	return []LinterInfo{
-		{LangGo, "go", "go vet"},
-		{LangPython, "ruff", "ruff"},
-		{LangTypeScript, "tsc", "tsc (TypeScript)"},
-		{LangJavaScript, "eslint", "eslint"},
-		{LangRust, "cargo", "cargo clippy"},
+	var infos []LinterInfo
+	for _, d := range builtinRegistry().Only(KindLint).Enabled() {
+		infos = append(infos, LinterInfo{d.Language, d.Binary(), d.Name})
 	}
+	return infos
 }
 
 // LinterForLanguage returns the linter info for a given language.
@@ -46,23 +45,13 @@ func LinterForLanguage(lang Language) (LinterInfo, bool) {
 	return LinterInfo{}, false
 }
 
-// DetectLanguage maps a file extension to a language.
+// builtinRegistry is DefaultRegistry built once, for lookups that only
+// read it.
+var builtinRegistry = sync.OnceValue(DefaultRegistry)
+
+// DetectLanguage maps a file to a language using the built-in registry globs.
 func DetectLanguage(filePath string) Language {
-	ext := strings.ToLower(filepath.Ext(filePath))
-	switch ext {
-	case ".go":
-		return LangGo
-	case ".py", ".pyi":
-		return LangPython
-	case ".ts", ".tsx", ".mts", ".cts":
-		return LangTypeScript
-	case ".js", ".jsx", ".mjs", ".cjs":
-		return LangJavaScript
-	case ".rs":
-		return LangRust
-	default:
-		return LangUnknown
-	}
+	return builtinRegistry().LanguageOf(filePath)
 }
 
 // IsCodeFile returns true if the file has a recognized code extension.
@@ -80,9 +69,21 @@ func CheckLinterInstalled(lang Language) bool {
 	return err == nil
 }
 
-// GetChangedFiles returns files changed in git (staged + unstaged).
-// If all is true, returns all tracked files instead.
+// GetChangedFiles returns code files changed in git (staged + unstaged).
+// If all is true, returns all tracked code files instead.
 func GetChangedFiles(projectDir string, all bool) []string {
+	var files []string
+	for _, f := range ListGitFiles(projectDir, all) {
+		if IsCodeFile(f) {
+			files = append(files, f)
+		}
+	}
+	return files
+}
+
+// ListGitFiles returns files changed in git (staged + unstaged), or all
+// tracked files if all is true, without filtering by language.
+func ListGitFiles(projectDir string, all bool) []string {
 	var cmd *exec.Cmd
 	if all {
 		cmd = exec.Command("git", "ls-files")
@@ -107,7 +108,7 @@ func GetChangedFiles(projectDir string, all bool) []string {
 	var files []string
 	for _, line := range strings.Split(strings.TrimSpace(string(out)), "\n") {
 		line = strings.TrimSpace(line)
-		if line != "" && IsCodeFile(line) {
+		if line != "" {
 			files = append(files, line)
 		}
 	}
@@ -127,20 +128,37 @@ func GroupFilesByLanguage(files []string) map[Language][]string {
 }
 
 // RunForHook runs lint on a specific file and returns diagnostics as a string.
-// Returns empty string if no issues found or linter not installed.
-func RunForHook(filePath string, projectDir string) string {
-	lang := DetectLanguage(filePath)
-	if lang == LangUnknown {
-		return ""
+// Linters come from the registry (.do/lint.yaml merged with the built-ins).
+// With hook.changed_lines set, only diagnostics on lines changed since HEAD
+// are reported. With a Cache set, unchanged files are not linted again.
+// Returns empty string if no issues found or no linter applies.
+func (r *Registry) RunForHook(filePath string, projectDir string) string {
+	if filepath.IsAbs(filePath) {
+		if rel, err := filepath.Rel(projectDir, filePath); err == nil && !strings.HasPrefix(rel, "..") {
+			filePath = rel
+		}
 	}
 
-	if !CheckLinterInstalled(lang) {
-		info, _ := LinterForLanguage(lang)
-		return "Lint skipped: " + info.DisplayName + " not installed."
+	var diags []Diagnostic
+	var skipped []string
+	for _, d := range r.Only(KindLint).ForFile(filePath) {
+		if !d.Installed(projectDir) {
+			skipped = append(skipped, d.Name)
+			continue
+		}
+		for _, b := range d.batches([]string{filePath}, projectDir) {
+			diags = append(diags, r.runBatch(d, b, projectDir)...)
+		}
+	}
+	if r.Hook.ChangedLines && len(diags) > 0 {
+		if changed, err := GitChangedLines(projectDir, ""); err == nil {
+			diags = FilterChanged(diags, changed)
+		}
 	}
-
-	diags := RunLinter(lang, []string{filePath}, projectDir)
 	if len(diags) == 0 {
+		if len(skipped) > 0 {
+			return "Lint skipped: " + strings.Join(skipped, ", ") + " not installed."
+		}
 		return ""
 	}
 
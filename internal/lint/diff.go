@@ -0,0 +1,174 @@
+package lint
+
+import (
+	"bufio"
+	"fmt"
+	"math"
+	"os/exec"
+	"path/filepath"
+	"regexp"
+	"sort"
+	"strconv"
+	"strings"
+)
+
+// LineRange is an inclusive range of 1-based line numbers.
+type LineRange struct {
+	Start int
+	End   int
+}
+
+// ChangedLines maps slash-separated file paths to their added or modified lines.
+type ChangedLines map[string][]LineRange
+
+// hunkHeader matches: @@ -12,3 +14,5 @@ optional section
+var hunkHeader = regexp.MustCompile(`^@@ -\d+(?:,\d+)? \+(\d+)(?:,(\d+))? @@`)
+
+// ParseUnifiedDiff collects the added lines of each file in a unified diff.
+// Deleted files and pure deletions contribute nothing.
+func ParseUnifiedDiff(diff string) ChangedLines {
+	changed := make(ChangedLines)
+	var file string
+	newLine := 0
+	inHunk := false
+
+	sc := bufio.NewScanner(strings.NewReader(diff))
+	sc.Buffer(make([]byte, 0, 64*1024), 10*1024*1024)
+	for sc.Scan() {
+		line := sc.Text()
+		switch {
+		case strings.HasPrefix(line, "diff --git "):
+			file, inHunk = "", false
+		case strings.HasPrefix(line, "+++ ") && !inHunk:
+			file = diffPath(strings.TrimPrefix(line, "+++ "))
+		case strings.HasPrefix(line, "@@"):
+			m := hunkHeader.FindStringSubmatch(line)
+			if m == nil {
+				inHunk = false
+				continue
+			}
+			newLine, _ = strconv.Atoi(m[1])
+			inHunk = true
+		case !inHunk || file == "":
+		case strings.HasPrefix(line, "+"):
+			changed.add(file, newLine, newLine)
+			newLine++
+		case strings.HasPrefix(line, "-"), strings.HasPrefix(line, `\`):
+			// Removed line or "\ No newline at end of file": no new-side line.
+		default:
+			newLine++
+		}
+	}
+	return changed
+}
+
+// diffPath strips the b/ prefix and any tab-separated timestamp from a
+// +++ header. /dev/null (deleted file) yields "".
+func diffPath(p string) string {
+	if i := strings.IndexByte(p, '\t'); i >= 0 {
+		p = p[:i]
+	}
+	p = strings.Trim(p, `"`)
+	if p == "/dev/null" {
+		return ""
+	}
+	return strings.TrimPrefix(p, "b/")
+}
+
+// add records a range, merging it with an adjacent previous range.
+func (c ChangedLines) add(file string, start, end int) {
+	ranges := c[file]
+	if n := len(ranges); n > 0 && ranges[n-1].End+1 >= start {
+		if end > ranges[n-1].End {
+			ranges[n-1].End = end
+		}
+		return
+	}
+	c[file] = append(ranges, LineRange{start, end})
+}
+
+// Files returns the changed file paths, sorted.
+func (c ChangedLines) Files() []string {
+	files := make([]string, 0, len(c))
+	for f := range c {
+		files = append(files, f)
+	}
+	sort.Strings(files)
+	return files
+}
+
+// Overlaps reports whether any line in [start, end] of file was changed.
+func (c ChangedLines) Overlaps(file string, start, end int) bool {
+	if end < start {
+		end = start
+	}
+	for _, r := range c[filepath.ToSlash(file)] {
+		if start <= r.End && end >= r.Start {
+			return true
+		}
+	}
+	return false
+}
+
+// FilterChanged keeps diagnostics whose span touches a changed line.
+// Diagnostics without a line number are kept if their file changed.
+func FilterChanged(diags []Diagnostic, changed ChangedLines) []Diagnostic {
+	var kept []Diagnostic
+	for _, d := range diags {
+		if d.Line <= 0 {
+			if _, ok := changed[filepath.ToSlash(d.File)]; ok {
+				kept = append(kept, d)
+			}
+			continue
+		}
+		if changed.Overlaps(d.File, d.Line, d.EndLine) {
+			kept = append(kept, d)
+		}
+	}
+	return kept
+}
+
+// GitChangedLines returns lines added or modified relative to base, with
+// paths relative to projectDir. Without a base the working tree is compared
+// to HEAD; with one it is compared to the merge base of base and HEAD, which
+// is what a pull request adds. Untracked files count as entirely changed.
+func GitChangedLines(projectDir, base string) (ChangedLines, error) {
+	ref := "HEAD"
+	if base != "" {
+		out, err := gitOutput(projectDir, "merge-base", base, "HEAD")
+		if err != nil {
+			return nil, fmt.Errorf("find merge base with %s: %w", base, err)
+		}
+		ref = strings.TrimSpace(out)
+	}
+
+	diff, err := gitOutput(projectDir, "diff", "--relative", "--no-color", "--no-ext-diff", "-U0", ref)
+	if err != nil {
+		return nil, fmt.Errorf("git diff %s: %w", ref, err)
+	}
+	changed := ParseUnifiedDiff(diff)
+
+	untracked, err := gitOutput(projectDir, "ls-files", "--others", "--exclude-standard")
+	if err != nil {
+		return nil, fmt.Errorf("list untracked files: %w", err)
+	}
+	for _, f := range strings.Split(strings.TrimSpace(untracked), "\n") {
+		if f = strings.TrimSpace(f); f != "" {
+			changed[f] = []LineRange{{1, math.MaxInt}}
+		}
+	}
+	return changed, nil
+}
+
+func gitOutput(dir string, args ...string) (string, error) {
+	cmd := exec.Command("git", args...)
+	cmd.Dir = dir
+	out, err := cmd.Output()
+	if err != nil {
+		if ee, ok := err.(*exec.ExitError); ok && len(ee.Stderr) > 0 {
+			return "", fmt.Errorf("%s", strings.TrimSpace(string(ee.Stderr)))
+		}
+		return "", err
+	}
+	return string(out), nil
+}
@@ -0,0 +1,116 @@
+package lint
+
+import (
+	"os"
+	"os/exec"
+	"path/filepath"
+	"testing"
+)
+
+const sampleDiff = `diff --git a/a.go b/a.go
+index 111..222 100644
+--- a/a.go
++++ b/a.go
+@@ -3,0 +4,2 @@ func main() {
++	x := 1
++	_ = x
+@@ -10 +12 @@ func other() {
+-	old()
++	new()
+diff --git a/gone.go b/gone.go
+deleted file mode 100644
+--- a/gone.go
++++ /dev/null
+@@ -1,2 +0,0 @@
+-package gone
+-
+diff --git a/b.py b/b.py
+--- a/b.py
++++ b/b.py
+@@ -1,3 +1,3 @@
+ import os
+-import sys
++import re
+ print(os)
+`
+
+func Test_ParseUnifiedDiff(t *testing.T) {
+	changed := ParseUnifiedDiff(sampleDiff)
+
+	if got := changed["a.go"]; len(got) != 2 || got[0] != (LineRange{4, 5}) || got[1] != (LineRange{12, 12}) {
+		t.Errorf("a.go: got %+v", got)
+	}
+	if got := changed["b.py"]; len(got) != 1 || got[0] != (LineRange{2, 2}) {
+		t.Errorf("b.py (context diff): got %+v", got)
+	}
+	if _, ok := changed["gone.go"]; ok {
+		t.Error("deleted file should not be recorded")
+	}
+	if files := changed.Files(); len(files) != 2 || files[0] != "a.go" {
+		t.Errorf("Files: got %v", files)
+	}
+}
+
+func Test_FilterChanged(t *testing.T) {
+	changed := ParseUnifiedDiff(sampleDiff)
+	diags := []Diagnostic{
+		{File: "a.go", Line: 4},
+		{File: "a.go", Line: 7},
+		{File: "a.go", Line: 10, EndLine: 12}, // span reaches a changed line
+		{File: "b.py", Line: 0},               // file-level
+		{File: "c.rs", Line: 1},
+	}
+	kept := FilterChanged(diags, changed)
+	if len(kept) != 3 {
+		t.Fatalf("expected 3 diagnostics, got %+v", kept)
+	}
+	if kept[1].Line != 10 || kept[2].File != "b.py" {
+		t.Errorf("got %+v", kept)
+	}
+}
+
+func Test_GitChangedLines_worktree_and_untracked(t *testing.T) {
+	if _, err := exec.LookPath("git"); err != nil {
+		t.Skip("git not available")
+	}
+	dir := t.TempDir()
+	git := func(args ...string) {
+		t.Helper()
+		cmd := exec.Command("git", append([]string{"-c", "user.email=t@t", "-c", "user.name=t"}, args...)...)
+		cmd.Dir = dir
+		if out, err := cmd.CombinedOutput(); err != nil {
+			t.Fatalf("git %v: %v\n%s", args, err, out)
+		}
+	}
+	git("init", "-q")
+	writeSource(t, dir, "a.txt", "one\ntwo\nthree\n")
+	git("add", "a.txt")
+	git("commit", "-qm", "init")
+
+	writeSource(t, dir, "a.txt", "one\nTWO\nthree\nfour\n")
+	writeSource(t, dir, "new.txt", "fresh\n")
+	if err := os.MkdirAll(filepath.Join(dir, "sub"), 0755); err != nil {
+		t.Fatal(err)
+	}
+
+	changed, err := GitChangedLines(dir, "")
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if got := changed["a.txt"]; len(got) != 2 || got[0] != (LineRange{2, 2}) || got[1] != (LineRange{4, 4}) {
+		t.Errorf("a.txt: got %+v", got)
+	}
+	if !changed.Overlaps("new.txt", 500, 500) {
+		t.Error("untracked file should be entirely changed")
+	}
+
+	git("add", "-A")
+	git("commit", "-qm", "second")
+	changed, err = GitChangedLines(dir, "HEAD~1")
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if !changed.Overlaps("a.txt", 2, 2) || changed.Overlaps("a.txt", 1, 1) {
+		t.Errorf("against base: got %+v", changed)
+	}
+}
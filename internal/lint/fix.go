@@ -0,0 +1,387 @@
+package lint
+
+import (
+	"errors"
+	"fmt"
+	"io/fs"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+
+	"gopkg.in/yaml.v3"
+)
+
+// CommandList is a list of alternative command templates. In YAML it may
+// be a single string or a sequence.
+type CommandList []string
+
+// UnmarshalYAML accepts a scalar or a sequence of strings.
+func (c *CommandList) UnmarshalYAML(node *yaml.Node) error {
+	if node.Kind == yaml.ScalarNode {
+		*c = CommandList{node.Value}
+		return nil
+	}
+	var list []string
+	if err := node.Decode(&list); err != nil {
+		return err
+	}
+	*c = list
+	return nil
+}
+
+// FixCommand returns the first fix alternative whose executable is
+// installed in the project's .do/tools/bin or on PATH.
+func (d LinterDef) FixCommand(projectDir string) (string, bool) {
+	for _, cmd := range d.Fix {
+		args := splitCommand(cmd)
+		if len(args) == 0 {
+			continue
+		}
+		if lookTool(projectDir, args[0]) {
+			return cmd, true
+		}
+	}
+	return "", false
+}
+
+// FileChange is the content of a file before and after fixing.
+type FileChange struct {
+	File   string
+	Before []byte
+	After  []byte
+}
+
+// Diff returns the change as a unified diff.
+func (c FileChange) Diff() string {
+	return UnifiedDiff(c.File, c.Before, c.After)
+}
+
+// FixError reports a fix command that failed to run or exited with a code
+// outside the linter's ExitCodes.
+type FixError struct {
+	Linter string
+	Err    error
+	Output string
+}
+
+func (e *FixError) Error() string {
+	msg := e.Linter + ": " + e.Err.Error()
+	if out := strings.TrimSpace(e.Output); out != "" {
+		msg += "\n" + out
+	}
+	return msg
+}
+
+func (e *FixError) Unwrap() error { return e.Err }
+
+// Fix runs the fix command of every matching linter over files and returns
+// the files whose content changed, sorted by path. Linters run one after
+// another so two fixers never rewrite the same file at once. A fix command
+// without {files} or {file} rewrites its whole working directory, so every
+// file there that the linter handles is compared too, not only files.
+// A failing fixer stops only its own linter: the changes made so far are
+// returned together with a *FixError per failed linter.
+func (r *Registry) Fix(files []string, projectDir string) ([]FileChange, error) {
+	before := make(map[string][]byte, len(files))
+	var order []string
+	seen := make(map[string]bool, len(files))
+	snapshot := func(f string) error {
+		abs := absPath(f, projectDir)
+		if seen[abs] {
+			return nil
+		}
+		data, err := os.ReadFile(abs)
+		if err != nil {
+			return fmt.Errorf("read %s: %w", f, err)
+		}
+		seen[abs] = true
+		before[f] = data
+		order = append(order, f)
+		return nil
+	}
+	for _, f := range files {
+		if err := snapshot(f); err != nil {
+			return nil, err
+		}
+	}
+
+	var failures []error
+	groups := r.Group(files)
+	for _, d := range r.Enabled() {
+		matched := groups[d.Name]
+		if len(matched) == 0 {
+			continue
+		}
+		cmd, ok := d.FixCommand(projectDir)
+		if !ok {
+			continue
+		}
+		wholeDir := !strings.Contains(cmd, "{files}") && !strings.Contains(cmd, "{file}")
+		for _, b := range d.batches(matched, projectDir) {
+			if wholeDir {
+				for _, f := range d.filesUnder(b.dir, projectDir) {
+					if err := snapshot(f); err != nil {
+						return nil, err
+					}
+				}
+			}
+			if out, err := execTemplate(cmd, b.dir, b.files, projectDir); !d.ran(err) {
+				failures = append(failures, &FixError{Linter: d.Name, Err: err, Output: string(out)})
+				break
+			}
+		}
+	}
+
+	var changes []FileChange
+	for _, f := range order {
+		after, err := os.ReadFile(absPath(f, projectDir))
+		if err != nil {
+			return nil, fmt.Errorf("read %s: %w", f, err)
+		}
+		if string(after) != string(before[f]) {
+			changes = append(changes, FileChange{File: f, Before: before[f], After: after})
+		}
+	}
+	sort.Slice(changes, func(i, j int) bool { return changes[i].File < changes[j].File })
+	return changes, errors.Join(failures...)
+}
+
+// filesUnder returns the files below dir that the linter handles, relative
+// to projectDir. Hidden directories and dependency or build output
+// directories are skipped.
+func (d LinterDef) filesUnder(dir, projectDir string) []string {
+	var files []string
+	_ = filepath.WalkDir(dir, func(p string, e fs.DirEntry, err error) error {
+		if err != nil {
+			return nil
+		}
+		if e.IsDir() {
+			if p != dir && skipDir(e.Name()) {
+				return filepath.SkipDir
+			}
+			return nil
+		}
+		rel, err := filepath.Rel(projectDir, p)
+		if err != nil || !e.Type().IsRegular() || !d.Matches(rel) {
+			return nil
+		}
+		files = append(files, filepath.ToSlash(rel))
+		return nil
+	})
+	return files
+}
+
+// skipDir reports whether a directory walk skips a directory: hidden
+// directories and those holding dependencies, caches or build output.
+func skipDir(name string) bool {
+	return strings.HasPrefix(name, ".") || name == "node_modules" || name == "vendor" || name == "__pycache__" || name == "target"
+}
+
+func absPath(f, projectDir string) string {
+	if filepath.IsAbs(f) {
+		return f
+	}
+	return filepath.Join(projectDir, f)
+}
+
+// DirtyFiles returns the files that have uncommitted changes or are not
+// tracked by git. Outside a git repository every file counts as dirty.
+func DirtyFiles(projectDir string, files []string) []string {
+	if len(files) == 0 {
+		return nil
+	}
+	args := append([]string{"status", "--porcelain", "--untracked-files=all", "--"}, files...)
+	out, err := gitOutput(projectDir, args...)
+	if err != nil {
+		return append([]string(nil), files...)
+	}
+
+	dirty := make(map[string]bool)
+	for _, line := range strings.Split(out, "\n") {
+		if len(line) < 4 {
+			continue
+		}
+		p := line[3:]
+		if i := strings.Index(p, " -> "); i >= 0 {
+			p = p[i+4:]
+		}
+		dirty[strings.Trim(p, `"`)] = true
+	}
+
+	root, err := gitOutput(projectDir, "rev-parse", "--show-prefix")
+	prefix := ""
+	if err == nil {
+		prefix = strings.TrimSpace(root)
+	}
+	var result []string
+	for _, f := range files {
+		rel := filepath.ToSlash(f)
+		if filepath.IsAbs(f) {
+			if r, err := filepath.Rel(projectDir, f); err == nil {
+				rel = filepath.ToSlash(r)
+			}
+		}
+		if dirty[prefix+rel] {
+			result = append(result, f)
+		}
+	}
+	return result
+}
+
+// UnifiedDiff renders a line-based unified diff with three lines of context.
+func UnifiedDiff(name string, before, after []byte) string {
+	a := splitLines(string(before))
+	b := splitLines(string(after))
+	ops := diffLines(a, b)
+
+	var sb strings.Builder
+	fmt.Fprintf(&sb, "--- a/%s\n+++ b/%s\n", name, name)
+
+	const context = 3
+	for i := 0; i < len(ops); {
+		if ops[i].kind == ' ' {
+			i++
+			continue
+		}
+		// Hunk spans from context before the change to context after the
+		// last change that is within 2*context lines of the previous one.
+		start := i - context
+		if start < 0 {
+			start = 0
+		}
+		end := i
+		for j := i; j < len(ops); j++ {
+			if ops[j].kind != ' ' {
+				end = j
+			} else if j-end > 2*context {
+				break
+			}
+		}
+		stop := end + context + 1
+		if stop > len(ops) {
+			stop = len(ops)
+		}
+
+		aStart, bStart := ops[start].aLine, ops[start].bLine
+		aCount, bCount := 0, 0
+		for _, op := range ops[start:stop] {
+			if op.kind != '+' {
+				aCount++
+			}
+			if op.kind != '-' {
+				bCount++
+			}
+		}
+		fmt.Fprintf(&sb, "@@ -%s +%s @@\n", hunkRange(aStart, aCount), hunkRange(bStart, bCount))
+		for _, op := range ops[start:stop] {
+			sb.WriteByte(op.kind)
+			sb.WriteString(op.text)
+			sb.WriteByte('\n')
+		}
+		i = stop
+	}
+	return sb.String()
+}
+
+func hunkRange(start, count int) string {
+	if count == 0 {
+		return fmt.Sprintf("%d,0", start-1)
+	}
+	if count == 1 {
+		return fmt.Sprintf("%d", start)
+	}
+	return fmt.Sprintf("%d,%d", start, count)
+}
+
+func splitLines(s string) []string {
+	if s == "" {
+		return nil
+	}
+	return strings.Split(strings.TrimSuffix(s, "\n"), "\n")
+}
+
+type diffOp struct {
+	kind  byte // ' ', '-', '+'
+	text  string
+	aLine int // 1-based line in a where this op sits
+	bLine int // 1-based line in b where this op sits
+}
+
+// maxDiffCells bounds the LCS table; larger changes are shown as a block
+// replacement of the differing middle.
+const maxDiffCells = 4_000_000
+
+// diffLines computes an edit script via longest common subsequence after
+// trimming the common prefix and suffix.
+func diffLines(a, b []string) []diffOp {
+	pre := 0
+	for pre < len(a) && pre < len(b) && a[pre] == b[pre] {
+		pre++
+	}
+	suf := 0
+	for suf < len(a)-pre && suf < len(b)-pre && a[len(a)-1-suf] == b[len(b)-1-suf] {
+		suf++
+	}
+	am, bm := a[pre:len(a)-suf], b[pre:len(b)-suf]
+
+	var ops []diffOp
+	ai, bi := 1, 1
+	emit := func(kind byte, text string) {
+		ops = append(ops, diffOp{kind, text, ai, bi})
+		if kind != '+' {
+			ai++
+		}
+		if kind != '-' {
+			bi++
+		}
+	}
+
+	for _, l := range a[:pre] {
+		emit(' ', l)
+	}
+	if len(am)*len(bm) > maxDiffCells {
+		for _, l := range am {
+			emit('-', l)
+		}
+		for _, l := range bm {
+			emit('+', l)
+		}
+	} else {
+		n, m := len(am), len(bm)
+		lcs := make([][]int, n+1)
+		for i := range lcs {
+			lcs[i] = make([]int, m+1)
+		}
+		for i := n - 1; i >= 0; i-- {
+			for j := m - 1; j >= 0; j-- {
+				if am[i] == bm[j] {
+					lcs[i][j] = lcs[i+1][j+1] + 1
+				} else if lcs[i+1][j] >= lcs[i][j+1] {
+					lcs[i][j] = lcs[i+1][j]
+				} else {
+					lcs[i][j] = lcs[i][j+1]
+				}
+			}
+		}
+		i, j := 0, 0
+		for i < n || j < m {
+			switch {
+			case i < n && j < m && am[i] == bm[j]:
+				emit(' ', am[i])
+				i++
+				j++
+			case i < n && (j == m || lcs[i+1][j] >= lcs[i][j+1]):
+				emit('-', am[i])
+				i++
+			default:
+				emit('+', bm[j])
+				j++
+			}
+		}
+	}
+	for _, l := range a[len(a)-suf:] {
+		emit(' ', l)
+	}
+	return ops
+}
@@ -0,0 +1,190 @@
+package lint
+
+import (
+	"errors"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"gopkg.in/yaml.v3"
+)
+
+func Test_UnifiedDiff(t *testing.T) {
+	before := "a\nb\nc\nd\ne\nf\ng\nh\ni\nj\nk\n"
+	after := "a\nB\nc\nd\ne\nf\ng\nh\ni\nj\nk\nl\n"
+	want := `--- a/x.txt
++++ b/x.txt
+@@ -1,5 +1,5 @@
+ a
+-b
++B
+ c
+ d
+ e
+@@ -9,3 +9,4 @@
+ i
+ j
+ k
++l
+`
+	if got := UnifiedDiff("x.txt", []byte(before), []byte(after)); got != want {
+		t.Errorf("got:\n%s\nwant:\n%s", got, want)
+	}
+}
+
+func Test_UnifiedDiff_new_file(t *testing.T) {
+	want := "--- a/n.txt\n+++ b/n.txt\n@@ -0,0 +1,2 @@\n+x\n+y\n"
+	if got := UnifiedDiff("n.txt", nil, []byte("x\ny\n")); got != want {
+		t.Errorf("got %q", got)
+	}
+}
+
+func Test_CommandList_yaml_scalar_or_sequence(t *testing.T) {
+	var def LinterDef
+	if err := yaml.Unmarshal([]byte("fix: ruff check --fix {files}\n"), &def); err != nil {
+		t.Fatal(err)
+	}
+	if len(def.Fix) != 1 || def.Fix[0] != "ruff check --fix {files}" {
+		t.Errorf("scalar: got %v", def.Fix)
+	}
+	if err := yaml.Unmarshal([]byte("fix:\n  - goimports -w {files}\n  - gofmt -w {files}\n"), &def); err != nil {
+		t.Fatal(err)
+	}
+	if len(def.Fix) != 2 {
+		t.Errorf("sequence: got %v", def.Fix)
+	}
+}
+
+func Test_Registry_Fix_reports_changed_files(t *testing.T) {
+	if _, err := exec.LookPath("sh"); err != nil {
+		t.Skip("sh not available")
+	}
+	dir := t.TempDir()
+	writeSource(t, dir, "bad.txt", "bad\n")
+	writeSource(t, dir, "good.txt", "good\n")
+
+	reg := &Registry{Linters: []LinterDef{{
+		Name:    "upper",
+		Files:   []string{"*.txt"},
+		Command: "true",
+		Format:  FormatRegex,
+		Pattern: ".",
+		Fix:     CommandList{"no-such-fixer {files}", `sh -c 'for f in "$@"; do grep -q bad "$f" && echo fixed > "$f"; done; true' sh {files}`},
+	}}}
+
+	changes, err := reg.Fix([]string{"bad.txt", "good.txt"}, dir)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if len(changes) != 1 || changes[0].File != "bad.txt" || string(changes[0].After) != "fixed\n" {
+		t.Fatalf("got %+v", changes)
+	}
+	data, _ := os.ReadFile(filepath.Join(dir, "bad.txt"))
+	if string(data) != "fixed\n" {
+		t.Errorf("file not rewritten: %q", data)
+	}
+}
+
+func Test_Registry_Fix_whole_directory_fixer_reports_every_change(t *testing.T) {
+	if _, err := exec.LookPath("sh"); err != nil {
+		t.Skip("sh not available")
+	}
+	dir := t.TempDir()
+	os.MkdirAll(filepath.Join(dir, "sub"), 0755)
+	os.MkdirAll(filepath.Join(dir, "node_modules"), 0755)
+	writeSource(t, dir, "a.txt", "bad\n")
+	writeSource(t, dir, "sub/other.txt", "bad\n")
+	writeSource(t, dir, "node_modules/dep.txt", "bad\n")
+
+	reg := &Registry{Linters: []LinterDef{{
+		Name:    "module",
+		Files:   []string{"*.txt"},
+		Command: "true",
+		Format:  FormatRegex,
+		Pattern: ".",
+		Fix:     CommandList{`sh -c 'for f in a.txt sub/other.txt; do echo fixed > "$f"; done'`},
+	}}}
+
+	changes, err := reg.Fix([]string{"a.txt"}, dir)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(changes) != 2 || changes[0].File != "a.txt" || changes[1].File != "sub/other.txt" {
+		t.Errorf("got %+v", changes)
+	}
+}
+
+func Test_Registry_Fix_reports_failing_fixer(t *testing.T) {
+	if _, err := exec.LookPath("sh"); err != nil {
+		t.Skip("sh not available")
+	}
+	dir := t.TempDir()
+	writeSource(t, dir, "a.txt", "bad\n")
+
+	reg := &Registry{Linters: []LinterDef{
+		{
+			Name:    "broken",
+			Files:   []string{"*.txt"},
+			Command: "true",
+			Format:  FormatRegex,
+			Pattern: ".",
+			Fix:     CommandList{`sh -c 'echo config error; exit 2'`},
+		},
+		{
+			Name:      "findings",
+			Files:     []string{"*.txt"},
+			Command:   "true",
+			Format:    FormatRegex,
+			Pattern:   ".",
+			Fix:       CommandList{`sh -c 'echo fixed > a.txt; exit 3'`},
+			ExitCodes: []int{0, 3},
+		},
+	}}
+
+	changes, err := reg.Fix([]string{"a.txt"}, dir)
+	var fixErr *FixError
+	if !errors.As(err, &fixErr) || fixErr.Linter != "broken" || !strings.Contains(err.Error(), "config error") {
+		t.Fatalf("expected FixError for broken, got %v", err)
+	}
+	if len(changes) != 1 || string(changes[0].After) != "fixed\n" {
+		t.Errorf("changes of the other fixer not returned: %+v", changes)
+	}
+}
+
+func Test_DirtyFiles(t *testing.T) {
+	if _, err := exec.LookPath("git"); err != nil {
+		t.Skip("git not available")
+	}
+	dir := t.TempDir()
+	run := func(args ...string) {
+		t.Helper()
+		cmd := exec.Command("git", append([]string{"-c", "user.email=t@t", "-c", "user.name=t"}, args...)...)
+		cmd.Dir = dir
+		if out, err := cmd.CombinedOutput(); err != nil {
+			t.Fatalf("git %v: %v\n%s", args, err, out)
+		}
+	}
+	run("init", "-q")
+	if err := os.MkdirAll(filepath.Join(dir, "sub"), 0755); err != nil {
+		t.Fatal(err)
+	}
+	writeSource(t, dir, "clean.txt", "x\n")
+	writeSource(t, dir, "sub/edited.txt", "x\n")
+	run("add", "-A")
+	run("commit", "-qm", "init")
+	writeSource(t, dir, "sub/edited.txt", "y\n")
+	writeSource(t, dir, "new.txt", "z\n")
+
+	dirty := DirtyFiles(dir, []string{"clean.txt", "sub/edited.txt", "new.txt"})
+	if len(dirty) != 2 || dirty[0] != "sub/edited.txt" || dirty[1] != "new.txt" {
+		t.Errorf("got %v", dirty)
+	}
+
+	// Paths are resolved relative to a project dir below the repo root.
+	dirty = DirtyFiles(filepath.Join(dir, "sub"), []string{"edited.txt"})
+	if len(dirty) != 1 {
+		t.Errorf("from subdir: got %v", dirty)
+	}
+}
@@ -0,0 +1,87 @@
+package lint
+
+import (
+	"testing"
+)
+
+func Test_ParseFormatDiff_one_diagnostic_per_hunk(t *testing.T) {
+	data := []byte(`diff a.go.orig a.go
+--- a.go.orig
++++ a.go
+@@ -1,4 +1,4 @@
+ package a
+ 
+-func  A() {}
++func A() {}
+ 
+@@ -20,3 +20,4 @@ func B() {
+ 	x := 1
++
+ 	_ = x
+--- b.py
++++ b.py
+@@ -7,2 +7,2 @@
+-x=1
++x = 1
+ y = 2
+`)
+	diags := ParseFormatDiff(data)
+	if len(diags) != 3 {
+		t.Fatalf("expected 3 diagnostics, got %+v", diags)
+	}
+	want := []struct {
+		file      string
+		line, end int
+	}{{"a.go", 3, 3}, {"a.go", 21, 21}, {"b.py", 7, 7}}
+	for i, w := range want {
+		if diags[i].File != w.file || diags[i].Line != w.line || diags[i].EndLine != w.end {
+			t.Errorf("diag %d: got %+v, want %s:%d-%d", i, diags[i], w.file, w.line, w.end)
+		}
+	}
+}
+
+func Test_LinterDef_Parse_formatter_sets_rule_and_severity(t *testing.T) {
+	def := LinterDef{Name: "prettier", Kind: KindFormat, Format: "filelist"}
+	diags, err := def.Parse([]byte("src/a.ts\nsrc/b.css\n\n"))
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if len(diags) != 2 {
+		t.Fatalf("expected 2 diagnostics, got %+v", diags)
+	}
+	d := diags[0]
+	if d.File != "src/a.ts" || d.Rule != FormatRule || d.Severity != "error" || d.Source != "prettier" || d.Message == "" {
+		t.Errorf("got %+v", d)
+	}
+}
+
+func Test_Parse_rustfmt_check_output(t *testing.T) {
+	def, ok := DefaultRegistry().Lookup("rustfmt")
+	if !ok {
+		t.Fatal("rustfmt not registered")
+	}
+	out := "Diff in /p/src/main.rs at line 1:\n-fn main(){}\n+fn main() {}\nDiff in /p/src/lib.rs:12:\n"
+	diags, err := def.Parse([]byte(out))
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if len(diags) != 2 || diags[0].Line != 1 || diags[1].File != "/p/src/lib.rs" || diags[1].Line != 12 {
+		t.Errorf("got %+v", diags)
+	}
+}
+
+func Test_Registry_Only_splits_linters_and_formatters(t *testing.T) {
+	reg := DefaultRegistry()
+	for _, d := range reg.Only(KindFormat).Linters {
+		if !d.IsFormatter() {
+			t.Errorf("%s is not a formatter", d.Name)
+		}
+	}
+	lints := reg.Only(KindLint).Linters
+	if len(lints) != len(AllLinters()) {
+		t.Errorf("got %d linters, want %d", len(lints), len(AllLinters()))
+	}
+	if len(reg.Only(KindFormat).ForFile("main.go")) != 1 {
+		t.Error("gofmt should handle main.go")
+	}
+}
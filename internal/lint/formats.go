@@ -0,0 +1,462 @@
+package lint
+
+import (
+	"bytes"
+	"encoding/json"
+	"encoding/xml"
+	"fmt"
+	"regexp"
+	"strconv"
+	"strings"
+)
+
+// builtinParsers are the bespoke parsers usable as a LinterDef format.
+var builtinParsers = map[string]func([]byte) ([]Diagnostic, error){
+	"govet":    func(b []byte) ([]Diagnostic, error) { return ParseGoVetOutput(string(b)) },
+	"ruff":     ParseRuffJSON,
+	"tsc":      func(b []byte) ([]Diagnostic, error) { return ParseTscOutput(string(b)) },
+	"eslint":   ParseESLintJSON,
+	"clippy":   ParseClippyJSON,
+	"diff":     func(b []byte) ([]Diagnostic, error) { return ParseFormatDiff(b), nil },
+	"filelist": func(b []byte) ([]Diagnostic, error) { return ParseFileList(b), nil },
+}
+
+// JSONMapping maps fields of a JSON report to Diagnostic fields.
+//
+// Items is a dotted path to the result objects; a segment ending in "[]"
+// iterates an array ("[]" for a top-level array, "runs[].results[]").
+// Field paths are dotted paths relative to each result, may index arrays
+// numerically ("locations.0.line"), and may start with "../" to read from
+// the enclosing iterated object (e.g. eslint's "../filePath").
+type JSONMapping struct {
+	Items      string            `yaml:"items,omitempty"`
+	Lines      bool              `yaml:"lines,omitempty"` // one JSON document per line
+	File       string            `yaml:"file"`
+	Line       string            `yaml:"line"`
+	Column     string            `yaml:"column,omitempty"`
+	EndLine    string            `yaml:"end_line,omitempty"`
+	EndColumn  string            `yaml:"end_column,omitempty"`
+	Severity   string            `yaml:"severity,omitempty"`
+	Message    string            `yaml:"message"`
+	Rule       string            `yaml:"rule,omitempty"`
+	HelpURL    string            `yaml:"help_url,omitempty"`
+	Severities map[string]string `yaml:"severities,omitempty"` // raw value -> error|warning
+}
+
+// sarifMapping reads SARIF 2.1.0 results.
+var sarifMapping = JSONMapping{
+	Items:     "runs[].results[]",
+	File:      "locations.0.physicalLocation.artifactLocation.uri",
+	Line:      "locations.0.physicalLocation.region.startLine",
+	Column:    "locations.0.physicalLocation.region.startColumn",
+	EndLine:   "locations.0.physicalLocation.region.endLine",
+	EndColumn: "locations.0.physicalLocation.region.endColumn",
+	Severity:  "level",
+	Message:   "message.text",
+	Rule:      "ruleId",
+}
+
+// Parse converts linter output into diagnostics according to the format.
+// Missing sources and severities are filled from the definition.
+func (d LinterDef) Parse(out []byte) ([]Diagnostic, error) {
+	var diags []Diagnostic
+	var err error
+	switch d.Format {
+	case FormatSARIF:
+		diags, err = ParseSARIF(out)
+	case FormatCheckstyle:
+		diags, err = ParseCheckstyleXML(out)
+	case FormatJSON:
+		if d.JSON == nil {
+			return nil, fmt.Errorf("json format requires a json mapping")
+		}
+		diags, err = ParseJSONMapping(out, *d.JSON)
+	case FormatRegex:
+		re, cerr := regexp.Compile(d.Pattern)
+		if cerr != nil {
+			return nil, fmt.Errorf("compile pattern: %w", cerr)
+		}
+		diags = ParseRegexOutput(string(out), re)
+	default:
+		parse, ok := builtinParsers[d.Format]
+		if !ok {
+			return nil, fmt.Errorf("unknown format %q", d.Format)
+		}
+		diags, err = parse(out)
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	for i := range diags {
+		if diags[i].Source == "" {
+			diags[i].Source = d.Name
+		}
+		if d.IsFormatter() {
+			diags[i].Rule = FormatRule
+			if diags[i].Message == "" {
+				diags[i].Message = "not formatted"
+			}
+			if diags[i].Severity == "" && d.Severity == "" {
+				diags[i].Severity = "error"
+			}
+		}
+		if diags[i].Severity == "" {
+			diags[i].Severity = d.Severity
+		}
+		diags[i].Severity = normalizeSeverity(diags[i].Severity)
+	}
+	return diags, nil
+}
+
+// normalizeSeverity folds tool-specific levels into "error" or "warning".
+func normalizeSeverity(s string) string {
+	switch strings.ToLower(strings.TrimSpace(s)) {
+	case "error", "fatal", "failure", "critical", "blocker", "major":
+		return "error"
+	default:
+		return "warning"
+	}
+}
+
+// ParseFormatDiff reads a formatter's unified diff (gofmt -d, ruff format
+// --diff) and reports one diagnostic per hunk, spanning the changed lines
+// of the original file.
+func ParseFormatDiff(data []byte) []Diagnostic {
+	var diags []Diagnostic
+	var file string
+	var cur *Diagnostic
+	oldLine := 0
+	removed := false // previous hunk line was removed
+	flush := func() {
+		if cur != nil && cur.Line > 0 {
+			diags = append(diags, *cur)
+		}
+		cur = nil
+	}
+
+	for _, line := range strings.Split(string(data), "\n") {
+		switch {
+		case strings.HasPrefix(line, "diff "), strings.HasPrefix(line, "--- "):
+			flush()
+		case strings.HasPrefix(line, "+++ "):
+			flush()
+			file = strings.TrimSuffix(diffPath(strings.TrimPrefix(line, "+++ ")), ".orig")
+		case strings.HasPrefix(line, "@@"):
+			flush()
+			m := oldHunkHeader.FindStringSubmatch(line)
+			if m == nil || file == "" {
+				continue
+			}
+			oldLine, _ = strconv.Atoi(m[1])
+			removed = false
+			cur = &Diagnostic{File: file}
+		case cur == nil:
+		case strings.HasPrefix(line, "-"), strings.HasPrefix(line, "+"):
+			// An added line replacing removed ones sits on the last removed
+			// line; a pure insertion sits on the line after it.
+			at := oldLine
+			if line[0] == '+' && removed {
+				at--
+			}
+			at = max(at, 1)
+			if cur.Line == 0 {
+				cur.Line = at
+			}
+			cur.EndLine = max(cur.EndLine, at)
+			removed = line[0] == '-' || removed && line[0] == '+'
+			if line[0] == '-' {
+				oldLine++
+			}
+		case strings.HasPrefix(line, " "):
+			removed = false
+			oldLine++
+		}
+	}
+	flush()
+	return diags
+}
+
+// oldHunkHeader captures the original-file start line of a hunk.
+var oldHunkHeader = regexp.MustCompile(`^@@ -(\d+)(?:,\d+)? \+\d+(?:,\d+)? @@`)
+
+// ParseFileList reads one path per line (prettier --list-different,
+// gofmt -l) and reports each file as not formatted.
+func ParseFileList(data []byte) []Diagnostic {
+	var diags []Diagnostic
+	for _, line := range strings.Split(string(data), "\n") {
+		line = strings.TrimSpace(line)
+		if line == "" || strings.ContainsAny(line, " \t") {
+			continue
+		}
+		diags = append(diags, Diagnostic{File: line})
+	}
+	return diags
+}
+
+// ParseRegexOutput matches each output line against re. Named groups
+// file, line, column, end_line, end_column, severity, message, rule and
+// url fill the diagnostic.
+func ParseRegexOutput(output string, re *regexp.Regexp) []Diagnostic {
+	var diags []Diagnostic
+	names := re.SubexpNames()
+	for _, line := range strings.Split(output, "\n") {
+		m := re.FindStringSubmatch(strings.TrimRight(line, "\r"))
+		if m == nil {
+			continue
+		}
+		var d Diagnostic
+		for i, name := range names {
+			switch name {
+			case "file":
+				d.File = m[i]
+			case "line":
+				d.Line, _ = strconv.Atoi(m[i])
+			case "column":
+				d.Column, _ = strconv.Atoi(m[i])
+			case "end_line":
+				d.EndLine, _ = strconv.Atoi(m[i])
+			case "end_column":
+				d.EndColumn, _ = strconv.Atoi(m[i])
+			case "url":
+				d.HelpURL = m[i]
+			case "severity":
+				d.Severity = m[i]
+			case "message":
+				d.Message = strings.TrimSpace(m[i])
+			case "rule":
+				d.Rule = m[i]
+			}
+		}
+		if d.File == "" && d.Message == "" {
+			continue
+		}
+		diags = append(diags, d)
+	}
+	return diags
+}
+
+// ParseCheckstyleXML parses checkstyle XML reports (shellcheck, ktlint,
+// hadolint and many others can emit them).
+func ParseCheckstyleXML(data []byte) ([]Diagnostic, error) {
+	if len(bytes.TrimSpace(data)) == 0 {
+		return nil, nil
+	}
+
+	var report struct {
+		Files []struct {
+			Name   string `xml:"name,attr"`
+			Errors []struct {
+				Line     int    `xml:"line,attr"`
+				Column   int    `xml:"column,attr"`
+				Severity string `xml:"severity,attr"`
+				Message  string `xml:"message,attr"`
+				Source   string `xml:"source,attr"`
+			} `xml:"error"`
+		} `xml:"file"`
+	}
+	if err := xml.Unmarshal(data, &report); err != nil {
+		return nil, fmt.Errorf("parse checkstyle: %w", err)
+	}
+
+	var diags []Diagnostic
+	for _, f := range report.Files {
+		for _, e := range f.Errors {
+			diags = append(diags, Diagnostic{
+				File:     f.Name,
+				Line:     e.Line,
+				Column:   e.Column,
+				Severity: e.Severity,
+				Message:  e.Message,
+				Rule:     e.Source,
+			})
+		}
+	}
+	return diags, nil
+}
+
+// ParseSARIF parses SARIF 2.1.0 results; help URLs come from the rule
+// metadata of each run's driver.
+func ParseSARIF(data []byte) ([]Diagnostic, error) {
+	diags, err := ParseJSONMapping(data, sarifMapping)
+	if err != nil || len(diags) == 0 {
+		return diags, err
+	}
+
+	var log struct {
+		Runs []struct {
+			Tool struct {
+				Driver struct {
+					Rules []struct {
+						ID      string `json:"id"`
+						HelpURI string `json:"helpUri"`
+					} `json:"rules"`
+				} `json:"driver"`
+			} `json:"tool"`
+		} `json:"runs"`
+	}
+	if json.Unmarshal(data, &log) != nil {
+		return diags, nil
+	}
+	help := make(map[string]string)
+	for _, run := range log.Runs {
+		for _, r := range run.Tool.Driver.Rules {
+			if r.HelpURI != "" {
+				help[r.ID] = r.HelpURI
+			}
+		}
+	}
+	for i := range diags {
+		if diags[i].HelpURL == "" {
+			diags[i].HelpURL = help[diags[i].Rule]
+		}
+	}
+	return diags, nil
+}
+
+// ParseJSONMapping extracts diagnostics from a JSON report using m.
+func ParseJSONMapping(data []byte, m JSONMapping) ([]Diagnostic, error) {
+	var docs [][]byte
+	if m.Lines {
+		for _, line := range bytes.Split(data, []byte("\n")) {
+			if len(bytes.TrimSpace(line)) > 0 {
+				docs = append(docs, line)
+			}
+		}
+	} else if len(bytes.TrimSpace(data)) > 0 {
+		docs = append(docs, data)
+	}
+
+	var diags []Diagnostic
+	for _, doc := range docs {
+		var root any
+		if err := json.Unmarshal(doc, &root); err != nil {
+			if m.Lines {
+				continue
+			}
+			return nil, fmt.Errorf("parse json: %w", err)
+		}
+		var items [][]any
+		collectJSONItems(root, splitJSONPath(m.Items), nil, &items)
+		for _, stack := range items {
+			d := Diagnostic{
+				File:      jsonString(stack, m.File),
+				Line:      jsonInt(stack, m.Line),
+				Column:    jsonInt(stack, m.Column),
+				EndLine:   jsonInt(stack, m.EndLine),
+				EndColumn: jsonInt(stack, m.EndColumn),
+				Severity:  jsonString(stack, m.Severity),
+				Message:   jsonString(stack, m.Message),
+				Rule:      jsonString(stack, m.Rule),
+				HelpURL:   jsonString(stack, m.HelpURL),
+			}
+			if mapped, ok := m.Severities[d.Severity]; ok {
+				d.Severity = mapped
+			}
+			if d.Message == "" {
+				continue
+			}
+			diags = append(diags, d)
+		}
+	}
+	return diags, nil
+}
+
+func splitJSONPath(p string) []string {
+	if p == "" {
+		return nil
+	}
+	return strings.Split(p, ".")
+}
+
+// collectJSONItems walks segs from v. Every "[]" segment iterates an array;
+// each reached item is recorded after the iterated objects enclosing it.
+func collectJSONItems(v any, segs []string, stack []any, out *[][]any) {
+	if len(segs) == 0 {
+		*out = append(*out, withItem(stack, v))
+		return
+	}
+	seg := segs[0]
+	if key := strings.TrimSuffix(seg, "[]"); key != "" {
+		v = jsonChild(v, key)
+	}
+	if !strings.HasSuffix(seg, "[]") {
+		collectJSONItems(v, segs[1:], stack, out)
+		return
+	}
+	arr, ok := v.([]any)
+	if !ok {
+		return
+	}
+	for _, el := range arr {
+		if len(segs) == 1 {
+			*out = append(*out, withItem(stack, el))
+			continue
+		}
+		collectJSONItems(el, segs[1:], withItem(stack, el), out)
+	}
+}
+
+func withItem(stack []any, v any) []any {
+	return append(append([]any(nil), stack...), v)
+}
+
+func jsonChild(v any, key string) any {
+	switch t := v.(type) {
+	case map[string]any:
+		return t[key]
+	case []any:
+		i, err := strconv.Atoi(key)
+		if err != nil || i < 0 || i >= len(t) {
+			return nil
+		}
+		return t[i]
+	}
+	return nil
+}
+
+// jsonLookup resolves a field path against the innermost item of stack.
+func jsonLookup(stack []any, p string) any {
+	if p == "" || len(stack) == 0 {
+		return nil
+	}
+	level := len(stack) - 1
+	for strings.HasPrefix(p, "../") {
+		p = strings.TrimPrefix(p, "../")
+		level--
+	}
+	if level < 0 {
+		return nil
+	}
+	v := stack[level]
+	for _, key := range strings.Split(p, ".") {
+		v = jsonChild(v, key)
+		if v == nil {
+			return nil
+		}
+	}
+	return v
+}
+
+func jsonString(stack []any, p string) string {
+	switch v := jsonLookup(stack, p).(type) {
+	case string:
+		return v
+	case float64:
+		return strconv.FormatFloat(v, 'f', -1, 64)
+	case bool:
+		return strconv.FormatBool(v)
+	}
+	return ""
+}
+
+func jsonInt(stack []any, p string) int {
+	switch v := jsonLookup(stack, p).(type) {
+	case float64:
+		return int(v)
+	case string:
+		n, _ := strconv.Atoi(v)
+		return n
+	}
+	return 0
+}
@@ -11,30 +11,31 @@ const (
 	ExitError   = 2
 )
 
-// EvaluateResults checks diagnostics and prints formatted output.
-// Returns exit code: 0 for success/warnings-only, 2 for errors.
-func EvaluateResults(diags []Diagnostic) int {
-	if len(diags) == 0 {
-		fmt.Println("Lint: all clean")
-		return ExitSuccess
-	}
+// Summary counts diagnostics by severity.
+type Summary struct {
+	Errors   int `json:"errors"`
+	Warnings int `json:"warnings"`
+	Files    int `json:"files"`
+}
 
-	errors := 0
-	warnings := 0
+// Summarize counts errors, warnings and affected files.
+func Summarize(diags []Diagnostic) Summary {
+	var s Summary
 	for _, d := range diags {
 		if d.Severity == "error" {
-			errors++
+			s.Errors++
 		} else {
-			warnings++
+			s.Warnings++
 		}
 	}
+	s.Files = CountUniqueFiles(diags)
+	return s
+}
 
-	fmt.Print(FormatDiagnostics(diags))
-
-	fileCount := CountUniqueFiles(diags)
-	fmt.Printf("\n%d errors, %d warnings in %d files\n", errors, warnings, fileCount)
-
-	if errors > 0 {
+// EvaluateResults returns the exit code for diagnostics:
+// 0 for success/warnings-only, 2 for errors. It does not print.
+func EvaluateResults(diags []Diagnostic) int {
+	if Summarize(diags).Errors > 0 {
 		return ExitError
 	}
 	return ExitSuccess
@@ -79,6 +80,14 @@ func FormatDiagnostics(diags []Diagnostic) string {
 			}
 			sb.WriteString(fmt.Sprintf("  %d:%d  %s  %s%s  (%s)\n",
 				d.Line, d.Column, prefix, d.Message, ruleStr, d.Source))
+			for _, fix := range d.Fixes {
+				if fix.Message != "" {
+					sb.WriteString("    fix: " + fix.Message + "\n")
+				}
+			}
+			if d.HelpURL != "" {
+				sb.WriteString("    see: " + d.HelpURL + "\n")
+			}
 		}
 	}
 	return sb.String()
@@ -0,0 +1,344 @@
+package lint
+
+import (
+	"encoding/json"
+	"encoding/xml"
+	"fmt"
+	"io"
+	"sort"
+	"strings"
+)
+
+// Output formats accepted by WriteReport.
+const (
+	OutputText   = "text"
+	OutputJSON   = "json"
+	OutputSARIF  = "sarif"
+	OutputJUnit  = "junit"
+	OutputGitHub = "github"
+)
+
+// OutputFormats lists the report formats in display order.
+func OutputFormats() []string {
+	return []string{OutputText, OutputJSON, OutputSARIF, OutputJUnit, OutputGitHub}
+}
+
+// WriteReport writes diagnostics to w in the given format.
+func WriteReport(w io.Writer, format string, diags []Diagnostic) error {
+	diags = SortDiagnostics(diags)
+	switch format {
+	case "", OutputText:
+		return writeText(w, diags)
+	case OutputJSON:
+		return writeJSON(w, diags)
+	case OutputSARIF:
+		return writeSARIF(w, diags)
+	case OutputJUnit:
+		return writeJUnit(w, diags)
+	case OutputGitHub:
+		return writeGitHub(w, diags)
+	default:
+		return fmt.Errorf("invalid format %q (valid: %s)", format, strings.Join(OutputFormats(), ", "))
+	}
+}
+
+// SortDiagnostics returns a copy of diags ordered by file, line and column.
+func SortDiagnostics(diags []Diagnostic) []Diagnostic {
+	sorted := append([]Diagnostic(nil), diags...)
+	sort.SliceStable(sorted, func(i, j int) bool {
+		a, b := sorted[i], sorted[j]
+		if a.File != b.File {
+			return a.File < b.File
+		}
+		if a.Line != b.Line {
+			return a.Line < b.Line
+		}
+		return a.Column < b.Column
+	})
+	return sorted
+}
+
+func writeText(w io.Writer, diags []Diagnostic) error {
+	if len(diags) == 0 {
+		_, err := fmt.Fprintln(w, "Lint: all clean")
+		return err
+	}
+	s := Summarize(diags)
+	_, err := fmt.Fprintf(w, "%s\n%d errors, %d warnings in %d files\n",
+		FormatDiagnostics(diags), s.Errors, s.Warnings, s.Files)
+	return err
+}
+
+func writeJSON(w io.Writer, diags []Diagnostic) error {
+	if diags == nil {
+		diags = []Diagnostic{}
+	}
+	report := struct {
+		Diagnostics []Diagnostic `json:"diagnostics"`
+		Summary     Summary      `json:"summary"`
+	}{diags, Summarize(diags)}
+
+	enc := json.NewEncoder(w)
+	enc.SetIndent("", "  ")
+	return enc.Encode(report)
+}
+
+// SARIF 2.1.0 output, one run per linter.
+
+type sarifLog struct {
+	Schema  string     `json:"$schema"`
+	Version string     `json:"version"`
+	Runs    []sarifRun `json:"runs"`
+}
+
+type sarifRun struct {
+	Tool    sarifTool     `json:"tool"`
+	Results []sarifResult `json:"results"`
+}
+
+type sarifTool struct {
+	Driver struct {
+		Name  string      `json:"name"`
+		Rules []sarifRule `json:"rules,omitempty"`
+	} `json:"driver"`
+}
+
+type sarifRule struct {
+	ID      string `json:"id"`
+	HelpURI string `json:"helpUri,omitempty"`
+}
+
+type sarifResult struct {
+	RuleID    string          `json:"ruleId,omitempty"`
+	Level     string          `json:"level"`
+	Message   sarifText       `json:"message"`
+	Locations []sarifLocation `json:"locations,omitempty"`
+	Fixes     []sarifFix      `json:"fixes,omitempty"`
+}
+
+type sarifText struct {
+	Text string `json:"text"`
+}
+
+type sarifLocation struct {
+	PhysicalLocation struct {
+		ArtifactLocation sarifArtifact `json:"artifactLocation"`
+		Region           *sarifRegion  `json:"region,omitempty"`
+	} `json:"physicalLocation"`
+}
+
+type sarifArtifact struct {
+	URI string `json:"uri"`
+}
+
+type sarifRegion struct {
+	StartLine   int `json:"startLine"`
+	StartColumn int `json:"startColumn,omitempty"`
+	EndLine     int `json:"endLine,omitempty"`
+	EndColumn   int `json:"endColumn,omitempty"`
+}
+
+type sarifFix struct {
+	Description     *sarifText            `json:"description,omitempty"`
+	ArtifactChanges []sarifArtifactChange `json:"artifactChanges"`
+}
+
+type sarifArtifactChange struct {
+	ArtifactLocation sarifArtifact      `json:"artifactLocation"`
+	Replacements     []sarifReplacement `json:"replacements"`
+}
+
+type sarifReplacement struct {
+	DeletedRegion   sarifRegion `json:"deletedRegion"`
+	InsertedContent *sarifText  `json:"insertedContent,omitempty"`
+}
+
+func writeSARIF(w io.Writer, diags []Diagnostic) error {
+	log := sarifLog{
+		Schema:  "https://json.schemastore.org/sarif-2.1.0.json",
+		Version: "2.1.0",
+		Runs:    []sarifRun{},
+	}
+
+	runIndex := make(map[string]int)
+	ruleSeen := make(map[string]bool)
+	for _, d := range diags {
+		idx, ok := runIndex[d.Source]
+		if !ok {
+			var run sarifRun
+			run.Tool.Driver.Name = d.Source
+			run.Results = []sarifResult{}
+			log.Runs = append(log.Runs, run)
+			idx = len(log.Runs) - 1
+			runIndex[d.Source] = idx
+		}
+		run := &log.Runs[idx]
+
+		if d.Rule != "" && !ruleSeen[d.Source+"\x00"+d.Rule] {
+			ruleSeen[d.Source+"\x00"+d.Rule] = true
+			run.Tool.Driver.Rules = append(run.Tool.Driver.Rules, sarifRule{ID: d.Rule, HelpURI: d.HelpURL})
+		}
+
+		level := "warning"
+		if d.Severity == "error" {
+			level = "error"
+		}
+		res := sarifResult{RuleID: d.Rule, Level: level, Message: sarifText{d.Message}}
+		if d.File != "" {
+			var loc sarifLocation
+			loc.PhysicalLocation.ArtifactLocation.URI = d.File
+			if d.Line > 0 {
+				loc.PhysicalLocation.Region = &sarifRegion{
+					StartLine: d.Line, StartColumn: d.Column,
+					EndLine: d.EndLine, EndColumn: d.EndColumn,
+				}
+			}
+			res.Locations = []sarifLocation{loc}
+		}
+		for _, fix := range d.Fixes {
+			if len(fix.Edits) == 0 {
+				continue
+			}
+			change := sarifArtifactChange{ArtifactLocation: sarifArtifact{d.File}}
+			for _, e := range fix.Edits {
+				change.Replacements = append(change.Replacements, sarifReplacement{
+					DeletedRegion:   sarifRegion{StartLine: e.Line, StartColumn: e.Column, EndLine: e.EndLine, EndColumn: e.EndColumn},
+					InsertedContent: &sarifText{e.NewText},
+				})
+			}
+			sf := sarifFix{ArtifactChanges: []sarifArtifactChange{change}}
+			if fix.Message != "" {
+				sf.Description = &sarifText{fix.Message}
+			}
+			res.Fixes = append(res.Fixes, sf)
+		}
+		run.Results = append(run.Results, res)
+	}
+
+	enc := json.NewEncoder(w)
+	enc.SetIndent("", "  ")
+	return enc.Encode(log)
+}
+
+// JUnit XML output: one suite per linter, one test case per file.
+
+type junitSuites struct {
+	XMLName  xml.Name     `xml:"testsuites"`
+	Name     string       `xml:"name,attr"`
+	Tests    int          `xml:"tests,attr"`
+	Failures int          `xml:"failures,attr"`
+	Suites   []junitSuite `xml:"testsuite"`
+}
+
+type junitSuite struct {
+	Name     string      `xml:"name,attr"`
+	Tests    int         `xml:"tests,attr"`
+	Failures int         `xml:"failures,attr"`
+	Cases    []junitCase `xml:"testcase"`
+}
+
+type junitCase struct {
+	ClassName string         `xml:"classname,attr"`
+	Name      string         `xml:"name,attr"`
+	Failures  []junitFailure `xml:"failure"`
+}
+
+type junitFailure struct {
+	Message string `xml:"message,attr"`
+	Type    string `xml:"type,attr"`
+	Text    string `xml:",chardata"`
+}
+
+func writeJUnit(w io.Writer, diags []Diagnostic) error {
+	suites := junitSuites{Name: "godo lint"}
+	suiteIndex := make(map[string]int)
+	caseIndex := make(map[string]int)
+
+	for _, d := range diags {
+		si, ok := suiteIndex[d.Source]
+		if !ok {
+			suites.Suites = append(suites.Suites, junitSuite{Name: d.Source})
+			si = len(suites.Suites) - 1
+			suiteIndex[d.Source] = si
+		}
+		suite := &suites.Suites[si]
+
+		key := d.Source + "\x00" + d.File
+		ci, ok := caseIndex[key]
+		if !ok {
+			suite.Cases = append(suite.Cases, junitCase{ClassName: d.Source, Name: d.File})
+			ci = len(suite.Cases) - 1
+			caseIndex[key] = ci
+			suite.Tests++
+			suites.Tests++
+		}
+
+		text := fmt.Sprintf("%s:%d:%d: %s", d.File, d.Line, d.Column, d.Message)
+		if d.Rule != "" {
+			text += " [" + d.Rule + "]"
+		}
+		c := &suite.Cases[ci]
+		if len(c.Failures) == 0 {
+			suite.Failures++
+			suites.Failures++
+		}
+		c.Failures = append(c.Failures, junitFailure{Message: d.Message, Type: d.Severity, Text: text})
+	}
+
+	if _, err := io.WriteString(w, xml.Header); err != nil {
+		return err
+	}
+	enc := xml.NewEncoder(w)
+	enc.Indent("", "  ")
+	if err := enc.Encode(suites); err != nil {
+		return err
+	}
+	_, err := io.WriteString(w, "\n")
+	return err
+}
+
+// writeGitHub emits GitHub Actions workflow commands, which the runner
+// turns into annotations on the pull request diff.
+func writeGitHub(w io.Writer, diags []Diagnostic) error {
+	for _, d := range diags {
+		level := "warning"
+		if d.Severity == "error" {
+			level = "error"
+		}
+		props := []string{"file=" + githubProperty(d.File)}
+		if d.Line > 0 {
+			props = append(props, fmt.Sprintf("line=%d", d.Line))
+		}
+		if d.Column > 0 {
+			props = append(props, fmt.Sprintf("col=%d", d.Column))
+		}
+		if d.EndLine > 0 {
+			props = append(props, fmt.Sprintf("endLine=%d", d.EndLine))
+		}
+		if d.EndColumn > 0 {
+			props = append(props, fmt.Sprintf("endColumn=%d", d.EndColumn))
+		}
+		title := d.Source
+		if d.Rule != "" {
+			title += " " + d.Rule
+		}
+		props = append(props, "title="+githubProperty(title))
+
+		msg := d.Message
+		if d.HelpURL != "" {
+			msg += "\n" + d.HelpURL
+		}
+		if _, err := fmt.Fprintf(w, "::%s %s::%s\n", level, strings.Join(props, ","), githubData(msg)); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func githubData(s string) string {
+	return strings.NewReplacer("%", "%25", "\r", "%0D", "\n", "%0A").Replace(s)
+}
+
+func githubProperty(s string) string {
+	return strings.NewReplacer("%", "%25", "\r", "%0D", "\n", "%0A", ":", "%3A", ",", "%2C").Replace(s)
+}
@@ -0,0 +1,173 @@
+package lint
+
+import (
+	"bytes"
+	"encoding/json"
+	"encoding/xml"
+	"strings"
+	"testing"
+)
+
+var sampleDiags = []Diagnostic{
+	{File: "b.py", Line: 4, Column: 1, EndLine: 4, EndColumn: 10, Severity: "error", Message: "undefined name, really", Rule: "F821", Source: "ruff", HelpURL: "https://docs.astral.sh/ruff/rules/undefined-name",
+		Fixes: []Fix{{Message: "Remove import", Edits: []TextEdit{{Line: 4, Column: 1, EndLine: 5, EndColumn: 1, NewText: ""}}}}},
+	{File: "a.go", Line: 2, Column: 3, Severity: "warning", Message: "unreachable code", Source: "go vet"},
+}
+
+func Test_EvaluateResults_exit_codes(t *testing.T) {
+	if got := EvaluateResults(nil); got != ExitSuccess {
+		t.Errorf("no diagnostics: got %d", got)
+	}
+	if got := EvaluateResults(sampleDiags[1:]); got != ExitSuccess {
+		t.Errorf("warnings only: got %d", got)
+	}
+	if got := EvaluateResults(sampleDiags); got != ExitError {
+		t.Errorf("with errors: got %d", got)
+	}
+}
+
+func Test_WriteReport_text(t *testing.T) {
+	var buf bytes.Buffer
+	if err := WriteReport(&buf, OutputText, sampleDiags); err != nil {
+		t.Fatal(err)
+	}
+	out := buf.String()
+	if strings.Index(out, "a.go") > strings.Index(out, "b.py") {
+		t.Error("files should be sorted")
+	}
+	for _, want := range []string{"fix: Remove import", "see: https://docs.astral.sh", "1 errors, 1 warnings in 2 files"} {
+		if !strings.Contains(out, want) {
+			t.Errorf("missing %q in:\n%s", want, out)
+		}
+	}
+
+	buf.Reset()
+	_ = WriteReport(&buf, OutputText, nil)
+	if buf.String() != "Lint: all clean\n" {
+		t.Errorf("clean: got %q", buf.String())
+	}
+}
+
+func Test_WriteReport_json(t *testing.T) {
+	var buf bytes.Buffer
+	if err := WriteReport(&buf, OutputJSON, nil); err != nil {
+		t.Fatal(err)
+	}
+	if !strings.Contains(buf.String(), `"diagnostics": []`) {
+		t.Errorf("empty report should have an empty array: %s", buf.String())
+	}
+
+	buf.Reset()
+	_ = WriteReport(&buf, OutputJSON, sampleDiags)
+	var report struct {
+		Diagnostics []Diagnostic `json:"diagnostics"`
+		Summary     Summary      `json:"summary"`
+	}
+	if err := json.Unmarshal(buf.Bytes(), &report); err != nil {
+		t.Fatal(err)
+	}
+	if report.Summary.Errors != 1 || len(report.Diagnostics) != 2 || report.Diagnostics[1].Fixes[0].Message != "Remove import" {
+		t.Errorf("got %+v", report)
+	}
+}
+
+func Test_WriteReport_sarif(t *testing.T) {
+	var buf bytes.Buffer
+	if err := WriteReport(&buf, OutputSARIF, sampleDiags); err != nil {
+		t.Fatal(err)
+	}
+	var log sarifLog
+	if err := json.Unmarshal(buf.Bytes(), &log); err != nil {
+		t.Fatal(err)
+	}
+	if len(log.Runs) != 2 {
+		t.Fatalf("expected one run per linter, got %d", len(log.Runs))
+	}
+	ruff := log.Runs[1]
+	if ruff.Tool.Driver.Name != "ruff" || ruff.Tool.Driver.Rules[0].HelpURI == "" {
+		t.Errorf("ruff run: got %+v", ruff.Tool)
+	}
+	res := ruff.Results[0]
+	if res.Level != "error" || res.Locations[0].PhysicalLocation.Region.EndColumn != 10 || len(res.Fixes) != 1 {
+		t.Errorf("ruff result: got %+v", res)
+	}
+
+	// Round trip through the SARIF parser.
+	parsed, err := ParseSARIF(buf.Bytes())
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(parsed) != 2 || parsed[1].HelpURL != sampleDiags[0].HelpURL {
+		t.Errorf("round trip: got %+v", parsed)
+	}
+}
+
+func Test_WriteReport_junit(t *testing.T) {
+	var buf bytes.Buffer
+	if err := WriteReport(&buf, OutputJUnit, sampleDiags); err != nil {
+		t.Fatal(err)
+	}
+	var suites junitSuites
+	if err := xml.Unmarshal(buf.Bytes(), &suites); err != nil {
+		t.Fatal(err)
+	}
+	if suites.Tests != 2 || suites.Failures != 2 || len(suites.Suites) != 2 {
+		t.Errorf("got %+v", suites)
+	}
+}
+
+func Test_WriteReport_github_escapes(t *testing.T) {
+	var buf bytes.Buffer
+	if err := WriteReport(&buf, OutputGitHub, sampleDiags); err != nil {
+		t.Fatal(err)
+	}
+	lines := strings.Split(strings.TrimSpace(buf.String()), "\n")
+	if len(lines) != 2 {
+		t.Fatalf("expected 2 annotations, got %q", buf.String())
+	}
+	if lines[0] != "::warning file=a.go,line=2,col=3,title=go vet::unreachable code" {
+		t.Errorf("warning: got %q", lines[0])
+	}
+	want := "::error file=b.py,line=4,col=1,endLine=4,endColumn=10,title=ruff F821::undefined name, really%0Ahttps://docs.astral.sh/ruff/rules/undefined-name"
+	if lines[1] != want {
+		t.Errorf("error:\n got %q\nwant %q", lines[1], want)
+	}
+}
+
+func Test_WriteReport_invalid_format(t *testing.T) {
+	if err := WriteReport(&bytes.Buffer{}, "xml", nil); err == nil {
+		t.Error("expected error for unknown format")
+	}
+}
+
+func Test_ParseRuffJSON_fix_and_url(t *testing.T) {
+	data := []byte(`[{"code":"F401","message":"os imported but unused","filename":"a.py",
+"location":{"row":1,"column":8},"end_location":{"row":1,"column":10},
+"url":"https://docs.astral.sh/ruff/rules/unused-import",
+"fix":{"message":"Remove unused import: os","edits":[{"content":"","location":{"row":1,"column":1},"end_location":{"row":2,"column":1}}]}}]`)
+	diags, err := ParseRuffJSON(data)
+	if err != nil || len(diags) != 1 {
+		t.Fatalf("expected 1 diagnostic, got %d", len(diags))
+	}
+	d := diags[0]
+	if d.EndColumn != 10 || d.HelpURL == "" || len(d.Fixes) != 1 || d.Fixes[0].Edits[0].EndLine != 2 {
+		t.Errorf("got %+v", d)
+	}
+}
+
+func Test_ParseClippyJSON_suggestion(t *testing.T) {
+	data := []byte(`{"reason":"compiler-message","message":{"code":{"code":"clippy::needless_return"},"level":"warning","message":"unneeded return statement",` +
+		`"spans":[{"file_name":"src/lib.rs","line_start":3,"line_end":3,"column_start":5,"column_end":14}],` +
+		`"children":[{"message":"remove return","spans":[{"file_name":"src/lib.rs","line_start":3,"line_end":3,"column_start":5,"column_end":14,"suggested_replacement":"x"}]}]}}`)
+	diags, err := ParseClippyJSON(data)
+	if err != nil || len(diags) != 1 {
+		t.Fatalf("expected 1 diagnostic, got %d", len(diags))
+	}
+	d := diags[0]
+	if d.HelpURL != "https://rust-lang.github.io/rust-clippy/master/index.html#needless_return" {
+		t.Errorf("HelpURL: got %q", d.HelpURL)
+	}
+	if len(d.Fixes) != 1 || d.Fixes[0].Edits[0].NewText != "x" || d.EndColumn != 14 {
+		t.Errorf("got %+v", d)
+	}
+}
@@ -0,0 +1,789 @@
+package lint
+
+import (
+	"errors"
+	"fmt"
+	"os"
+	"os/exec"
+	"path"
+	"path/filepath"
+	"regexp"
+	"runtime"
+	"slices"
+	"sort"
+	"strings"
+	"sync"
+
+	"gopkg.in/yaml.v3"
+)
+
+// RegistryFile is the project-local linter registry, relative to the project root.
+const RegistryFile = ".do/lint.yaml"
+
+// Working directory strategies for LinterDef.WorkDir.
+const (
+	WorkDirProject = "project" // run once in the project directory (default)
+	WorkDirFile    = "file"    // run once per directory holding matched files
+	WorkDirRoot    = "root"    // run once per nearest ancestor holding a RootMarkers file
+)
+
+// Kinds of registry entries. Formatters report unformatted code as
+// diagnostics with rule FormatRule and run only in check mode.
+const (
+	KindLint   = "lint"
+	KindFormat = "format"
+)
+
+// FormatRule is the rule of every formatter diagnostic.
+const FormatRule = "format"
+
+// Output formats for LinterDef.Format. The names of the built-in parsers
+// (govet, ruff, tsc, eslint, clippy, diff, filelist) are accepted as well.
+const (
+	FormatSARIF      = "sarif"
+	FormatCheckstyle = "checkstyle"
+	FormatJSON       = "json"
+	FormatRegex      = "regex"
+)
+
+// LinterDef declares a linter: which files it handles, how to invoke it
+// and how to read its output.
+//
+// Command is split on whitespace (quotes group words) and supports the
+// placeholders {files} (matched files, relative to the working directory),
+// {file} (run once per file), {dir} (working directory) and {root}
+// (project directory).
+type LinterDef struct {
+	Name        string       `yaml:"name"`
+	Kind        string       `yaml:"kind,omitempty"` // lint (default) or format
+	Language    Language     `yaml:"language,omitempty"`
+	Files       []string     `yaml:"files"`
+	Command     string       `yaml:"command"`
+	WorkDir     string       `yaml:"workdir,omitempty"`
+	RootMarkers []string     `yaml:"root_markers,omitempty"`
+	Format      string       `yaml:"format"`
+	Pattern     string       `yaml:"pattern,omitempty"`  // regex format: named groups, see ParseRegexOutput
+	JSON        *JSONMapping `yaml:"json,omitempty"`     // json format: field mapping
+	Severity    string       `yaml:"severity,omitempty"` // used when the output carries none
+	Fix         CommandList  `yaml:"fix,omitempty"`      // auto-fix command; the first installed alternative is used
+	Disabled    bool         `yaml:"disabled,omitempty"`
+
+	// ExitCodes are the exit codes meaning the tool ran, clean or with
+	// findings (default 0 and 1). Any other code is a tool failure, whose
+	// result is never cached.
+	ExitCodes []int `yaml:"exit_codes,omitempty"`
+
+	// Cache key inputs: the tool version (the output of VersionCommand,
+	// default "<binary> --version") and the contents of ConfigFiles found
+	// in the working directory or any parent up to the project directory.
+	VersionCommand string   `yaml:"version_command,omitempty"`
+	ConfigFiles    []string `yaml:"config_files,omitempty"`
+}
+
+// Registry is an ordered set of linter definitions.
+type Registry struct {
+	Linters     []LinterDef `yaml:"linters"`
+	Hook        HookConfig  `yaml:"hook,omitempty"`
+	Concurrency int         `yaml:"concurrency,omitempty"` // parallel linter invocations; 0 means one per CPU
+	Cache       *Cache      `yaml:"-"`                     // nil disables result caching
+}
+
+// HookConfig controls linting from the PostToolUse hook.
+type HookConfig struct {
+	OnEdit       bool `yaml:"on_edit,omitempty"`       // lint files after Write/Edit
+	ChangedLines bool `yaml:"changed_lines,omitempty"` // report only lines changed since HEAD
+}
+
+// DefaultRegistry returns the built-in linters and formatters used when a
+// project has no .do/lint.yaml, and which a project registry extends or
+// overrides. Linters run once per module root (nearest go.mod, pyproject.toml, tsconfig.json,
+// package.json or Cargo.toml), so monorepos lint every module in its own
+// context.
+func DefaultRegistry() *Registry {
+	return &Registry{Linters: []LinterDef{
+		{
+			Name:           "go vet",
+			Language:       LangGo,
+			Files:          []string{"*.go"},
+			Command:        "go vet ./...",
+			Format:         "govet",
+			WorkDir:        WorkDirRoot,
+			RootMarkers:    []string{"go.mod"},
+			VersionCommand: "go version",
+			ConfigFiles:    []string{"go.mod", "go.sum"},
+		},
+		{
+			Name:        "ruff",
+			Language:    LangPython,
+			Files:       []string{"*.py", "*.pyi"},
+			Command:     "ruff check --output-format=json {files}",
+			Format:      "ruff",
+			Fix:         CommandList{"ruff check --fix {files}"},
+			WorkDir:     WorkDirRoot,
+			RootMarkers: []string{"pyproject.toml", "ruff.toml", ".ruff.toml"},
+			ConfigFiles: []string{"pyproject.toml", "ruff.toml", ".ruff.toml"},
+		},
+		{
+			Name:        "tsc",
+			Language:    LangTypeScript,
+			Files:       []string{"*.ts", "*.tsx", "*.mts", "*.cts"},
+			Command:     "tsc --noEmit --pretty false",
+			Format:      "tsc",
+			ExitCodes:   []int{0, 1, 2},
+			WorkDir:     WorkDirRoot,
+			RootMarkers: []string{"tsconfig.json"},
+			ConfigFiles: []string{"tsconfig.json", "package.json"},
+		},
+		{
+			Name:        "eslint",
+			Language:    LangJavaScript,
+			Files:       []string{"*.js", "*.jsx", "*.mjs", "*.cjs"},
+			Command:     "eslint --format json {files}",
+			Format:      "eslint",
+			Fix:         CommandList{"eslint --fix {files}"},
+			WorkDir:     WorkDirRoot,
+			RootMarkers: []string{"package.json"},
+			ConfigFiles: []string{"eslint.config.js", "eslint.config.mjs", "eslint.config.cjs", ".eslintrc", ".eslintrc.js", ".eslintrc.cjs", ".eslintrc.json", ".eslintrc.yml", ".eslintrc.yaml", "package.json"},
+		},
+		{
+			Name:           "cargo clippy",
+			Language:       LangRust,
+			Files:          []string{"*.rs"},
+			Command:        "cargo clippy --message-format=json",
+			Format:         "clippy",
+			ExitCodes:      []int{0, 101},
+			Fix:            CommandList{"cargo clippy --fix --allow-no-vcs"},
+			WorkDir:        WorkDirRoot,
+			RootMarkers:    []string{"Cargo.toml"},
+			VersionCommand: "cargo clippy --version",
+			ConfigFiles:    []string{"Cargo.toml", "Cargo.lock", "clippy.toml", ".clippy.toml"},
+		},
+		{
+			Name:           "gofmt",
+			Kind:           KindFormat,
+			Language:       LangGo,
+			Files:          []string{"*.go"},
+			Command:        "gofmt -d {files}",
+			Format:         "diff",
+			Fix:            CommandList{"goimports -w {files}", "gofmt -w {files}"},
+			VersionCommand: "go version",
+		},
+		{
+			Name:        "ruff format",
+			Kind:        KindFormat,
+			Language:    LangPython,
+			Files:       []string{"*.py", "*.pyi"},
+			Command:     "ruff format --diff {files}",
+			Format:      "diff",
+			Fix:         CommandList{"ruff format {files}"},
+			WorkDir:     WorkDirRoot,
+			RootMarkers: []string{"pyproject.toml", "ruff.toml", ".ruff.toml"},
+			ConfigFiles: []string{"pyproject.toml", "ruff.toml", ".ruff.toml"},
+		},
+		{
+			Name:        "prettier",
+			Kind:        KindFormat,
+			Files:       []string{"*.js", "*.jsx", "*.mjs", "*.cjs", "*.ts", "*.tsx", "*.mts", "*.cts", "*.css", "*.scss"},
+			Command:     "prettier --list-different {files}",
+			Format:      "filelist",
+			Fix:         CommandList{"prettier --write {files}"},
+			WorkDir:     WorkDirRoot,
+			RootMarkers: []string{"package.json"},
+			ConfigFiles: []string{".prettierrc", ".prettierrc.json", ".prettierrc.yaml", ".prettierrc.yml", ".prettierrc.js", "prettier.config.js", ".prettierignore", ".editorconfig", "package.json"},
+		},
+		{
+			Name:        "rustfmt",
+			Kind:        KindFormat,
+			Language:    LangRust,
+			Files:       []string{"*.rs"},
+			Command:     "rustfmt --check {files}",
+			Format:      FormatRegex,
+			Pattern:     `^Diff in (?P<file>.+?)(?: at line |:)(?P<line>\d+):?$`,
+			Fix:         CommandList{"rustfmt {files}"},
+			ConfigFiles: []string{"rustfmt.toml", ".rustfmt.toml"},
+		},
+	}}
+}
+
+// LoadRegistry returns the default registry merged with {projectDir}/.do/lint.yaml.
+// A project entry whose name matches a default overrides the fields it sets
+// (disabled: true turns the default off); other entries are appended.
+// A missing file is not an error.
+func LoadRegistry(projectDir string) (*Registry, error) {
+	reg := DefaultRegistry()
+
+	p := filepath.Join(projectDir, RegistryFile)
+	data, err := os.ReadFile(p)
+	if errors.Is(err, os.ErrNotExist) {
+		return reg, nil
+	}
+	if err != nil {
+		return nil, fmt.Errorf("read lint registry %s: %w", p, err)
+	}
+
+	var project Registry
+	if err := yaml.Unmarshal(data, &project); err != nil {
+		return nil, fmt.Errorf("parse lint registry %s: %w", p, err)
+	}
+	for _, def := range project.Linters {
+		reg.merge(def)
+	}
+	reg.Hook = project.Hook
+	reg.Concurrency = project.Concurrency
+	if err := reg.Validate(); err != nil {
+		return nil, fmt.Errorf("lint registry %s: %w", p, err)
+	}
+	return reg, nil
+}
+
+// merge overlays def onto the linter with the same name, or appends it.
+func (r *Registry) merge(def LinterDef) {
+	for i := range r.Linters {
+		cur := &r.Linters[i]
+		if cur.Name != def.Name {
+			continue
+		}
+		if def.Kind != "" {
+			cur.Kind = def.Kind
+		}
+		if def.Language != "" {
+			cur.Language = def.Language
+		}
+		if len(def.Files) > 0 {
+			cur.Files = def.Files
+		}
+		if def.Command != "" {
+			cur.Command = def.Command
+		}
+		if def.WorkDir != "" {
+			cur.WorkDir = def.WorkDir
+		}
+		if len(def.RootMarkers) > 0 {
+			cur.RootMarkers = def.RootMarkers
+		}
+		if def.Format != "" {
+			cur.Format = def.Format
+		}
+		if def.Pattern != "" {
+			cur.Pattern = def.Pattern
+		}
+		if def.JSON != nil {
+			cur.JSON = def.JSON
+		}
+		if def.Severity != "" {
+			cur.Severity = def.Severity
+		}
+		if len(def.Fix) > 0 {
+			cur.Fix = def.Fix
+		}
+		if def.VersionCommand != "" {
+			cur.VersionCommand = def.VersionCommand
+		}
+		if len(def.ConfigFiles) > 0 {
+			cur.ConfigFiles = def.ConfigFiles
+		}
+		if len(def.ExitCodes) > 0 {
+			cur.ExitCodes = def.ExitCodes
+		}
+		cur.Disabled = def.Disabled
+		return
+	}
+	r.Linters = append(r.Linters, def)
+}
+
+// Validate checks that every enabled linter can be run and parsed.
+func (r *Registry) Validate() error {
+	seen := make(map[string]bool)
+	for _, d := range r.Linters {
+		if d.Name == "" {
+			return fmt.Errorf("linter without a name")
+		}
+		if seen[d.Name] {
+			return fmt.Errorf("linter %q defined twice", d.Name)
+		}
+		seen[d.Name] = true
+		if d.Disabled {
+			continue
+		}
+		if err := d.validate(); err != nil {
+			return fmt.Errorf("linter %q: %w", d.Name, err)
+		}
+	}
+	return nil
+}
+
+func (d LinterDef) validate() error {
+	switch d.Kind {
+	case "", KindLint, KindFormat:
+	default:
+		return fmt.Errorf("unknown kind %q", d.Kind)
+	}
+	if len(d.Files) == 0 {
+		return fmt.Errorf("no file globs")
+	}
+	if len(splitCommand(d.Command)) == 0 {
+		return fmt.Errorf("no command")
+	}
+	switch d.WorkDir {
+	case "", WorkDirProject, WorkDirFile:
+	case WorkDirRoot:
+		if len(d.RootMarkers) == 0 {
+			return fmt.Errorf("workdir %q requires root_markers", WorkDirRoot)
+		}
+	default:
+		return fmt.Errorf("unknown workdir %q", d.WorkDir)
+	}
+	switch d.Format {
+	case FormatSARIF, FormatCheckstyle:
+	case FormatRegex:
+		if d.Pattern == "" {
+			return fmt.Errorf("regex format requires pattern")
+		}
+		if _, err := regexp.Compile(d.Pattern); err != nil {
+			return fmt.Errorf("invalid pattern: %w", err)
+		}
+	case FormatJSON:
+		if d.JSON == nil {
+			return fmt.Errorf("json format requires a json mapping")
+		}
+	default:
+		if _, ok := builtinParsers[d.Format]; !ok {
+			return fmt.Errorf("unknown format %q", d.Format)
+		}
+	}
+	return nil
+}
+
+// Enabled returns the linters that are not disabled, in registry order.
+func (r *Registry) Enabled() []LinterDef {
+	var defs []LinterDef
+	for _, d := range r.Linters {
+		if !d.Disabled {
+			defs = append(defs, d)
+		}
+	}
+	return defs
+}
+
+// IsFormatter reports whether the entry is a formatter check.
+func (d LinterDef) IsFormatter() bool {
+	return d.Kind == KindFormat
+}
+
+// Only returns a copy of the registry holding the entries of one kind.
+func (r *Registry) Only(kind string) *Registry {
+	cp := *r
+	cp.Linters = nil
+	for _, d := range r.Linters {
+		if d.IsFormatter() == (kind == KindFormat) {
+			cp.Linters = append(cp.Linters, d)
+		}
+	}
+	return &cp
+}
+
+// Lookup returns the linter with the given name.
+func (r *Registry) Lookup(name string) (LinterDef, bool) {
+	for _, d := range r.Linters {
+		if d.Name == name {
+			return d, true
+		}
+	}
+	return LinterDef{}, false
+}
+
+// ForFile returns the enabled linters whose globs match the file.
+func (r *Registry) ForFile(file string) []LinterDef {
+	var defs []LinterDef
+	for _, d := range r.Enabled() {
+		if d.Matches(file) {
+			defs = append(defs, d)
+		}
+	}
+	return defs
+}
+
+// LanguageOf returns the language of the first linter matching the file.
+func (r *Registry) LanguageOf(file string) Language {
+	for _, d := range r.ForFile(file) {
+		if d.Language != "" {
+			return d.Language
+		}
+	}
+	return LangUnknown
+}
+
+// Group assigns files to the linters that handle them, keyed by linter name.
+// A file may be handled by several linters.
+func (r *Registry) Group(files []string) map[string][]string {
+	groups := make(map[string][]string)
+	for _, f := range files {
+		for _, d := range r.ForFile(f) {
+			groups[d.Name] = append(groups[d.Name], f)
+		}
+	}
+	return groups
+}
+
+// Run lints files with every matching, installed linter and merges the
+// results. Invocations (one per linter and working directory) run
+// concurrently, bounded by Concurrency; the merged output keeps registry
+// and directory order and drops duplicates reported by overlapping runs.
+func (r *Registry) Run(files []string, projectDir string) []Diagnostic {
+	type job struct {
+		def   LinterDef
+		batch runBatch
+	}
+	groups := r.Group(files)
+	var jobs []job
+	for _, d := range r.Enabled() {
+		matched := groups[d.Name]
+		if len(matched) == 0 || !d.Installed(projectDir) {
+			continue
+		}
+		for _, b := range d.batches(matched, projectDir) {
+			jobs = append(jobs, job{d, b})
+		}
+	}
+
+	workers := r.Concurrency
+	if workers <= 0 {
+		workers = runtime.NumCPU()
+	}
+	results := make([][]Diagnostic, len(jobs))
+	sem := make(chan struct{}, workers)
+	var wg sync.WaitGroup
+	for i, j := range jobs {
+		wg.Add(1)
+		sem <- struct{}{}
+		go func() {
+			defer wg.Done()
+			defer func() { <-sem }()
+			results[i] = r.runBatch(j.def, j.batch, projectDir)
+		}()
+	}
+	wg.Wait()
+
+	var diags []Diagnostic
+	seen := make(map[string]bool)
+	for _, res := range results {
+		for _, d := range res {
+			key := fmt.Sprintf("%s\x00%s\x00%d\x00%d\x00%s\x00%s", d.Source, d.File, d.Line, d.Column, d.Rule, d.Message)
+			if seen[key] {
+				continue
+			}
+			seen[key] = true
+			diags = append(diags, d)
+		}
+	}
+	return diags
+}
+
+// runBatch runs one invocation, through the cache when there is one.
+func (r *Registry) runBatch(d LinterDef, b runBatch, projectDir string) []Diagnostic {
+	if r.Cache != nil {
+		return r.Cache.run(d, b, projectDir)
+	}
+	return d.runBatch(b, projectDir)
+}
+
+// Binary returns the executable the linter invokes.
+func (d LinterDef) Binary() string {
+	args := splitCommand(d.Command)
+	if len(args) == 0 {
+		return ""
+	}
+	return args[0]
+}
+
+// Installed reports whether the linter's executable is in the project's
+// .do/tools/bin or on PATH.
+func (d LinterDef) Installed(projectDir string) bool {
+	bin := d.Binary()
+	if bin == "" {
+		return false
+	}
+	return lookTool(projectDir, bin)
+}
+
+// Matches reports whether one of the linter's globs matches the file.
+// Globs without a slash match the base name; others match the slash-separated
+// path, where ** spans directories. Matching is case-insensitive.
+func (d LinterDef) Matches(file string) bool {
+	if file == "" {
+		return false
+	}
+	rel := strings.ToLower(filepath.ToSlash(file))
+	for _, g := range d.Files {
+		if matchGlob(strings.ToLower(g), rel) {
+			return true
+		}
+	}
+	return false
+}
+
+func matchGlob(pattern, rel string) bool {
+	if !strings.Contains(pattern, "/") {
+		ok, _ := path.Match(pattern, path.Base(rel))
+		return ok
+	}
+	re, err := regexp.Compile(globToRegexp(pattern))
+	if err != nil {
+		return false
+	}
+	return re.MatchString(strings.TrimPrefix(rel, "./"))
+}
+
+// globToRegexp translates a slash glob with ** support into an anchored regexp.
+func globToRegexp(pattern string) string {
+	var sb strings.Builder
+	sb.WriteString("^")
+	for i := 0; i < len(pattern); i++ {
+		c := pattern[i]
+		switch {
+		case strings.HasPrefix(pattern[i:], "**/"):
+			sb.WriteString("(.*/)?")
+			i += 2
+		case strings.HasPrefix(pattern[i:], "**"):
+			sb.WriteString(".*")
+			i++
+		case c == '*':
+			sb.WriteString("[^/]*")
+		case c == '?':
+			sb.WriteString("[^/]")
+		default:
+			sb.WriteString(regexp.QuoteMeta(string(c)))
+		}
+	}
+	sb.WriteString("$")
+	return sb.String()
+}
+
+// Run executes the linter over files (relative to projectDir) once per
+// working directory and returns diagnostics with project-relative paths.
+// Linter exit codes are ignored; most linters exit non-zero on findings.
+func (d LinterDef) Run(files []string, projectDir string) []Diagnostic {
+	var diags []Diagnostic
+	for _, batch := range d.batches(files, projectDir) {
+		diags = append(diags, d.runBatch(batch, projectDir)...)
+	}
+	return diags
+}
+
+// runBatch runs one invocation and returns its diagnostics.
+func (d LinterDef) runBatch(batch runBatch, projectDir string) []Diagnostic {
+	diags, _ := d.runBatchChecked(batch, projectDir)
+	return diags
+}
+
+// runBatchChecked is runBatch that also reports whether the tool ran to
+// completion (an exit code in ExitCodes) and its output parsed, so a crash
+// is not mistaken for a clean result.
+func (d LinterDef) runBatchChecked(batch runBatch, projectDir string) ([]Diagnostic, bool) {
+	out, err := d.exec(batch.dir, batch.files, projectDir)
+	ran := d.ran(err)
+	parsed, err := d.Parse(out)
+	if err != nil {
+		return nil, false
+	}
+	for i := range parsed {
+		parsed[i].File = normalizeDiagPath(parsed[i].File, batch.dir, projectDir)
+	}
+	return parsed, ran
+}
+
+// ran reports whether a command that returned err ran, clean or with
+// findings.
+func (d LinterDef) ran(err error) bool {
+	if err == nil {
+		return true
+	}
+	var exitErr *exec.ExitError
+	return errors.As(err, &exitErr) && d.ranWith(exitErr.ExitCode())
+}
+
+// ranWith reports whether an exit code means the tool ran, clean or with
+// findings.
+func (d LinterDef) ranWith(code int) bool {
+	codes := d.ExitCodes
+	if len(codes) == 0 {
+		codes = []int{0, 1}
+	}
+	return slices.Contains(codes, code)
+}
+
+type runBatch struct {
+	dir   string
+	files []string // relative to dir
+}
+
+// batches splits files by working directory according to the WorkDir strategy.
+func (d LinterDef) batches(files []string, projectDir string) []runBatch {
+	byDir := make(map[string][]string)
+	for _, f := range files {
+		abs := f
+		if !filepath.IsAbs(abs) {
+			abs = filepath.Join(projectDir, f)
+		}
+		dir := projectDir
+		switch d.WorkDir {
+		case WorkDirFile:
+			dir = filepath.Dir(abs)
+		case WorkDirRoot:
+			dir = findRoot(filepath.Dir(abs), projectDir, d.RootMarkers)
+		}
+		rel, err := filepath.Rel(dir, abs)
+		if err != nil {
+			rel = abs
+		}
+		byDir[dir] = append(byDir[dir], rel)
+	}
+
+	dirs := make([]string, 0, len(byDir))
+	for dir := range byDir {
+		dirs = append(dirs, dir)
+	}
+	sort.Strings(dirs)
+
+	batches := make([]runBatch, 0, len(dirs))
+	for _, dir := range dirs {
+		batches = append(batches, runBatch{dir: dir, files: byDir[dir]})
+	}
+	return batches
+}
+
+// findRoot walks up from dir to projectDir looking for any marker file.
+// Falls back to projectDir when none is found.
+func findRoot(dir, projectDir string, markers []string) string {
+	projectDir = filepath.Clean(projectDir)
+	for {
+		for _, m := range markers {
+			if _, err := os.Stat(filepath.Join(dir, m)); err == nil {
+				return dir
+			}
+		}
+		if filepath.Clean(dir) == projectDir {
+			return projectDir
+		}
+		parent := filepath.Dir(dir)
+		if parent == dir || !within(parent, projectDir) {
+			return projectDir
+		}
+		dir = parent
+	}
+}
+
+// exec runs the linter command in dir.
+func (d LinterDef) exec(dir string, files []string, projectDir string) ([]byte, error) {
+	return execTemplate(d.Command, dir, files, projectDir)
+}
+
+// execTemplate expands a command template and runs it, once per file if
+// the template uses {file}.
+func execTemplate(command, dir string, files []string, projectDir string) ([]byte, error) {
+	tmpl := splitCommand(command)
+	perFile := false
+	for _, a := range tmpl {
+		if strings.Contains(a, "{file}") {
+			perFile = true
+		}
+	}
+	if !perFile {
+		return runCommand(expandArgs(tmpl, files, "", dir, projectDir), dir)
+	}
+
+	var all []byte
+	var lastErr error
+	for _, f := range files {
+		out, err := runCommand(expandArgs(tmpl, files, f, dir, projectDir), dir)
+		all = append(all, out...)
+		if err != nil {
+			lastErr = err
+		}
+	}
+	return all, lastErr
+}
+
+func expandArgs(tmpl, files []string, file, dir, projectDir string) []string {
+	var args []string
+	for _, a := range tmpl {
+		if a == "{files}" {
+			args = append(args, files...)
+			continue
+		}
+		a = strings.ReplaceAll(a, "{file}", file)
+		a = strings.ReplaceAll(a, "{dir}", dir)
+		a = strings.ReplaceAll(a, "{root}", projectDir)
+		args = append(args, a)
+	}
+	// Pinned project-local tools win over whatever is on PATH.
+	if len(args) > 0 {
+		args[0] = ToolPath(projectDir, args[0])
+	}
+	return args
+}
+
+// runCommand runs args in dir and returns stdout, or combined output when
+// stdout is empty (compilers such as go vet and tsc report on stderr).
+func runCommand(args []string, dir string) ([]byte, error) {
+	cmd := exec.Command(args[0], args[1:]...)
+	cmd.Dir = dir
+	var stdout, stderr strings.Builder
+	cmd.Stdout = &stdout
+	cmd.Stderr = &stderr
+	err := cmd.Run()
+	if stdout.Len() == 0 {
+		return []byte(stderr.String()), err
+	}
+	return []byte(stdout.String()), err
+}
+
+// splitCommand splits a command line on whitespace; single or double
+// quotes group words.
+func splitCommand(s string) []string {
+	var args []string
+	var cur strings.Builder
+	inWord := false
+	var quote rune
+	for _, r := range s {
+		switch {
+		case quote != 0:
+			if r == quote {
+				quote = 0
+			} else {
+				cur.WriteRune(r)
+			}
+		case r == '\'' || r == '"':
+			quote = r
+			inWord = true
+		case r == ' ' || r == '\t' || r == '\n':
+			if inWord {
+				args = append(args, cur.String())
+				cur.Reset()
+				inWord = false
+			}
+		default:
+			cur.WriteRune(r)
+			inWord = true
+		}
+	}
+	if inWord {
+		args = append(args, cur.String())
+	}
+	return args
+}
+
+// normalizeDiagPath makes a reported path relative to the project directory.
+func normalizeDiagPath(file, dir, projectDir string) string {
+	if file == "" {
+		return file
+	}
+	file = strings.TrimPrefix(file, "file://")
+	if !filepath.IsAbs(file) {
+		file = filepath.Join(dir, file)
+	}
+	if rel, err := filepath.Rel(projectDir, file); err == nil && !strings.HasPrefix(rel, "..") {
+		return filepath.ToSlash(rel)
+	}
+	return file
+}
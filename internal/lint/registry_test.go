@@ -0,0 +1,272 @@
+package lint
+
+import (
+	"os"
+	"os/exec"
+	"path/filepath"
+	"regexp"
+	"testing"
+)
+
+func writeRegistry(t *testing.T, dir, content string) {
+	t.Helper()
+	if err := os.MkdirAll(filepath.Join(dir, ".do"), 0755); err != nil {
+		t.Fatal(err)
+	}
+	if err := os.WriteFile(filepath.Join(dir, RegistryFile), []byte(content), 0644); err != nil {
+		t.Fatal(err)
+	}
+}
+
+func Test_LoadRegistry_missing_file_uses_defaults(t *testing.T) {
+	reg, err := LoadRegistry(t.TempDir())
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if len(reg.Enabled()) != len(DefaultRegistry().Linters) {
+		t.Errorf("got %d linters, want defaults", len(reg.Enabled()))
+	}
+}
+
+func Test_LoadRegistry_merges_project_entries(t *testing.T) {
+	dir := t.TempDir()
+	writeRegistry(t, dir, `linters:
+  - name: go vet
+    disabled: true
+  - name: gofmt
+    disabled: true
+  - name: ruff
+    command: ruff check --output-format=json --select E {files}
+  - name: shellcheck
+    language: shell
+    files: ["*.sh", "scripts/**/*.bash"]
+    command: shellcheck -f checkstyle {files}
+    format: checkstyle
+`)
+
+	reg, err := LoadRegistry(dir)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if d, _ := reg.Lookup("go vet"); !d.Disabled {
+		t.Error("go vet should be disabled")
+	}
+	if d, _ := reg.Lookup("ruff"); d.Format != "ruff" || d.Command != "ruff check --output-format=json --select E {files}" {
+		t.Errorf("ruff override: got %+v", d)
+	}
+	if got := reg.LanguageOf("deploy.sh"); got != "shell" {
+		t.Errorf("LanguageOf(deploy.sh): got %q, want shell", got)
+	}
+	if got := reg.LanguageOf("main.go"); got != LangUnknown {
+		t.Errorf("disabled go vet should not claim main.go, got %q", got)
+	}
+	groups := reg.Group([]string{"a.sh", "scripts/ci/x.bash", "other/x.bash", "b.py"})
+	if len(groups["shellcheck"]) != 2 || len(groups["ruff"]) != 1 {
+		t.Errorf("groups: got %v", groups)
+	}
+}
+
+func Test_LoadRegistry_rejects_invalid_entry(t *testing.T) {
+	dir := t.TempDir()
+	writeRegistry(t, dir, `linters:
+  - name: custom
+    files: ["*.x"]
+    command: custom-lint
+    format: regex
+`)
+	if _, err := LoadRegistry(dir); err == nil {
+		t.Error("regex format without pattern should be rejected")
+	}
+}
+
+func Test_LinterDef_Run_regex_output(t *testing.T) {
+	if _, err := exec.LookPath("sh"); err != nil {
+		t.Skip("sh not available")
+	}
+	dir := t.TempDir()
+	def := LinterDef{
+		Name:    "fake",
+		Files:   []string{"*.txt"},
+		Command: `sh -c "echo notes.txt:3:7: warning: trailing space [W01]"`,
+		Format:  FormatRegex,
+		Pattern: `^(?P<file>[^:]+):(?P<line>\d+):(?P<column>\d+): (?P<severity>\w+): (?P<message>.+) \[(?P<rule>\w+)\]$`,
+	}
+
+	diags := def.Run([]string{"notes.txt"}, dir)
+	if len(diags) != 1 {
+		t.Fatalf("expected 1 diagnostic, got %v", diags)
+	}
+	d := diags[0]
+	if d.File != "notes.txt" || d.Line != 3 || d.Column != 7 || d.Rule != "W01" || d.Source != "fake" || d.Severity != "warning" {
+		t.Errorf("diagnostic: got %+v", d)
+	}
+}
+
+func Test_ParseRegexOutput_named_groups(t *testing.T) {
+	re := regexp.MustCompile(`^(?P<file>\S+) line (?P<line>\d+): (?P<message>.+)$`)
+	diags := ParseRegexOutput("a.kt line 4: unused import\nnoise\n", re)
+	if len(diags) != 1 || diags[0].File != "a.kt" || diags[0].Line != 4 || diags[0].Message != "unused import" {
+		t.Errorf("got %+v", diags)
+	}
+}
+
+func Test_ParseCheckstyleXML(t *testing.T) {
+	data := []byte(`<?xml version="1.0"?>
+<checkstyle version="4.3">
+  <file name="run.sh">
+    <error line="2" column="5" severity="error" message="Double quote to prevent globbing" source="ShellCheck.SC2086"/>
+    <error line="9" column="1" severity="info" message="Use $(...)" source="ShellCheck.SC2006"/>
+  </file>
+</checkstyle>`)
+	diags, err := ParseCheckstyleXML(data)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if len(diags) != 2 {
+		t.Fatalf("expected 2 diagnostics, got %d", len(diags))
+	}
+	if diags[0].File != "run.sh" || diags[0].Line != 2 || diags[0].Rule != "ShellCheck.SC2086" {
+		t.Errorf("first: got %+v", diags[0])
+	}
+}
+
+func Test_Parse_sarif(t *testing.T) {
+	data := []byte(`{"version":"2.1.0","runs":[{"results":[
+{"ruleId":"errcheck","level":"error","message":{"text":"unchecked error"},
+ "locations":[{"physicalLocation":{"artifactLocation":{"uri":"pkg/a.go"},"region":{"startLine":12,"startColumn":3}}}]},
+{"ruleId":"unused","message":{"text":"unused var"},
+ "locations":[{"physicalLocation":{"artifactLocation":{"uri":"pkg/b.go"},"region":{"startLine":1}}}]}
+]}]}`)
+	def := LinterDef{Name: "golangci-lint", Format: FormatSARIF}
+	diags, err := def.Parse(data)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if len(diags) != 2 {
+		t.Fatalf("expected 2 diagnostics, got %d", len(diags))
+	}
+	if diags[0].File != "pkg/a.go" || diags[0].Line != 12 || diags[0].Column != 3 || diags[0].Severity != "error" {
+		t.Errorf("first: got %+v", diags[0])
+	}
+	if diags[1].Severity != "warning" || diags[1].Source != "golangci-lint" {
+		t.Errorf("second: got %+v", diags[1])
+	}
+}
+
+func Test_ParseJSONMapping_parent_fields_and_severities(t *testing.T) {
+	data := []byte(`[{"filePath":"/p/a.js","messages":[
+{"ruleId":"no-undef","severity":2,"message":"x is not defined","line":3,"column":1},
+{"ruleId":"semi","severity":1,"message":"Missing semicolon","line":5,"column":9}]}]`)
+	m := JSONMapping{
+		Items:      "[].messages[]",
+		File:       "../filePath",
+		Line:       "line",
+		Column:     "column",
+		Severity:   "severity",
+		Message:    "message",
+		Rule:       "ruleId",
+		Severities: map[string]string{"2": "error", "1": "warning"},
+	}
+	diags, err := ParseJSONMapping(data, m)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if len(diags) != 2 {
+		t.Fatalf("expected 2 diagnostics, got %d", len(diags))
+	}
+	if diags[0].File != "/p/a.js" || diags[0].Severity != "error" || diags[0].Rule != "no-undef" {
+		t.Errorf("first: got %+v", diags[0])
+	}
+	if diags[1].Line != 5 || diags[1].Severity != "warning" {
+		t.Errorf("second: got %+v", diags[1])
+	}
+}
+
+func Test_LinterDef_Matches_globs(t *testing.T) {
+	d := LinterDef{Files: []string{"Dockerfile", "*.Dockerfile", "deploy/**/*.yaml"}}
+	for _, f := range []string{"Dockerfile", "build/Dockerfile", "api.dockerfile", "deploy/a.yaml", "deploy/k8s/b.yaml"} {
+		if !d.Matches(f) {
+			t.Errorf("%s should match", f)
+		}
+	}
+	for _, f := range []string{"Dockerfile.md", "other/a.yaml", ""} {
+		if d.Matches(f) {
+			t.Errorf("%s should not match", f)
+		}
+	}
+}
+
+func Test_findRoot_nearest_marker(t *testing.T) {
+	dir := t.TempDir()
+	mod := filepath.Join(dir, "services", "api")
+	if err := os.MkdirAll(filepath.Join(mod, "internal"), 0755); err != nil {
+		t.Fatal(err)
+	}
+	if err := os.WriteFile(filepath.Join(mod, "go.mod"), []byte("module api\n"), 0644); err != nil {
+		t.Fatal(err)
+	}
+
+	if got := findRoot(filepath.Join(mod, "internal"), dir, []string{"go.mod"}); got != mod {
+		t.Errorf("got %q, want %q", got, mod)
+	}
+	if got := findRoot(filepath.Join(dir, "services"), dir, []string{"go.mod"}); got != dir {
+		t.Errorf("no marker: got %q, want project dir", got)
+	}
+
+	// A sibling sharing the project dir's name as a prefix is outside it.
+	project := filepath.Join(dir, "proj")
+	sibling := filepath.Join(dir, "proj-other")
+	if err := os.MkdirAll(filepath.Join(sibling, "sub"), 0755); err != nil {
+		t.Fatal(err)
+	}
+	if err := os.WriteFile(filepath.Join(sibling, "go.mod"), []byte("module other\n"), 0644); err != nil {
+		t.Fatal(err)
+	}
+	if got := findRoot(filepath.Join(sibling, "sub"), project, []string{"go.mod"}); got != project {
+		t.Errorf("sibling: got %q, want %q", got, project)
+	}
+}
+
+func Test_Registry_Run_per_module_root(t *testing.T) {
+	if _, err := exec.LookPath("sh"); err != nil {
+		t.Skip("sh not available")
+	}
+	dir := t.TempDir()
+	for _, mod := range []string{"svc/a", "svc/b"} {
+		if err := os.MkdirAll(filepath.Join(dir, mod, "pkg"), 0755); err != nil {
+			t.Fatal(err)
+		}
+		writeSource(t, filepath.Join(dir, mod), "mod.marker", "")
+	}
+
+	reg := &Registry{Concurrency: 2, Linters: []LinterDef{{
+		Name:        "where",
+		Files:       []string{"*.txt"},
+		Command:     `sh -c 'for f in "$@"; do echo "$f:1:1: linted in $(basename "$PWD")"; done' sh {files}`,
+		WorkDir:     WorkDirRoot,
+		RootMarkers: []string{"mod.marker"},
+		Format:      FormatRegex,
+		Pattern:     `^(?P<file>[^:]+):(?P<line>\d+):(?P<column>\d+): (?P<message>.+)$`,
+	}}}
+
+	files := []string{"svc/a/x.txt", "svc/a/pkg/y.txt", "svc/b/z.txt", "top.txt"}
+	diags := reg.Run(files, dir)
+	if len(diags) != 4 {
+		t.Fatalf("expected 4 diagnostics, got %+v", diags)
+	}
+	got := make(map[string]string)
+	for _, d := range diags {
+		got[d.File] = d.Message
+	}
+	want := map[string]string{
+		"svc/a/x.txt":     "linted in a",
+		"svc/a/pkg/y.txt": "linted in a",
+		"svc/b/z.txt":     "linted in b",
+		"top.txt":         "linted in " + filepath.Base(dir),
+	}
+	for f, msg := range want {
+		if got[f] != msg {
+			t.Errorf("%s: got %q, want %q", f, got[f], msg)
+		}
+	}
+}
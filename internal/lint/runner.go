@@ -1,8 +1,9 @@
 package lint
 
 import (
+	"bytes"
 	"encoding/json"
-	"os/exec"
+	"fmt"
 	"regexp"
 	"strconv"
 	"strings"
@@ -10,47 +11,53 @@ import (
 
 // Diagnostic represents a single lint finding.
 type Diagnostic struct {
-	File     string `json:"file"`
-	Line     int    `json:"line"`
-	Column   int    `json:"column"`
-	Severity string `json:"severity"` // "error" or "warning"
-	Message  string `json:"message"`
-	Rule     string `json:"rule"`
-	Source   string `json:"source"` // linter name
+	File      string `json:"file"`
+	Line      int    `json:"line"`
+	Column    int    `json:"column"`
+	EndLine   int    `json:"end_line,omitempty"`
+	EndColumn int    `json:"end_column,omitempty"`
+	Severity  string `json:"severity"` // "error" or "warning"
+	Message   string `json:"message"`
+	Rule      string `json:"rule"`
+	Source    string `json:"source"` // linter name
+	HelpURL   string `json:"help_url,omitempty"`
+	Fixes     []Fix  `json:"fixes,omitempty"`
 }
 
-// RunLinter dispatches to the appropriate linter for the given language.
-func RunLinter(lang Language, files []string, projectDir string) []Diagnostic {
-	switch lang {
-	case LangGo:
-		return RunGoVet(files, projectDir)
-	case LangPython:
-		return RunRuff(files, projectDir)
-	case LangTypeScript:
-		return RunTsc(files, projectDir)
-	case LangJavaScript:
-		return RunESLint(files, projectDir)
-	case LangRust:
-		return RunCargoClippy(projectDir)
-	default:
-		return nil
-	}
+// Fix is a suggested change that resolves a diagnostic.
+type Fix struct {
+	Message string     `json:"message,omitempty"`
+	Edits   []TextEdit `json:"edits,omitempty"`
 }
 
-// RunGoVet runs `go vet ./...` and parses the output.
-func RunGoVet(files []string, projectDir string) []Diagnostic {
-	cmd := exec.Command("go", "vet", "./...")
-	cmd.Dir = projectDir
-	out, _ := cmd.CombinedOutput()
-	return ParseGoVetOutput(string(out))
+// TextEdit replaces the text between two 1-based positions with NewText.
+type TextEdit struct {
+	Line      int    `json:"line"`
+	Column    int    `json:"column"`
+	EndLine   int    `json:"end_line"`
+	EndColumn int    `json:"end_column"`
+	NewText   string `json:"new_text"`
+}
+
+// RunLinter runs the built-in linters registered for the given language.
+func RunLinter(lang Language, files []string, projectDir string) []Diagnostic {
+	var diags []Diagnostic
+	for _, d := range builtinRegistry().Only(KindLint).Enabled() {
+		if d.Language == lang {
+			diags = append(diags, d.Run(files, projectDir)...)
+		}
+	}
+	return diags
 }
 
 // goVetPattern matches: file.go:line:column: message
 var goVetPattern = regexp.MustCompile(`^\.?/?([^:]+\.go):(\d+):(\d+):\s*(.+)$`)
 
-// ParseGoVetOutput parses go vet stderr output.
-func ParseGoVetOutput(output string) []Diagnostic {
+// ParseGoVetOutput parses go vet stderr output. Output without a single
+// diagnostic line (a build or module error) is an error.
+func ParseGoVetOutput(output string) ([]Diagnostic, error) {
 	var diags []Diagnostic
+	var unknown string
 	for _, line := range strings.Split(output, "\n") {
 		line = strings.TrimSpace(line)
 		if line == "" || strings.HasPrefix(line, "#") {
@@ -58,6 +65,9 @@ func ParseGoVetOutput(output string) []Diagnostic {
 		}
 		matches := goVetPattern.FindStringSubmatch(line)
 		if len(matches) != 5 {
+			if unknown == "" {
+				unknown = line
+			}
 			continue
 		}
 		lineNum, err := strconv.Atoi(matches[2])
@@ -77,37 +87,48 @@ func ParseGoVetOutput(output string) []Diagnostic {
 			Source:   "go vet",
 		})
 	}
-	return diags
+	return diags, unparsed("go vet", diags, unknown)
 }
 
-// RunRuff runs `ruff check --output-format=json` and parses the JSON output.
-func RunRuff(files []string, projectDir string) []Diagnostic {
-	args := []string{"check", "--output-format=json"}
-	args = append(args, files...)
-	cmd := exec.Command("ruff", args...)
-	cmd.Dir = projectDir
-	out, _ := cmd.Output()
-	return ParseRuffJSON(out)
+// unparsed reports output that a text parser could not read at all: a
+// tool that printed something but no diagnostic crashed or failed to
+// start, and must not be taken for a clean run.
+func unparsed(tool string, diags []Diagnostic, unknown string) error {
+	if len(diags) > 0 || unknown == "" {
+		return nil
+	}
+	return fmt.Errorf("unrecognized %s output: %s", tool, unknown)
 }
 
 // ParseRuffJSON parses ruff JSON output.
-func ParseRuffJSON(data []byte) []Diagnostic {
-	if len(data) == 0 {
-		return nil
+func ParseRuffJSON(data []byte) ([]Diagnostic, error) {
+	if len(bytes.TrimSpace(data)) == 0 {
+		return nil, nil
 	}
 
+	type ruffLocation struct {
+		Row    int `json:"row"`
+		Column int `json:"column"`
+	}
 	var ruffDiags []struct {
-		Code     string `json:"code"`
-		Message  string `json:"message"`
-		Location struct {
-			Row    int `json:"row"`
-			Column int `json:"column"`
-		} `json:"location"`
-		Filename string `json:"filename"`
+		Code        string       `json:"code"`
+		Message     string       `json:"message"`
+		Location    ruffLocation `json:"location"`
+		EndLocation ruffLocation `json:"end_location"`
+		Filename    string       `json:"filename"`
+		URL         string       `json:"url"`
+		Fix         *struct {
+			Message string `json:"message"`
+			Edits   []struct {
+				Content     string       `json:"content"`
+				Location    ruffLocation `json:"location"`
+				EndLocation ruffLocation `json:"end_location"`
+			} `json:"edits"`
+		} `json:"fix"`
 	}
 
 	if err := json.Unmarshal(data, &ruffDiags); err != nil {
-		return nil
+		return nil, fmt.Errorf("parse ruff output: %w", err)
 	}
 
 	var diags []Diagnostic
@@ -116,34 +137,44 @@ func ParseRuffJSON(data []byte) []Diagnostic {
 		if strings.HasPrefix(d.Code, "E") || strings.HasPrefix(d.Code, "F") {
 			severity = "error"
 		}
-		diags = append(diags, Diagnostic{
-			File:     d.Filename,
-			Line:     d.Location.Row,
-			Column:   d.Location.Column,
-			Severity: severity,
-			Message:  d.Message,
-			Rule:     d.Code,
-			Source:   "ruff",
-		})
+		diag := Diagnostic{
+			File:      d.Filename,
+			Line:      d.Location.Row,
+			Column:    d.Location.Column,
+			EndLine:   d.EndLocation.Row,
+			EndColumn: d.EndLocation.Column,
+			Severity:  severity,
+			Message:   d.Message,
+			Rule:      d.Code,
+			Source:    "ruff",
+			HelpURL:   d.URL,
+		}
+		if d.Fix != nil {
+			fix := Fix{Message: d.Fix.Message}
+			for _, e := range d.Fix.Edits {
+				fix.Edits = append(fix.Edits, TextEdit{
+					Line:      e.Location.Row,
+					Column:    e.Location.Column,
+					EndLine:   e.EndLocation.Row,
+					EndColumn: e.EndLocation.Column,
+					NewText:   e.Content,
+				})
+			}
+			diag.Fixes = []Fix{fix}
+		}
+		diags = append(diags, diag)
 	}
-	return diags
-}
-
-// RunTsc runs `tsc --noEmit --pretty false` and parses the output.
-func RunTsc(files []string, projectDir string) []Diagnostic {
-	args := []string{"--noEmit", "--pretty", "false"}
-	cmd := exec.Command("tsc", args...)
-	cmd.Dir = projectDir
-	out, _ := cmd.CombinedOutput()
-	return ParseTscOutput(string(out))
+	return diags, nil
 }
 
 // tscPattern matches: file.ts(line,column): error TS1234: message
 var tscPattern = regexp.MustCompile(`^([^(]+)\((\d+),(\d+)\):\s*(error|warning)\s+(TS\d+):\s*(.+)$`)
 
-// ParseTscOutput parses tsc text output.
-func ParseTscOutput(output string) []Diagnostic {
+// ParseTscOutput parses tsc text output. Output without a single
+// diagnostic line is an error.
+func ParseTscOutput(output string) ([]Diagnostic, error) {
 	var diags []Diagnostic
+	var unknown string
 	for _, line := range strings.Split(output, "\n") {
 		line = strings.TrimSpace(line)
 		if line == "" {
@@ -151,6 +182,9 @@ func ParseTscOutput(output string) []Diagnostic {
 		}
 		matches := tscPattern.FindStringSubmatch(line)
 		if len(matches) != 7 {
+			if unknown == "" {
+				unknown = line
+			}
 			continue
 		}
 		lineNum, err := strconv.Atoi(matches[2])
@@ -171,38 +205,30 @@ func ParseTscOutput(output string) []Diagnostic {
 			Source:   "tsc",
 		})
 	}
-	return diags
-}
-
-// RunESLint runs `eslint --format json` and parses the JSON output.
-func RunESLint(files []string, projectDir string) []Diagnostic {
-	args := []string{"--format", "json"}
-	args = append(args, files...)
-	cmd := exec.Command("eslint", args...)
-	cmd.Dir = projectDir
-	out, _ := cmd.Output()
-	return ParseESLintJSON(out)
+	return diags, unparsed("tsc", diags, unknown)
 }
 
 // ParseESLintJSON parses eslint JSON output.
-func ParseESLintJSON(data []byte) []Diagnostic {
-	if len(data) == 0 {
-		return nil
+func ParseESLintJSON(data []byte) ([]Diagnostic, error) {
+	if len(bytes.TrimSpace(data)) == 0 {
+		return nil, nil
 	}
 
 	var eslintResults []struct {
 		FilePath string `json:"filePath"`
 		Messages []struct {
-			RuleID   string `json:"ruleId"`
-			Severity int    `json:"severity"`
-			Message  string `json:"message"`
-			Line     int    `json:"line"`
-			Column   int    `json:"column"`
+			RuleID    string `json:"ruleId"`
+			Severity  int    `json:"severity"`
+			Message   string `json:"message"`
+			Line      int    `json:"line"`
+			Column    int    `json:"column"`
+			EndLine   int    `json:"endLine"`
+			EndColumn int    `json:"endColumn"`
 		} `json:"messages"`
 	}
 
 	if err := json.Unmarshal(data, &eslintResults); err != nil {
-		return nil
+		return nil, fmt.Errorf("parse eslint output: %w", err)
 	}
 
 	var diags []Diagnostic
@@ -213,34 +239,28 @@ func ParseESLintJSON(data []byte) []Diagnostic {
 				severity = "error"
 			}
 			diags = append(diags, Diagnostic{
-				File:     file.FilePath,
-				Line:     msg.Line,
-				Column:   msg.Column,
-				Severity: severity,
-				Message:  msg.Message,
-				Rule:     msg.RuleID,
-				Source:   "eslint",
+				File:      file.FilePath,
+				Line:      msg.Line,
+				Column:    msg.Column,
+				EndLine:   msg.EndLine,
+				EndColumn: msg.EndColumn,
+				Severity:  severity,
+				Message:   msg.Message,
+				Rule:      msg.RuleID,
+				Source:    "eslint",
 			})
 		}
 	}
-	return diags
-}
-
-// RunCargoClippy runs `cargo clippy --message-format=json` and parses the output.
-func RunCargoClippy(projectDir string) []Diagnostic {
-	cmd := exec.Command("cargo", "clippy", "--message-format=json")
-	cmd.Dir = projectDir
-	out, _ := cmd.Output()
-	return ParseClippyJSON(out)
+	return diags, nil
 }
 
 // ParseClippyJSON parses cargo clippy JSON output (one JSON per line).
-func ParseClippyJSON(data []byte) []Diagnostic {
-	if len(data) == 0 {
-		return nil
-	}
-
+// Output without a single JSON line (cargo failed before building) is an
+// error.
+func ParseClippyJSON(data []byte) ([]Diagnostic, error) {
 	var diags []Diagnostic
+	var unknown string
+	parsedAny := false
 	for _, line := range strings.Split(string(data), "\n") {
 		line = strings.TrimSpace(line)
 		if line == "" {
@@ -253,19 +273,23 @@ func ParseClippyJSON(data []byte) []Diagnostic {
 				Code *struct {
 					Code string `json:"code"`
 				} `json:"code"`
-				Level   string `json:"level"`
-				Message string `json:"message"`
-				Spans   []struct {
-					FileName    string `json:"file_name"`
-					LineStart   int    `json:"line_start"`
-					ColumnStart int    `json:"column_start"`
-				} `json:"spans"`
+				Level    string       `json:"level"`
+				Message  string       `json:"message"`
+				Spans    []clippySpan `json:"spans"`
+				Children []struct {
+					Message string       `json:"message"`
+					Spans   []clippySpan `json:"spans"`
+				} `json:"children"`
 			} `json:"message"`
 		}
 
 		if err := json.Unmarshal([]byte(line), &msg); err != nil {
+			if unknown == "" {
+				unknown = line
+			}
 			continue
 		}
+		parsedAny = true
 		if msg.Reason != "compiler-message" || msg.Message == nil {
 			continue
 		}
@@ -280,18 +304,66 @@ func ParseClippyJSON(data []byte) []Diagnostic {
 			rule = msg.Message.Code.Code
 		}
 
+		var fixes []Fix
+		for _, child := range msg.Message.Children {
+			fix := Fix{Message: child.Message}
+			for _, span := range child.Spans {
+				if span.SuggestedReplacement == nil {
+					continue
+				}
+				fix.Edits = append(fix.Edits, TextEdit{
+					Line:      span.LineStart,
+					Column:    span.ColumnStart,
+					EndLine:   span.LineEnd,
+					EndColumn: span.ColumnEnd,
+					NewText:   *span.SuggestedReplacement,
+				})
+			}
+			if len(fix.Edits) > 0 {
+				fixes = append(fixes, fix)
+			}
+		}
+
 		for _, span := range msg.Message.Spans {
 			diags = append(diags, Diagnostic{
-				File:     span.FileName,
-				Line:     span.LineStart,
-				Column:   span.ColumnStart,
-				Severity: severity,
-				Message:  msg.Message.Message,
-				Rule:     rule,
-				Source:   "clippy",
+				File:      span.FileName,
+				Line:      span.LineStart,
+				Column:    span.ColumnStart,
+				EndLine:   span.LineEnd,
+				EndColumn: span.ColumnEnd,
+				Severity:  severity,
+				Message:   msg.Message.Message,
+				Rule:      rule,
+				Source:    "clippy",
+				HelpURL:   clippyHelpURL(rule),
+				Fixes:     fixes,
 			})
 			break // Only use first span
 		}
 	}
-	return diags
+	if parsedAny {
+		return diags, nil
+	}
+	return diags, unparsed("cargo clippy", diags, unknown)
+}
+
+// clippySpan is a source span in cargo's JSON diagnostics.
+type clippySpan struct {
+	FileName             string  `json:"file_name"`
+	LineStart            int     `json:"line_start"`
+	LineEnd              int     `json:"line_end"`
+	ColumnStart          int     `json:"column_start"`
+	ColumnEnd            int     `json:"column_end"`
+	SuggestedReplacement *string `json:"suggested_replacement"`
+}
+
+// clippyHelpURL returns the documentation page for a clippy lint or rustc error code.
+func clippyHelpURL(code string) string {
+	switch {
+	case strings.HasPrefix(code, "clippy::"):
+		return "https://rust-lang.github.io/rust-clippy/master/index.html#" + strings.TrimPrefix(code, "clippy::")
+	case len(code) == 5 && code[0] == 'E':
+		return "https://doc.rust-lang.org/error_codes/" + code + ".html"
+	}
+	return ""
 }
@@ -120,8 +120,8 @@ func Test_RunLinter_unknown_returns_nil(t *testing.T) {
 
 func Test_ParseGoVetOutput_valid(t *testing.T) {
 	output := "main.go:10:5: unreachable code\nutils.go:20:1: unused variable\n"
-	diags := ParseGoVetOutput(output)
-	if len(diags) != 2 {
+	diags, err := ParseGoVetOutput(output)
+	if err != nil || len(diags) != 2 {
 		t.Fatalf("expected 2 diagnostics, got %d", len(diags))
 	}
 	if diags[0].File != "main.go" {
@@ -136,8 +136,23 @@ func Test_ParseGoVetOutput_valid(t *testing.T) {
 }
 
 func Test_ParseGoVetOutput_empty(t *testing.T) {
-	diags := ParseGoVetOutput("")
-	if len(diags) != 0 {
-		t.Errorf("expected 0 diagnostics for empty output, got %d", len(diags))
+	diags, err := ParseGoVetOutput("")
+	if err != nil || len(diags) != 0 {
+		t.Errorf("expected 0 diagnostics for empty output, got %d (%v)", len(diags), err)
+	}
+}
+
+func Test_builtin_parsers_reject_unrecognized_output(t *testing.T) {
+	garbage := []byte("Error: Cannot find module 'eslint-plugin-foo'\n    at require (node:internal)\n")
+	for name, parse := range builtinParsers {
+		if name == "diff" || name == "filelist" {
+			continue
+		}
+		if diags, err := parse(garbage); err == nil {
+			t.Errorf("%s: garbage parsed as %d diagnostics without error", name, len(diags))
+		}
+		if _, err := parse(nil); err != nil {
+			t.Errorf("%s: empty output: %v", name, err)
+		}
 	}
 }
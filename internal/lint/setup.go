@@ -18,12 +18,15 @@ type PackageManager struct {
 type LinterInstallInfo struct {
 	DisplayName    string
 	Language       Language
+	Languages      []Language // further languages the tool serves
+	Command        string     // binary to look up; empty means the language's linter
 	InstallMethods map[string][]string
 	DownloadURL    string
-	BuiltIn        bool // true for go vet (no install needed)
+	BuiltIn        bool // true for go vet and gofmt (no install needed)
 }
 
-// AllLinterInstallInfo returns installation info for all supported linters.
+// AllLinterInstallInfo returns installation info for all supported linters
+// and formatters. ruff covers both linting and formatting.
 func AllLinterInstallInfo() []LinterInstallInfo {
 	return []LinterInstallInfo{
 		{
@@ -67,7 +70,62 @@ func AllLinterInstallInfo() []LinterInstallInfo {
 			},
 			DownloadURL: "https://www.rust-lang.org/tools/install",
 		},
+		{
+			DisplayName: "gofmt (formatter)",
+			Language:    LangGo,
+			Command:     "gofmt",
+			BuiltIn:     true,
+		},
+		{
+			DisplayName: "prettier (formatter)",
+			Language:    LangJavaScript,
+			Languages:   []Language{LangTypeScript},
+			Command:     "prettier",
+			InstallMethods: map[string][]string{
+				"npm":  {"npm", "install", "-g", "prettier"},
+				"brew": {"brew", "install", "prettier"},
+			},
+			DownloadURL: "https://prettier.io/docs/en/install",
+		},
+		{
+			DisplayName: "rustfmt (formatter)",
+			Language:    LangRust,
+			Command:     "rustfmt",
+			InstallMethods: map[string][]string{
+				"rustup": {"rustup", "component", "add", "rustfmt"},
+			},
+			DownloadURL: "https://github.com/rust-lang/rustfmt",
+		},
+	}
+}
+
+// serves reports whether the tool handles any of langs.
+func (info LinterInstallInfo) serves(langs []Language) bool {
+	for _, lang := range langs {
+		if info.Language == lang {
+			return true
+		}
+		for _, l := range info.Languages {
+			if l == lang {
+				return true
+			}
+		}
 	}
+	return false
+}
+
+// installed reports whether the tool's binary is in the project's
+// .do/tools/bin or on PATH.
+func (info LinterInstallInfo) installed(projectDir string) bool {
+	bin := info.Command
+	if bin == "" {
+		l, ok := LinterForLanguage(info.Language)
+		if !ok {
+			return false
+		}
+		bin = l.Command
+	}
+	return lookTool(projectDir, bin)
 }
 
 // DetectPackageManagers scans the system for available package managers.
@@ -103,7 +161,7 @@ func ScanProjectLanguages(projectDir string) []Language {
 		}
 		name := info.Name()
 		if info.IsDir() {
-			if strings.HasPrefix(name, ".") || name == "node_modules" || name == "vendor" || name == "__pycache__" || name == "target" {
+			if skipDir(name) {
 				return filepath.SkipDir
 			}
 			return nil
@@ -128,27 +186,21 @@ type SetupStatus struct {
 	Missing   []LinterInstallInfo
 }
 
-// CheckSetupStatus checks which linters are installed and which are missing for the given languages.
-func CheckSetupStatus(langs []Language) SetupStatus {
+// CheckSetupStatus checks which linters are installed, project-locally or
+// globally, and which are missing for the given languages.
+func CheckSetupStatus(projectDir string, langs []Language) SetupStatus {
 	allInfo := AllLinterInstallInfo()
 	var status SetupStatus
 
 	for _, info := range allInfo {
-		needed := false
-		for _, lang := range langs {
-			if info.Language == lang {
-				needed = true
-				break
-			}
-		}
-		if !needed {
+		if !info.serves(langs) {
 			continue
 		}
 		if info.BuiltIn {
 			status.Installed = append(status.Installed, info.DisplayName+" (built-in)")
 			continue
 		}
-		if CheckLinterInstalled(info.Language) {
+		if info.installed(projectDir) {
 			status.Installed = append(status.Installed, info.DisplayName)
 			continue
 		}
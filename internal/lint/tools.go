@@ -0,0 +1,486 @@
+package lint
+
+import (
+	"archive/tar"
+	"archive/zip"
+	"bytes"
+	"compress/gzip"
+	"crypto/sha256"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"os"
+	"os/exec"
+	"path"
+	"path/filepath"
+	"runtime"
+	"strings"
+	"time"
+
+	"gopkg.in/yaml.v3"
+)
+
+// ToolsLockFile pins project-local linter versions, relative to the project root.
+const ToolsLockFile = ".do/tools.lock"
+
+// ToolsDir holds project-local linter installs; ToolsBinDir holds the
+// executables linters are run from.
+const (
+	ToolsDir    = ".do/tools"
+	ToolsBinDir = ".do/tools/bin"
+)
+
+// Install methods for ToolPin.Method.
+const (
+	MethodPip     = "pip"     // pip install --target
+	MethodNpm     = "npm"     // npm install --prefix
+	MethodGo      = "go"      // go install with GOBIN
+	MethodArchive = "archive" // download a release archive or binary
+)
+
+// ToolLock is the set of pinned project-local tools.
+type ToolLock struct {
+	Mirror string    `yaml:"mirror,omitempty"` // default mirror directory, relative to the project
+	Tools  []ToolPin `yaml:"tools"`
+}
+
+// ToolPin pins one tool to an exact version.
+//
+// Package is the pip or npm package, the Go module path, or for archives
+// a download URL where {version}, {os} and {arch} are substituted. It
+// defaults to Name. Bins are the executables linked into .do/tools/bin,
+// defaulting to Name. An archive is only installed when it matches SHA256.
+type ToolPin struct {
+	Name     string   `yaml:"name"`
+	Version  string   `yaml:"version"`
+	Method   string   `yaml:"method"`
+	Package  string   `yaml:"package,omitempty"`
+	Bins     []string `yaml:"bins,omitempty"`
+	SHA256   string   `yaml:"sha256,omitempty"` // archive checksum
+	Language Language `yaml:"-"`
+}
+
+// DefaultToolPins returns the pins written to a new lock file. go vet,
+// gofmt, clippy and rustfmt come with their toolchains and are not pinned.
+func DefaultToolPins() []ToolPin {
+	return []ToolPin{
+		{Name: "ruff", Version: "0.6.9", Method: MethodPip, Language: LangPython},
+		{Name: "typescript", Version: "5.6.3", Method: MethodNpm, Bins: []string{"tsc"}, Language: LangTypeScript},
+		{Name: "eslint", Version: "9.12.0", Method: MethodNpm, Language: LangJavaScript},
+		{Name: "prettier", Version: "3.3.3", Method: MethodNpm, Language: LangJavaScript},
+	}
+}
+
+// NewToolLock pins the default tools for the given languages.
+func NewToolLock(langs []Language) *ToolLock {
+	lock := &ToolLock{}
+	for _, pin := range DefaultToolPins() {
+		for _, lang := range langs {
+			if pin.Language == lang || pin.Name == "prettier" && lang == LangTypeScript {
+				lock.Tools = append(lock.Tools, pin)
+				break
+			}
+		}
+	}
+	return lock
+}
+
+// LoadToolLock reads {projectDir}/.do/tools.lock. The returned error wraps
+// os.ErrNotExist when there is no lock file.
+func LoadToolLock(projectDir string) (*ToolLock, error) {
+	p := filepath.Join(projectDir, ToolsLockFile)
+	data, err := os.ReadFile(p)
+	if err != nil {
+		return nil, fmt.Errorf("read tools lock: %w", err)
+	}
+	var lock ToolLock
+	if err := yaml.Unmarshal(data, &lock); err != nil {
+		return nil, fmt.Errorf("parse tools lock %s: %w", p, err)
+	}
+	for _, pin := range lock.Tools {
+		if err := pin.validate(); err != nil {
+			return nil, fmt.Errorf("tools lock %s: %q: %w", p, pin.Name, err)
+		}
+	}
+	return &lock, nil
+}
+
+// Save writes the lock file atomically.
+func (l *ToolLock) Save(projectDir string) error {
+	p := filepath.Join(projectDir, ToolsLockFile)
+	if err := os.MkdirAll(filepath.Dir(p), 0755); err != nil {
+		return fmt.Errorf("create tools lock dir: %w", err)
+	}
+	data, err := yaml.Marshal(l)
+	if err != nil {
+		return fmt.Errorf("marshal tools lock: %w", err)
+	}
+	tmp := p + ".tmp"
+	if err := os.WriteFile(tmp, data, 0644); err != nil {
+		return fmt.Errorf("write tools lock: %w", err)
+	}
+	if err := os.Rename(tmp, p); err != nil {
+		os.Remove(tmp)
+		return fmt.Errorf("rename tools lock: %w", err)
+	}
+	return nil
+}
+
+func (p ToolPin) validate() error {
+	if p.Name == "" || p.Version == "" {
+		return fmt.Errorf("name and version are required")
+	}
+	switch p.Method {
+	case MethodPip, MethodNpm, MethodGo:
+	case MethodArchive:
+		if p.Package == "" {
+			return fmt.Errorf("archive method requires a package URL")
+		}
+		if p.SHA256 == "" {
+			return fmt.Errorf("archive method requires a sha256 checksum")
+		}
+	default:
+		return fmt.Errorf("unknown method %q", p.Method)
+	}
+	return nil
+}
+
+func (p ToolPin) pkg() string {
+	if p.Package != "" {
+		return p.Package
+	}
+	return p.Name
+}
+
+func (p ToolPin) bins() []string {
+	if len(p.Bins) > 0 {
+		return p.Bins
+	}
+	return []string{p.Name}
+}
+
+// dir is where the pinned version is installed.
+func (p ToolPin) dir(projectDir string) string {
+	return filepath.Join(projectDir, ToolsDir, "pkgs", p.Name+"@"+p.Version)
+}
+
+// Installed reports whether this exact version is installed and linked.
+func (p ToolPin) Installed(projectDir string) bool {
+	if _, err := os.Stat(filepath.Join(p.dir(projectDir), ".installed")); err != nil {
+		return false
+	}
+	for _, bin := range p.bins() {
+		if _, ok := localTool(projectDir, bin); !ok {
+			return false
+		}
+	}
+	return true
+}
+
+// ToolPath returns the project-local executable for bin when one is
+// installed in .do/tools/bin, else bin unchanged so PATH is searched.
+func ToolPath(projectDir, bin string) string {
+	if p, ok := localTool(projectDir, bin); ok {
+		return p
+	}
+	return bin
+}
+
+// lookTool reports whether bin is installed locally or on PATH.
+func lookTool(projectDir, bin string) bool {
+	if _, ok := localTool(projectDir, bin); ok {
+		return true
+	}
+	_, err := exec.LookPath(bin)
+	return err == nil
+}
+
+func localTool(projectDir, bin string) (string, bool) {
+	if projectDir == "" || bin == "" || filepath.Base(bin) != bin {
+		return "", false
+	}
+	p := filepath.Join(projectDir, ToolsBinDir, bin)
+	if runtime.GOOS == "windows" {
+		p += ".exe"
+	}
+	info, err := os.Stat(p)
+	if err != nil || info.IsDir() {
+		return "", false
+	}
+	return p, true
+}
+
+// InstallOptions controls InstallTool.
+type InstallOptions struct {
+	// Mirror is a local directory to install from without network access:
+	// pip/ holds wheels (pip --find-links), npm/ is an npm cache (npm cache
+	// add), go/ is a GOPROXY file tree, and archive/ holds downloaded
+	// archives by file name.
+	Mirror string
+	Stdout io.Writer
+	Stderr io.Writer
+}
+
+// InstallTool installs the pinned version into .do/tools and links its
+// executables into .do/tools/bin. An already installed version is kept.
+func InstallTool(projectDir string, pin ToolPin, opts InstallOptions) error {
+	if err := pin.validate(); err != nil {
+		return fmt.Errorf("install %s: %w", pin.Name, err)
+	}
+	if pin.Installed(projectDir) {
+		return nil
+	}
+	if opts.Stdout == nil {
+		opts.Stdout = io.Discard
+	}
+	if opts.Stderr == nil {
+		opts.Stderr = io.Discard
+	}
+	if opts.Mirror != "" && !filepath.IsAbs(opts.Mirror) {
+		opts.Mirror = filepath.Join(projectDir, opts.Mirror)
+	}
+
+	dir := pin.dir(projectDir)
+	if err := os.RemoveAll(dir); err != nil {
+		return fmt.Errorf("clean %s: %w", dir, err)
+	}
+	if err := os.MkdirAll(dir, 0755); err != nil {
+		return fmt.Errorf("create %s: %w", dir, err)
+	}
+	ignoreToolsInGit(projectDir)
+
+	var err error
+	switch pin.Method {
+	case MethodPip:
+		err = installPip(pin, dir, opts)
+	case MethodNpm:
+		err = installNpm(pin, dir, opts)
+	case MethodGo:
+		err = installGo(pin, dir, opts)
+	case MethodArchive:
+		err = installArchive(pin, dir, opts)
+	}
+	if err != nil {
+		os.RemoveAll(dir)
+		return fmt.Errorf("install %s@%s: %w", pin.Name, pin.Version, err)
+	}
+
+	binDir := filepath.Join(projectDir, ToolsBinDir)
+	if err := os.MkdirAll(binDir, 0755); err != nil {
+		return fmt.Errorf("create %s: %w", binDir, err)
+	}
+	for _, bin := range pin.bins() {
+		if err := linkTool(pin, dir, binDir, bin); err != nil {
+			return fmt.Errorf("install %s@%s: %w", pin.Name, pin.Version, err)
+		}
+	}
+	return os.WriteFile(filepath.Join(dir, ".installed"), []byte(time.Now().UTC().Format(time.RFC3339)+"\n"), 0644)
+}
+
+func installPip(pin ToolPin, dir string, opts InstallOptions) error {
+	pip := "pip3"
+	if _, err := exec.LookPath(pip); err != nil {
+		pip = "pip"
+	}
+	args := []string{pip, "install", "--disable-pip-version-check", "--target", dir}
+	if opts.Mirror != "" {
+		args = append(args, "--no-index", "--find-links", filepath.Join(opts.Mirror, "pip"))
+	}
+	args = append(args, pin.pkg()+"=="+pin.Version)
+	return runInstallCmd(args, "", nil, opts)
+}
+
+func installNpm(pin ToolPin, dir string, opts InstallOptions) error {
+	args := []string{"npm", "install", "--prefix", dir, "--no-audit", "--no-fund", "--no-save"}
+	if opts.Mirror != "" {
+		args = append(args, "--offline", "--cache", filepath.Join(opts.Mirror, "npm"))
+	}
+	args = append(args, pin.pkg()+"@"+pin.Version)
+	return runInstallCmd(args, "", nil, opts)
+}
+
+func installGo(pin ToolPin, dir string, opts InstallOptions) error {
+	env := []string{"GOBIN=" + filepath.Join(dir, "bin"), "GOFLAGS=-mod=mod"}
+	if opts.Mirror != "" {
+		env = append(env, "GOPROXY=file://"+filepath.ToSlash(filepath.Join(opts.Mirror, "go")), "GOSUMDB=off")
+	}
+	return runInstallCmd([]string{"go", "install", pin.pkg() + "@" + pin.Version}, "", env, opts)
+}
+
+func runInstallCmd(args []string, dir string, env []string, opts InstallOptions) error {
+	fmt.Fprintf(opts.Stdout, "  Running: %s\n", strings.Join(args, " "))
+	cmd := exec.Command(args[0], args[1:]...)
+	cmd.Dir = dir
+	cmd.Env = append(os.Environ(), env...)
+	cmd.Stdout = opts.Stdout
+	cmd.Stderr = opts.Stderr
+	return cmd.Run()
+}
+
+// installArchive fetches the archive (from the mirror when set), checks
+// its checksum and unpacks the executables into dir/bin.
+func installArchive(pin ToolPin, dir string, opts InstallOptions) error {
+	url := strings.NewReplacer("{version}", pin.Version, "{os}", runtime.GOOS, "{arch}", runtime.GOARCH).Replace(pin.Package)
+	name := path.Base(url)
+
+	var data []byte
+	var err error
+	if opts.Mirror != "" {
+		src := filepath.Join(opts.Mirror, "archive", name)
+		fmt.Fprintf(opts.Stdout, "  Using: %s\n", src)
+		data, err = os.ReadFile(src)
+	} else {
+		fmt.Fprintf(opts.Stdout, "  Downloading: %s\n", url)
+		data, err = download(url)
+	}
+	if err != nil {
+		return err
+	}
+	sum := sha256.Sum256(data)
+	if got := hex.EncodeToString(sum[:]); pin.SHA256 == "" || !strings.EqualFold(got, pin.SHA256) {
+		return fmt.Errorf("checksum mismatch for %s: got %s, want %s", name, got, pin.SHA256)
+	}
+
+	binDir := filepath.Join(dir, "bin")
+	if err := os.MkdirAll(binDir, 0755); err != nil {
+		return err
+	}
+	wanted := make(map[string]bool)
+	for _, b := range pin.bins() {
+		wanted[b] = true
+		wanted[b+".exe"] = true
+	}
+	switch {
+	case strings.HasSuffix(name, ".tar.gz"), strings.HasSuffix(name, ".tgz"):
+		return extractTarGz(data, binDir, wanted)
+	case strings.HasSuffix(name, ".zip"):
+		return extractZip(data, binDir, wanted)
+	default:
+		return os.WriteFile(filepath.Join(binDir, pin.bins()[0]), data, 0755)
+	}
+}
+
+func download(url string) ([]byte, error) {
+	client := &http.Client{Timeout: 5 * time.Minute}
+	resp, err := client.Get(url)
+	if err != nil {
+		return nil, fmt.Errorf("download %s: %w", url, err)
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode != http.StatusOK {
+		return nil, fmt.Errorf("download %s: %s", url, resp.Status)
+	}
+	return io.ReadAll(resp.Body)
+}
+
+func extractTarGz(data []byte, binDir string, wanted map[string]bool) error {
+	gz, err := gzip.NewReader(bytes.NewReader(data))
+	if err != nil {
+		return fmt.Errorf("read archive: %w", err)
+	}
+	tr := tar.NewReader(gz)
+	for {
+		h, err := tr.Next()
+		if errors.Is(err, io.EOF) {
+			return nil
+		}
+		if err != nil {
+			return fmt.Errorf("read archive: %w", err)
+		}
+		base := path.Base(h.Name)
+		if h.Typeflag != tar.TypeReg || !wanted[base] {
+			continue
+		}
+		if err := writeExecutable(filepath.Join(binDir, base), tr); err != nil {
+			return err
+		}
+	}
+}
+
+func extractZip(data []byte, binDir string, wanted map[string]bool) error {
+	zr, err := zip.NewReader(bytes.NewReader(data), int64(len(data)))
+	if err != nil {
+		return fmt.Errorf("read archive: %w", err)
+	}
+	for _, f := range zr.File {
+		base := path.Base(f.Name)
+		if f.FileInfo().IsDir() || !wanted[base] {
+			continue
+		}
+		rc, err := f.Open()
+		if err != nil {
+			return fmt.Errorf("read archive: %w", err)
+		}
+		err = writeExecutable(filepath.Join(binDir, base), rc)
+		rc.Close()
+		if err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func writeExecutable(p string, r io.Reader) error {
+	f, err := os.OpenFile(p, os.O_CREATE|os.O_WRONLY|os.O_TRUNC, 0755)
+	if err != nil {
+		return err
+	}
+	if _, err := io.Copy(f, r); err != nil {
+		f.Close()
+		return err
+	}
+	return f.Close()
+}
+
+// linkTool makes bin callable from binDir. pip installs get a wrapper that
+// sets PYTHONPATH; everything else is symlinked.
+func linkTool(pin ToolPin, dir, binDir, bin string) error {
+	var target string
+	switch pin.Method {
+	case MethodNpm:
+		target = filepath.Join(dir, "node_modules", ".bin", bin)
+	default:
+		target = filepath.Join(dir, "bin", bin)
+	}
+	if runtime.GOOS == "windows" {
+		if _, err := os.Stat(target + ".exe"); err == nil {
+			target += ".exe"
+			bin += ".exe"
+		}
+	}
+	if _, err := os.Stat(target); err != nil {
+		return fmt.Errorf("executable %s not found after install", bin)
+	}
+
+	// Relative links keep working when the project directory moves.
+	relDir, err := filepath.Rel(binDir, dir)
+	if err != nil {
+		return err
+	}
+	relTarget, err := filepath.Rel(binDir, target)
+	if err != nil {
+		return err
+	}
+	link := filepath.Join(binDir, bin)
+	os.Remove(link)
+	if pin.Method == MethodPip {
+		script := fmt.Sprintf("#!/bin/sh\nd=$(dirname \"$0\")\nPYTHONPATH=\"$d/%s${PYTHONPATH:+:$PYTHONPATH}\" exec \"$d/%s\" \"$@\"\n",
+			filepath.ToSlash(relDir), filepath.ToSlash(relTarget))
+		return os.WriteFile(link, []byte(script), 0755)
+	}
+	return os.Symlink(relTarget, link)
+}
+
+// ignoreToolsInGit keeps installed tools out of git status; the lock file
+// one level up stays tracked.
+func ignoreToolsInGit(projectDir string) {
+	p := filepath.Join(projectDir, ToolsDir, ".gitignore")
+	if _, err := os.Stat(p); err == nil {
+		return
+	}
+	if err := os.MkdirAll(filepath.Dir(p), 0755); err == nil {
+		_ = os.WriteFile(p, []byte("*\n"), 0644)
+	}
+}
@@ -0,0 +1,136 @@
+package lint
+
+import (
+	"archive/tar"
+	"bytes"
+	"compress/gzip"
+	"crypto/sha256"
+	"encoding/hex"
+	"errors"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+// writeMirrorArchive packs an executable script into mirror/archive/name
+// and returns the archive checksum.
+func writeMirrorArchive(t *testing.T, mirror, name, bin, script string) string {
+	t.Helper()
+	var buf bytes.Buffer
+	gz := gzip.NewWriter(&buf)
+	tw := tar.NewWriter(gz)
+	if err := tw.WriteHeader(&tar.Header{Name: "pkg/bin/" + bin, Mode: 0755, Size: int64(len(script)), Typeflag: tar.TypeReg}); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := tw.Write([]byte(script)); err != nil {
+		t.Fatal(err)
+	}
+	if err := tw.Close(); err != nil {
+		t.Fatal(err)
+	}
+	if err := gz.Close(); err != nil {
+		t.Fatal(err)
+	}
+	if err := os.MkdirAll(filepath.Join(mirror, "archive"), 0755); err != nil {
+		t.Fatal(err)
+	}
+	if err := os.WriteFile(filepath.Join(mirror, "archive", name), buf.Bytes(), 0644); err != nil {
+		t.Fatal(err)
+	}
+	sum := sha256.Sum256(buf.Bytes())
+	return hex.EncodeToString(sum[:])
+}
+
+func Test_InstallTool_archive_from_mirror_is_preferred(t *testing.T) {
+	if _, err := exec.LookPath("sh"); err != nil {
+		t.Skip("sh not available")
+	}
+	dir := t.TempDir()
+	mirror := t.TempDir()
+	sum := writeMirrorArchive(t, mirror, "fakelint-1.2.0.tar.gz", "fakelint",
+		"#!/bin/sh\nfor f in \"$@\"; do echo \"$f:1: pinned 1.2.0\"; done\n")
+	pin := ToolPin{
+		Name:    "fakelint",
+		Version: "1.2.0",
+		Method:  MethodArchive,
+		Package: "https://example.invalid/fakelint-{version}.tar.gz", // never fetched
+		SHA256:  sum,
+	}
+
+	if err := InstallTool(dir, pin, InstallOptions{Mirror: mirror}); err != nil {
+		t.Fatalf("install: %v", err)
+	}
+	if !pin.Installed(dir) {
+		t.Fatal("pin should be installed")
+	}
+	if got := ToolPath(dir, "fakelint"); got != filepath.Join(dir, ToolsBinDir, "fakelint") {
+		t.Errorf("ToolPath: got %q", got)
+	}
+
+	writeSource(t, dir, "a.txt", "x\n")
+	def := LinterDef{
+		Name:    "fakelint",
+		Files:   []string{"*.txt"},
+		Command: "fakelint {files}",
+		Format:  FormatRegex,
+		Pattern: `^(?P<file>[^:]+):(?P<line>\d+): (?P<message>.+)$`,
+	}
+	if !def.Installed(dir) {
+		t.Fatal("linter should be found in .do/tools/bin")
+	}
+	diags := def.Run([]string{"a.txt"}, dir)
+	if len(diags) != 1 || diags[0].Message != "pinned 1.2.0" {
+		t.Errorf("diags: %+v", diags)
+	}
+}
+
+func Test_InstallTool_rejects_checksum_mismatch(t *testing.T) {
+	dir := t.TempDir()
+	mirror := t.TempDir()
+	writeMirrorArchive(t, mirror, "tool.tar.gz", "tool", "#!/bin/sh\n")
+	pin := ToolPin{Name: "tool", Version: "1", Method: MethodArchive, Package: "https://example.invalid/tool.tar.gz", SHA256: "00"}
+
+	if err := InstallTool(dir, pin, InstallOptions{Mirror: mirror}); err == nil {
+		t.Fatal("expected checksum error")
+	}
+	if pin.Installed(dir) {
+		t.Error("failed install should not be marked installed")
+	}
+}
+
+func Test_ToolLock_round_trip(t *testing.T) {
+	dir := t.TempDir()
+	if _, err := LoadToolLock(dir); !errors.Is(err, os.ErrNotExist) {
+		t.Fatalf("expected os.ErrNotExist, got %v", err)
+	}
+	lock := NewToolLock([]Language{LangTypeScript})
+	if len(lock.Tools) != 2 {
+		t.Fatalf("typescript project should pin typescript and prettier: %+v", lock.Tools)
+	}
+	if err := lock.Save(dir); err != nil {
+		t.Fatal(err)
+	}
+	loaded, err := LoadToolLock(dir)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if loaded.Tools[0].Name != "typescript" || loaded.Tools[0].bins()[0] != "tsc" || loaded.Tools[1].Version != "3.3.3" {
+		t.Errorf("loaded: %+v", loaded.Tools)
+	}
+
+	if err := os.WriteFile(filepath.Join(dir, ToolsLockFile), []byte("tools:\n  - name: x\n    version: '1'\n    method: brew\n"), 0644); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := LoadToolLock(dir); err == nil {
+		t.Error("unknown method should be rejected")
+	}
+
+	if err := os.WriteFile(filepath.Join(dir, ToolsLockFile), []byte("tools:\n  - name: x\n    version: '1'\n    method: archive\n    package: https://example.invalid/x.tar.gz\n"), 0644); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := LoadToolLock(dir); err == nil || !strings.Contains(err.Error(), "sha256") {
+		t.Errorf("archive without sha256 should be rejected: %v", err)
+	}
+}
@@ -0,0 +1,218 @@
+package mode
+
+import (
+	"errors"
+	"fmt"
+	"os"
+	"path"
+	"path/filepath"
+	"slices"
+	"strings"
+
+	"gopkg.in/yaml.v3"
+)
+
+// ConfigFile defines additional modes, relative to a project directory or
+// the home directory.
+const ConfigFile = ".do/modes.yaml"
+
+// Mode is an execution mode.
+type Mode struct {
+	Name        string `yaml:"name"`
+	Description string `yaml:"description,omitempty"`
+	// Prefix starts every response, e.g. "[Focus]".
+	Prefix string `yaml:"prefix,omitempty"`
+	// Instructions are injected with every prompt.
+	Instructions string `yaml:"instructions,omitempty"`
+	// AllowedTools, when set, are the only tools the mode may use;
+	// DeniedTools are never allowed. AgentOnlyTools may only be used by
+	// agents, so the main conversation has to delegate them. See Check.
+	AllowedTools   []string `yaml:"allowed_tools,omitempty"`
+	DeniedTools    []string `yaml:"denied_tools,omitempty"`
+	AgentOnlyTools []string `yaml:"agent_only_tools,omitempty"`
+	// Permission is the permission mode (bypass, accept, default or plan)
+	// applied when switching to this mode.
+	Permission string `yaml:"permission,omitempty"`
+}
+
+// Builtin returns the built-in modes.
+func Builtin() []Mode {
+	return []Mode{
+		{Name: "do", Prefix: "[Do]", Description: "Full delegation: all implementation goes to agents via Task()"},
+		{Name: "focus", Prefix: "[Focus]", Description: "Direct execution: the orchestrator reads and edits files itself",
+			DeniedTools: agentTools},
+		{Name: "team", Prefix: "[Team]", Description: "Agent Teams: parallel teams via TeamCreate and SendMessage",
+			AgentOnlyTools: editTools},
+		{Name: "review", Prefix: "[Review]", Description: "Read-only review: no file edits",
+			DeniedTools: editTools},
+	}
+}
+
+// Set is the modes available in a directory, in listing order.
+type Set struct {
+	Modes []Mode
+}
+
+// Get returns the mode called name.
+func (s *Set) Get(name string) (Mode, bool) {
+	name = strings.ToLower(name)
+	for _, m := range s.Modes {
+		if m.Name == name {
+			return m, true
+		}
+	}
+	return Mode{}, false
+}
+
+// Names returns the mode names.
+func (s *Set) Names() []string {
+	names := make([]string, len(s.Modes))
+	for i, m := range s.Modes {
+		names[i] = m.Name
+	}
+	return names
+}
+
+// Prefix returns the response prefix of the mode called name. A mode that
+// is not defined gets its capitalized name in brackets.
+func (s *Set) Prefix(name string) string {
+	if m, ok := s.Get(name); ok {
+		return m.Prefix
+	}
+	return defaultPrefix(name)
+}
+
+func defaultPrefix(name string) string {
+	if name == "" {
+		return "[Do]"
+	}
+	return "[" + strings.ToUpper(name[:1]) + name[1:] + "]"
+}
+
+type configFile struct {
+	Modes []Mode `yaml:"modes"`
+}
+
+// Load returns the built-in modes, overlaid with ~/.do/modes.yaml and then
+// with the modes.yaml files from the project root down to dir. A mode
+// defined again replaces the earlier definition of that name; new modes
+// are listed after the built-in ones.
+func Load(dir string) (*Set, error) {
+	set := &Set{Modes: Builtin()}
+	var paths []string
+	if home, err := os.UserHomeDir(); err == nil {
+		paths = append(paths, filepath.Join(home, ConfigFile))
+	}
+	dirs := projectDirs(dir)
+	for i := len(dirs) - 1; i >= 0; i-- {
+		p := filepath.Join(dirs[i], ConfigFile)
+		if len(paths) == 0 || p != paths[0] {
+			paths = append(paths, p)
+		}
+	}
+	for _, p := range paths {
+		if err := set.merge(p); err != nil {
+			return nil, err
+		}
+	}
+	return set, nil
+}
+
+func (s *Set) merge(path string) error {
+	data, err := os.ReadFile(path)
+	if errors.Is(err, os.ErrNotExist) {
+		return nil
+	}
+	if err != nil {
+		return fmt.Errorf("read modes config: %w", err)
+	}
+	var cfg configFile
+	if err := yaml.Unmarshal(data, &cfg); err != nil {
+		return fmt.Errorf("parse modes config %s: %w", path, err)
+	}
+	for _, m := range cfg.Modes {
+		m.Name = strings.ToLower(strings.TrimSpace(m.Name))
+		if err := m.validate(); err != nil {
+			return fmt.Errorf("modes config %s: %w", path, err)
+		}
+		if m.Prefix == "" {
+			m.Prefix = defaultPrefix(m.Name)
+		}
+		replaced := false
+		for i := range s.Modes {
+			if s.Modes[i].Name == m.Name {
+				s.Modes[i], replaced = m, true
+			}
+		}
+		if !replaced {
+			s.Modes = append(s.Modes, m)
+		}
+	}
+	return nil
+}
+
+func (m Mode) validate() error {
+	if m.Name == "" {
+		return fmt.Errorf("mode name is required")
+	}
+	if strings.ContainsAny(m.Name, " /\\") {
+		return fmt.Errorf("mode %q: name must not contain spaces or slashes", m.Name)
+	}
+	if _, ok := PermissionModes[m.Name]; ok {
+		return fmt.Errorf("mode %q: name is a permission mode", m.Name)
+	}
+	for _, p := range slices.Concat(m.AllowedTools, m.DeniedTools, m.AgentOnlyTools) {
+		if _, err := path.Match(p, ""); err != nil {
+			return fmt.Errorf("mode %q: invalid tool pattern %q", m.Name, p)
+		}
+	}
+	if _, ok := PermissionModes[m.Permission]; m.Permission != "" && !ok {
+		return fmt.Errorf("mode %q: invalid permission %q (valid: bypass, accept, default, plan)", m.Name, m.Permission)
+	}
+	return nil
+}
+
+// FindRoot returns the project root for dir: CLAUDE_PROJECT_DIR when dir
+// is inside it, else the nearest ancestor with a .git entry, else the
+// outermost ancestor with a .do directory other than the home directory,
+// else dir itself. Nested .do directories hold per-directory state.
+func FindRoot(dir string) string {
+	dir, _ = filepath.Abs(dir)
+	if root := os.Getenv("CLAUDE_PROJECT_DIR"); root != "" {
+		if rel, err := filepath.Rel(root, dir); err == nil && rel != ".." && !strings.HasPrefix(rel, ".."+string(filepath.Separator)) {
+			return root
+		}
+	}
+	home, _ := os.UserHomeDir()
+	root := dir
+	for d := dir; ; {
+		if _, err := os.Stat(filepath.Join(d, ".git")); err == nil {
+			return d
+		}
+		if fi, err := os.Stat(filepath.Join(d, ".do")); err == nil && fi.IsDir() && d != home {
+			root = d
+		}
+		parent := filepath.Dir(d)
+		if parent == d {
+			return root
+		}
+		d = parent
+	}
+}
+
+// projectDirs returns dir and its ancestors up to the project root,
+// nearest first.
+func projectDirs(dir string) []string {
+	dir, _ = filepath.Abs(dir)
+	root := FindRoot(dir)
+	dirs := []string{dir}
+	for d := dir; d != root; {
+		parent := filepath.Dir(d)
+		if parent == d {
+			break
+		}
+		d = parent
+		dirs = append(dirs, d)
+	}
+	return dirs
+}
@@ -2,13 +2,21 @@ package mode
 
 import (
 	"encoding/json"
+	"errors"
+	"fmt"
 	"os"
+	"path/filepath"
 	"strings"
 )
 
-// StateFile is the path to the mode state file relative to project root.
+// StateFile is the path to the mode state file relative to a project
+// directory.
 const StateFile = ".do/.current-mode"
 
+// SessionDir holds per-session mode overrides, one file per session ID,
+// relative to the project root.
+const SessionDir = ".do/.session-modes"
+
 // PermissionModes maps short names to Claude Code's defaultMode setting values.
 var PermissionModes = map[string]string{
 	"bypass":  "bypassPermissions",
@@ -17,37 +25,91 @@ var PermissionModes = map[string]string{
 	"plan":    "plan",
 }
 
-// ExecutionModes defines valid do execution modes.
-var ExecutionModes = map[string]bool{
-	"do": true, "focus": true, "team": true,
+// ReadState reads the current execution mode for the working directory.
+// See Current.
+func ReadState() string {
+	return Current(".", "")
 }
 
-// ReadState reads the current execution mode from the state file.
-// Falls back to DO_MODE env var, then defaults to "do".
-func ReadState() string {
-	data, err := os.ReadFile(StateFile)
-	if err != nil {
-		m := os.Getenv("DO_MODE")
-		if m == "" {
-			return "do"
+// Current returns the execution mode for dir and session: the session's
+// override, else the nearest StateFile from dir up to the project root,
+// else the DO_MODE env var, else "do". An invalid session ID has no
+// override.
+func Current(dir, sessionID string) string {
+	if validSessionID(sessionID) == nil {
+		if m := readModeFile(filepath.Join(FindRoot(dir), SessionDir, sessionID)); m != "" {
+			return m
 		}
-		return m
 	}
-	m := strings.TrimSpace(string(data))
-	if m == "" {
-		m = os.Getenv("DO_MODE")
-		if m == "" {
-			return "do"
+	for _, d := range projectDirs(dir) {
+		if m := readModeFile(filepath.Join(d, StateFile)); m != "" {
+			return m
 		}
+	}
+	if m := os.Getenv("DO_MODE"); m != "" {
 		return m
 	}
-	return m
+	return "do"
+}
+
+func readModeFile(path string) string {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return ""
+	}
+	return strings.TrimSpace(string(data))
 }
 
-// WriteState persists the execution mode to the state file.
+// WriteState persists the execution mode to the state file in the working
+// directory.
 func WriteState(mode string) {
-	os.MkdirAll(".do", 0755)
-	os.WriteFile(StateFile, []byte(mode+"\n"), 0644)
+	_ = WriteStateAt(".", mode)
+}
+
+// WriteStateAt persists the execution mode to the state file in dir.
+func WriteStateAt(dir, mode string) error {
+	if err := os.MkdirAll(filepath.Join(dir, ".do"), 0755); err != nil {
+		return fmt.Errorf("create .do directory: %w", err)
+	}
+	if err := os.WriteFile(filepath.Join(dir, StateFile), []byte(mode+"\n"), 0644); err != nil {
+		return fmt.Errorf("write mode state: %w", err)
+	}
+	return nil
+}
+
+// WriteSessionState sets the mode of one session in the project containing
+// dir, overriding the directory's mode.
+func WriteSessionState(dir, sessionID, mode string) error {
+	if err := validSessionID(sessionID); err != nil {
+		return err
+	}
+	sessions := filepath.Join(FindRoot(dir), SessionDir)
+	if err := os.MkdirAll(sessions, 0755); err != nil {
+		return fmt.Errorf("create session mode directory: %w", err)
+	}
+	if err := os.WriteFile(filepath.Join(sessions, sessionID), []byte(mode+"\n"), 0644); err != nil {
+		return fmt.Errorf("write session mode: %w", err)
+	}
+	return nil
+}
+
+// ClearSessionState removes the mode override of a session, if any.
+func ClearSessionState(dir, sessionID string) error {
+	if err := validSessionID(sessionID); err != nil {
+		return err
+	}
+	err := os.Remove(filepath.Join(FindRoot(dir), SessionDir, sessionID))
+	if err != nil && !errors.Is(err, os.ErrNotExist) {
+		return fmt.Errorf("clear session mode: %w", err)
+	}
+	return nil
+}
+
+func validSessionID(id string) error {
+	if id == "" || strings.ContainsAny(id, "/\\") || id == "." || id == ".." {
+		return fmt.Errorf("invalid session ID %q", id)
+	}
+	return nil
 }
 
 // SetDefaultMode updates defaultMode in .claude/settings.local.json.
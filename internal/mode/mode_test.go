@@ -4,6 +4,7 @@ import (
 	"encoding/json"
 	"os"
 	"path/filepath"
+	"strings"
 	"testing"
 )
 
@@ -148,16 +149,119 @@ func Test_SetDefaultMode_default_removes_key(t *testing.T) {
 	}
 }
 
-func Test_ExecutionModes_valid(t *testing.T) {
-	for _, m := range []string{"do", "focus", "team"} {
-		if !ExecutionModes[m] {
-			t.Errorf("%q should be a valid execution mode", m)
-		}
+func Test_Current_walks_up_to_project_root_and_honors_sessions(t *testing.T) {
+	t.Setenv("HOME", t.TempDir())
+	t.Setenv("CLAUDE_PROJECT_DIR", "")
+	t.Setenv("DO_MODE", "")
+	root := t.TempDir()
+	sub := filepath.Join(root, "services", "api")
+	os.MkdirAll(filepath.Join(root, ".git"), 0755)
+	os.MkdirAll(sub, 0755)
+
+	if got := Current(sub, ""); got != "do" {
+		t.Errorf("no state: got %q", got)
+	}
+	if err := WriteStateAt(root, "team"); err != nil {
+		t.Fatal(err)
+	}
+	if got := Current(sub, "s1"); got != "team" {
+		t.Errorf("root state from subdirectory: got %q", got)
+	}
+	WriteStateAt(filepath.Join(root, "services"), "focus")
+	if got := Current(sub, ""); got != "focus" {
+		t.Errorf("nearest state should win: got %q", got)
+	}
+
+	if err := WriteSessionState(sub, "s1", "do"); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := os.Stat(filepath.Join(root, SessionDir, "s1")); err != nil {
+		t.Errorf("session override not stored at root: %v", err)
+	}
+	if got, other := Current(sub, "s1"), Current(sub, "s2"); got != "do" || other != "focus" {
+		t.Errorf("session override: s1 %q, s2 %q", got, other)
+	}
+	ClearSessionState(sub, "s1")
+	if got := Current(sub, "s1"); got != "focus" {
+		t.Errorf("after clear: got %q", got)
+	}
+	if err := WriteSessionState(sub, "../x", "do"); err == nil {
+		t.Error("session ID with a slash should be refused")
+	}
+	if got := Current(sub, "../.current-mode"); got != "focus" {
+		t.Errorf("session ID with a slash must not be read as a path: got %q", got)
 	}
 }
 
-func Test_ExecutionModes_invalid(t *testing.T) {
-	if ExecutionModes["bogus"] {
-		t.Error("'bogus' should not be a valid execution mode")
+func Test_Load_merges_user_and_project_modes(t *testing.T) {
+	home := t.TempDir()
+	t.Setenv("HOME", home)
+	t.Setenv("CLAUDE_PROJECT_DIR", "")
+	root := t.TempDir()
+	sub := filepath.Join(root, "web")
+	os.MkdirAll(filepath.Join(home, ".do"), 0755)
+	os.MkdirAll(filepath.Join(root, ".do"), 0755)
+	os.MkdirAll(filepath.Join(sub, ".do"), 0755)
+
+	os.WriteFile(filepath.Join(home, ConfigFile), []byte(`modes:
+  - name: review
+    description: user review
+    denied_tools: [Write, Edit]
+`), 0644)
+	os.WriteFile(filepath.Join(root, ConfigFile), []byte(`modes:
+  - name: Review
+    description: project review
+    permission: plan
+  - name: focus
+    prefix: "[F]"
+    instructions: edit directly
+`), 0644)
+	os.WriteFile(filepath.Join(sub, ConfigFile), []byte(`modes:
+  - name: spike
+`), 0644)
+
+	set, err := Load(sub)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if got := strings.Join(set.Names(), ","); got != "do,focus,team,review,spike" {
+		t.Errorf("names: %s", got)
+	}
+	review, _ := set.Get("review")
+	if review.Description != "project review" || review.Permission != "plan" || review.DeniedTools != nil {
+		t.Errorf("project definition should replace user one: %+v", review)
+	}
+	if set.Prefix("focus") != "[F]" || set.Prefix("spike") != "[Spike]" || set.Prefix("auto") != "[Auto]" {
+		t.Errorf("prefixes: %q %q %q", set.Prefix("focus"), set.Prefix("spike"), set.Prefix("auto"))
+	}
+
+	os.WriteFile(filepath.Join(sub, ConfigFile), []byte("modes: [{name: plan}]\n"), 0644)
+	if _, err := Load(sub); err == nil {
+		t.Error("a mode named like a permission mode should be refused")
+	}
+}
+
+func Test_Mode_Check_applies_tool_policy(t *testing.T) {
+	set := &Set{Modes: Builtin()}
+	focus, _ := set.Get("focus")
+	if d := focus.Check("Task", false); d == nil || d.AgentOnly {
+		t.Errorf("focus should deny Task: %v", d)
+	}
+	team, _ := set.Get("team")
+	if d := team.Check("Write", false); d == nil || !d.AgentOnly {
+		t.Errorf("team should require an agent for Write: %v", d)
+	}
+	if d := team.Check("Write", true); d != nil {
+		t.Errorf("team agent Write: %v", d)
+	}
+
+	ro := Mode{Name: "ro", AllowedTools: []string{"Read", "Grep", "mcp__docs__*"}}
+	for tool, allowed := range map[string]bool{"Read": true, "mcp__docs__search": true, "Bash": false, "mcp__github__pr": false} {
+		if got := ro.Check(tool, true) == nil; got != allowed {
+			t.Errorf("ro/%s: allowed %v, want %v", tool, got, allowed)
+		}
+	}
+	if got := strings.Join(set.Allowing("Edit"), ","); got != "do,focus" {
+		t.Errorf("modes allowing Edit: %s", got)
 	}
 }
@@ -0,0 +1,64 @@
+package mode
+
+import (
+	"fmt"
+	"path"
+)
+
+// editTools change files.
+var editTools = []string{"Write", "Edit", "MultiEdit", "NotebookEdit"}
+
+// agentTools launch agents. Claude Code has named the tool both Task and
+// Agent.
+var agentTools = []string{"Task", "Agent"}
+
+// Denial explains why a mode does not allow a tool.
+type Denial struct {
+	Mode string
+	Tool string
+	// AgentOnly is set when the tool is allowed, but only inside agents.
+	AgentOnly bool
+}
+
+func (d *Denial) Error() string {
+	if d.AgentOnly {
+		return fmt.Sprintf("%s must be delegated to an agent in %s mode", d.Tool, d.Mode)
+	}
+	return fmt.Sprintf("%s is not allowed in %s mode", d.Tool, d.Mode)
+}
+
+// Check reports whether the mode allows tool. inAgent is set for calls
+// made by a subagent rather than the main conversation. Tool patterns may
+// use path.Match globs, e.g. "mcp__github__*".
+func (m Mode) Check(tool string, inAgent bool) *Denial {
+	if matchTool(m.DeniedTools, tool) {
+		return &Denial{Mode: m.Name, Tool: tool}
+	}
+	if len(m.AllowedTools) > 0 && !matchTool(m.AllowedTools, tool) {
+		return &Denial{Mode: m.Name, Tool: tool}
+	}
+	if !inAgent && matchTool(m.AgentOnlyTools, tool) {
+		return &Denial{Mode: m.Name, Tool: tool, AgentOnly: true}
+	}
+	return nil
+}
+
+// Allowing returns the modes that let the main conversation use tool.
+func (s *Set) Allowing(tool string) []string {
+	var names []string
+	for _, m := range s.Modes {
+		if m.Check(tool, false) == nil {
+			names = append(names, m.Name)
+		}
+	}
+	return names
+}
+
+func matchTool(patterns []string, tool string) bool {
+	for _, p := range patterns {
+		if ok, _ := path.Match(p, tool); ok {
+			return true
+		}
+	}
+	return false
+}
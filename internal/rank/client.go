@@ -63,8 +63,8 @@ type SessionSubmission struct {
 	EndedAt             string `json:"ended_at"`
 	InputTokens         int64  `json:"input_tokens"`
 	OutputTokens        int64  `json:"output_tokens"`
-	CacheCreationTokens int64  `json:"cache_creation_tokens"`
-	CacheReadTokens     int64  `json:"cache_read_tokens"`
+	CacheCreationTokens int64  `json:"cache_creation_tokens,omitempty"`
+	CacheReadTokens     int64  `json:"cache_read_tokens,omitempty"`
 	ModelName           string `json:"model_name,omitempty"`
 	AnonymousProjectID  string `json:"anonymous_project_id,omitempty"`
 	StartedAt           string `json:"started_at,omitempty"`
@@ -105,6 +105,9 @@ type Client struct {
 // NewClient creates a new Client.
 func NewClient(apiKey string) *Client {
 	baseURL := DefaultBaseURL
+	if envURL := os.Getenv("RANK_API_URL"); envURL != "" {
+		baseURL = envURL
+	}
 	if envURL := os.Getenv("DO_RANK_API_URL"); envURL != "" {
 		baseURL = envURL
 	}
@@ -126,8 +129,8 @@ func ComputeSignature(apiKey, timestamp, body string) string {
 	return hex.EncodeToString(mac.Sum(nil))
 }
 
-// doAuthRequest performs an authenticated HTTP request to the Rank API.
-func (c *Client) doAuthRequest(ctx context.Context, method, path string, body []byte) (*http.Response, error) {
+// newAuthRequest builds a signed HTTP request to the Rank API.
+func (c *Client) newAuthRequest(ctx context.Context, method, path string, body []byte) (*http.Request, error) {
 	if c.apiKey == "" {
 		return nil, &AuthError{Message: "API key not configured"}
 	}
@@ -158,6 +161,16 @@ func (c *Client) doAuthRequest(ctx context.Context, method, path string, body []
 	req.Header.Set("X-Timestamp", timestamp)
 	req.Header.Set("X-Signature", signature)
 
+	return req, nil
+}
+
+// doAuthRequest performs an authenticated HTTP request to the Rank API.
+func (c *Client) doAuthRequest(ctx context.Context, method, path string, body []byte) (*http.Response, error) {
+	req, err := c.newAuthRequest(ctx, method, path, body)
+	if err != nil {
+		return nil, err
+	}
+
 	resp, err := c.httpClient.Do(req)
 	if err != nil {
 		return nil, &ClientError{Message: fmt.Sprintf("request failed: %v", err)}
@@ -166,8 +179,8 @@ func (c *Client) doAuthRequest(ctx context.Context, method, path string, body []
 	return resp, nil
 }
 
-// SubmitSession submits a single session metric to the Rank API.
-func (c *Client) SubmitSession(ctx context.Context, session *SessionSubmission) error {
+// marshalSession clamps the token counts of a session and encodes it.
+func marshalSession(session *SessionSubmission) ([]byte, error) {
 	session.InputTokens = ClampTokens(session.InputTokens)
 	session.OutputTokens = ClampTokens(session.OutputTokens)
 	session.CacheCreationTokens = ClampTokens(session.CacheCreationTokens)
@@ -175,7 +188,16 @@ func (c *Client) SubmitSession(ctx context.Context, session *SessionSubmission)
 
 	body, err := json.Marshal(session)
 	if err != nil {
-		return &ClientError{Message: fmt.Sprintf("marshal session: %v", err)}
+		return nil, &ClientError{Message: fmt.Sprintf("marshal session: %v", err)}
+	}
+	return body, nil
+}
+
+// SubmitSession submits a single session metric to the Rank API.
+func (c *Client) SubmitSession(ctx context.Context, session *SessionSubmission) error {
+	body, err := marshalSession(session)
+	if err != nil {
+		return err
 	}
 
 	path := "/api/" + APIVersion + "/sessions"
@@ -196,6 +218,84 @@ func (c *Client) SubmitSession(ctx context.Context, session *SessionSubmission)
 	return nil
 }
 
+// SignedRequest is a request exactly as it would be sent.
+type SignedRequest struct {
+	Method  string
+	URL     string
+	Headers http.Header
+	Body    []byte
+}
+
+// PrepareSubmission returns the signed request SubmitSession would send,
+// without sending it.
+func (c *Client) PrepareSubmission(ctx context.Context, session *SessionSubmission) (*SignedRequest, error) {
+	body, err := marshalSession(session)
+	if err != nil {
+		return nil, err
+	}
+	req, err := c.newAuthRequest(ctx, http.MethodPost, "/api/"+APIVersion+"/sessions", body)
+	if err != nil {
+		return nil, err
+	}
+	return &SignedRequest{Method: req.Method, URL: req.URL.String(), Headers: req.Header, Body: body}, nil
+}
+
+// BatchResult is the server's answer to a batch submission. Sessions not
+// listed as duplicates or rejected were accepted.
+type BatchResult struct {
+	Accepted   []string         `json:"accepted"`
+	Duplicates []string         `json:"duplicates"`
+	Rejected   []BatchRejection `json:"rejected"`
+}
+
+// BatchRejection is a session the server refused, with the reason.
+type BatchRejection struct {
+	SessionHash string `json:"session_hash"`
+	Message     string `json:"message"`
+}
+
+// SubmitSessions submits several sessions in one request.
+func (c *Client) SubmitSessions(ctx context.Context, sessions []*SessionSubmission) (*BatchResult, error) {
+	for _, s := range sessions {
+		s.InputTokens = ClampTokens(s.InputTokens)
+		s.OutputTokens = ClampTokens(s.OutputTokens)
+		s.CacheCreationTokens = ClampTokens(s.CacheCreationTokens)
+		s.CacheReadTokens = ClampTokens(s.CacheReadTokens)
+	}
+
+	body, err := json.Marshal(map[string]any{"sessions": sessions})
+	if err != nil {
+		return nil, &ClientError{Message: fmt.Sprintf("marshal sessions: %v", err)}
+	}
+
+	path := "/api/" + APIVersion + "/sessions/batch"
+	resp, err := c.doAuthRequest(ctx, http.MethodPost, path, body)
+	if err != nil {
+		return nil, err
+	}
+	defer func() { _ = resp.Body.Close() }()
+
+	if resp.StatusCode == http.StatusUnauthorized || resp.StatusCode == http.StatusForbidden {
+		return nil, &AuthError{Message: "authentication failed"}
+	}
+
+	if resp.StatusCode >= 400 {
+		return nil, parseAPIError(resp)
+	}
+
+	respBody, err := io.ReadAll(resp.Body)
+	if err != nil {
+		return nil, &ClientError{Message: fmt.Sprintf("read response: %v", err)}
+	}
+
+	var result BatchResult
+	if err := json.Unmarshal(respBody, &result); err != nil {
+		return nil, &ClientError{Message: fmt.Sprintf("parse batch response: %v", err)}
+	}
+
+	return &result, nil
+}
+
 // GetUserRank returns the current user's ranking information.
 func (c *Client) GetUserRank(ctx context.Context) (*UserInfo, error) {
 	path := "/api/" + APIVersion + "/rank"
@@ -0,0 +1,128 @@
+package rank
+
+import (
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"slices"
+	"strings"
+
+	"gopkg.in/yaml.v3"
+)
+
+const privacyFilename = "privacy.yaml"
+
+// Optional submission fields, by their JSON name. The session hash, end
+// time and input/output token counts are always sent.
+const (
+	FieldCacheCreationTokens = "cache_creation_tokens"
+	FieldCacheReadTokens     = "cache_read_tokens"
+	FieldModelName           = "model_name"
+	FieldAnonymousProjectID  = "anonymous_project_id"
+	FieldStartedAt           = "started_at"
+	FieldDurationSeconds     = "duration_seconds"
+	FieldTurnCount           = "turn_count"
+)
+
+// OptionalFields returns the submission fields that can be withheld.
+func OptionalFields() []string {
+	return []string{
+		FieldCacheCreationTokens, FieldCacheReadTokens, FieldModelName,
+		FieldAnonymousProjectID, FieldStartedAt, FieldDurationSeconds, FieldTurnCount,
+	}
+}
+
+// Privacy lists the optional fields never sent to the Rank API.
+type Privacy struct {
+	Omit []string `yaml:"omit"`
+}
+
+// GetPrivacyPath returns the privacy config path (~/.do/rank/privacy.yaml).
+func GetPrivacyPath() string {
+	return filepath.Join(GetCredentialsDir(), privacyFilename)
+}
+
+// LoadPrivacy reads the privacy config. A missing file omits nothing.
+func LoadPrivacy() (*Privacy, error) {
+	data, err := os.ReadFile(GetPrivacyPath())
+	if errors.Is(err, os.ErrNotExist) {
+		return &Privacy{}, nil
+	}
+	if err != nil {
+		return nil, fmt.Errorf("read rank privacy config: %w", err)
+	}
+	var p Privacy
+	if err := yaml.Unmarshal(data, &p); err != nil {
+		return nil, fmt.Errorf("parse rank privacy config: %w", err)
+	}
+	if err := p.Validate(); err != nil {
+		return nil, fmt.Errorf("rank privacy config %s: %w", GetPrivacyPath(), err)
+	}
+	return &p, nil
+}
+
+// SavePrivacy writes the privacy config atomically.
+func SavePrivacy(p *Privacy) error {
+	if err := p.Validate(); err != nil {
+		return err
+	}
+	if err := os.MkdirAll(GetCredentialsDir(), credDirPerm); err != nil {
+		return fmt.Errorf("create rank directory: %w", err)
+	}
+	data, err := yaml.Marshal(p)
+	if err != nil {
+		return fmt.Errorf("marshal rank privacy config: %w", err)
+	}
+	path := GetPrivacyPath()
+	tmp := path + ".tmp"
+	if err := os.WriteFile(tmp, data, credFilePerm); err != nil {
+		return fmt.Errorf("write rank privacy config: %w", err)
+	}
+	if err := os.Rename(tmp, path); err != nil {
+		_ = os.Remove(tmp)
+		return fmt.Errorf("rename rank privacy config: %w", err)
+	}
+	return nil
+}
+
+// Validate reports fields that are unknown or cannot be withheld.
+func (p *Privacy) Validate() error {
+	for _, f := range p.Omit {
+		if !slices.Contains(OptionalFields(), f) {
+			return fmt.Errorf("field %q cannot be omitted (optional fields: %s)", f, strings.Join(OptionalFields(), ", "))
+		}
+	}
+	return nil
+}
+
+// Omits reports whether field is withheld.
+func (p *Privacy) Omits(field string) bool {
+	return p != nil && slices.Contains(p.Omit, field)
+}
+
+// Apply clears the withheld fields of s, so they are left out of the
+// request body.
+func (p *Privacy) Apply(s *SessionSubmission) {
+	if p.Omits(FieldCacheCreationTokens) {
+		s.CacheCreationTokens = 0
+	}
+	if p.Omits(FieldCacheReadTokens) {
+		s.CacheReadTokens = 0
+	}
+	if p.Omits(FieldModelName) {
+		s.ModelName = ""
+	}
+	if p.Omits(FieldAnonymousProjectID) {
+		s.AnonymousProjectID = ""
+	}
+	if p.Omits(FieldStartedAt) {
+		s.StartedAt = ""
+	}
+	if p.Omits(FieldDurationSeconds) {
+		s.DurationSeconds = 0
+	}
+	if p.Omits(FieldTurnCount) {
+		s.TurnCount = 0
+	}
+}
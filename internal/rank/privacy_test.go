@@ -0,0 +1,64 @@
+package rank
+
+import (
+	"context"
+	"encoding/json"
+	"strings"
+	"testing"
+)
+
+func Test_Privacy_roundtrip_and_Apply(t *testing.T) {
+	t.Setenv("HOME", t.TempDir())
+
+	p, err := LoadPrivacy()
+	if err != nil || len(p.Omit) != 0 {
+		t.Fatalf("default privacy: %+v, %v", p, err)
+	}
+	if err := SavePrivacy(&Privacy{Omit: []string{"input_tokens"}}); err == nil {
+		t.Error("required field should not be omittable")
+	}
+	if err := SavePrivacy(&Privacy{Omit: []string{FieldModelName, FieldAnonymousProjectID, FieldCacheReadTokens}}); err != nil {
+		t.Fatal(err)
+	}
+	p, err = LoadPrivacy()
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	sub := &SessionSubmission{
+		SessionHash: "h", EndedAt: "2026-01-05T10:00:00Z", InputTokens: 1, OutputTokens: 2,
+		CacheCreationTokens: 3, CacheReadTokens: 4, ModelName: "claude-opus-4-5",
+		AnonymousProjectID: "abc", TurnCount: 5,
+	}
+	p.Apply(sub)
+	body, _ := json.Marshal(sub)
+	for _, field := range []string{FieldModelName, FieldAnonymousProjectID, FieldCacheReadTokens} {
+		if strings.Contains(string(body), `"`+field+`"`) {
+			t.Errorf("%s still sent: %s", field, body)
+		}
+	}
+	if !strings.Contains(string(body), `"turn_count":5`) || !strings.Contains(string(body), `"cache_creation_tokens":3`) {
+		t.Errorf("kept fields missing: %s", body)
+	}
+}
+
+func Test_PrepareSubmission_signs_the_exact_body(t *testing.T) {
+	t.Setenv("RANK_API_URL", "http://rank.test")
+	t.Setenv("DO_RANK_API_URL", "")
+	client := NewClient("key-123")
+
+	req, err := client.PrepareSubmission(context.Background(), &SessionSubmission{SessionHash: "h", InputTokens: MaxTokens + 1})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if req.Method != "POST" || req.URL != "http://rank.test/api/v1/sessions" {
+		t.Errorf("request line: %s %s", req.Method, req.URL)
+	}
+	if !strings.Contains(string(req.Body), `"input_tokens":100000000`) {
+		t.Errorf("tokens not clamped: %s", req.Body)
+	}
+	want := ComputeSignature("key-123", req.Headers.Get("X-Timestamp"), string(req.Body))
+	if req.Headers.Get("X-Signature") != want {
+		t.Errorf("signature %s, want %s", req.Headers.Get("X-Signature"), want)
+	}
+}
@@ -0,0 +1,466 @@
+package rank
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+	"time"
+)
+
+const (
+	queueDirname = "queue"
+	sentFilename = "sent.log"
+)
+
+// QueueEntry is a finished session waiting to be submitted. The session
+// hash is computed once, when the session is first queued, so resubmitting
+// the same entry is recognized as a duplicate.
+type QueueEntry struct {
+	SessionID  string            `json:"session_id"`
+	QueuedAt   string            `json:"queued_at"`
+	Attempts   int               `json:"attempts"`
+	LastError  string            `json:"last_error,omitempty"`
+	Submission SessionSubmission `json:"submission"`
+}
+
+// Queue is a directory of pending submissions, one JSON file per session,
+// plus a log of the session hashes already sent.
+type Queue struct {
+	dir string
+}
+
+// GetQueueDir returns the submission queue directory (~/.do/rank/queue/).
+func GetQueueDir() string {
+	return filepath.Join(GetCredentialsDir(), queueDirname)
+}
+
+// NewQueue returns the queue stored in dir.
+func NewQueue(dir string) *Queue {
+	return &Queue{dir: dir}
+}
+
+// Dir returns the queue directory.
+func (q *Queue) Dir() string {
+	return q.dir
+}
+
+func (q *Queue) entryPath(sessionID string) string {
+	return filepath.Join(q.dir, sessionID+".json")
+}
+
+// BuildSubmission converts parsed transcript usage into a submission with
+// a fresh session hash. The project path is sent only as its anonymous
+// SHA-256 ID.
+func BuildSubmission(usage *TranscriptUsage) (*SessionSubmission, error) {
+	hash, err := ComputeSessionHash(
+		usage.EndedAt,
+		ClampTokens(usage.InputTokens),
+		ClampTokens(usage.OutputTokens),
+	)
+	if err != nil {
+		return nil, fmt.Errorf("compute hash: %w", err)
+	}
+	sub := &SessionSubmission{
+		SessionHash:         hash,
+		EndedAt:             usage.EndedAt,
+		InputTokens:         ClampTokens(usage.InputTokens),
+		OutputTokens:        ClampTokens(usage.OutputTokens),
+		CacheCreationTokens: ClampTokens(usage.CacheCreationTokens),
+		CacheReadTokens:     ClampTokens(usage.CacheReadTokens),
+		ModelName:           usage.ModelName,
+		StartedAt:           usage.StartedAt,
+		DurationSeconds:     usage.DurationSeconds,
+		TurnCount:           usage.TurnCount,
+	}
+	if usage.ProjectPath != "" {
+		sub.AnonymousProjectID = AnonymizeProjectPath(usage.ProjectPath)
+	}
+	return sub, nil
+}
+
+// Add queues a session. A session already in the queue keeps its hash and
+// attempt count but takes the new usage, as a resumed session ends again
+// with more tokens. Sessions already sent are ignored.
+func (q *Queue) Add(sessionID string, sub *SessionSubmission) error {
+	if !IsValidSessionID(sessionID) {
+		return fmt.Errorf("invalid session ID %q", sessionID)
+	}
+	hashes, sessions, err := q.sentLog()
+	if err != nil {
+		return err
+	}
+	if hashes[sub.SessionHash] || sessions[sessionID] {
+		return nil
+	}
+	if err := os.MkdirAll(q.dir, credDirPerm); err != nil {
+		return fmt.Errorf("create rank queue directory: %w", err)
+	}
+
+	entry := &QueueEntry{
+		SessionID:  sessionID,
+		QueuedAt:   time.Now().UTC().Format(time.RFC3339),
+		Submission: *sub,
+	}
+	if old, err := q.load(q.entryPath(sessionID)); err == nil {
+		entry.QueuedAt = old.QueuedAt
+		entry.Attempts = old.Attempts
+		entry.Submission.SessionHash = old.Submission.SessionHash
+	}
+	return q.save(entry)
+}
+
+// ErrAlreadySent is returned by Prepare and Submit for a session in the
+// sent log.
+var ErrAlreadySent = errors.New("session already submitted")
+
+// Prepare readies sub for an immediate submission of sessionID: a session
+// already queued (by the SessionEnd hook) keeps its queued hash, so the
+// server recognizes it if it is sent again.
+func (q *Queue) Prepare(sessionID string, sub *SessionSubmission) error {
+	hashes, sessions, err := q.sentLog()
+	if err != nil {
+		return err
+	}
+	if hashes[sub.SessionHash] || sessions[sessionID] {
+		return ErrAlreadySent
+	}
+	if old, err := q.load(q.entryPath(sessionID)); err == nil {
+		sub.SessionHash = old.Submission.SessionHash
+	}
+	return nil
+}
+
+// Submit sends one session now, outside Sync. The session is prepared as
+// by Prepare and, once sent (or known to the server), recorded in the sent
+// log and dropped from the queue, so a later Sync does not send it again.
+// A retryable failure queues it instead and reports queued.
+func (q *Queue) Submit(ctx context.Context, client *Client, sessionID string, sub *SessionSubmission, privacy *Privacy) (queued bool, err error) {
+	if err := q.Prepare(sessionID, sub); err != nil {
+		return false, err
+	}
+	payload := *sub
+	privacy.Apply(&payload)
+
+	err = client.SubmitSession(ctx, &payload)
+	var apiErr *APIError
+	switch {
+	case err == nil, errors.As(err, &apiErr) && apiErr.StatusCode == http.StatusConflict:
+		return false, q.markSent(&QueueEntry{SessionID: sessionID, Submission: *sub})
+	case IsRetryable(err):
+		if qerr := q.Add(sessionID, sub); qerr != nil {
+			return false, fmt.Errorf("%w (queueing also failed: %v)", err, qerr)
+		}
+		return true, err
+	}
+	return false, err
+}
+
+// List returns the queued entries, oldest first.
+func (q *Queue) List() ([]*QueueEntry, error) {
+	paths, err := filepath.Glob(filepath.Join(q.dir, "*.json"))
+	if err != nil {
+		return nil, fmt.Errorf("list rank queue: %w", err)
+	}
+	var entries []*QueueEntry
+	for _, p := range paths {
+		e, err := q.load(p)
+		if err != nil {
+			return nil, err
+		}
+		entries = append(entries, e)
+	}
+	sort.SliceStable(entries, func(i, j int) bool {
+		if entries[i].QueuedAt != entries[j].QueuedAt {
+			return entries[i].QueuedAt < entries[j].QueuedAt
+		}
+		return entries[i].SessionID < entries[j].SessionID
+	})
+	return entries, nil
+}
+
+// Remove deletes a session from the queue.
+func (q *Queue) Remove(sessionID string) error {
+	err := os.Remove(q.entryPath(sessionID))
+	if err != nil && !os.IsNotExist(err) {
+		return fmt.Errorf("remove queued session: %w", err)
+	}
+	return nil
+}
+
+// Sent returns the set of session hashes already submitted.
+func (q *Queue) Sent() (map[string]bool, error) {
+	hashes, _, err := q.sentLog()
+	return hashes, err
+}
+
+// sentLog reads the sent log: one "hash session-id time" line per session.
+func (q *Queue) sentLog() (hashes, sessions map[string]bool, err error) {
+	hashes, sessions = make(map[string]bool), make(map[string]bool)
+	data, err := os.ReadFile(filepath.Join(q.dir, sentFilename))
+	if os.IsNotExist(err) {
+		return hashes, sessions, nil
+	}
+	if err != nil {
+		return nil, nil, fmt.Errorf("read rank sent log: %w", err)
+	}
+	for _, line := range strings.Split(string(data), "\n") {
+		fields := strings.Fields(line)
+		if len(fields) > 0 {
+			hashes[fields[0]] = true
+		}
+		if len(fields) > 1 {
+			sessions[fields[1]] = true
+		}
+	}
+	return hashes, sessions, nil
+}
+
+// markSent records the entry's hash in the sent log and removes it from
+// the queue.
+func (q *Queue) markSent(e *QueueEntry) error {
+	if err := os.MkdirAll(q.dir, credDirPerm); err != nil {
+		return fmt.Errorf("create rank queue directory: %w", err)
+	}
+	f, err := os.OpenFile(filepath.Join(q.dir, sentFilename), os.O_CREATE|os.O_APPEND|os.O_WRONLY, credFilePerm)
+	if err != nil {
+		return fmt.Errorf("open rank sent log: %w", err)
+	}
+	_, err = fmt.Fprintf(f, "%s %s %s\n", e.Submission.SessionHash, e.SessionID, time.Now().UTC().Format(time.RFC3339))
+	if cerr := f.Close(); err == nil {
+		err = cerr
+	}
+	if err != nil {
+		return fmt.Errorf("write rank sent log: %w", err)
+	}
+	return q.Remove(e.SessionID)
+}
+
+func (q *Queue) load(path string) (*QueueEntry, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, fmt.Errorf("read queued session: %w", err)
+	}
+	var e QueueEntry
+	if err := json.Unmarshal(data, &e); err != nil {
+		return nil, fmt.Errorf("parse queued session %s: %w", filepath.Base(path), err)
+	}
+	return &e, nil
+}
+
+func (q *Queue) save(e *QueueEntry) error {
+	data, err := json.MarshalIndent(e, "", "  ")
+	if err != nil {
+		return fmt.Errorf("marshal queued session: %w", err)
+	}
+	path := q.entryPath(e.SessionID)
+	tmp := path + ".tmp"
+	if err := os.WriteFile(tmp, data, credFilePerm); err != nil {
+		return fmt.Errorf("write queued session: %w", err)
+	}
+	if err := os.Rename(tmp, path); err != nil {
+		_ = os.Remove(tmp)
+		return fmt.Errorf("rename queued session: %w", err)
+	}
+	return nil
+}
+
+// SyncOptions controls Sync. Zero values use the defaults.
+type SyncOptions struct {
+	BatchSize   int                 // sessions per request (default 20)
+	MaxAttempts int                 // tries per batch on retryable errors (default 4)
+	Backoff     time.Duration       // first retry delay, doubled each time (default 1s)
+	Sleep       func(time.Duration) // default time.Sleep
+	Privacy     *Privacy            // fields withheld from every submission
+}
+
+// SyncReport lists what Sync did with each queued session.
+type SyncReport struct {
+	Sent       []*QueueEntry
+	Duplicates []*QueueEntry // already known locally or to the server
+	Rejected   []*QueueEntry // refused by the server; LastError says why
+	Pending    []*QueueEntry // left in the queue after retryable failures
+}
+
+// Sync submits the queued sessions in batches. Rate limits, server errors
+// and network failures are retried with exponential backoff; when retries
+// run out the remaining sessions stay queued for the next sync. Sessions
+// the server refuses (4xx) are kept with their error so they are not lost,
+// and an authentication failure stops the sync.
+func Sync(ctx context.Context, client *Client, q *Queue, opts SyncOptions) (*SyncReport, error) {
+	if opts.BatchSize <= 0 {
+		opts.BatchSize = 20
+	}
+	if opts.MaxAttempts <= 0 {
+		opts.MaxAttempts = 4
+	}
+	if opts.Backoff <= 0 {
+		opts.Backoff = time.Second
+	}
+	if opts.Sleep == nil {
+		opts.Sleep = time.Sleep
+	}
+
+	entries, err := q.List()
+	if err != nil {
+		return nil, err
+	}
+	sent, err := q.Sent()
+	if err != nil {
+		return nil, err
+	}
+
+	report := &SyncReport{}
+	var todo []*QueueEntry
+	seen := make(map[string]bool)
+	for _, e := range entries {
+		hash := e.Submission.SessionHash
+		if sent[hash] || seen[hash] {
+			if err := q.Remove(e.SessionID); err != nil {
+				return report, err
+			}
+			report.Duplicates = append(report.Duplicates, e)
+			continue
+		}
+		seen[hash] = true
+		todo = append(todo, e)
+	}
+
+	for i := 0; i < len(todo); i += opts.BatchSize {
+		batch := todo[i:min(i+opts.BatchSize, len(todo))]
+		out, err := submitWithRetry(ctx, client, batch, opts)
+		for _, e := range batch {
+			hash := e.Submission.SessionHash
+			switch {
+			case out.rejected[hash] != "":
+				e.Attempts++
+				e.LastError = out.rejected[hash]
+				if err := q.save(e); err != nil {
+					return report, err
+				}
+				report.Rejected = append(report.Rejected, e)
+				continue
+			case out.duplicate[hash]:
+				report.Duplicates = append(report.Duplicates, e)
+			case out.sent[hash]:
+				report.Sent = append(report.Sent, e)
+			default:
+				continue
+			}
+			if err := q.markSent(e); err != nil {
+				return report, err
+			}
+		}
+		if err != nil {
+			for _, e := range batch {
+				if out.done(e.Submission.SessionHash) {
+					continue
+				}
+				e.Attempts++
+				e.LastError = err.Error()
+				_ = q.save(e)
+				report.Pending = append(report.Pending, e)
+			}
+			report.Pending = append(report.Pending, todo[i+len(batch):]...)
+			var authErr *AuthError
+			if errors.As(err, &authErr) || ctx.Err() != nil {
+				return report, err
+			}
+			return report, nil
+		}
+	}
+	return report, nil
+}
+
+// batchOutcome collects per-session results across the attempts of a batch.
+type batchOutcome struct {
+	sent      map[string]bool
+	duplicate map[string]bool
+	rejected  map[string]string
+}
+
+func (o *batchOutcome) done(hash string) bool {
+	return o.sent[hash] || o.duplicate[hash] || o.rejected[hash] != ""
+}
+
+func submitWithRetry(ctx context.Context, client *Client, batch []*QueueEntry, opts SyncOptions) (*batchOutcome, error) {
+	out := &batchOutcome{sent: make(map[string]bool), duplicate: make(map[string]bool), rejected: make(map[string]string)}
+	delay := opts.Backoff
+	for attempt := 1; ; attempt++ {
+		err := submitBatch(ctx, client, batch, opts.Privacy, out)
+		if err == nil || !IsRetryable(err) || attempt >= opts.MaxAttempts || ctx.Err() != nil {
+			return out, err
+		}
+		opts.Sleep(delay)
+		delay *= 2
+	}
+}
+
+// submitBatch sends the sessions of a batch without a result yet, falling
+// back to one request per session when the server has no batch endpoint.
+func submitBatch(ctx context.Context, client *Client, batch []*QueueEntry, privacy *Privacy, out *batchOutcome) error {
+	var subs []*SessionSubmission
+	for _, e := range batch {
+		if !out.done(e.Submission.SessionHash) {
+			sub := e.Submission
+			privacy.Apply(&sub)
+			subs = append(subs, &sub)
+		}
+	}
+	if len(subs) == 0 {
+		return nil
+	}
+
+	res, err := client.SubmitSessions(ctx, subs)
+	var apiErr *APIError
+	if err == nil {
+		for _, h := range res.Duplicates {
+			out.duplicate[h] = true
+		}
+		for _, r := range res.Rejected {
+			out.rejected[r.SessionHash] = r.Message
+		}
+		for _, sub := range subs {
+			if !out.done(sub.SessionHash) {
+				out.sent[sub.SessionHash] = true
+			}
+		}
+		return nil
+	}
+	if !errors.As(err, &apiErr) || apiErr.StatusCode != http.StatusNotFound && apiErr.StatusCode != http.StatusMethodNotAllowed {
+		return err
+	}
+
+	for _, sub := range subs {
+		err := client.SubmitSession(ctx, sub)
+		switch {
+		case err == nil:
+			out.sent[sub.SessionHash] = true
+		case errors.As(err, &apiErr) && apiErr.StatusCode == http.StatusConflict:
+			out.duplicate[sub.SessionHash] = true
+		case errors.As(err, &apiErr) && !IsRetryable(err):
+			out.rejected[sub.SessionHash] = apiErr.Message
+		default:
+			return err
+		}
+	}
+	return nil
+}
+
+// IsRetryable reports whether a failed request may succeed if repeated:
+// network errors, rate limiting and server errors.
+func IsRetryable(err error) bool {
+	var apiErr *APIError
+	if errors.As(err, &apiErr) {
+		return apiErr.StatusCode == http.StatusTooManyRequests ||
+			apiErr.StatusCode == http.StatusRequestTimeout ||
+			apiErr.StatusCode >= 500
+	}
+	var clientErr *ClientError
+	return errors.As(err, &clientErr)
+}
@@ -0,0 +1,241 @@
+package rank
+
+import (
+	"context"
+	"encoding/json"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"sync"
+	"testing"
+	"time"
+)
+
+// stubServer records the submitted session hashes. Each status in
+// batchStatus answers one batch request, in order, before batches succeed;
+// noBatch makes the batch endpoint answer 404.
+type stubServer struct {
+	mu          sync.Mutex
+	batchStatus []int
+	noBatch     bool
+	known       map[string]bool
+	batches     int
+	singles     int
+	badSigs     int
+}
+
+func (s *stubServer) handler(t *testing.T) http.Handler {
+	mux := http.NewServeMux()
+	check := func(r *http.Request) []byte {
+		body, _ := io.ReadAll(r.Body)
+		if r.Header.Get("X-Signature") != ComputeSignature(r.Header.Get("X-API-Key"), r.Header.Get("X-Timestamp"), string(body)) {
+			s.badSigs++
+		}
+		return body
+	}
+	mux.HandleFunc("POST /api/v1/sessions/batch", func(w http.ResponseWriter, r *http.Request) {
+		s.mu.Lock()
+		defer s.mu.Unlock()
+		body := check(r)
+		if s.noBatch {
+			http.NotFound(w, r)
+			return
+		}
+		s.batches++
+		if len(s.batchStatus) > 0 {
+			status := s.batchStatus[0]
+			s.batchStatus = s.batchStatus[1:]
+			w.WriteHeader(status)
+			_, _ = w.Write([]byte(`{"message":"try later"}`))
+			return
+		}
+		var req struct {
+			Sessions []SessionSubmission `json:"sessions"`
+		}
+		if err := json.Unmarshal(body, &req); err != nil {
+			t.Errorf("batch body: %v", err)
+		}
+		var res BatchResult
+		for _, sub := range req.Sessions {
+			switch {
+			case sub.InputTokens < 0:
+				res.Rejected = append(res.Rejected, BatchRejection{SessionHash: sub.SessionHash, Message: "negative tokens"})
+			case s.known[sub.SessionHash]:
+				res.Duplicates = append(res.Duplicates, sub.SessionHash)
+			default:
+				s.known[sub.SessionHash] = true
+				res.Accepted = append(res.Accepted, sub.SessionHash)
+			}
+		}
+		_ = json.NewEncoder(w).Encode(res)
+	})
+	mux.HandleFunc("POST /api/v1/sessions", func(w http.ResponseWriter, r *http.Request) {
+		s.mu.Lock()
+		defer s.mu.Unlock()
+		s.singles++
+		var sub SessionSubmission
+		_ = json.Unmarshal(check(r), &sub)
+		if s.known[sub.SessionHash] {
+			w.WriteHeader(http.StatusConflict)
+			return
+		}
+		s.known[sub.SessionHash] = true
+		w.WriteHeader(http.StatusCreated)
+	})
+	return mux
+}
+
+func newStub(t *testing.T, s *stubServer) *Client {
+	t.Helper()
+	s.known = make(map[string]bool)
+	srv := httptest.NewServer(s.handler(t))
+	t.Cleanup(srv.Close)
+	t.Setenv("DO_RANK_API_URL", "")
+	t.Setenv("RANK_API_URL", srv.URL)
+	return NewClient("test-key")
+}
+
+func queueSessions(t *testing.T, q *Queue, ids ...string) {
+	t.Helper()
+	for i, id := range ids {
+		sub, err := BuildSubmission(&TranscriptUsage{EndedAt: "2026-01-05T10:00:00Z", InputTokens: int64(100 * (i + 1)), OutputTokens: 10})
+		if err != nil {
+			t.Fatal(err)
+		}
+		if err := q.Add(id, sub); err != nil {
+			t.Fatal(err)
+		}
+	}
+}
+
+func Test_Sync_batches_retries_and_dedupes(t *testing.T) {
+	stub := &stubServer{batchStatus: []int{http.StatusServiceUnavailable, http.StatusTooManyRequests}}
+	client := newStub(t, stub)
+	q := NewQueue(t.TempDir())
+	queueSessions(t, q, "s1", "s2", "s3")
+
+	var sleeps []time.Duration
+	opts := SyncOptions{BatchSize: 2, Backoff: time.Millisecond, Sleep: func(d time.Duration) { sleeps = append(sleeps, d) }}
+	report, err := Sync(context.Background(), client, q, opts)
+	if err != nil {
+		t.Fatalf("Sync: %v", err)
+	}
+	if len(report.Sent) != 3 || len(report.Pending) != 0 || len(report.Duplicates) != 0 {
+		t.Errorf("report: %+v", report)
+	}
+	if stub.batches != 4 || stub.badSigs != 0 {
+		t.Errorf("batches %d, bad signatures %d", stub.batches, stub.badSigs)
+	}
+	if len(sleeps) != 2 || sleeps[1] != 2*sleeps[0] {
+		t.Errorf("backoff: %v", sleeps)
+	}
+	if entries, _ := q.List(); len(entries) != 0 {
+		t.Errorf("queue not drained: %d left", len(entries))
+	}
+
+	// A session that ends again after it was sent is not queued twice.
+	queueSessions(t, q, "s1")
+	if entries, _ := q.List(); len(entries) != 0 {
+		t.Errorf("sent session requeued: %+v", entries)
+	}
+
+	// A session the server already has is reported as a duplicate.
+	queueSessions(t, q, "s4")
+	entries, _ := q.List()
+	stub.known[entries[0].Submission.SessionHash] = true
+	report, err = Sync(context.Background(), client, q, opts)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(report.Duplicates) != 1 || len(report.Sent) != 0 {
+		t.Errorf("duplicate report: %+v", report)
+	}
+}
+
+func Test_Sync_keeps_sessions_queued_when_retries_run_out(t *testing.T) {
+	stub := &stubServer{batchStatus: []int{502, 502, 502}}
+	client := newStub(t, stub)
+	q := NewQueue(t.TempDir())
+	queueSessions(t, q, "s1", "s2")
+
+	report, err := Sync(context.Background(), client, q, SyncOptions{MaxAttempts: 3, Sleep: func(time.Duration) {}})
+	if err != nil {
+		t.Fatalf("Sync: %v", err)
+	}
+	if len(report.Pending) != 2 || len(report.Sent) != 0 {
+		t.Errorf("report: %+v", report)
+	}
+	entries, _ := q.List()
+	if len(entries) != 2 || entries[0].Attempts != 1 || entries[0].LastError == "" {
+		t.Errorf("queued entries: %+v", entries)
+	}
+}
+
+func Test_Sync_falls_back_to_single_submissions(t *testing.T) {
+	stub := &stubServer{noBatch: true}
+	client := newStub(t, stub)
+	q := NewQueue(t.TempDir())
+	queueSessions(t, q, "s1", "s2")
+	entries, _ := q.List()
+	stub.known[entries[1].Submission.SessionHash] = true
+
+	report, err := Sync(context.Background(), client, q, SyncOptions{})
+	if err != nil {
+		t.Fatalf("Sync: %v", err)
+	}
+	if stub.singles != 2 || len(report.Sent) != 1 || len(report.Duplicates) != 1 {
+		t.Errorf("singles %d, report %+v", stub.singles, report)
+	}
+}
+
+func Test_Queue_Add_keeps_hash_of_requeued_session(t *testing.T) {
+	q := NewQueue(t.TempDir())
+	queueSessions(t, q, "s1")
+	first, _ := q.List()
+	queueSessions(t, q, "s1")
+	second, _ := q.List()
+	if len(second) != 1 || second[0].Submission.SessionHash != first[0].Submission.SessionHash {
+		t.Errorf("requeue changed entry: %+v -> %+v", first[0], second[0])
+	}
+	if err := q.Add("../evil", &SessionSubmission{}); err == nil {
+		t.Error("invalid session ID should be refused")
+	}
+}
+
+func Test_Queue_Submit_then_Sync_sends_session_once(t *testing.T) {
+	stub := &stubServer{noBatch: true}
+	client := newStub(t, stub)
+	q := NewQueue(t.TempDir())
+
+	// SessionEnd queues the session; then the user submits it by hand,
+	// with a freshly built submission and therefore a new random hash.
+	queueSessions(t, q, "s1")
+	queued, _ := q.List()
+	sub, err := BuildSubmission(&TranscriptUsage{EndedAt: "2026-01-05T10:00:00Z", InputTokens: 100, OutputTokens: 10})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if sub.SessionHash == queued[0].Submission.SessionHash {
+		t.Fatal("test needs distinct hashes")
+	}
+	if wasQueued, err := q.Submit(context.Background(), client, "s1", sub, nil); err != nil || wasQueued {
+		t.Fatalf("Submit: queued %v, %v", wasQueued, err)
+	}
+	if !stub.known[queued[0].Submission.SessionHash] || len(stub.known) != 1 {
+		t.Errorf("server got %v, want the queued hash", stub.known)
+	}
+	if entries, _ := q.List(); len(entries) != 0 {
+		t.Errorf("submitted session still queued: %+v", entries)
+	}
+
+	report, err := Sync(context.Background(), client, q, SyncOptions{})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(report.Sent) != 0 || stub.singles != 1 {
+		t.Errorf("sync resent the session: report %+v, singles %d", report, stub.singles)
+	}
+	if _, err := q.Submit(context.Background(), client, "s1", sub, nil); err != ErrAlreadySent {
+		t.Errorf("second submit: %v", err)
+	}
+}
@@ -1,16 +1,15 @@
 package rank
 
 import (
-	"bufio"
 	"crypto/sha256"
 	"encoding/hex"
 	"encoding/json"
-	"fmt"
 	"os"
 	"path/filepath"
 	"runtime"
-	"strings"
 	"time"
+
+	"github.com/yejune/godo/internal/transcript"
 )
 
 // TranscriptUsage represents token usage extracted from a Claude Code transcript.
@@ -20,88 +19,56 @@ type TranscriptUsage struct {
 	CacheCreationTokens int64  `json:"cache_creation_tokens"`
 	CacheReadTokens     int64  `json:"cache_read_tokens"`
 	ModelName           string `json:"model_name"`
+	ProjectPath         string `json:"project_path,omitempty"`
 	StartedAt           string `json:"started_at,omitempty"`
 	EndedAt             string `json:"ended_at,omitempty"`
 	DurationSeconds     int    `json:"duration_seconds,omitempty"`
 	TurnCount           int    `json:"turn_count,omitempty"`
 }
 
-// transcriptMessage represents a single line in the JSONL transcript file.
-type transcriptMessage struct {
-	Timestamp string        `json:"timestamp"`
-	Type      string        `json:"type"`
-	Message   transcriptMsg `json:"message"`
-	Model     string        `json:"model"`
-}
-
-// transcriptMsg represents the message content with usage data.
-type transcriptMsg struct {
-	Usage *transcriptUsage `json:"usage"`
-	Model string           `json:"model"`
-}
-
-// transcriptUsage represents token usage information from a transcript line.
-type transcriptUsage struct {
-	InputTokens              int64 `json:"input_tokens"`
-	OutputTokens             int64 `json:"output_tokens"`
-	CacheCreationInputTokens int64 `json:"cache_creation_input_tokens"`
-	CacheReadInputTokens     int64 `json:"cache_read_input_tokens"`
-}
-
 // ParseTranscript parses a Claude Code transcript JSONL file and extracts token usage.
+// A response written as several lines (one per content block) is counted once,
+// and only prompts typed by the user count as turns.
 func ParseTranscript(path string) (*TranscriptUsage, error) {
-	file, err := os.Open(path)
-	if err != nil {
-		return nil, fmt.Errorf("open transcript: %w", err)
-	}
-	defer func() { _ = file.Close() }()
-
 	usage := &TranscriptUsage{}
 	var firstTimestamp, lastTimestamp string
+	seen := make(transcript.Responses)
 
-	scanner := bufio.NewScanner(file)
-	scanner.Buffer(make([]byte, 0, 64*1024), 1024*1024)
-
-	for scanner.Scan() {
-		line := strings.TrimSpace(scanner.Text())
-		if line == "" {
-			continue
+	err := transcript.ReadLines(path, func(l transcript.Line) {
+		if l.Timestamp != "" {
+			if firstTimestamp == "" {
+				firstTimestamp = l.Timestamp
+			}
+			lastTimestamp = l.Timestamp
 		}
 
-		var msg transcriptMessage
-		if err := json.Unmarshal([]byte(line), &msg); err != nil {
-			continue
+		if usage.ProjectPath == "" && !l.IsSidechain {
+			usage.ProjectPath = l.CWD
 		}
 
-		if msg.Timestamp != "" {
-			if firstTimestamp == "" {
-				firstTimestamp = msg.Timestamp
-			}
-			lastTimestamp = msg.Timestamp
+		var msg transcript.Message
+		if len(l.Message) == 0 || json.Unmarshal(l.Message, &msg) != nil {
+			return
 		}
 
-		if msg.Type == "user" {
+		if transcript.IsPrompt(l, &msg) {
 			usage.TurnCount++
 		}
 
-		if usage.ModelName == "" {
-			if msg.Model != "" {
-				usage.ModelName = msg.Model
-			} else if msg.Message.Model != "" {
-				usage.ModelName = msg.Message.Model
-			}
+		if usage.ModelName == "" && !l.IsSidechain && msg.Model != "" && msg.Model != "<synthetic>" {
+			usage.ModelName = msg.Model
 		}
 
-		if msg.Message.Usage != nil {
-			usage.InputTokens += msg.Message.Usage.InputTokens
-			usage.OutputTokens += msg.Message.Usage.OutputTokens
-			usage.CacheCreationTokens += msg.Message.Usage.CacheCreationInputTokens
-			usage.CacheReadTokens += msg.Message.Usage.CacheReadInputTokens
+		if msg.Billed() && seen.First(l, &msg) {
+			t := msg.Tokens()
+			usage.InputTokens += t.Input
+			usage.OutputTokens += t.Output
+			usage.CacheCreationTokens += t.CacheCreation
+			usage.CacheReadTokens += t.CacheRead
 		}
-	}
-
-	if err := scanner.Err(); err != nil {
-		return nil, fmt.Errorf("scan transcript: %w", err)
+	})
+	if err != nil {
+		return nil, err
 	}
 
 	usage.StartedAt = firstTimestamp
@@ -0,0 +1,54 @@
+package rank
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+func Test_ParseTranscript_and_BuildSubmission_fill_every_field(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "s1.jsonl")
+	lines := `{"type":"user","timestamp":"2026-01-05T10:00:00Z","cwd":"/work/api","message":{"role":"user","content":"hi"}}
+{"type":"assistant","timestamp":"2026-01-05T10:00:05Z","requestId":"r1","message":{"id":"m1","model":"claude-opus-4-5","content":[{"type":"text"}],"usage":{"input_tokens":10,"output_tokens":5,"cache_creation_input_tokens":100,"cache_read_input_tokens":1000}}}
+{"type":"assistant","timestamp":"2026-01-05T10:00:06Z","requestId":"r1","message":{"id":"m1","model":"claude-opus-4-5","content":[{"type":"tool_use"}],"usage":{"input_tokens":10,"output_tokens":5,"cache_creation_input_tokens":100,"cache_read_input_tokens":1000}}}
+{"type":"user","timestamp":"2026-01-05T10:00:07Z","message":{"role":"user","content":[{"type":"tool_result","content":"ok"}]}}
+{"type":"user","timestamp":"2026-01-05T10:01:00Z","isMeta":true,"message":{"role":"user","content":"caveat"}}
+{"type":"user","timestamp":"2026-01-05T10:02:00Z","message":{"role":"user","content":[{"type":"text","text":"more"}]}}
+{"type":"assistant","timestamp":"2026-01-05T10:02:30Z","requestId":"r2","message":{"id":"m2","model":"claude-opus-4-5","usage":{"input_tokens":1,"output_tokens":2}}}
+`
+	if err := os.WriteFile(path, []byte(lines), 0644); err != nil {
+		t.Fatal(err)
+	}
+
+	usage, err := ParseTranscript(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if usage.InputTokens != 11 || usage.OutputTokens != 7 || usage.CacheCreationTokens != 100 || usage.CacheReadTokens != 1000 {
+		t.Errorf("tokens: %+v", usage)
+	}
+	if usage.TurnCount != 2 || usage.ModelName != "claude-opus-4-5" || usage.ProjectPath != "/work/api" || usage.DurationSeconds != 150 {
+		t.Errorf("usage: %+v", usage)
+	}
+
+	sub, err := BuildSubmission(usage)
+	if err != nil {
+		t.Fatal(err)
+	}
+	want := SessionSubmission{
+		SessionHash:         sub.SessionHash,
+		EndedAt:             "2026-01-05T10:02:30Z",
+		InputTokens:         11,
+		OutputTokens:        7,
+		CacheCreationTokens: 100,
+		CacheReadTokens:     1000,
+		ModelName:           "claude-opus-4-5",
+		AnonymousProjectID:  AnonymizeProjectPath("/work/api"),
+		StartedAt:           "2026-01-05T10:00:00Z",
+		DurationSeconds:     150,
+		TurnCount:           2,
+	}
+	if *sub != want || len(sub.SessionHash) != 64 {
+		t.Errorf("submission:\n got %+v\nwant %+v", *sub, want)
+	}
+}
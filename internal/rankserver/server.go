@@ -0,0 +1,239 @@
+package rankserver
+
+import (
+	"crypto/hmac"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"log"
+	"net/http"
+	"strconv"
+	"time"
+
+	"github.com/yejune/godo/internal/rank"
+)
+
+// Request limits.
+const (
+	MaxBodyBytes  = 1 << 20  // single session
+	MaxBatchBytes = 16 << 20 // batch
+	MaxBatchSize  = 500
+	MaxClockSkew  = 5 * time.Minute
+)
+
+// Server serves the Rank API from a Store.
+type Server struct {
+	store *Store
+	now   func() time.Time
+	log   *log.Logger
+}
+
+// New returns a Server for store. Requests are logged to logger when it is
+// not nil.
+func New(store *Store, logger *log.Logger) *Server {
+	return &Server{store: store, now: time.Now, log: logger}
+}
+
+// Handler returns the HTTP handler for the API:
+//
+//	POST /api/v1/sessions        submit one session (409 if already known)
+//	POST /api/v1/sessions/batch  submit {"sessions": [...]}
+//	GET  /api/v1/rank            the caller's totals and positions
+//
+// Every request must carry X-API-Key, X-Timestamp and X-Signature as sent
+// by rank.Client.
+func (s *Server) Handler() http.Handler {
+	mux := http.NewServeMux()
+	mux.HandleFunc("POST /api/"+rank.APIVersion+"/sessions", s.handleSession)
+	mux.HandleFunc("POST /api/"+rank.APIVersion+"/sessions/batch", s.handleBatch)
+	mux.HandleFunc("GET /api/"+rank.APIVersion+"/rank", s.handleRank)
+	mux.HandleFunc("GET /healthz", func(w http.ResponseWriter, r *http.Request) {
+		writeJSON(w, http.StatusOK, map[string]string{"status": "ok"})
+	})
+	return mux
+}
+
+func (s *Server) handleSession(w http.ResponseWriter, r *http.Request) {
+	user, body, ok := s.authenticate(w, r, MaxBodyBytes)
+	if !ok {
+		return
+	}
+	var sub rank.SessionSubmission
+	if err := json.Unmarshal(body, &sub); err != nil {
+		writeError(w, http.StatusBadRequest, "invalid session JSON")
+		return
+	}
+	endedAt, err := validate(&sub)
+	if err != nil {
+		writeError(w, http.StatusUnprocessableEntity, err.Error())
+		return
+	}
+	added, err := s.store.addSession(user.ID, &sub, endedAt)
+	if err != nil {
+		s.internalError(w, err)
+		return
+	}
+	if !added {
+		writeError(w, http.StatusConflict, "session already submitted")
+		return
+	}
+	s.logf("%s submitted session %.12s", user.Username, sub.SessionHash)
+	writeJSON(w, http.StatusCreated, map[string]string{"session_hash": sub.SessionHash})
+}
+
+func (s *Server) handleBatch(w http.ResponseWriter, r *http.Request) {
+	user, body, ok := s.authenticate(w, r, MaxBatchBytes)
+	if !ok {
+		return
+	}
+	var req struct {
+		Sessions []rank.SessionSubmission `json:"sessions"`
+	}
+	if err := json.Unmarshal(body, &req); err != nil {
+		writeError(w, http.StatusBadRequest, "invalid batch JSON")
+		return
+	}
+	if len(req.Sessions) > MaxBatchSize {
+		writeError(w, http.StatusRequestEntityTooLarge, fmt.Sprintf("at most %d sessions per batch", MaxBatchSize))
+		return
+	}
+
+	res := rank.BatchResult{Accepted: []string{}, Duplicates: []string{}, Rejected: []rank.BatchRejection{}}
+	for i := range req.Sessions {
+		sub := &req.Sessions[i]
+		endedAt, err := validate(sub)
+		if err != nil {
+			res.Rejected = append(res.Rejected, rank.BatchRejection{SessionHash: sub.SessionHash, Message: err.Error()})
+			continue
+		}
+		added, err := s.store.addSession(user.ID, sub, endedAt)
+		if err != nil {
+			s.internalError(w, err)
+			return
+		}
+		if added {
+			res.Accepted = append(res.Accepted, sub.SessionHash)
+		} else {
+			res.Duplicates = append(res.Duplicates, sub.SessionHash)
+		}
+	}
+	s.logf("%s submitted batch: %d accepted, %d duplicate, %d rejected",
+		user.Username, len(res.Accepted), len(res.Duplicates), len(res.Rejected))
+	writeJSON(w, http.StatusOK, res)
+}
+
+func (s *Server) handleRank(w http.ResponseWriter, r *http.Request) {
+	user, _, ok := s.authenticate(w, r, 0)
+	if !ok {
+		return
+	}
+	info, err := s.store.userInfo(user, s.now())
+	if err != nil {
+		s.internalError(w, err)
+		return
+	}
+	writeJSON(w, http.StatusOK, info)
+}
+
+// authenticate checks the API key, the timestamp and the HMAC signature of
+// the body, and returns the caller and the body. On failure it has written
+// the response.
+func (s *Server) authenticate(w http.ResponseWriter, r *http.Request, limit int64) (*User, []byte, bool) {
+	var body []byte
+	if limit > 0 {
+		var err error
+		body, err = io.ReadAll(http.MaxBytesReader(w, r.Body, limit))
+		if err != nil {
+			writeError(w, http.StatusRequestEntityTooLarge, "request body too large")
+			return nil, nil, false
+		}
+	}
+
+	key := r.Header.Get("X-API-Key")
+	timestamp := r.Header.Get("X-Timestamp")
+	signature := r.Header.Get("X-Signature")
+	if key == "" || timestamp == "" || signature == "" {
+		writeError(w, http.StatusUnauthorized, "missing authentication headers")
+		return nil, nil, false
+	}
+	sec, err := strconv.ParseInt(timestamp, 10, 64)
+	if err != nil {
+		writeError(w, http.StatusUnauthorized, "invalid timestamp")
+		return nil, nil, false
+	}
+	if skew := s.now().Sub(time.Unix(sec, 0)); skew > MaxClockSkew || skew < -MaxClockSkew {
+		writeError(w, http.StatusUnauthorized, "timestamp outside the allowed clock skew")
+		return nil, nil, false
+	}
+	want, _ := hex.DecodeString(rank.ComputeSignature(key, timestamp, string(body)))
+	got, err := hex.DecodeString(signature)
+	if err != nil || !hmac.Equal(got, want) {
+		writeError(w, http.StatusUnauthorized, "invalid signature")
+		return nil, nil, false
+	}
+
+	user, err := s.store.userByKey(key)
+	if errors.Is(err, ErrUserNotFound) {
+		writeError(w, http.StatusUnauthorized, "unknown or revoked API key")
+		return nil, nil, false
+	}
+	if err != nil {
+		s.internalError(w, err)
+		return nil, nil, false
+	}
+	return user, body, true
+}
+
+// validate checks a submission and returns its end time.
+func validate(sub *rank.SessionSubmission) (time.Time, error) {
+	if len(sub.SessionHash) != 64 {
+		return time.Time{}, fmt.Errorf("session_hash must be 64 hex characters")
+	}
+	if _, err := hex.DecodeString(sub.SessionHash); err != nil {
+		return time.Time{}, fmt.Errorf("session_hash must be 64 hex characters")
+	}
+	endedAt, err := time.Parse(time.RFC3339Nano, sub.EndedAt)
+	if err != nil {
+		return time.Time{}, fmt.Errorf("ended_at must be an RFC 3339 time")
+	}
+	for _, f := range []struct {
+		name  string
+		value int64
+	}{
+		{"input_tokens", sub.InputTokens},
+		{"output_tokens", sub.OutputTokens},
+		{"cache_creation_tokens", sub.CacheCreationTokens},
+		{"cache_read_tokens", sub.CacheReadTokens},
+	} {
+		if f.value < 0 || f.value > rank.MaxTokens {
+			return time.Time{}, fmt.Errorf("%s must be between 0 and %d", f.name, rank.MaxTokens)
+		}
+	}
+	if sub.DurationSeconds < 0 || sub.TurnCount < 0 {
+		return time.Time{}, fmt.Errorf("duration_seconds and turn_count must not be negative")
+	}
+	return endedAt, nil
+}
+
+func (s *Server) internalError(w http.ResponseWriter, err error) {
+	s.logf("error: %v", err)
+	writeError(w, http.StatusInternalServerError, "internal error")
+}
+
+func (s *Server) logf(format string, args ...any) {
+	if s.log != nil {
+		s.log.Printf(format, args...)
+	}
+}
+
+func writeError(w http.ResponseWriter, status int, message string) {
+	writeJSON(w, status, map[string]string{"message": message})
+}
+
+func writeJSON(w http.ResponseWriter, status int, v any) {
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(status)
+	_ = json.NewEncoder(w).Encode(v)
+}
@@ -0,0 +1,151 @@
+package rankserver
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"path/filepath"
+	"strconv"
+	"testing"
+	"time"
+
+	"github.com/yejune/godo/internal/rank"
+)
+
+func newTestServer(t *testing.T) (*Store, *Server) {
+	t.Helper()
+	store, err := Open(filepath.Join(t.TempDir(), "rank.db"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(func() { store.Close() })
+	srv := New(store, nil)
+	ts := httptest.NewServer(srv.Handler())
+	t.Cleanup(ts.Close)
+	t.Setenv("DO_RANK_API_URL", "")
+	t.Setenv("RANK_API_URL", ts.URL)
+	return store, srv
+}
+
+func submission(t *testing.T, endedAt time.Time, input, output int64) *rank.SessionSubmission {
+	t.Helper()
+	sub, err := rank.BuildSubmission(&rank.TranscriptUsage{
+		EndedAt:      endedAt.UTC().Format(time.RFC3339Nano),
+		InputTokens:  input,
+		OutputTokens: output,
+		ModelName:    "claude-opus-4-5",
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	return sub
+}
+
+func Test_Server_accepts_signed_sessions_and_ranks_users(t *testing.T) {
+	store, _ := newTestServer(t)
+	aliceKey, err := store.IssueKey("alice")
+	if err != nil {
+		t.Fatal(err)
+	}
+	bobKey, _ := store.IssueKey("bob")
+	if _, err := store.IssueKey("alice"); !errors.Is(err, ErrUserExists) {
+		t.Errorf("duplicate user: %v", err)
+	}
+
+	ctx := context.Background()
+	alice, bob := rank.NewClient(aliceKey), rank.NewClient(bobKey)
+	now := time.Now()
+	old := now.AddDate(-1, 0, 0)
+
+	first := submission(t, now, 100, 50)
+	if err := alice.SubmitSession(ctx, first); err != nil {
+		t.Fatalf("submit: %v", err)
+	}
+	var apiErr *rank.APIError
+	if err := alice.SubmitSession(ctx, first); !errors.As(err, &apiErr) || apiErr.StatusCode != http.StatusConflict {
+		t.Errorf("resubmit: want 409, got %v", err)
+	}
+
+	// Bob leads all-time with an old session; alice leads today.
+	res, err := bob.SubmitSessions(ctx, []*rank.SessionSubmission{
+		submission(t, old, 10_000, 0),
+		submission(t, now, 10, 0),
+		{SessionHash: "bad", EndedAt: "yesterday"},
+	})
+	if err != nil {
+		t.Fatalf("batch: %v", err)
+	}
+	if len(res.Accepted) != 2 || len(res.Rejected) != 1 || len(res.Duplicates) != 0 {
+		t.Errorf("batch result: %+v", res)
+	}
+
+	info, err := alice.GetUserRank(ctx)
+	if err != nil {
+		t.Fatalf("rank: %v", err)
+	}
+	if info.Username != "alice" || info.TotalSessions != 1 || info.TotalTokens != 150 {
+		t.Errorf("alice totals: %+v", info)
+	}
+	if info.Daily == nil || info.Daily.Position != 1 || info.Daily.TotalParticipants != 2 || info.Daily.CompositeScore != 150 {
+		t.Errorf("alice daily: %+v", info.Daily)
+	}
+	if info.AllTime == nil || info.AllTime.Position != 2 {
+		t.Errorf("alice all-time: %+v", info.AllTime)
+	}
+
+	info, err = bob.GetUserRank(ctx)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if info.AllTime.Position != 1 || info.Daily.Position != 2 || info.TotalTokens != 10_010 {
+		t.Errorf("bob: %+v all-time %+v daily %+v", info, info.AllTime, info.Daily)
+	}
+
+	if err := store.RevokeKey("bob"); err != nil {
+		t.Fatal(err)
+	}
+	var authErr *rank.AuthError
+	if _, err := bob.GetUserRank(ctx); !errors.As(err, &authErr) {
+		t.Errorf("revoked key: want auth error, got %v", err)
+	}
+	users, err := store.Users()
+	if err != nil || len(users) != 2 || !users[1].Revoked || users[1].Sessions != 2 {
+		t.Errorf("users: %+v, %v", users, err)
+	}
+}
+
+func Test_Server_rejects_bad_signatures_and_stale_timestamps(t *testing.T) {
+	store, srv := newTestServer(t)
+	key, _ := store.IssueKey("alice")
+	ts := httptest.NewServer(srv.Handler())
+	defer ts.Close()
+
+	body := []byte(`{"session_hash":"x"}`)
+	send := func(timestamp, signature string) int {
+		req, _ := http.NewRequest(http.MethodPost, ts.URL+"/api/v1/sessions", bytes.NewReader(body))
+		req.Header.Set("X-API-Key", key)
+		req.Header.Set("X-Timestamp", timestamp)
+		req.Header.Set("X-Signature", signature)
+		resp, err := http.DefaultClient.Do(req)
+		if err != nil {
+			t.Fatal(err)
+		}
+		resp.Body.Close()
+		return resp.StatusCode
+	}
+
+	now := time.Now().Unix()
+	ts1 := strconv.FormatInt(now, 10)
+	if got := send(ts1, rank.ComputeSignature(key, ts1, `{"other":1}`)); got != http.StatusUnauthorized {
+		t.Errorf("signature over another body: got %d", got)
+	}
+	ts2 := strconv.FormatInt(now-3600, 10)
+	if got := send(ts2, rank.ComputeSignature(key, ts2, string(body))); got != http.StatusUnauthorized {
+		t.Errorf("stale timestamp: got %d", got)
+	}
+	if got := send(ts1, rank.ComputeSignature(key, ts1, string(body))); got != http.StatusUnprocessableEntity {
+		t.Errorf("valid signature, invalid session: got %d", got)
+	}
+}
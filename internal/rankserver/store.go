@@ -0,0 +1,300 @@
+// Package rankserver is a self-hosted implementation of the Rank API that
+// rank.Client speaks, so a team can keep an internal leaderboard. Users get
+// API keys issued by the operator instead of signing in with OAuth.
+package rankserver
+
+import (
+	"crypto/rand"
+	"crypto/sha256"
+	"database/sql"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+	"time"
+
+	"github.com/yejune/godo/internal/rank"
+
+	_ "modernc.org/sqlite" // registers the "sqlite" driver
+)
+
+// KeyPrefix starts every issued API key.
+const KeyPrefix = "godo_"
+
+// ErrUserExists is returned when issuing a key for a username in use.
+var ErrUserExists = errors.New("user already exists")
+
+// ErrUserNotFound is returned for an unknown or revoked user.
+var ErrUserNotFound = errors.New("user not found")
+
+const schema = `
+CREATE TABLE IF NOT EXISTS users (
+	id         INTEGER PRIMARY KEY,
+	username   TEXT NOT NULL UNIQUE,
+	key_hash   TEXT NOT NULL UNIQUE,
+	created_at TEXT NOT NULL,
+	revoked_at TEXT
+);
+CREATE TABLE IF NOT EXISTS sessions (
+	session_hash          TEXT PRIMARY KEY,
+	user_id               INTEGER NOT NULL REFERENCES users(id),
+	ended_at              TEXT NOT NULL,
+	input_tokens          INTEGER NOT NULL,
+	output_tokens         INTEGER NOT NULL,
+	cache_creation_tokens INTEGER NOT NULL DEFAULT 0,
+	cache_read_tokens     INTEGER NOT NULL DEFAULT 0,
+	model_name            TEXT NOT NULL DEFAULT '',
+	anonymous_project_id  TEXT NOT NULL DEFAULT '',
+	started_at            TEXT NOT NULL DEFAULT '',
+	duration_seconds      INTEGER NOT NULL DEFAULT 0,
+	turn_count            INTEGER NOT NULL DEFAULT 0,
+	received_at           TEXT NOT NULL
+);
+CREATE INDEX IF NOT EXISTS sessions_ended ON sessions(ended_at);
+`
+
+// timeFormat stores times in UTC with a fixed width, so they sort as text.
+const timeFormat = "2006-01-02T15:04:05Z"
+
+// User is an API key holder. The key itself is never stored, only its
+// SHA-256 hash.
+type User struct {
+	ID        int64
+	Username  string
+	CreatedAt time.Time
+	Revoked   bool
+	Sessions  int
+	Tokens    int64
+}
+
+// Store keeps users and sessions in a SQLite database.
+type Store struct {
+	db *sql.DB
+}
+
+// DefaultDBPath returns the default database path (~/.do/rank/server.db).
+func DefaultDBPath() string {
+	return filepath.Join(rank.GetCredentialsDir(), "server.db")
+}
+
+// Open opens (creating if needed) the database at path.
+func Open(path string) (*Store, error) {
+	if err := os.MkdirAll(filepath.Dir(path), 0700); err != nil {
+		return nil, fmt.Errorf("create database directory: %w", err)
+	}
+	dsn := "file:" + path + "?_pragma=busy_timeout(5000)&_pragma=journal_mode(WAL)&_pragma=foreign_keys(1)"
+	db, err := sql.Open("sqlite", dsn)
+	if err != nil {
+		return nil, fmt.Errorf("open database: %w", err)
+	}
+	if _, err := db.Exec(schema); err != nil {
+		_ = db.Close()
+		return nil, fmt.Errorf("create schema: %w", err)
+	}
+	return &Store{db: db}, nil
+}
+
+// Close closes the database.
+func (s *Store) Close() error {
+	return s.db.Close()
+}
+
+// IssueKey creates a user and returns its new API key.
+func (s *Store) IssueKey(username string) (string, error) {
+	username = strings.TrimSpace(username)
+	if username == "" {
+		return "", fmt.Errorf("username is required")
+	}
+	key, err := newKey()
+	if err != nil {
+		return "", err
+	}
+	var exists int
+	if err := s.db.QueryRow(`SELECT COUNT(*) FROM users WHERE username = ?`, username).Scan(&exists); err != nil {
+		return "", fmt.Errorf("look up user: %w", err)
+	}
+	if exists > 0 {
+		return "", fmt.Errorf("%w: %s", ErrUserExists, username)
+	}
+	_, err = s.db.Exec(`INSERT INTO users (username, key_hash, created_at) VALUES (?, ?, ?)`,
+		username, hashKey(key), time.Now().UTC().Format(timeFormat))
+	if err != nil {
+		return "", fmt.Errorf("create user: %w", err)
+	}
+	return key, nil
+}
+
+// RotateKey replaces the API key of an active user and returns the new one.
+func (s *Store) RotateKey(username string) (string, error) {
+	key, err := newKey()
+	if err != nil {
+		return "", err
+	}
+	res, err := s.db.Exec(`UPDATE users SET key_hash = ? WHERE username = ? AND revoked_at IS NULL`, hashKey(key), username)
+	if err != nil {
+		return "", fmt.Errorf("rotate key: %w", err)
+	}
+	if n, _ := res.RowsAffected(); n == 0 {
+		return "", fmt.Errorf("%w: %s", ErrUserNotFound, username)
+	}
+	return key, nil
+}
+
+// RevokeKey disables a user's API key. Their sessions stay on the board.
+func (s *Store) RevokeKey(username string) error {
+	res, err := s.db.Exec(`UPDATE users SET revoked_at = ? WHERE username = ? AND revoked_at IS NULL`,
+		time.Now().UTC().Format(timeFormat), username)
+	if err != nil {
+		return fmt.Errorf("revoke key: %w", err)
+	}
+	if n, _ := res.RowsAffected(); n == 0 {
+		return fmt.Errorf("%w: %s", ErrUserNotFound, username)
+	}
+	return nil
+}
+
+// Users lists all users with their session and token totals.
+func (s *Store) Users() ([]User, error) {
+	rows, err := s.db.Query(`
+		SELECT u.id, u.username, u.created_at, u.revoked_at IS NOT NULL,
+		       COUNT(s.session_hash), COALESCE(SUM(s.input_tokens + s.output_tokens), 0)
+		FROM users u LEFT JOIN sessions s ON s.user_id = u.id
+		GROUP BY u.id ORDER BY u.username`)
+	if err != nil {
+		return nil, fmt.Errorf("list users: %w", err)
+	}
+	defer rows.Close()
+
+	var users []User
+	for rows.Next() {
+		var u User
+		var created string
+		if err := rows.Scan(&u.ID, &u.Username, &created, &u.Revoked, &u.Sessions, &u.Tokens); err != nil {
+			return nil, fmt.Errorf("scan user: %w", err)
+		}
+		u.CreatedAt, _ = time.Parse(timeFormat, created)
+		users = append(users, u)
+	}
+	return users, rows.Err()
+}
+
+// userByKey returns the active user holding key.
+func (s *Store) userByKey(key string) (*User, error) {
+	var u User
+	err := s.db.QueryRow(`SELECT id, username FROM users WHERE key_hash = ? AND revoked_at IS NULL`, hashKey(key)).
+		Scan(&u.ID, &u.Username)
+	if errors.Is(err, sql.ErrNoRows) {
+		return nil, ErrUserNotFound
+	}
+	if err != nil {
+		return nil, fmt.Errorf("look up key: %w", err)
+	}
+	return &u, nil
+}
+
+// addSession stores a session. It reports false when the session hash is
+// already known.
+func (s *Store) addSession(userID int64, sub *rank.SessionSubmission, endedAt time.Time) (bool, error) {
+	res, err := s.db.Exec(`
+		INSERT INTO sessions (session_hash, user_id, ended_at, input_tokens, output_tokens,
+			cache_creation_tokens, cache_read_tokens, model_name, anonymous_project_id,
+			started_at, duration_seconds, turn_count, received_at)
+		VALUES (?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?)
+		ON CONFLICT (session_hash) DO NOTHING`,
+		sub.SessionHash, userID, endedAt.UTC().Format(timeFormat), sub.InputTokens, sub.OutputTokens,
+		sub.CacheCreationTokens, sub.CacheReadTokens, sub.ModelName, sub.AnonymousProjectID,
+		sub.StartedAt, sub.DurationSeconds, sub.TurnCount, time.Now().UTC().Format(timeFormat))
+	if err != nil {
+		return false, fmt.Errorf("store session: %w", err)
+	}
+	n, _ := res.RowsAffected()
+	return n > 0, nil
+}
+
+// userInfo returns the totals and leaderboard positions of a user. The
+// score of a period is the input plus output tokens of the sessions that
+// ended in it; periods start at UTC midnight, Monday and the first of the
+// month. A period in which the user has no sessions has no position.
+func (s *Store) userInfo(u *User, now time.Time) (*rank.UserInfo, error) {
+	info := &rank.UserInfo{Username: u.Username, LastUpdated: now.UTC().Format(time.RFC3339)}
+	err := s.db.QueryRow(`
+		SELECT COUNT(*), COALESCE(SUM(input_tokens), 0), COALESCE(SUM(output_tokens), 0), COALESCE(MAX(received_at), '')
+		FROM sessions WHERE user_id = ?`, u.ID).
+		Scan(&info.TotalSessions, &info.InputTokens, &info.OutputTokens, &info.LastUpdated)
+	if err != nil {
+		return nil, fmt.Errorf("load user totals: %w", err)
+	}
+	info.TotalTokens = info.InputTokens + info.OutputTokens
+	if info.LastUpdated == "" {
+		info.LastUpdated = now.UTC().Format(time.RFC3339)
+	}
+
+	day := time.Date(now.UTC().Year(), now.UTC().Month(), now.UTC().Day(), 0, 0, 0, 0, time.UTC)
+	week := day.AddDate(0, 0, -((int(day.Weekday()) + 6) % 7))
+	month := time.Date(day.Year(), day.Month(), 1, 0, 0, 0, 0, time.UTC)
+	for _, p := range []struct {
+		since time.Time
+		dst   **rank.Position
+	}{
+		{day, &info.Daily},
+		{week, &info.Weekly},
+		{month, &info.Monthly},
+		{time.Time{}, &info.AllTime},
+	} {
+		pos, err := s.position(u.ID, p.since)
+		if err != nil {
+			return nil, err
+		}
+		*p.dst = pos
+	}
+	return info, nil
+}
+
+func (s *Store) position(userID int64, since time.Time) (*rank.Position, error) {
+	rows, err := s.db.Query(`
+		SELECT user_id, SUM(input_tokens + output_tokens) FROM sessions
+		WHERE ended_at >= ? GROUP BY user_id`, since.UTC().Format(timeFormat))
+	if err != nil {
+		return nil, fmt.Errorf("rank users: %w", err)
+	}
+	defer rows.Close()
+
+	scores := make(map[int64]float64)
+	for rows.Next() {
+		var id int64
+		var score float64
+		if err := rows.Scan(&id, &score); err != nil {
+			return nil, fmt.Errorf("scan score: %w", err)
+		}
+		scores[id] = score
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	mine, ok := scores[userID]
+	if !ok {
+		return nil, nil
+	}
+	pos := &rank.Position{Position: 1, CompositeScore: mine, TotalParticipants: len(scores)}
+	for _, score := range scores {
+		if score > mine {
+			pos.Position++
+		}
+	}
+	return pos, nil
+}
+
+func newKey() (string, error) {
+	b := make([]byte, 24)
+	if _, err := rand.Read(b); err != nil {
+		return "", fmt.Errorf("generate key: %w", err)
+	}
+	return KeyPrefix + hex.EncodeToString(b), nil
+}
+
+func hashKey(key string) string {
+	sum := sha256.Sum256([]byte(key))
+	return hex.EncodeToString(sum[:])
+}
@@ -0,0 +1,114 @@
+package statusline
+
+import (
+	"bytes"
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// CacheDir holds cached command segment output, relative to the project
+// root.
+const CacheDir = ".do/.statusline-cache"
+
+// commandValue returns the first output line of a command segment. Output
+// is cached for the segment's TTL. Failures are cached too, so a broken or
+// slow command costs at most one timeout per TTL; while a cached value
+// exists it is shown instead of a failure. Once the render deadline has
+// passed, the cached value is shown, however old.
+func commandValue(seg Segment, s *state) (Value, bool) {
+	ttl := seg.TTL
+	if ttl == 0 {
+		ttl = DefaultCommandTTL
+	}
+	path := filepath.Join(s.dir, CacheDir, cacheKey(seg.Command))
+
+	if info, err := os.Stat(path); err == nil && (time.Since(info.ModTime()) < ttl || !time.Now().Before(s.deadline)) {
+		data, _ := os.ReadFile(path)
+		return commandOutput(string(data))
+	}
+	if !time.Now().Before(s.deadline) {
+		return Value{}, false
+	}
+
+	out, err := runCommand(seg, s)
+	if err != nil {
+		if _, statErr := os.Stat(path); statErr == nil {
+			now := time.Now()
+			_ = os.Chtimes(path, now, now)
+			data, _ := os.ReadFile(path)
+			return commandOutput(string(data))
+		}
+		out = ""
+	}
+	writeCache(path, out)
+	return commandOutput(out)
+}
+
+func runCommand(seg Segment, s *state) (string, error) {
+	timeout := seg.Timeout
+	if timeout == 0 {
+		timeout = DefaultCommandTimeout
+	}
+	deadline := time.Now().Add(timeout)
+	if s.deadline.Before(deadline) {
+		deadline = s.deadline
+	}
+	ctx, cancel := context.WithDeadline(context.Background(), deadline)
+	defer cancel()
+
+	cmd := exec.CommandContext(ctx, "sh", "-c", seg.Command)
+	cmd.Dir = s.dir
+	cmd.Stdin = bytes.NewReader(s.raw)
+	cmd.WaitDelay = 100 * time.Millisecond
+	out, err := cmd.Output()
+	if err != nil {
+		return "", err
+	}
+	return firstLine(string(out)), nil
+}
+
+// commandOutput turns command output into a segment value. Numeric output
+// can be colored by thresholds.
+func commandOutput(out string) (Value, bool) {
+	if out == "" {
+		return Value{}, false
+	}
+	v := Value{Text: out}
+	if n, err := strconv.ParseFloat(strings.TrimSuffix(out, "%"), 64); err == nil {
+		v.Level, v.HasLevel = n, true
+	}
+	return v, true
+}
+
+func writeCache(path, out string) {
+	if err := os.MkdirAll(filepath.Dir(path), 0755); err != nil {
+		return
+	}
+	tmp := path + ".tmp"
+	if err := os.WriteFile(tmp, []byte(out), 0644); err != nil {
+		return
+	}
+	if err := os.Rename(tmp, path); err != nil {
+		_ = os.Remove(tmp)
+	}
+}
+
+func cacheKey(command string) string {
+	sum := sha256.Sum256([]byte(command))
+	return hex.EncodeToString(sum[:8])
+}
+
+func firstLine(s string) string {
+	s = strings.TrimSpace(s)
+	if i := strings.IndexByte(s, '\n'); i >= 0 {
+		s = strings.TrimSpace(s[:i])
+	}
+	return s
+}
@@ -0,0 +1,210 @@
+package statusline
+
+import (
+	"bufio"
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// GitCacheTTL bounds how stale cached git info can get. Commits, checkouts
+// and staging change the cache key, so the TTL only matters for edits to
+// the working tree.
+const GitCacheTTL = 5 * time.Second
+
+// GitInfo is the repository state shown by the git segment.
+type GitInfo struct {
+	Branch  string `json:"branch"`
+	Changes int    `json:"changes"`
+	Ahead   int    `json:"ahead"`
+	Behind  int    `json:"behind"`
+	Stashes int    `json:"stashes"`
+	// State is an operation in progress: rebase, merge, cherry-pick,
+	// revert or bisect.
+	State string `json:"state,omitempty"`
+	// Partial is set when git did not answer in time; only the branch,
+	// read from HEAD, is known.
+	Partial bool `json:"-"`
+}
+
+type gitCache struct {
+	Key  string    `json:"key"`
+	At   time.Time `json:"at"`
+	Info GitInfo   `json:"info"`
+}
+
+// LoadGitInfo returns the git info of the repository containing dir,
+// cached under cacheDir. It reports false outside a repository. git runs
+// at most until deadline; when it is too slow, expired cached info for the
+// same HEAD and index is used, or else just the branch.
+func LoadGitInfo(dir, cacheDir string, deadline time.Time) (GitInfo, bool) {
+	gitDir := findGitDir(dir)
+	if gitDir == "" {
+		return GitInfo{}, false
+	}
+	key := gitCacheKey(gitDir)
+	path := filepath.Join(cacheDir, "git-"+cacheKey(gitDir)+".json")
+
+	var cached gitCache
+	current := false
+	if data, err := os.ReadFile(path); err == nil && json.Unmarshal(data, &cached) == nil && cached.Key == key {
+		if time.Since(cached.At) < GitCacheTTL {
+			return cached.Info, true
+		}
+		current = true
+	}
+
+	info, err := gitStatus(dir, deadline)
+	if err != nil {
+		if current {
+			return cached.Info, true
+		}
+		return GitInfo{Branch: headBranch(gitDir), Partial: true}, true
+	}
+	info.State = gitState(gitDir)
+	// git status may refresh the index, so take the key again.
+	if data, err := json.Marshal(gitCache{Key: gitCacheKey(gitDir), At: time.Now(), Info: info}); err == nil {
+		writeCache(path, string(data))
+	}
+	return info, true
+}
+
+// gitStatus runs a single `git status` for branch, tracking and change
+// counts.
+func gitStatus(dir string, deadline time.Time) (GitInfo, error) {
+	ctx, cancel := context.WithDeadline(context.Background(), deadline)
+	defer cancel()
+
+	run := func(args ...string) ([]byte, error) {
+		cmd := exec.CommandContext(ctx, "git", args...)
+		cmd.Dir = dir
+		cmd.WaitDelay = 100 * time.Millisecond
+		return cmd.Output()
+	}
+	out, err := run("status", "--porcelain=v2", "--branch", "--show-stash")
+	if err != nil && ctx.Err() == nil {
+		// git before 2.35 has no --show-stash.
+		out, err = run("status", "--porcelain=v2", "--branch")
+	}
+	if err != nil {
+		return GitInfo{}, err
+	}
+	return parseStatus(string(out)), nil
+}
+
+// parseStatus reads `git status --porcelain=v2 --branch --show-stash`.
+func parseStatus(out string) GitInfo {
+	var info GitInfo
+	sc := bufio.NewScanner(strings.NewReader(out))
+	for sc.Scan() {
+		line := sc.Text()
+		if !strings.HasPrefix(line, "# ") {
+			if line != "" {
+				info.Changes++
+			}
+			continue
+		}
+		fields := strings.Fields(line[2:])
+		if len(fields) < 2 {
+			continue
+		}
+		switch fields[0] {
+		case "branch.head":
+			info.Branch = fields[1]
+		case "branch.ab":
+			if len(fields) == 3 {
+				info.Ahead, _ = strconv.Atoi(strings.TrimPrefix(fields[1], "+"))
+				info.Behind, _ = strconv.Atoi(strings.TrimPrefix(fields[2], "-"))
+			}
+		case "stash":
+			info.Stashes, _ = strconv.Atoi(fields[1])
+		}
+	}
+	if info.Branch == "(detached)" {
+		info.Branch = "HEAD"
+	}
+	return info
+}
+
+// findGitDir returns the git directory of the repository containing dir,
+// following the "gitdir:" file of worktrees and submodules.
+func findGitDir(dir string) string {
+	for {
+		p := filepath.Join(dir, ".git")
+		if fi, err := os.Stat(p); err == nil {
+			if fi.IsDir() {
+				return p
+			}
+			data, err := os.ReadFile(p)
+			if err != nil {
+				return ""
+			}
+			target := strings.TrimSpace(strings.TrimPrefix(string(data), "gitdir:"))
+			if !filepath.IsAbs(target) {
+				target = filepath.Join(dir, target)
+			}
+			return target
+		}
+		parent := filepath.Dir(dir)
+		if parent == dir {
+			return ""
+		}
+		dir = parent
+	}
+}
+
+// gitCacheKey changes whenever HEAD moves or the index is written.
+func gitCacheKey(gitDir string) string {
+	h := sha256.New()
+	head, _ := os.ReadFile(filepath.Join(gitDir, "HEAD"))
+	h.Write(head)
+	for _, name := range []string{"HEAD", "index"} {
+		if fi, err := os.Stat(filepath.Join(gitDir, name)); err == nil {
+			h.Write([]byte(strconv.FormatInt(fi.ModTime().UnixNano(), 10) + strconv.FormatInt(fi.Size(), 10)))
+		}
+	}
+	if ref, ok := strings.CutPrefix(strings.TrimSpace(string(head)), "ref: "); ok {
+		if fi, err := os.Stat(filepath.Join(gitDir, ref)); err == nil {
+			h.Write([]byte(strconv.FormatInt(fi.ModTime().UnixNano(), 10)))
+		}
+	}
+	return hex.EncodeToString(h.Sum(nil))
+}
+
+// headBranch reads the branch name from HEAD without running git.
+func headBranch(gitDir string) string {
+	head, err := os.ReadFile(filepath.Join(gitDir, "HEAD"))
+	if err != nil {
+		return ""
+	}
+	ref, ok := strings.CutPrefix(strings.TrimSpace(string(head)), "ref: refs/heads/")
+	if !ok {
+		return "HEAD"
+	}
+	return ref
+}
+
+// gitState returns the operation in progress, from the marker files git
+// leaves in its directory.
+func gitState(gitDir string) string {
+	for _, m := range []struct{ file, state string }{
+		{"rebase-merge", "rebase"},
+		{"rebase-apply", "rebase"},
+		{"MERGE_HEAD", "merge"},
+		{"CHERRY_PICK_HEAD", "cherry-pick"},
+		{"REVERT_HEAD", "revert"},
+		{"BISECT_LOG", "bisect"},
+	} {
+		if _, err := os.Stat(filepath.Join(gitDir, m.file)); err == nil {
+			return m.state
+		}
+	}
+	return ""
+}
@@ -0,0 +1,79 @@
+package statusline
+
+import (
+	"os"
+	"os/exec"
+	"path/filepath"
+	"testing"
+	"time"
+)
+
+func gitRun(t *testing.T, dir string, args ...string) {
+	t.Helper()
+	cmd := exec.Command("git", args...)
+	cmd.Dir = dir
+	cmd.Env = append(os.Environ(),
+		"GIT_AUTHOR_NAME=t", "GIT_AUTHOR_EMAIL=t@example.com",
+		"GIT_COMMITTER_NAME=t", "GIT_COMMITTER_EMAIL=t@example.com")
+	if out, err := cmd.CombinedOutput(); err != nil {
+		t.Fatalf("git %v: %v\n%s", args, err, out)
+	}
+}
+
+func Test_parseStatus_reads_branch_tracking_and_stash(t *testing.T) {
+	info := parseStatus(`# branch.oid 1234
+# branch.head feature
+# branch.upstream origin/feature
+# branch.ab +2 -3
+# stash 4
+1 .M N... 100644 100644 100644 a a f.go
+? new.go
+`)
+	want := GitInfo{Branch: "feature", Changes: 2, Ahead: 2, Behind: 3, Stashes: 4}
+	if info != want {
+		t.Errorf("got %+v, want %+v", info, want)
+	}
+}
+
+func Test_LoadGitInfo_caches_until_head_or_index_changes(t *testing.T) {
+	if _, err := exec.LookPath("git"); err != nil {
+		t.Skip("git not installed")
+	}
+	repo, cache := t.TempDir(), t.TempDir()
+	gitRun(t, repo, "init", "-q", "-b", "main")
+	os.WriteFile(filepath.Join(repo, "a"), []byte("a"), 0644)
+	gitRun(t, repo, "add", "a")
+	gitRun(t, repo, "commit", "-q", "-m", "a")
+	os.WriteFile(filepath.Join(repo, "a"), []byte("b"), 0644)
+	gitRun(t, repo, "stash", "-q")
+	sub := filepath.Join(repo, "sub")
+	os.Mkdir(sub, 0755)
+
+	deadline := func() time.Time { return time.Now().Add(5 * time.Second) }
+	info, ok := LoadGitInfo(sub, cache, deadline())
+	if !ok || info.Branch != "main" || info.Changes != 0 || info.Stashes != 1 || info.Partial {
+		t.Fatalf("first load: %+v, %v", info, ok)
+	}
+
+	// Working tree edits show once the TTL expires; staging shows at once.
+	os.WriteFile(filepath.Join(repo, "b"), []byte("b"), 0644)
+	if info, _ := LoadGitInfo(sub, cache, deadline()); info.Changes != 0 {
+		t.Errorf("cached load ran git: %+v", info)
+	}
+	gitRun(t, repo, "add", "b")
+	os.WriteFile(filepath.Join(repo, ".git", "MERGE_HEAD"), nil, 0644)
+	if info, _ := LoadGitInfo(sub, cache, deadline()); info.Changes != 1 || info.State != "merge" {
+		t.Errorf("after staging: %+v", info)
+	}
+
+	// Out of time with nothing cached for this HEAD: branch only.
+	gitRun(t, repo, "checkout", "-q", "-b", "topic")
+	info, ok = LoadGitInfo(sub, cache, time.Now())
+	if !ok || info.Branch != "topic" || !info.Partial {
+		t.Errorf("past deadline: %+v", info)
+	}
+
+	if _, ok := LoadGitInfo(t.TempDir(), cache, deadline()); ok {
+		t.Error("outside a repository should report false")
+	}
+}
@@ -0,0 +1,214 @@
+package statusline
+
+import (
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+	"time"
+
+	"gopkg.in/yaml.v3"
+)
+
+// LayoutFile is the statusline config path relative to the project root.
+// A file of the same name under the home directory applies to projects
+// that have none.
+const LayoutFile = ".do/statusline.yaml"
+
+// DefaultSeparator is drawn between segments.
+const DefaultSeparator = " | "
+
+// ExampleLayout is the default layout, written by `godo statusline init`.
+const ExampleLayout = `# godo statusline layout. Segments are drawn in order; a segment with
+# nothing to show (no git repo, zero cost, ...) is skipped.
+#
+# A segment is a built-in name, or a map with:
+#   name        built-in segment, or a label for a command segment
+#   format      text with placeholders; {value} is the segment's value
+#   color       color of the segment (red, green, yellow, blue, magenta,
+#               cyan, white, gray, bold, dim or none)
+#   thresholds  colors by value, e.g. [{at: 50, color: yellow}]; the
+#               highest "at" not above the value wins
+#   separator   drawn before this segment instead of the line separator
+#   command     shell command whose first output line is the value; it
+#               gets the status JSON on stdin
+#   ttl         how long command output is cached (default 10s)
+#   timeout     how long the command may run (default 1s, and never past
+#               render_timeout)
+#
+# Built-in segments: mode, persona, profile, model, agent, context,
+# duration, cwd, git, cost, budget, lines, teams, version. The budget
+# segment shows only when budgets are set in .do/budget.yaml.
+#
+# render_timeout caps the time spent on git and commands; past it, their
+# cached values are shown.
+separator: " | "
+render_timeout: 500ms
+segments:
+  - mode
+  - name: persona
+    separator: ""
+  - profile
+  - model
+  - agent
+  - name: context
+    format: "used:{value}%"
+    color: green
+    thresholds:
+      - {at: 50, color: yellow}
+      - {at: 80, color: red}
+  - name: duration
+    separator: " "
+  - cwd
+  - git
+  - cost
+  - budget
+  - teams
+  - version
+`
+
+// DefaultRenderTimeout is the render time budget when none is set.
+const DefaultRenderTimeout = 500 * time.Millisecond
+
+// Command segment defaults.
+const (
+	DefaultCommandTTL     = 10 * time.Second
+	DefaultCommandTimeout = time.Second
+)
+
+// Layout chooses and orders the statusline segments.
+type Layout struct {
+	Separator     string        `yaml:"separator"`
+	RenderTimeout time.Duration `yaml:"render_timeout"`
+	Segments      []Segment     `yaml:"segments"`
+}
+
+// Segment configures one part of the status line. Unset fields keep the
+// built-in segment's defaults.
+type Segment struct {
+	Name       string        `yaml:"name"`
+	Format     string        `yaml:"format,omitempty"`
+	Color      string        `yaml:"color,omitempty"`
+	Thresholds []Threshold   `yaml:"thresholds,omitempty"`
+	Separator  *string       `yaml:"separator,omitempty"`
+	Command    string        `yaml:"command,omitempty"`
+	TTL        time.Duration `yaml:"ttl,omitempty"`
+	Timeout    time.Duration `yaml:"timeout,omitempty"`
+}
+
+// Threshold colors a segment whose value is at least At.
+type Threshold struct {
+	At    float64 `yaml:"at"`
+	Color string  `yaml:"color"`
+}
+
+// UnmarshalYAML accepts a bare segment name as well as a map.
+func (s *Segment) UnmarshalYAML(node *yaml.Node) error {
+	if node.Kind == yaml.ScalarNode {
+		s.Name = node.Value
+		return nil
+	}
+	type plain Segment
+	return node.Decode((*plain)(s))
+}
+
+// Colors maps color names to ANSI codes.
+var Colors = map[string]string{
+	"none":    "",
+	"red":     AnsiRed,
+	"green":   AnsiGreen,
+	"yellow":  AnsiYellow,
+	"blue":    "\033[34m",
+	"magenta": "\033[35m",
+	"cyan":    "\033[36m",
+	"white":   "\033[37m",
+	"gray":    "\033[90m",
+	"bold":    "\033[1m",
+	"dim":     "\033[2m",
+}
+
+// DefaultLayout returns the layout used when no config file exists.
+func DefaultLayout() *Layout {
+	l, err := ParseLayout([]byte(ExampleLayout))
+	if err != nil {
+		panic("statusline: invalid default layout: " + err.Error())
+	}
+	return l
+}
+
+// ParseLayout parses and validates a layout.
+func ParseLayout(data []byte) (*Layout, error) {
+	var l Layout
+	if err := yaml.Unmarshal(data, &l); err != nil {
+		return nil, fmt.Errorf("parse statusline layout: %w", err)
+	}
+	if err := l.Validate(); err != nil {
+		return nil, err
+	}
+	if l.Separator == "" {
+		l.Separator = DefaultSeparator
+	}
+	if l.RenderTimeout == 0 {
+		l.RenderTimeout = DefaultRenderTimeout
+	}
+	return &l, nil
+}
+
+// LoadLayout reads the layout of the project at dir, falling back to the
+// user's layout and then to DefaultLayout. On error it also returns the
+// default layout, so the status line is still drawn.
+func LoadLayout(dir string) (*Layout, error) {
+	paths := []string{filepath.Join(dir, LayoutFile)}
+	if home, err := os.UserHomeDir(); err == nil {
+		paths = append(paths, filepath.Join(home, LayoutFile))
+	}
+	for _, path := range paths {
+		data, err := os.ReadFile(path)
+		if errors.Is(err, os.ErrNotExist) {
+			continue
+		}
+		if err != nil {
+			return DefaultLayout(), fmt.Errorf("read statusline layout: %w", err)
+		}
+		l, err := ParseLayout(data)
+		if err != nil {
+			return DefaultLayout(), fmt.Errorf("%s: %w", path, err)
+		}
+		if len(l.Segments) == 0 {
+			l.Segments = DefaultLayout().Segments
+		}
+		return l, nil
+	}
+	return DefaultLayout(), nil
+}
+
+// Validate reports unknown segments and colors.
+func (l *Layout) Validate() error {
+	if l.RenderTimeout < 0 {
+		return fmt.Errorf("render_timeout must not be negative")
+	}
+	for i, s := range l.Segments {
+		if s.Name == "" {
+			return fmt.Errorf("segment %d: name is required", i+1)
+		}
+		if s.Command == "" && builtins[s.Name] == nil {
+			return fmt.Errorf("segment %q: unknown segment (built-in: %s), or set command", s.Name, strings.Join(BuiltinSegments(), ", "))
+		}
+		if s.Command == "" && (s.TTL != 0 || s.Timeout != 0) {
+			return fmt.Errorf("segment %q: ttl and timeout apply to command segments only", s.Name)
+		}
+		if s.TTL < 0 || s.Timeout < 0 {
+			return fmt.Errorf("segment %q: ttl and timeout must not be negative", s.Name)
+		}
+		if _, ok := Colors[s.Color]; s.Color != "" && !ok {
+			return fmt.Errorf("segment %q: unknown color %q", s.Name, s.Color)
+		}
+		for _, t := range s.Thresholds {
+			if _, ok := Colors[t.Color]; !ok {
+				return fmt.Errorf("segment %q: unknown threshold color %q", s.Name, t.Color)
+			}
+		}
+	}
+	return nil
+}
@@ -0,0 +1,117 @@
+package statusline
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+func testInput(used int) *Input {
+	in := &Input{}
+	in.Model.DisplayName = "Claude Opus 4.5"
+	in.ContextWindow.UsedPercentage = &used
+	in.Cost.TotalCostUSD = 1.5
+	in.Cost.TotalDurationMS = 5 * 60000
+	return in
+}
+
+func Test_Build_default_layout_keeps_classic_order(t *testing.T) {
+	t.Setenv("HOME", t.TempDir())
+	t.Setenv("DO_PERSONA", "young-f")
+	t.Setenv("CLAUDE_CODE_EXPERIMENTAL_AGENT_TEAMS", "")
+	cfg := Config{Version: "v1.2.3", ReadMode: func(string) (string, string) { return "focus", "[Focus]" }, GetProfileName: func() string { return "work" }}
+
+	got := Build(DefaultLayout(), testInput(60), nil, cfg, t.TempDir())
+	parts := strings.Split(got, " | ")
+	if parts[0] != "[Focus]🦋" || parts[1] != "🤖work" || parts[2] != "opus" {
+		t.Errorf("leading segments: %q", got)
+	}
+	if parts[3] != AnsiYellow+"used:60%"+AnsiReset+" ⏰5m" {
+		t.Errorf("context segment: %q", parts[3])
+	}
+	if !strings.HasSuffix(got, " | $1.50 | 1.2.3") {
+		t.Errorf("trailing segments: %q", got)
+	}
+}
+
+func Test_Build_custom_formats_and_thresholds(t *testing.T) {
+	layout, err := ParseLayout([]byte(`
+separator: " · "
+segments:
+  - model
+  - name: context
+    format: "ctx {value}/{remaining}"
+    thresholds: [{at: 90, color: red}, {at: 40, color: blue}]
+  - name: cost
+    color: gray
+`))
+	if err != nil {
+		t.Fatal(err)
+	}
+	got := Build(layout, testInput(45), nil, Config{}, t.TempDir())
+	want := "opus · " + Colors["blue"] + "ctx 45/55" + AnsiReset + " · " + Colors["gray"] + "$1.50" + AnsiReset
+	if got != want {
+		t.Errorf("got %q, want %q", got, want)
+	}
+	// Below every threshold the built-in base color still applies.
+	got = Build(layout, testInput(10), nil, Config{}, t.TempDir())
+	if !strings.Contains(got, " · "+AnsiGreen+"ctx 10/90"+AnsiReset+" · ") {
+		t.Errorf("below thresholds: %q", got)
+	}
+}
+
+func Test_Build_command_segment_is_cached(t *testing.T) {
+	dir := t.TempDir()
+	counter := filepath.Join(dir, "runs")
+	layout, err := ParseLayout([]byte(`
+segments:
+  - name: runs
+    command: 'echo x >> runs; wc -l < runs | tr -d " "'
+    format: "runs={value}"
+    ttl: 1h
+  - name: broken
+    command: exit 1
+`))
+	if err != nil {
+		t.Fatal(err)
+	}
+	for range 3 {
+		if got := Build(layout, testInput(0), []byte("{}"), Config{}, dir); got != "runs=1" {
+			t.Fatalf("got %q", got)
+		}
+	}
+	data, _ := os.ReadFile(counter)
+	if n := strings.Count(string(data), "x"); n != 1 {
+		t.Errorf("command ran %d times, want 1", n)
+	}
+}
+
+func Test_ParseLayout_rejects_unknown_segments_and_colors(t *testing.T) {
+	for _, src := range []string{
+		"segments: [weather]",
+		"segments: [{name: cost, color: purple}]",
+		"segments: [{name: cost, ttl: 5s}]",
+		"segments: [{command: date}]",
+	} {
+		if _, err := ParseLayout([]byte(src)); err == nil {
+			t.Errorf("%s: want error", src)
+		}
+	}
+}
+
+func Test_LoadLayout_falls_back_to_default(t *testing.T) {
+	t.Setenv("HOME", t.TempDir())
+	dir := t.TempDir()
+	l, err := LoadLayout(dir)
+	if err != nil || len(l.Segments) != len(DefaultLayout().Segments) {
+		t.Fatalf("missing file: %v, %d segments", err, len(l.Segments))
+	}
+
+	os.MkdirAll(filepath.Join(dir, ".do"), 0755)
+	os.WriteFile(filepath.Join(dir, LayoutFile), []byte("segments: [nope]"), 0644)
+	l, err = LoadLayout(dir)
+	if err == nil || l == nil || len(l.Segments) == 0 {
+		t.Errorf("invalid file: want error and default layout, got %v", err)
+	}
+}
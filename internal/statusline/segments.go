@@ -0,0 +1,319 @@
+package statusline
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/yejune/godo/internal/budget"
+)
+
+// Value is what a segment shows: Text fills {value}, Fields fill the other
+// placeholders, and Level is what thresholds compare against.
+type Value struct {
+	Text     string
+	Fields   map[string]string
+	Level    float64
+	HasLevel bool
+}
+
+// builtin is a segment computed from the status input.
+type builtin struct {
+	format     string
+	color      string
+	thresholds []Threshold
+	value      func(s *state) (Value, bool)
+}
+
+// state is what segments are rendered from.
+type state struct {
+	input *Input
+	raw   []byte
+	cfg   Config
+	dir   string
+	// deadline is when the render time budget runs out; slow sources fall
+	// back to cached values after it.
+	deadline time.Time
+}
+
+var builtins = map[string]*builtin{
+	"mode":     {value: modeValue},
+	"persona":  {value: personaValue},
+	"profile":  {format: "🤖{value}", value: profileValue},
+	"model":    {value: modelValue},
+	"agent":    {format: "🤖{value}", value: agentValue},
+	"context":  {format: "used:{value}%", color: "green", thresholds: []Threshold{{50, "yellow"}, {80, "red"}}, value: contextValue},
+	"duration": {format: "⏰{value}", value: durationValue},
+	"cwd":      {value: cwdValue},
+	"git":      {value: gitValue},
+	"cost":     {value: costValue},
+	"budget":   {format: "💰{value}", thresholds: []Threshold{{80, "yellow"}, {100, "red"}}, value: budgetValue},
+	"lines":    {format: "+{added} -{removed}", value: linesValue},
+	"teams":    {value: teamsValue},
+	"version":  {value: versionValue},
+}
+
+// BuiltinSegments returns the names of the built-in segments.
+func BuiltinSegments() []string {
+	names := make([]string, 0, len(builtins))
+	for name := range builtins {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	return names
+}
+
+func modeValue(s *state) (Value, bool) {
+	name, prefix := "do", "[Do]"
+	if s.cfg.ReadMode != nil {
+		name, prefix = s.cfg.ReadMode(s.input.SessionID)
+	}
+	return Value{Text: prefix, Fields: map[string]string{"name": name}}, true
+}
+
+func personaValue(s *state) (Value, bool) {
+	persona := os.Getenv("DO_PERSONA")
+	icon := PersonaIcon(persona)
+	return Value{Text: icon, Fields: map[string]string{"name": persona}}, icon != ""
+}
+
+func profileValue(s *state) (Value, bool) {
+	if s.cfg.GetProfileName == nil {
+		return Value{}, false
+	}
+	profile := s.cfg.GetProfileName()
+	return Value{Text: profile}, profile != "default"
+}
+
+func modelValue(s *state) (Value, bool) {
+	short := ShortenModel(s.input.Model.DisplayName)
+	if short == "" {
+		short = ShortenModel(s.input.Model.ID)
+	}
+	return Value{Text: short, Fields: map[string]string{
+		"id":   s.input.Model.ID,
+		"name": s.input.Model.DisplayName,
+	}}, short != ""
+}
+
+func agentValue(s *state) (Value, bool) {
+	if s.input.Agent == nil || s.input.Agent.Name == "" {
+		return Value{}, false
+	}
+	return Value{Text: s.input.Agent.Name}, true
+}
+
+func contextValue(s *state) (Value, bool) {
+	cw := s.input.ContextWindow
+	percent := 0
+	if cw.UsedPercentage != nil {
+		percent = *cw.UsedPercentage
+	} else if cw.RemainingPercentage != nil {
+		percent = 100 - *cw.RemainingPercentage
+	}
+	if percent > 100 {
+		percent = 100
+	}
+	return Value{
+		Text:     strconv.Itoa(percent),
+		Fields:   map[string]string{"remaining": strconv.Itoa(100 - percent), "size": strconv.Itoa(cw.ContextWindowSize)},
+		Level:    float64(percent),
+		HasLevel: true,
+	}, true
+}
+
+func durationValue(s *state) (Value, bool) {
+	mins := s.input.Cost.TotalDurationMS / 60000
+	if mins <= 0 {
+		return Value{}, false
+	}
+	text := fmt.Sprintf("%dm", mins)
+	if mins >= 60 {
+		text = fmt.Sprintf("%dh%dm", mins/60, mins%60)
+	}
+	return Value{Text: text, Fields: map[string]string{"minutes": strconv.Itoa(mins)}, Level: float64(mins), HasLevel: true}, true
+}
+
+func cwdValue(s *state) (Value, bool) {
+	dir := getCwd()
+	return Value{Text: TildeDir(dir), Fields: map[string]string{"base": filepath.Base(dir)}}, dir != ""
+}
+
+// gitValue shows the branch, then the change count, commits ahead and
+// behind upstream, stashes and the operation in progress when there are
+// any, e.g. "main +3 ↑1↓2 ⚑1 REBASE".
+func gitValue(s *state) (Value, bool) {
+	info, ok := LoadGitInfo(getCwd(), filepath.Join(s.dir, CacheDir), s.deadline)
+	if !ok || info.Branch == "" {
+		return Value{}, false
+	}
+	text := info.Branch
+	switch {
+	case info.Partial:
+		text += " …"
+	case info.Changes > 0:
+		text += fmt.Sprintf(" +%d", info.Changes)
+	}
+	if info.Ahead > 0 || info.Behind > 0 {
+		text += " "
+		if info.Ahead > 0 {
+			text += fmt.Sprintf("↑%d", info.Ahead)
+		}
+		if info.Behind > 0 {
+			text += fmt.Sprintf("↓%d", info.Behind)
+		}
+	}
+	if info.Stashes > 0 {
+		text += fmt.Sprintf(" ⚑%d", info.Stashes)
+	}
+	if info.State != "" {
+		text += " " + strings.ToUpper(info.State)
+	}
+	return Value{
+		Text: text,
+		Fields: map[string]string{
+			"branch":  info.Branch,
+			"changes": strconv.Itoa(info.Changes),
+			"ahead":   strconv.Itoa(info.Ahead),
+			"behind":  strconv.Itoa(info.Behind),
+			"stash":   strconv.Itoa(info.Stashes),
+			"state":   info.State,
+		},
+		Level:    float64(info.Changes),
+		HasLevel: !info.Partial,
+	}, true
+}
+
+func costValue(s *state) (Value, bool) {
+	cost := s.input.Cost.TotalCostUSD
+	text := FormatCost(cost)
+	return Value{Text: text, Level: cost, HasLevel: true}, text != ""
+}
+
+// budgetValue shows the budget closest to its limit; Level is the percent
+// of that limit used. {session} and {daily} show the USD budget of the
+// period, or its token budget when no USD budget is set. Session spend is
+// priced from the transcript, as the prompt hook does. Spend not measured
+// before the render deadline is shown from the last cached result; a
+// period with none is left out.
+func budgetValue(s *state) (Value, bool) {
+	cfg, err := budget.Load(s.dir)
+	if err != nil || !cfg.Enabled() {
+		return Value{}, false
+	}
+	var session, daily budget.Spend
+	known := map[string]bool{}
+	if cfg.Session.Set() && s.input.TranscriptPath != "" {
+		session, known[budget.PeriodSession] = budget.SessionBy(s.input.TranscriptPath, s.deadline)
+	}
+	if cfg.Daily.Set() {
+		daily, known[budget.PeriodDaily] = budget.TodayBy(time.Now(), s.deadline)
+	}
+	var statuses []budget.Status
+	for _, st := range cfg.Check(session, daily) {
+		if known[st.Period] {
+			statuses = append(statuses, st)
+		}
+	}
+	worst, ok := budget.Worst(statuses)
+	if !ok {
+		return Value{}, false
+	}
+	fields := map[string]string{"session": "", "daily": "", "percent": strconv.Itoa(int(worst.Ratio() * 100))}
+	for _, st := range statuses {
+		if fields[st.Period] == "" {
+			fields[st.Period] = st.Amounts()
+		}
+	}
+	return Value{Text: worst.String(), Fields: fields, Level: worst.Ratio() * 100, HasLevel: true}, true
+}
+
+func linesValue(s *state) (Value, bool) {
+	added, removed := s.input.Cost.TotalLinesAdded, s.input.Cost.TotalLinesRemoved
+	return Value{
+		Text:     fmt.Sprintf("+%d -%d", added, removed),
+		Fields:   map[string]string{"added": strconv.Itoa(added), "removed": strconv.Itoa(removed)},
+		Level:    float64(added + removed),
+		HasLevel: true,
+	}, added+removed > 0
+}
+
+func teamsValue(s *state) (Value, bool) {
+	return Value{Text: "👥"}, os.Getenv("CLAUDE_CODE_EXPERIMENTAL_AGENT_TEAMS") == "1"
+}
+
+func versionValue(s *state) (Value, bool) {
+	version := strings.TrimPrefix(s.cfg.Version, "v")
+	if version == "" {
+		return Value{}, false
+	}
+	update := ""
+	if s.cfg.Version == "dev" {
+		// Development builds are never out of date.
+	} else if latest := LoadLatestVersion(filepath.Join(s.dir, CacheDir)); latest != "" && IsNewer(latest, s.cfg.Version) {
+		update = "🆙"
+	}
+	return Value{Text: version + update, Fields: map[string]string{"version": version, "update": update}}, true
+}
+
+// renderSegment draws one configured segment, reporting false when it has
+// nothing to show.
+func renderSegment(seg Segment, s *state) (string, bool) {
+	var v Value
+	var ok bool
+	format, color, thresholds := "{value}", "", []Threshold(nil)
+	if seg.Command != "" {
+		v, ok = commandValue(seg, s)
+	} else {
+		b := builtins[seg.Name]
+		v, ok = b.value(s)
+		if b.format != "" {
+			format = b.format
+		}
+		color, thresholds = b.color, b.thresholds
+	}
+	if !ok {
+		return "", false
+	}
+	if seg.Format != "" {
+		format = seg.Format
+	}
+	if seg.Color != "" {
+		color = seg.Color
+	}
+	if seg.Thresholds != nil {
+		thresholds = seg.Thresholds
+	}
+
+	pairs := []string{"{value}", v.Text}
+	for k, f := range v.Fields {
+		pairs = append(pairs, "{"+k+"}", f)
+	}
+	text := strings.NewReplacer(pairs...).Replace(format)
+	if v.HasLevel {
+		color = thresholdColor(v.Level, color, thresholds)
+	}
+	if code := Colors[color]; code != "" {
+		text = code + text + AnsiReset
+	}
+	return text, true
+}
+
+// thresholdColor returns the color of the highest threshold at or below
+// level, or base when there is none.
+func thresholdColor(level float64, base string, thresholds []Threshold) string {
+	best := -1
+	for i, t := range thresholds {
+		if level >= t.At && (best < 0 || t.At >= thresholds[best].At) {
+			best = i
+		}
+	}
+	if best < 0 {
+		return base
+	}
+	return thresholds[best].Color
+}
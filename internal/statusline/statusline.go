@@ -3,11 +3,12 @@ package statusline
 import (
 	"encoding/json"
 	"fmt"
+	"io"
 	"os"
-	"os/exec"
 	"path/filepath"
 	"strconv"
 	"strings"
+	"time"
 )
 
 // ANSI color codes.
@@ -20,7 +21,9 @@ const (
 
 // Input represents the actual JSON that Claude Code sends via stdin.
 type Input struct {
-	Model struct {
+	SessionID      string `json:"session_id"`
+	TranscriptPath string `json:"transcript_path"`
+	Model          struct {
 		ID          string `json:"id"`
 		DisplayName string `json:"display_name"`
 	} `json:"model"`
@@ -44,114 +47,55 @@ type Input struct {
 
 // Config holds external dependencies for rendering the status line.
 type Config struct {
-	Version        string
-	ReadModeState  func() string
+	Version string
+	// ReadMode returns the execution mode of a session and its response
+	// prefix.
+	ReadMode       func(sessionID string) (name, prefix string)
 	GetProfileName func() string
 }
 
-// Render reads JSON from stdin and prints the formatted status line.
+// Render reads JSON from stdin and prints the status line drawn by the
+// project's layout (see LayoutFile).
 func Render(cfg Config) {
+	raw, err := io.ReadAll(os.Stdin)
 	var input Input
-	decoder := json.NewDecoder(os.Stdin)
-	if err := decoder.Decode(&input); err != nil {
+	if err == nil {
+		err = json.Unmarshal(raw, &input)
+	}
+	if err != nil {
 		fmt.Print("[Do]")
 		return
 	}
 
-	mode := "do"
-	if cfg.ReadModeState != nil {
-		mode = cfg.ReadModeState()
-	}
-	var modePrefix string
-	switch strings.ToLower(mode) {
-	case "focus":
-		modePrefix = "[Focus]"
-	case "do":
-		modePrefix = "[Do]"
-	case "team":
-		modePrefix = "[Team]"
-	case "auto":
-		modePrefix = "[Auto]"
-	default:
-		modePrefix = "[Do]"
-	}
-
-	modelShort := ShortenModel(input.Model.DisplayName)
-	if modelShort == "" {
-		modelShort = ShortenModel(input.Model.ID)
-	}
-
-	ctxPercent := 0
-	if input.ContextWindow.UsedPercentage != nil {
-		ctxPercent = *input.ContextWindow.UsedPercentage
-	} else if input.ContextWindow.RemainingPercentage != nil {
-		ctxPercent = 100 - *input.ContextWindow.RemainingPercentage
-	}
-	if ctxPercent > 100 {
-		ctxPercent = 100
-	}
-	ctxStr := ColorizeContext(ctxPercent)
-
-	branch, changes := GetGitInfo()
-	costStr := FormatCost(input.Cost.TotalCostUSD)
-
-	if icon := PersonaIcon(os.Getenv("DO_PERSONA")); icon != "" {
-		modePrefix += icon
-	}
-
-	parts := []string{modePrefix}
-
-	if cfg.GetProfileName != nil {
-		if profile := cfg.GetProfileName(); profile != "default" {
-			parts = append(parts, "🤖"+profile)
-		}
-	}
-
-	if modelShort != "" {
-		parts = append(parts, modelShort)
-	}
-
-	if input.Agent != nil && input.Agent.Name != "" {
-		parts = append(parts, "🤖"+input.Agent.Name)
+	dir := projectDir()
+	layout, err := LoadLayout(dir)
+	line := Build(layout, &input, raw, cfg, dir)
+	if err != nil {
+		line += layout.Separator + AnsiYellow + "⚠statusline.yaml" + AnsiReset
 	}
+	fmt.Print(line)
+}
 
-	ctxPart := ctxStr
-	if input.Cost.TotalDurationMS > 0 {
-		mins := input.Cost.TotalDurationMS / 60000
-		if mins >= 60 {
-			ctxPart += fmt.Sprintf(" ⏰%dh%dm", mins/60, mins%60)
-		} else if mins > 0 {
-			ctxPart += fmt.Sprintf(" ⏰%dm", mins)
+// Build draws the segments of layout for input. raw is the input JSON,
+// passed to command segments; dir is the project root.
+func Build(layout *Layout, input *Input, raw []byte, cfg Config, dir string) string {
+	s := &state{input: input, raw: raw, cfg: cfg, dir: dir, deadline: time.Now().Add(layout.RenderTimeout)}
+	var b strings.Builder
+	for _, seg := range layout.Segments {
+		text, ok := renderSegment(seg, s)
+		if !ok {
+			continue
 		}
-	}
-	parts = append(parts, ctxPart)
-
-	parts = append(parts, TildeDir(getCwd()))
-
-	if branch != "" {
-		gitPart := branch
-		if changes > 0 {
-			gitPart += fmt.Sprintf(" +%d", changes)
+		if b.Len() > 0 {
+			if seg.Separator != nil {
+				b.WriteString(*seg.Separator)
+			} else {
+				b.WriteString(layout.Separator)
+			}
 		}
-		parts = append(parts, gitPart)
-	}
-
-	if costStr != "" {
-		parts = append(parts, costStr)
-	}
-
-	if os.Getenv("CLAUDE_CODE_EXPERIMENTAL_AGENT_TEAMS") == "1" {
-		parts = append(parts, "👥")
+		b.WriteString(text)
 	}
-
-	version := cfg.Version
-	verStr := strings.TrimPrefix(version, "v")
-	if latest := ReadLatestVersion(); latest != "" && version != "dev" && IsNewer(latest, version) {
-		verStr += "🆙"
-	}
-	parts = append(parts, verStr)
-
-	fmt.Print(strings.Join(parts, " | "))
+	return b.String()
 }
 
 // ShortenModel extracts a short model identifier.
@@ -195,45 +139,32 @@ func ColorizeContext(percent int) string {
 	}
 }
 
-// GetGitInfo returns the current branch name and count of uncommitted changes.
-func GetGitInfo() (string, int) {
-	branchCmd := exec.Command("git", "rev-parse", "--abbrev-ref", "HEAD")
-	branchOut, err := branchCmd.Output()
-	if err != nil {
-		return "", 0
-	}
-	branch := strings.TrimSpace(string(branchOut))
-
-	statusCmd := exec.Command("git", "status", "--porcelain")
-	statusOut, err := statusCmd.Output()
-	if err != nil {
-		return branch, 0
-	}
-
-	changes := 0
-	lines := strings.Split(strings.TrimSpace(string(statusOut)), "\n")
-	for _, line := range lines {
-		if strings.TrimSpace(line) != "" {
-			changes++
-		}
-	}
-
-	return branch, changes
-}
-
 // ReadLatestVersion reads the cached latest version from .do/.latest-version.
 func ReadLatestVersion() string {
-	projectDir := os.Getenv("CLAUDE_PROJECT_DIR")
-	if projectDir == "" {
-		projectDir, _ = os.Getwd()
-	}
-	data, err := os.ReadFile(filepath.Join(projectDir, ".do", ".latest-version"))
+	data, err := os.ReadFile(filepath.Join(projectDir(), ".do", ".latest-version"))
 	if err != nil {
 		return ""
 	}
 	return strings.TrimSpace(string(data))
 }
 
+// VersionCacheTTL is how long LoadLatestVersion reuses the latest version.
+const VersionCacheTTL = time.Minute
+
+// LoadLatestVersion returns ReadLatestVersion, cached in cacheDir for
+// VersionCacheTTL so the status line does not read it on every render.
+func LoadLatestVersion(cacheDir string) string {
+	path := filepath.Join(cacheDir, "latest-version")
+	if info, err := os.Stat(path); err == nil && time.Since(info.ModTime()) < VersionCacheTTL {
+		if data, err := os.ReadFile(path); err == nil {
+			return strings.TrimSpace(string(data))
+		}
+	}
+	latest := ReadLatestVersion()
+	writeCache(path, latest)
+	return latest
+}
+
 // IsNewer returns true if latest version is newer than current.
 func IsNewer(latest, current string) bool {
 	parse := func(v string) []int {
@@ -293,6 +224,14 @@ func FormatCost(cost float64) string {
 	return "$" + strconv.FormatFloat(cost, 'f', 2, 64)
 }
 
+// projectDir returns CLAUDE_PROJECT_DIR, or the working directory.
+func projectDir() string {
+	if dir := os.Getenv("CLAUDE_PROJECT_DIR"); dir != "" {
+		return dir
+	}
+	return getCwd()
+}
+
 func getCwd() string {
 	dir, _ := os.Getwd()
 	return dir
@@ -0,0 +1,35 @@
+package statusline
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+)
+
+func Test_LoadLatestVersion_caches_for_ttl(t *testing.T) {
+	dir := t.TempDir()
+	t.Setenv("CLAUDE_PROJECT_DIR", dir)
+	cacheDir := filepath.Join(dir, CacheDir)
+	latestPath := filepath.Join(dir, ".do", ".latest-version")
+	if err := os.MkdirAll(filepath.Dir(latestPath), 0755); err != nil {
+		t.Fatal(err)
+	}
+	os.WriteFile(latestPath, []byte("1.2.0\n"), 0644)
+
+	if got := LoadLatestVersion(cacheDir); got != "1.2.0" {
+		t.Fatalf("first: got %q", got)
+	}
+	os.WriteFile(latestPath, []byte("1.3.0\n"), 0644)
+	if got := LoadLatestVersion(cacheDir); got != "1.2.0" {
+		t.Errorf("within TTL: got %q, want the cached 1.2.0", got)
+	}
+
+	old := time.Now().Add(-2 * VersionCacheTTL)
+	if err := os.Chtimes(filepath.Join(cacheDir, "latest-version"), old, old); err != nil {
+		t.Fatal(err)
+	}
+	if got := LoadLatestVersion(cacheDir); got != "1.3.0" {
+		t.Errorf("after TTL: got %q", got)
+	}
+}
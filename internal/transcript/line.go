@@ -0,0 +1,181 @@
+package transcript
+
+import (
+	"bufio"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"strings"
+)
+
+// Tokens counts tokens by kind.
+type Tokens struct {
+	Input         int64 `json:"input"`
+	Output        int64 `json:"output"`
+	CacheCreation int64 `json:"cache_creation"`
+	CacheRead     int64 `json:"cache_read"`
+}
+
+// Add adds o to t.
+func (t *Tokens) Add(o Tokens) {
+	t.Input += o.Input
+	t.Output += o.Output
+	t.CacheCreation += o.CacheCreation
+	t.CacheRead += o.CacheRead
+}
+
+// Total returns the sum of all kinds.
+func (t Tokens) Total() int64 {
+	return t.Input + t.Output + t.CacheCreation + t.CacheRead
+}
+
+// Line is one transcript JSONL entry.
+type Line struct {
+	Type          string          `json:"type"`
+	Timestamp     string          `json:"timestamp"`
+	SessionID     string          `json:"sessionId"`
+	CWD           string          `json:"cwd"`
+	Version       string          `json:"version"`
+	RequestID     string          `json:"requestId"`
+	AgentID       string          `json:"agentId"`
+	IsMeta        bool            `json:"isMeta"`
+	IsSidechain   bool            `json:"isSidechain"`
+	Message       json.RawMessage `json:"message"`
+	ToolUseResult json.RawMessage `json:"toolUseResult"`
+}
+
+// Message is the message of a user or assistant line.
+type Message struct {
+	ID      string          `json:"id"`
+	Model   string          `json:"model"`
+	Content json.RawMessage `json:"content"`
+	Usage   *struct {
+		InputTokens              int64 `json:"input_tokens"`
+		OutputTokens             int64 `json:"output_tokens"`
+		CacheCreationInputTokens int64 `json:"cache_creation_input_tokens"`
+		CacheReadInputTokens     int64 `json:"cache_read_input_tokens"`
+	} `json:"usage"`
+}
+
+// Billed reports whether m is an API response with usage. Messages Claude
+// Code writes itself use the model "<synthetic>".
+func (m *Message) Billed() bool {
+	return m.Usage != nil && m.Model != "" && m.Model != "<synthetic>"
+}
+
+// Tokens returns the usage of a billed message.
+func (m *Message) Tokens() Tokens {
+	if m.Usage == nil {
+		return Tokens{}
+	}
+	return Tokens{
+		Input:         m.Usage.InputTokens,
+		Output:        m.Usage.OutputTokens,
+		CacheCreation: m.Usage.CacheCreationInputTokens,
+		CacheRead:     m.Usage.CacheReadInputTokens,
+	}
+}
+
+// Block is one content block of a message.
+type Block struct {
+	Type      string          `json:"type"`
+	Text      string          `json:"text"`
+	ID        string          `json:"id"`
+	Name      string          `json:"name"`
+	Input     json.RawMessage `json:"input"`
+	ToolUseID string          `json:"tool_use_id"`
+	IsError   bool            `json:"is_error"`
+	Content   json.RawMessage `json:"content"`
+}
+
+// Blocks decodes the content blocks of m; string content has none.
+func (m *Message) Blocks() ([]Block, error) {
+	if len(m.Content) == 0 || m.Content[0] != '[' {
+		return nil, nil
+	}
+	var blocks []Block
+	if err := json.Unmarshal(m.Content, &blocks); err != nil {
+		return nil, err
+	}
+	return blocks, nil
+}
+
+// IsPrompt reports whether a user line was typed by the user rather than
+// being meta, sidechain or tool result content.
+func IsPrompt(l Line, m *Message) bool {
+	if l.Type != "user" || l.IsMeta || l.IsSidechain {
+		return false
+	}
+	blocks, err := m.Blocks()
+	return err == nil && isPrompt(m.Content, blocks)
+}
+
+// isPrompt reports whether user message content was typed by the user
+// rather than being tool results.
+func isPrompt(content json.RawMessage, blocks []Block) bool {
+	if blocks == nil {
+		var text string
+		return json.Unmarshal(content, &text) == nil && strings.TrimSpace(text) != ""
+	}
+	for _, b := range blocks {
+		if b.Type == "tool_result" {
+			return false
+		}
+	}
+	return len(blocks) > 0
+}
+
+// Responses remembers the API responses already counted. A response split
+// over several lines (one per content block) repeats its message and
+// request id; a response without a message id is never a repeat.
+type Responses map[string]bool
+
+// First reports whether the response on l is seen for the first time.
+func (r Responses) First(l Line, m *Message) bool {
+	if m.ID == "" {
+		return true
+	}
+	key := m.ID + "\x00" + l.RequestID
+	if r[key] {
+		return false
+	}
+	r[key] = true
+	return true
+}
+
+// ReadLines calls fn for each line of the transcript at path. Lines that
+// are not JSON are skipped.
+func ReadLines(path string, fn func(Line)) error {
+	f, err := os.Open(path)
+	if err != nil {
+		return fmt.Errorf("open transcript: %w", err)
+	}
+	defer f.Close()
+
+	r := bufio.NewReaderSize(f, 64*1024)
+	for {
+		raw, err := r.ReadBytes('\n')
+		if len(raw) > 0 {
+			var l Line
+			if json.Unmarshal(raw, &l) == nil {
+				fn(l)
+			}
+		}
+		if errors.Is(err, io.EOF) {
+			return nil
+		}
+		if err != nil {
+			return fmt.Errorf("read transcript: %w", err)
+		}
+	}
+}
+
+// SubagentFiles returns the transcripts of the subagents a session ran,
+// stored in <session>/subagents/ next to its transcript.
+func SubagentFiles(path string) []string {
+	files, _ := filepath.Glob(filepath.Join(strings.TrimSuffix(path, ".jsonl"), "subagents", "*.jsonl"))
+	return files
+}
@@ -0,0 +1,220 @@
+package transcript
+
+import (
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+	"time"
+)
+
+// Session is a whole transcript read into memory.
+type Session struct {
+	Info
+	Path    string      `json:"path"`
+	Turns   []*Turn     `json:"turns"`
+	Skipped []LineError `json:"skipped,omitempty"`
+}
+
+// Load reads the transcript at path. Subagents recorded in their own files
+// (<session>/subagents/*.jsonl next to the transcript) are attached to the
+// tool call that launched them.
+func Load(path string) (*Session, error) {
+	f, err := os.Open(path)
+	if err != nil {
+		return nil, fmt.Errorf("open transcript: %w", err)
+	}
+	defer f.Close()
+
+	s := &Session{Path: path}
+	r := NewReader(f)
+	for {
+		t, err := r.Next()
+		if errors.Is(err, io.EOF) {
+			break
+		}
+		if err != nil {
+			return nil, err
+		}
+		s.Turns = append(s.Turns, t)
+	}
+	s.Info = r.Info()
+	s.Skipped = r.Skipped()
+	if s.SessionID == "" {
+		s.SessionID = strings.TrimSuffix(filepath.Base(path), ".jsonl")
+	}
+
+	for _, file := range SubagentFiles(path) {
+		agents, skipped, err := loadAgents(file)
+		if err != nil {
+			return nil, err
+		}
+		for _, e := range skipped {
+			s.Skipped = append(s.Skipped, LineError{Line: e.Line, Err: filepath.Base(file) + ": " + e.Err})
+		}
+		for _, a := range agents {
+			s.attach(a)
+		}
+	}
+	return s, nil
+}
+
+func loadAgents(path string) ([]*Agent, []LineError, error) {
+	f, err := os.Open(path)
+	if err != nil {
+		return nil, nil, fmt.Errorf("open subagent transcript: %w", err)
+	}
+	defer f.Close()
+
+	var agents []*Agent
+	r := NewReader(f)
+	for {
+		t, err := r.Next()
+		if errors.Is(err, io.EOF) {
+			break
+		}
+		if err != nil {
+			return nil, nil, err
+		}
+		agents = append(agents, t.Agents...)
+	}
+	return agents, r.Skipped(), nil
+}
+
+// attach adds an agent read from a subagent file to the agent of the same
+// id, or else to the turn it ran in.
+func (s *Session) attach(a *Agent) {
+	for _, t := range s.Turns {
+		for _, x := range t.Agents {
+			if a.ID != "" && x.ID == a.ID {
+				if x.Requests == 0 && len(x.Tools) == 0 {
+					merge(x, a)
+				}
+				return
+			}
+		}
+	}
+	if len(s.Turns) == 0 {
+		return
+	}
+	turn := s.Turns[len(s.Turns)-1]
+	for _, t := range s.Turns {
+		if !a.Start.Before(t.Start) {
+			turn = t
+		}
+	}
+	turn.Agents = append(turn.Agents, a)
+}
+
+// Usage returns the token usage of the session, subagents included.
+func (s *Session) Usage() Tokens {
+	var total Tokens
+	for _, t := range s.Turns {
+		total.Add(t.Total())
+	}
+	return total
+}
+
+// Requests returns the number of API responses, subagents included.
+func (s *Session) Requests() int {
+	n := 0
+	for _, t := range s.Turns {
+		n += t.Requests
+		for _, a := range t.Agents {
+			n += a.Requests
+		}
+	}
+	return n
+}
+
+// ToolStat summarizes the calls of one tool.
+type ToolStat struct {
+	Name        string        `json:"name"`
+	Calls       int           `json:"calls"`
+	Failed      int           `json:"failed"`
+	Duration    time.Duration `json:"duration_ns"`
+	ResultBytes int           `json:"result_bytes"`
+}
+
+// ToolStats summarizes tool calls across turns and subagents, by total
+// duration, longest first.
+func (s *Session) ToolStats() []ToolStat {
+	index := make(map[string]int)
+	var stats []ToolStat
+	add := func(c *ToolCall) {
+		i, ok := index[c.Name]
+		if !ok {
+			i = len(stats)
+			index[c.Name] = i
+			stats = append(stats, ToolStat{Name: c.Name})
+		}
+		st := &stats[i]
+		st.Calls++
+		if c.Error {
+			st.Failed++
+		}
+		st.Duration += c.Duration()
+		st.ResultBytes += c.ResultBytes
+	}
+	for _, t := range s.Turns {
+		for _, c := range t.Tools {
+			add(c)
+		}
+		for _, a := range t.Agents {
+			for _, c := range a.Tools {
+				add(c)
+			}
+		}
+	}
+	sort.SliceStable(stats, func(i, j int) bool {
+		if stats[i].Duration != stats[j].Duration {
+			return stats[i].Duration > stats[j].Duration
+		}
+		return stats[i].Name < stats[j].Name
+	})
+	return stats
+}
+
+// AgentStat summarizes the subagents of one type.
+type AgentStat struct {
+	Type     string        `json:"type"`
+	Runs     int           `json:"runs"`
+	Requests int           `json:"requests"`
+	Usage    Tokens        `json:"usage"`
+	Duration time.Duration `json:"duration_ns"`
+}
+
+// AgentStats summarizes subagents by type, by total tokens, most first.
+func (s *Session) AgentStats() []AgentStat {
+	index := make(map[string]int)
+	var stats []AgentStat
+	for _, t := range s.Turns {
+		for _, a := range t.Agents {
+			typ := a.Type
+			if typ == "" {
+				typ = "(unknown)"
+			}
+			i, ok := index[typ]
+			if !ok {
+				i = len(stats)
+				index[typ] = i
+				stats = append(stats, AgentStat{Type: typ})
+			}
+			st := &stats[i]
+			st.Runs++
+			st.Requests += a.Requests
+			st.Usage.Add(a.Usage)
+			st.Duration += a.Duration()
+		}
+	}
+	sort.SliceStable(stats, func(i, j int) bool {
+		if stats[i].Usage.Total() != stats[j].Usage.Total() {
+			return stats[i].Usage.Total() > stats[j].Usage.Total()
+		}
+		return stats[i].Type < stats[j].Type
+	})
+	return stats
+}
@@ -0,0 +1,451 @@
+// Package transcript reads Claude Code session transcripts as a sequence
+// of turns: the prompt, the responses and their token usage, the tool
+// calls they made, the subagents they launched and any model switch.
+package transcript
+
+import (
+	"bufio"
+	"bytes"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"strings"
+	"time"
+)
+
+// Turn is one user prompt and everything that happened until the next.
+// Usage counts only main-chain responses; subagent usage is on Agents.
+type Turn struct {
+	Index    int           `json:"index"`
+	Prompt   string        `json:"prompt"`
+	Start    time.Time     `json:"start"`
+	End      time.Time     `json:"end"`
+	Model    string        `json:"model,omitempty"`
+	Requests int           `json:"requests"`
+	Usage    Tokens        `json:"usage"`
+	Tools    []*ToolCall   `json:"tools,omitempty"`
+	Agents   []*Agent      `json:"agents,omitempty"`
+	Switches []ModelSwitch `json:"model_switches,omitempty"`
+}
+
+// Duration is the time from the prompt to the last entry of the turn.
+func (t *Turn) Duration() time.Duration {
+	return t.End.Sub(t.Start)
+}
+
+// Total returns the turn's usage including its subagents.
+func (t *Turn) Total() Tokens {
+	total := t.Usage
+	for _, a := range t.Agents {
+		total.Add(a.Usage)
+	}
+	return total
+}
+
+// ToolCall is one tool_use block and its result. Done is false when no
+// result was recorded, e.g. after an interrupt.
+type ToolCall struct {
+	ID          string    `json:"id"`
+	Name        string    `json:"name"`
+	Start       time.Time `json:"start"`
+	End         time.Time `json:"end,omitzero"`
+	Done        bool      `json:"done"`
+	Error       bool      `json:"error"`
+	ResultBytes int       `json:"result_bytes"`
+	AgentID     string    `json:"agent_id,omitempty"`
+}
+
+// Duration is the time between the call and its result.
+func (c *ToolCall) Duration() time.Duration {
+	if !c.Done {
+		return 0
+	}
+	return c.End.Sub(c.Start)
+}
+
+// Agent is a subagent sidechain, launched by a Task (or Agent) tool call.
+type Agent struct {
+	ID          string      `json:"id,omitempty"`
+	Type        string      `json:"type,omitempty"`
+	Description string      `json:"description,omitempty"`
+	Start       time.Time   `json:"start"`
+	End         time.Time   `json:"end"`
+	Models      []string    `json:"models,omitempty"`
+	Requests    int         `json:"requests"`
+	Usage       Tokens      `json:"usage"`
+	Tools       []*ToolCall `json:"tools,omitempty"`
+}
+
+// Duration is the time between the first and last sidechain entry.
+func (a *Agent) Duration() time.Duration {
+	return a.End.Sub(a.Start)
+}
+
+// ModelSwitch records a main-chain response using a different model than
+// the one before it.
+type ModelSwitch struct {
+	Time time.Time `json:"time"`
+	From string    `json:"from"`
+	To   string    `json:"to"`
+}
+
+// LineError is a transcript line that could not be parsed.
+type LineError struct {
+	Line int    `json:"line"`
+	Err  string `json:"error"`
+}
+
+func (e LineError) Error() string {
+	return fmt.Sprintf("line %d: %s", e.Line, e.Err)
+}
+
+// Info is session metadata seen while reading.
+type Info struct {
+	SessionID string    `json:"session_id"`
+	Project   string    `json:"project"`
+	Version   string    `json:"version,omitempty"`
+	Start     time.Time `json:"start"`
+	End       time.Time `json:"end"`
+}
+
+// launchers are the tool names that start a subagent.
+var launchers = map[string]bool{"Task": true, "Agent": true}
+
+// Reader streams turns from a transcript. A turn is returned by Next once
+// the following prompt (or the end of input) is read.
+type Reader struct {
+	r       *bufio.Reader
+	lineNo  int
+	err     error
+	info    Info
+	skipped []LineError
+
+	cur      *Turn
+	ready    []*Turn
+	turns    int
+	model    string               // last main-chain model
+	seen     Responses            // responses already counted
+	pending  map[string]*ToolCall // tool calls awaiting a result
+	agents   map[string]*Agent    // by agent id, or by launching tool id
+	launches []*ToolCall          // launcher calls of the current turn without a result
+}
+
+// NewReader returns a Reader for the transcript in r.
+func NewReader(r io.Reader) *Reader {
+	return &Reader{
+		r:       bufio.NewReaderSize(r, 64*1024),
+		seen:    make(Responses),
+		pending: make(map[string]*ToolCall),
+		agents:  make(map[string]*Agent),
+	}
+}
+
+// Next returns the next complete turn, or io.EOF after the last one.
+func (r *Reader) Next() (*Turn, error) {
+	for len(r.ready) == 0 {
+		if r.err != nil {
+			if r.cur != nil {
+				t := r.cur
+				r.cur = nil
+				return t, nil
+			}
+			if errors.Is(r.err, io.EOF) {
+				return nil, io.EOF
+			}
+			return nil, fmt.Errorf("read transcript: %w", r.err)
+		}
+		raw, err := r.r.ReadBytes('\n')
+		if err != nil {
+			r.err = err
+		}
+		if len(raw) > 0 {
+			r.lineNo++
+		}
+		if len(bytes.TrimSpace(raw)) == 0 {
+			continue
+		}
+		var l Line
+		if err := json.Unmarshal(raw, &l); err != nil {
+			r.skipped = append(r.skipped, LineError{Line: r.lineNo, Err: err.Error()})
+			continue
+		}
+		if err := r.add(l); err != nil {
+			r.skipped = append(r.skipped, LineError{Line: r.lineNo, Err: err.Error()})
+		}
+	}
+	t := r.ready[0]
+	r.ready = r.ready[1:]
+	return t, nil
+}
+
+// Info returns the session metadata read so far.
+func (r *Reader) Info() Info {
+	return r.info
+}
+
+// Skipped returns the lines that could not be parsed so far.
+func (r *Reader) Skipped() []LineError {
+	return r.skipped
+}
+
+func (r *Reader) add(l Line) error {
+	if l.Timestamp == "" {
+		return nil // bookkeeping lines (mode, last-prompt, ...) carry no time
+	}
+	ts, err := time.Parse(time.RFC3339Nano, l.Timestamp)
+	if err != nil {
+		return fmt.Errorf("invalid timestamp %q", l.Timestamp)
+	}
+	r.note(l, ts)
+	if len(l.Message) == 0 {
+		if r.cur != nil && !l.IsSidechain {
+			r.cur.End = maxTime(r.cur.End, ts)
+		}
+		return nil
+	}
+	var msg Message
+	if err := json.Unmarshal(l.Message, &msg); err != nil {
+		return fmt.Errorf("invalid message: %v", err)
+	}
+	blocks, err := msg.Blocks()
+	if err != nil {
+		return fmt.Errorf("invalid message content: %v", err)
+	}
+
+	if l.Type == "user" && !l.IsSidechain && !l.IsMeta && isPrompt(msg.Content, blocks) {
+		r.startTurn(ts, promptText(msg.Content, blocks))
+		return nil
+	}
+	if r.cur == nil {
+		r.startTurn(ts, "")
+	}
+	if !l.IsSidechain {
+		r.cur.End = maxTime(r.cur.End, ts)
+	}
+
+	var a *Agent
+	if l.IsSidechain {
+		a = r.agentFor(l.AgentID)
+		if a.Start.IsZero() || ts.Before(a.Start) {
+			a.Start = ts
+		}
+		a.End = maxTime(a.End, ts)
+	}
+
+	switch l.Type {
+	case "assistant":
+		r.response(l, msg, blocks, ts, a)
+	case "user":
+		r.results(l, blocks, ts)
+	}
+	return nil
+}
+
+func (r *Reader) note(l Line, ts time.Time) {
+	if r.info.Start.IsZero() || ts.Before(r.info.Start) {
+		r.info.Start = ts
+	}
+	r.info.End = maxTime(r.info.End, ts)
+	if r.info.SessionID == "" {
+		r.info.SessionID = l.SessionID
+	}
+	if r.info.Project == "" && !l.IsSidechain {
+		r.info.Project = l.CWD
+	}
+	if l.Version != "" {
+		r.info.Version = l.Version
+	}
+}
+
+func (r *Reader) startTurn(ts time.Time, prompt string) {
+	if r.cur != nil {
+		r.ready = append(r.ready, r.cur)
+	}
+	r.turns++
+	r.cur = &Turn{Index: r.turns, Prompt: prompt, Start: ts, End: ts}
+	r.launches = nil
+	clear(r.pending)
+}
+
+func (r *Reader) response(l Line, msg Message, blocks []Block, ts time.Time, a *Agent) {
+	if msg.Billed() && r.seen.First(l, &msg) {
+		t := msg.Tokens()
+		if a != nil {
+			a.Requests++
+			a.Usage.Add(t)
+			if !contains(a.Models, msg.Model) {
+				a.Models = append(a.Models, msg.Model)
+			}
+		} else {
+			r.cur.Requests++
+			r.cur.Usage.Add(t)
+			if r.model != "" && r.model != msg.Model {
+				r.cur.Switches = append(r.cur.Switches, ModelSwitch{Time: ts, From: r.model, To: msg.Model})
+			}
+			if r.cur.Model == "" {
+				r.cur.Model = msg.Model
+			}
+			r.model = msg.Model
+		}
+	}
+
+	for _, b := range blocks {
+		if b.Type != "tool_use" || b.ID == "" {
+			continue
+		}
+		call := &ToolCall{ID: b.ID, Name: b.Name, Start: ts}
+		r.pending[b.ID] = call
+		if a != nil {
+			a.Tools = append(a.Tools, call)
+			continue
+		}
+		r.cur.Tools = append(r.cur.Tools, call)
+		if launchers[b.Name] {
+			var in struct {
+				SubagentType string `json:"subagent_type"`
+				Description  string `json:"description"`
+			}
+			_ = json.Unmarshal(b.Input, &in)
+			ag := &Agent{Type: in.SubagentType, Description: in.Description}
+			r.agents["tool:"+b.ID] = ag
+			r.cur.Agents = append(r.cur.Agents, ag)
+			r.launches = append(r.launches, call)
+		}
+	}
+}
+
+func (r *Reader) results(l Line, blocks []Block, ts time.Time) {
+	for _, b := range blocks {
+		if b.Type != "tool_result" {
+			continue
+		}
+		call, ok := r.pending[b.ToolUseID]
+		if !ok {
+			continue
+		}
+		delete(r.pending, b.ToolUseID)
+		call.End, call.Done, call.Error = ts, true, b.IsError
+		call.ResultBytes = resultSize(b.Content)
+		if !launchers[call.Name] || l.IsSidechain {
+			continue
+		}
+		r.launches = removeCall(r.launches, call)
+		var res struct {
+			AgentID string `json:"agentId"`
+		}
+		if len(l.ToolUseResult) > 0 && l.ToolUseResult[0] == '{' {
+			_ = json.Unmarshal(l.ToolUseResult, &res)
+		}
+		if res.AgentID == "" {
+			continue
+		}
+		call.AgentID = res.AgentID
+		ag := r.agents["tool:"+call.ID]
+		ag.ID = res.AgentID
+		// Sidechain lines tagged with this id before the result arrived
+		// were collected under a separate agent; fold them in.
+		if early, ok := r.agents[res.AgentID]; ok && early != ag {
+			merge(ag, early)
+			r.cur.Agents = removeAgent(r.cur.Agents, early)
+		}
+		r.agents[res.AgentID] = ag
+	}
+}
+
+// agentFor returns the agent a sidechain line belongs to: by agent id when
+// the line has one, otherwise the most recent launcher call still running.
+func (r *Reader) agentFor(id string) *Agent {
+	if id != "" {
+		if a, ok := r.agents[id]; ok {
+			return a
+		}
+	} else if n := len(r.launches); n > 0 {
+		return r.agents["tool:"+r.launches[n-1].ID]
+	}
+	a := &Agent{ID: id}
+	if id != "" {
+		r.agents[id] = a
+	}
+	r.cur.Agents = append(r.cur.Agents, a)
+	return a
+}
+
+func merge(dst, src *Agent) {
+	if dst.Start.IsZero() || !src.Start.IsZero() && src.Start.Before(dst.Start) {
+		dst.Start = src.Start
+	}
+	dst.End = maxTime(dst.End, src.End)
+	dst.Requests += src.Requests
+	dst.Usage.Add(src.Usage)
+	dst.Tools = append(dst.Tools, src.Tools...)
+	for _, m := range src.Models {
+		if !contains(dst.Models, m) {
+			dst.Models = append(dst.Models, m)
+		}
+	}
+}
+
+func promptText(content json.RawMessage, blocks []Block) string {
+	var text string
+	if blocks == nil {
+		_ = json.Unmarshal(content, &text)
+	}
+	for _, b := range blocks {
+		if b.Type == "text" {
+			text = b.Text
+			break
+		}
+	}
+	return strings.TrimSpace(text)
+}
+
+func resultSize(content json.RawMessage) int {
+	var text string
+	if json.Unmarshal(content, &text) == nil {
+		return len(text)
+	}
+	var parts []Block
+	if json.Unmarshal(content, &parts) == nil {
+		n := 0
+		for _, p := range parts {
+			n += len(p.Text)
+		}
+		return n
+	}
+	return len(content)
+}
+
+func removeCall(calls []*ToolCall, c *ToolCall) []*ToolCall {
+	for i, x := range calls {
+		if x == c {
+			return append(calls[:i], calls[i+1:]...)
+		}
+	}
+	return calls
+}
+
+func removeAgent(agents []*Agent, a *Agent) []*Agent {
+	for i, x := range agents {
+		if x == a {
+			return append(agents[:i], agents[i+1:]...)
+		}
+	}
+	return agents
+}
+
+func contains(list []string, s string) bool {
+	for _, x := range list {
+		if x == s {
+			return true
+		}
+	}
+	return false
+}
+
+func maxTime(a, b time.Time) time.Time {
+	if b.After(a) {
+		return b
+	}
+	return a
+}
@@ -0,0 +1,168 @@
+package transcript
+
+import (
+	"encoding/json"
+	"errors"
+	"io"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+)
+
+const sample = `{"type":"user","timestamp":"2026-01-05T10:00:00Z","sessionId":"s1","cwd":"/work/api","version":"2.0.1","message":{"role":"user","content":"fix the tests"}}
+{"type":"assistant","timestamp":"2026-01-05T10:00:02Z","requestId":"r1","message":{"id":"m1","model":"claude-sonnet-4-5","content":[{"type":"text","text":"looking"}],"usage":{"input_tokens":10,"output_tokens":5,"cache_read_input_tokens":100}}}
+{"type":"assistant","timestamp":"2026-01-05T10:00:03Z","requestId":"r1","message":{"id":"m1","model":"claude-sonnet-4-5","content":[{"type":"tool_use","id":"t1","name":"Bash","input":{"command":"go test"}}],"usage":{"input_tokens":10,"output_tokens":5,"cache_read_input_tokens":100}}}
+{"type":"user","timestamp":"2026-01-05T10:00:13Z","message":{"role":"user","content":[{"type":"tool_result","tool_use_id":"t1","is_error":true,"content":"FAIL"}]}}
+this is not json
+{"type":"assistant","timestamp":"2026-01-05T10:00:20Z","requestId":"r2","message":{"id":"m2","model":"claude-opus-4-5","content":[{"type":"tool_use","id":"t2","name":"Task","input":{"subagent_type":"Explore","description":"find flaky test"}}],"usage":{"input_tokens":20,"output_tokens":8}}}
+{"type":"user","timestamp":"2026-01-05T10:00:21Z","isSidechain":true,"message":{"role":"user","content":"find flaky test"}}
+{"type":"assistant","timestamp":"2026-01-05T10:00:25Z","isSidechain":true,"requestId":"r3","message":{"id":"m3","model":"claude-haiku-4-5","content":[{"type":"tool_use","id":"t3","name":"Grep","input":{}}],"usage":{"input_tokens":300,"output_tokens":30}}}
+{"type":"user","timestamp":"2026-01-05T10:00:26Z","isSidechain":true,"message":{"role":"user","content":[{"type":"tool_result","tool_use_id":"t3","content":"x_test.go"}]}}
+{"type":"user","timestamp":"2026-01-05T10:01:00Z","message":{"role":"user","content":[{"type":"tool_result","tool_use_id":"t2","content":[{"type":"text","text":"found it"}]}]}}
+{"type":"mode","mode":"normal"}
+{"type":"user","timestamp":"2026-01-05T10:05:00Z","isMeta":true,"message":{"role":"user","content":"<command-name>/clear</command-name>"}}
+{"type":"user","timestamp":"2026-01-05T10:06:00Z","message":{"role":"user","content":[{"type":"text","text":"thanks\nbye"}]}}
+{"type":"assistant","timestamp":"2026-01-05T10:06:04Z","requestId":"r4","message":{"id":"m4","model":"claude-opus-4-5","content":[{"type":"tool_use","id":"t4","name":"Read","input":{}}],"usage":{"input_tokens":1,"output_tokens":1}}}
+`
+
+func Test_Reader_streams_turns_with_tools_agents_and_switches(t *testing.T) {
+	r := NewReader(strings.NewReader(sample))
+
+	t1, err := r.Next()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if t1.Index != 1 || t1.Prompt != "fix the tests" || t1.Model != "claude-sonnet-4-5" {
+		t.Errorf("turn 1: %+v", t1)
+	}
+	if t1.Requests != 2 || t1.Usage.Input != 30 || t1.Usage.CacheRead != 100 {
+		t.Errorf("turn 1 usage: requests %d, %+v", t1.Requests, t1.Usage)
+	}
+	if t1.Duration() != 5*time.Minute {
+		t.Errorf("turn 1 duration: %v", t1.Duration())
+	}
+	if len(t1.Tools) != 2 {
+		t.Fatalf("turn 1 tools: %+v", t1.Tools)
+	}
+	if bash := t1.Tools[0]; bash.Name != "Bash" || !bash.Done || !bash.Error || bash.Duration() != 10*time.Second || bash.ResultBytes != 4 {
+		t.Errorf("bash call: %+v", bash)
+	}
+	if task := t1.Tools[1]; task.Error || task.Duration() != 40*time.Second || task.ResultBytes != 8 {
+		t.Errorf("task call: %+v", task)
+	}
+	if len(t1.Switches) != 1 || t1.Switches[0].From != "claude-sonnet-4-5" || t1.Switches[0].To != "claude-opus-4-5" {
+		t.Errorf("switches: %+v", t1.Switches)
+	}
+	if len(t1.Agents) != 1 {
+		t.Fatalf("agents: %+v", t1.Agents)
+	}
+	a := t1.Agents[0]
+	if a.Type != "Explore" || a.Description != "find flaky test" || a.Requests != 1 || a.Usage.Input != 300 ||
+		len(a.Tools) != 1 || a.Tools[0].Name != "Grep" || !a.Tools[0].Done || a.Duration() != 5*time.Second {
+		t.Errorf("agent: %+v", a)
+	}
+	if got := t1.Total().Input; got != 330 {
+		t.Errorf("turn total input: %d", got)
+	}
+
+	t2, err := r.Next()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if t2.Index != 2 || t2.Prompt != "thanks\nbye" || len(t2.Switches) != 0 || len(t2.Tools) != 1 || t2.Tools[0].Done {
+		t.Errorf("turn 2: %+v", t2)
+	}
+	if _, err := r.Next(); !errors.Is(err, io.EOF) {
+		t.Errorf("want EOF, got %v", err)
+	}
+
+	info := r.Info()
+	if info.SessionID != "s1" || info.Project != "/work/api" || info.Version != "2.0.1" {
+		t.Errorf("info: %+v", info)
+	}
+	if sk := r.Skipped(); len(sk) != 1 || sk[0].Line != 5 {
+		t.Errorf("skipped: %+v", sk)
+	}
+}
+
+func Test_Load_attaches_subagent_files_by_agent_id(t *testing.T) {
+	dir := t.TempDir()
+	main := `{"type":"user","timestamp":"2026-01-05T10:00:00Z","sessionId":"s2","cwd":"/work","message":{"role":"user","content":"review"}}
+{"type":"assistant","timestamp":"2026-01-05T10:00:01Z","requestId":"r1","message":{"id":"m1","model":"claude-opus-4-5","content":[{"type":"tool_use","id":"t1","name":"Task","input":{"subagent_type":"reviewer"}}],"usage":{"input_tokens":5,"output_tokens":5}}}
+{"type":"user","timestamp":"2026-01-05T10:02:00Z","toolUseResult":{"agentId":"a7","status":"completed"},"message":{"role":"user","content":[{"type":"tool_result","tool_use_id":"t1","content":"done"}]}}
+`
+	sub := `{"type":"user","timestamp":"2026-01-05T10:00:02Z","isSidechain":true,"agentId":"a7","message":{"role":"user","content":"review"}}
+{"type":"assistant","timestamp":"2026-01-05T10:01:50Z","isSidechain":true,"agentId":"a7","requestId":"r2","message":{"id":"m2","model":"claude-sonnet-4-5","content":[{"type":"tool_use","id":"t2","name":"Read","input":{}}],"usage":{"input_tokens":1000,"output_tokens":100}}}
+{"type":"user","timestamp":"2026-01-05T10:01:55Z","isSidechain":true,"agentId":"a7","message":{"role":"user","content":[{"type":"tool_result","tool_use_id":"t2","content":"src"}]}}
+`
+	path := filepath.Join(dir, "s2.jsonl")
+	if err := os.WriteFile(path, []byte(main), 0644); err != nil {
+		t.Fatal(err)
+	}
+	if err := os.MkdirAll(filepath.Join(dir, "s2", "subagents"), 0755); err != nil {
+		t.Fatal(err)
+	}
+	if err := os.WriteFile(filepath.Join(dir, "s2", "subagents", "agent-a7.jsonl"), []byte(sub), 0644); err != nil {
+		t.Fatal(err)
+	}
+
+	s, err := Load(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(s.Turns) != 1 || len(s.Turns[0].Agents) != 1 {
+		t.Fatalf("turns: %+v", s.Turns)
+	}
+	a := s.Turns[0].Agents[0]
+	if a.ID != "a7" || a.Type != "reviewer" || a.Requests != 1 || a.Usage.Input != 1000 || len(a.Tools) != 1 {
+		t.Errorf("agent: %+v", a)
+	}
+	if s.Turns[0].Tools[0].AgentID != "a7" {
+		t.Errorf("launcher agent id: %+v", s.Turns[0].Tools[0])
+	}
+	if s.Requests() != 2 || s.Usage().Input != 1005 {
+		t.Errorf("session totals: %d requests, %+v", s.Requests(), s.Usage())
+	}
+
+	tools := s.ToolStats()
+	if len(tools) != 2 || tools[0].Name != "Task" || tools[1].Name != "Read" || tools[1].Calls != 1 {
+		t.Errorf("tool stats: %+v", tools)
+	}
+	agents := s.AgentStats()
+	if len(agents) != 1 || agents[0].Type != "reviewer" || agents[0].Runs != 1 || agents[0].Usage.Output != 100 {
+		t.Errorf("agent stats: %+v", agents)
+	}
+}
+
+func Test_ReadLines_with_IsPrompt_and_Responses(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "s1.jsonl")
+	if err := os.WriteFile(path, []byte(sample), 0644); err != nil {
+		t.Fatal(err)
+	}
+	var lines, prompts, responses int
+	var total Tokens
+	seen := make(Responses)
+	err := ReadLines(path, func(l Line) {
+		lines++
+		var msg Message
+		if json.Unmarshal(l.Message, &msg) != nil {
+			return
+		}
+		if IsPrompt(l, &msg) {
+			prompts++
+		}
+		if msg.Billed() && seen.First(l, &msg) {
+			responses++
+			total.Add(msg.Tokens())
+		}
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	// The line that is not JSON is skipped; m1 is split over two lines.
+	if lines != 13 || prompts != 2 || responses != 4 || total.Input != 331 || total.CacheRead != 100 {
+		t.Errorf("lines %d, prompts %d, responses %d, tokens %+v", lines, prompts, responses, total)
+	}
+}
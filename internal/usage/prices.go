@@ -0,0 +1,92 @@
+package usage
+
+import (
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+
+	"gopkg.in/yaml.v3"
+)
+
+// PricesFile is the user price table, relative to the home directory.
+const PricesFile = ".do/usage/prices.yaml"
+
+// Price is the cost of one million tokens of each kind, in USD.
+type Price struct {
+	Input      float64 `yaml:"input" json:"input"`
+	Output     float64 `yaml:"output" json:"output"`
+	CacheWrite float64 `yaml:"cache_write" json:"cache_write"`
+	CacheRead  float64 `yaml:"cache_read" json:"cache_read"`
+}
+
+// Cost returns the price of t in USD.
+func (p Price) Cost(t Tokens) float64 {
+	return (float64(t.Input)*p.Input +
+		float64(t.Output)*p.Output +
+		float64(t.CacheCreation)*p.CacheWrite +
+		float64(t.CacheRead)*p.CacheRead) / 1e6
+}
+
+// PriceTable maps model name fragments to prices. A model uses the entry
+// with the longest key contained in its name, so "opus-4-5" wins over
+// "opus" for claude-opus-4-5-20251101.
+type PriceTable struct {
+	Models map[string]Price `yaml:"models"`
+}
+
+// DefaultPrices returns list prices for the Claude model families.
+func DefaultPrices() *PriceTable {
+	return &PriceTable{Models: map[string]Price{
+		"opus":      {Input: 15, Output: 75, CacheWrite: 18.75, CacheRead: 1.50},
+		"opus-4-5":  {Input: 5, Output: 25, CacheWrite: 6.25, CacheRead: 0.50},
+		"sonnet":    {Input: 3, Output: 15, CacheWrite: 3.75, CacheRead: 0.30},
+		"haiku":     {Input: 0.25, Output: 1.25, CacheWrite: 0.30, CacheRead: 0.03},
+		"3-5-haiku": {Input: 0.80, Output: 4, CacheWrite: 1, CacheRead: 0.08},
+		"haiku-4-5": {Input: 1, Output: 5, CacheWrite: 1.25, CacheRead: 0.10},
+	}}
+}
+
+// DefaultPricesPath returns ~/.do/usage/prices.yaml.
+func DefaultPricesPath() string {
+	home, err := os.UserHomeDir()
+	if err != nil {
+		home = "."
+	}
+	return filepath.Join(home, PricesFile)
+}
+
+// LoadPrices returns the default prices overlaid with the table at path.
+// Entries in the file replace defaults with the same key and add new
+// ones. A missing file is not an error.
+func LoadPrices(path string) (*PriceTable, error) {
+	table := DefaultPrices()
+	data, err := os.ReadFile(path)
+	if errors.Is(err, os.ErrNotExist) {
+		return table, nil
+	}
+	if err != nil {
+		return nil, fmt.Errorf("read price table: %w", err)
+	}
+	var custom PriceTable
+	if err := yaml.Unmarshal(data, &custom); err != nil {
+		return nil, fmt.Errorf("parse price table %s: %w", path, err)
+	}
+	for k, p := range custom.Models {
+		table.Models[strings.ToLower(k)] = p
+	}
+	return table, nil
+}
+
+// Lookup returns the price for a model name.
+func (t *PriceTable) Lookup(model string) (Price, bool) {
+	model = strings.ToLower(model)
+	best, found := "", false
+	for k := range t.Models {
+		if strings.Contains(model, k) && (!found || len(k) > len(best) || len(k) == len(best) && k < best) {
+			best, found = k, true
+		}
+	}
+	return t.Models[best], found
+}
@@ -0,0 +1,376 @@
+package usage
+
+import (
+	"encoding/csv"
+	"encoding/json"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// Groupings for Aggregate.
+const (
+	ByDay     = "day"
+	ByWeek    = "week"
+	ByMonth   = "month"
+	ByProject = "project"
+	ByModel   = "model"
+	BySession = "session"
+)
+
+// Groupings returns the valid Aggregate groupings.
+func Groupings() []string {
+	return []string{ByDay, ByWeek, ByMonth, ByProject, ByModel, BySession}
+}
+
+// Output formats for WriteReport.
+const (
+	FormatTable = "table"
+	FormatCSV   = "csv"
+	FormatJSON  = "json"
+)
+
+// Formats returns the valid output formats.
+func Formats() []string {
+	return []string{FormatTable, FormatCSV, FormatJSON}
+}
+
+// Group is the usage of one day, week, month, project, model or session.
+// Duration adds up, per session, the span of its entries in the group.
+type Group struct {
+	Key      string        `json:"key"`
+	Project  string        `json:"project,omitempty"` // session grouping only
+	Start    time.Time     `json:"start"`
+	Sessions int           `json:"sessions"`
+	Turns    int           `json:"turns"`
+	Requests int           `json:"requests"`
+	Tokens   Tokens        `json:"tokens"`
+	Cost     float64       `json:"cost_usd"`
+	Duration time.Duration `json:"-"`
+}
+
+// MarshalJSON adds the duration in seconds and the token total.
+func (g Group) MarshalJSON() ([]byte, error) {
+	type plain Group
+	return json.Marshal(struct {
+		plain
+		TotalTokens     int64 `json:"total_tokens"`
+		DurationSeconds int64 `json:"duration_seconds"`
+	}{plain(g), g.Tokens.Total(), int64(g.Duration.Seconds())})
+}
+
+// Report is aggregated usage.
+type Report struct {
+	By          string   `json:"by"`
+	Groups      []Group  `json:"groups"`
+	Total       Group    `json:"total"`
+	TopSessions []Group  `json:"top_sessions,omitempty"`
+	Unpriced    []string `json:"unpriced_models,omitempty"` // models without a price; counted at $0
+}
+
+// Aggregate groups entries and prices them. Time groupings use the
+// location of loc and are sorted chronologically; the others are sorted
+// by cost, highest first. top sets how many sessions, by cost, are listed
+// in TopSessions.
+func Aggregate(d *Data, by string, prices *PriceTable, loc *time.Location, top int) (*Report, error) {
+	keyOf, err := groupKey(by, loc)
+	if err != nil {
+		return nil, err
+	}
+	projects := make(map[string]string, len(d.Sessions))
+	for _, s := range d.Sessions {
+		projects[s.ID] = s.Project
+	}
+
+	groups, unpriced := group(d.Entries, keyOf, prices)
+	for i := range groups {
+		if by == BySession {
+			groups[i].Project = projects[groups[i].Key]
+		}
+	}
+	if by == ByDay || by == ByWeek || by == ByMonth {
+		sort.Slice(groups, func(i, j int) bool { return groups[i].Key < groups[j].Key })
+	} else {
+		sortByCost(groups)
+	}
+
+	r := &Report{By: by, Groups: groups, Unpriced: unpriced}
+	total, _ := group(d.Entries, func(Entry) string { return "TOTAL" }, prices)
+	if len(total) > 0 {
+		r.Total = total[0]
+	} else {
+		r.Total.Key = "TOTAL"
+	}
+
+	if top > 0 {
+		sessions, _ := group(d.Entries, func(e Entry) string { return e.SessionID }, prices)
+		sortByCost(sessions)
+		if len(sessions) > top {
+			sessions = sessions[:top]
+		}
+		for i := range sessions {
+			sessions[i].Project = projects[sessions[i].Key]
+		}
+		r.TopSessions = sessions
+	}
+	return r, nil
+}
+
+func groupKey(by string, loc *time.Location) (func(Entry) string, error) {
+	if loc == nil {
+		loc = time.Local
+	}
+	switch by {
+	case ByDay:
+		return func(e Entry) string { return e.Time.In(loc).Format("2006-01-02") }, nil
+	case ByWeek:
+		return func(e Entry) string {
+			y, w := e.Time.In(loc).ISOWeek()
+			return fmt.Sprintf("%d-W%02d", y, w)
+		}, nil
+	case ByMonth:
+		return func(e Entry) string { return e.Time.In(loc).Format("2006-01") }, nil
+	case ByProject:
+		return func(e Entry) string { return e.Project }, nil
+	case ByModel:
+		return func(e Entry) string { return e.Model }, nil
+	case BySession:
+		return func(e Entry) string { return e.SessionID }, nil
+	}
+	return nil, fmt.Errorf("invalid grouping %q (valid: %s)", by, strings.Join(Groupings(), ", "))
+}
+
+func group(entries []Entry, keyOf func(Entry) string, prices *PriceTable) ([]Group, []string) {
+	type span struct{ start, end time.Time }
+	index := make(map[string]int)
+	spans := make(map[string]map[string]*span)
+	var groups []Group
+	unpriced := make(map[string]bool)
+
+	for _, e := range entries {
+		key := keyOf(e)
+		if key == "" {
+			key = "(unknown)"
+		}
+		i, ok := index[key]
+		if !ok {
+			i = len(groups)
+			index[key] = i
+			groups = append(groups, Group{Key: key, Start: e.Time})
+			spans[key] = make(map[string]*span)
+		}
+		g := &groups[i]
+		if e.Time.Before(g.Start) {
+			g.Start = e.Time
+		}
+		sp, ok := spans[key][e.SessionID]
+		if !ok {
+			sp = &span{e.Time, e.Time}
+			spans[key][e.SessionID] = sp
+			g.Sessions++
+		}
+		if e.Time.Before(sp.start) {
+			sp.start = e.Time
+		}
+		if e.Time.After(sp.end) {
+			sp.end = e.Time
+		}
+
+		if e.Prompt {
+			g.Turns++
+			continue
+		}
+		g.Requests++
+		g.Tokens.Add(e.Tokens)
+		if p, ok := prices.Lookup(e.Model); ok {
+			g.Cost += p.Cost(e.Tokens)
+		} else {
+			unpriced[e.Model] = true
+		}
+	}
+
+	for i := range groups {
+		for _, sp := range spans[groups[i].Key] {
+			groups[i].Duration += sp.end.Sub(sp.start)
+		}
+	}
+	var models []string
+	for m := range unpriced {
+		models = append(models, m)
+	}
+	sort.Strings(models)
+	return groups, models
+}
+
+func sortByCost(groups []Group) {
+	sort.SliceStable(groups, func(i, j int) bool {
+		if groups[i].Cost != groups[j].Cost {
+			return groups[i].Cost > groups[j].Cost
+		}
+		if groups[i].Tokens.Total() != groups[j].Tokens.Total() {
+			return groups[i].Tokens.Total() > groups[j].Tokens.Total()
+		}
+		return groups[i].Key < groups[j].Key
+	})
+}
+
+// WriteReport writes r in the given format.
+func WriteReport(w io.Writer, format string, r *Report) error {
+	switch format {
+	case FormatTable:
+		return writeTable(w, r)
+	case FormatCSV:
+		return writeCSV(w, r)
+	case FormatJSON:
+		enc := json.NewEncoder(w)
+		enc.SetIndent("", "  ")
+		return enc.Encode(r)
+	}
+	return fmt.Errorf("invalid format %q (valid: %s)", format, strings.Join(Formats(), ", "))
+}
+
+func writeTable(w io.Writer, r *Report) error {
+	if len(r.Groups) == 0 {
+		_, err := fmt.Fprintln(w, "No usage found.")
+		return err
+	}
+
+	label := strings.ToUpper(r.By)
+	width := len(label)
+	for _, g := range r.Groups {
+		width = max(width, len(displayKey(r.By, g)))
+	}
+	width = min(width, 48)
+
+	row := func(key string, g Group) {
+		fmt.Fprintf(w, "%-*s %8d %6d %8d %8s %8s %8s %8s %8s %10s %9s\n",
+			width, truncate(key, width), g.Sessions, g.Turns, g.Requests,
+			FormatTokens(g.Tokens.Input), FormatTokens(g.Tokens.Output),
+			FormatTokens(g.Tokens.CacheCreation), FormatTokens(g.Tokens.CacheRead),
+			FormatTokens(g.Tokens.Total()), FormatCost(g.Cost), FormatDuration(g.Duration))
+	}
+	fmt.Fprintf(w, "%-*s %8s %6s %8s %8s %8s %8s %8s %8s %10s %9s\n",
+		width, label, "SESSIONS", "TURNS", "REQUESTS", "INPUT", "OUTPUT", "CACHE W", "CACHE R", "TOTAL", "COST", "DURATION")
+	for _, g := range r.Groups {
+		row(displayKey(r.By, g), g)
+	}
+	row("TOTAL", r.Total)
+
+	if len(r.TopSessions) > 0 {
+		fmt.Fprintf(w, "\nTop sessions by cost:\n")
+		fmt.Fprintf(w, "  %-8s  %-16s  %-32s %6s %8s %10s %9s\n", "SESSION", "STARTED", "PROJECT", "TURNS", "TOKENS", "COST", "DURATION")
+		for _, s := range r.TopSessions {
+			fmt.Fprintf(w, "  %-8s  %-16s  %-32s %6d %8s %10s %9s\n",
+				shortID(s.Key), s.Start.Local().Format("2006-01-02 15:04"), truncate(ShortPath(s.Project), 32),
+				s.Turns, FormatTokens(s.Tokens.Total()), FormatCost(s.Cost), FormatDuration(s.Duration))
+		}
+	}
+	if len(r.Unpriced) > 0 {
+		fmt.Fprintf(w, "\nNo price for %s; counted as $0 (add them to ~/%s).\n", strings.Join(r.Unpriced, ", "), PricesFile)
+	}
+	return nil
+}
+
+func writeCSV(w io.Writer, r *Report) error {
+	cw := csv.NewWriter(w)
+	header := []string{r.By}
+	if r.By == BySession {
+		header = append(header, "project", "start")
+	}
+	header = append(header, "sessions", "turns", "requests", "input_tokens", "output_tokens",
+		"cache_creation_tokens", "cache_read_tokens", "total_tokens", "cost_usd", "duration_seconds")
+	if err := cw.Write(header); err != nil {
+		return err
+	}
+	for _, g := range r.Groups {
+		rec := []string{g.Key}
+		if r.By == BySession {
+			rec = append(rec, g.Project, g.Start.UTC().Format(time.RFC3339))
+		}
+		rec = append(rec,
+			strconv.Itoa(g.Sessions), strconv.Itoa(g.Turns), strconv.Itoa(g.Requests),
+			strconv.FormatInt(g.Tokens.Input, 10), strconv.FormatInt(g.Tokens.Output, 10),
+			strconv.FormatInt(g.Tokens.CacheCreation, 10), strconv.FormatInt(g.Tokens.CacheRead, 10),
+			strconv.FormatInt(g.Tokens.Total(), 10), strconv.FormatFloat(g.Cost, 'f', 4, 64),
+			strconv.FormatInt(int64(g.Duration.Seconds()), 10))
+		if err := cw.Write(rec); err != nil {
+			return err
+		}
+	}
+	cw.Flush()
+	return cw.Error()
+}
+
+func displayKey(by string, g Group) string {
+	if by == ByProject {
+		return ShortPath(g.Key)
+	}
+	return g.Key
+}
+
+// ShortPath replaces the home directory prefix with ~.
+func ShortPath(p string) string {
+	home, err := os.UserHomeDir()
+	if err != nil || home == "" {
+		return p
+	}
+	if p == home {
+		return "~"
+	}
+	if strings.HasPrefix(p, home+string(filepath.Separator)) {
+		return "~" + p[len(home):]
+	}
+	return p
+}
+
+// shortID abbreviates a session id to its first eight characters.
+func shortID(id string) string {
+	if len(id) > 8 {
+		return id[:8]
+	}
+	return id
+}
+
+func truncate(s string, n int) string {
+	if len(s) <= n {
+		return s
+	}
+	if n <= 3 {
+		return s[:n]
+	}
+	return "..." + s[len(s)-n+3:]
+}
+
+// FormatTokens renders a token count compactly (950, 12.3K, 4.56M).
+func FormatTokens(n int64) string {
+	switch {
+	case n >= 1e9:
+		return fmt.Sprintf("%.2fB", float64(n)/1e9)
+	case n >= 1e6:
+		return fmt.Sprintf("%.2fM", float64(n)/1e6)
+	case n >= 1e4:
+		return fmt.Sprintf("%.1fK", float64(n)/1e3)
+	}
+	return strconv.FormatInt(n, 10)
+}
+
+// FormatCost renders USD with cents.
+func FormatCost(c float64) string {
+	return fmt.Sprintf("$%.2f", c)
+}
+
+// FormatDuration renders a duration as hours and minutes.
+func FormatDuration(d time.Duration) string {
+	d = d.Round(time.Minute)
+	h := int(d.Hours())
+	m := int(d.Minutes()) % 60
+	if h > 0 {
+		return fmt.Sprintf("%dh%02dm", h, m)
+	}
+	return fmt.Sprintf("%dm", m)
+}
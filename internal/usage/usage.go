@@ -0,0 +1,191 @@
+// Package usage aggregates token usage and estimated cost from local
+// Claude Code transcripts.
+package usage
+
+import (
+	"encoding/json"
+	"path/filepath"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/yejune/godo/internal/transcript"
+)
+
+// Tokens counts tokens by kind.
+type Tokens = transcript.Tokens
+
+// Entry is one API response with usage, or one user prompt (Prompt set,
+// no tokens). A prompt carries the model of the response that answered it.
+type Entry struct {
+	Time      time.Time
+	SessionID string
+	Project   string
+	Model     string
+	Prompt    bool
+	Tokens    Tokens
+}
+
+// Session summarizes one transcript.
+type Session struct {
+	ID       string    `json:"id"`
+	Project  string    `json:"project"`
+	Path     string    `json:"path"`
+	Start    time.Time `json:"start"`
+	End      time.Time `json:"end"`
+	Turns    int       `json:"turns"`
+	Requests int       `json:"requests"`
+	Models   []string  `json:"models"`
+	Tokens   Tokens    `json:"tokens"`
+}
+
+// Duration is the time between the first and last transcript entry.
+func (s Session) Duration() time.Duration {
+	return s.End.Sub(s.Start)
+}
+
+// ParseFile reads a transcript and the subagent transcripts next to it
+// (see transcript.SubagentFiles), whose usage counts toward the parent session.
+// Responses split over several lines (one per content block) share a
+// message and request id and are counted once, as are responses recorded
+// both in the parent transcript and in a subagent file.
+func ParseFile(path string) (*Session, []Entry, error) {
+	p := &parser{
+		s:      &Session{Path: path, ID: strings.TrimSuffix(filepath.Base(path), ".jsonl")},
+		seen:   make(transcript.Responses),
+		models: make(map[string]bool),
+	}
+	if err := transcript.ReadLines(path, p.add); err != nil {
+		return nil, nil, err
+	}
+	p.pending = nil
+	p.subagent = true
+	for _, sub := range transcript.SubagentFiles(path) {
+		if err := transcript.ReadLines(sub, p.add); err != nil {
+			return nil, nil, err
+		}
+	}
+
+	s := p.s
+	for i := range p.entries {
+		p.entries[i].SessionID = s.ID
+		p.entries[i].Project = s.Project
+	}
+	for m := range p.models {
+		s.Models = append(s.Models, m)
+	}
+	sort.Strings(s.Models)
+	return s, p.entries, nil
+}
+
+type parser struct {
+	s       *Session
+	entries []Entry
+	pending []int // prompts waiting for the model that answers them
+	seen    transcript.Responses
+	models  map[string]bool
+	idSet   bool
+	// subagent is set while reading subagent files: they add usage but
+	// neither prompts nor session metadata.
+	subagent bool
+}
+
+func (p *parser) add(l transcript.Line) {
+	ts, err := time.Parse(time.RFC3339Nano, l.Timestamp)
+	if err != nil {
+		return
+	}
+	s := p.s
+	if s.Start.IsZero() || ts.Before(s.Start) {
+		s.Start = ts
+	}
+	if ts.After(s.End) {
+		s.End = ts
+	}
+	if p.subagent {
+		l.IsSidechain = true
+	}
+	if l.SessionID != "" && !p.idSet {
+		s.ID, p.idSet = l.SessionID, true
+	}
+	if s.Project == "" && l.CWD != "" && !l.IsSidechain {
+		s.Project = l.CWD
+	}
+
+	var msg transcript.Message
+	if len(l.Message) == 0 || json.Unmarshal(l.Message, &msg) != nil {
+		return
+	}
+	switch l.Type {
+	case "user":
+		if !transcript.IsPrompt(l, &msg) {
+			return
+		}
+		s.Turns++
+		p.pending = append(p.pending, len(p.entries))
+		p.entries = append(p.entries, Entry{Time: ts, Prompt: true})
+	case "assistant":
+		if !msg.Billed() || !p.seen.First(l, &msg) {
+			return
+		}
+		t := msg.Tokens()
+		s.Requests++
+		s.Tokens.Add(t)
+		p.models[msg.Model] = true
+		for _, i := range p.pending {
+			p.entries[i].Model = msg.Model
+		}
+		p.pending = nil
+		p.entries = append(p.entries, Entry{Time: ts, Model: msg.Model, Tokens: t})
+	}
+}
+
+// Data is the parsed content of many transcripts.
+type Data struct {
+	Sessions []Session
+	Entries  []Entry
+}
+
+// Load parses every transcript. Unreadable files are skipped and counted
+// in the returned error list.
+func Load(paths []string) (*Data, []error) {
+	d := &Data{}
+	var errs []error
+	for _, p := range paths {
+		s, entries, err := ParseFile(p)
+		if err != nil {
+			errs = append(errs, err)
+			continue
+		}
+		if s.Start.IsZero() {
+			continue
+		}
+		d.Sessions = append(d.Sessions, *s)
+		d.Entries = append(d.Entries, entries...)
+	}
+	return d, errs
+}
+
+// Filter restricts data to entries in [since, until) and sessions whose
+// project contains project. Zero times are unbounded; sessions keep only
+// their entries in range.
+func (d *Data) Filter(since, until time.Time, project string) *Data {
+	out := &Data{}
+	keep := make(map[string]bool)
+	for _, e := range d.Entries {
+		if !since.IsZero() && e.Time.Before(since) || !until.IsZero() && !e.Time.Before(until) {
+			continue
+		}
+		if project != "" && !strings.Contains(strings.ToLower(e.Project), strings.ToLower(project)) {
+			continue
+		}
+		out.Entries = append(out.Entries, e)
+		keep[e.SessionID] = true
+	}
+	for _, s := range d.Sessions {
+		if keep[s.ID] {
+			out.Sessions = append(out.Sessions, s)
+		}
+	}
+	return out
+}
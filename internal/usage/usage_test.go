@@ -0,0 +1,172 @@
+package usage
+
+import (
+	"bytes"
+	"encoding/csv"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+)
+
+func writeTranscript(t *testing.T, dir, name string, lines ...string) string {
+	t.Helper()
+	p := filepath.Join(dir, name)
+	if err := os.WriteFile(p, []byte(strings.Join(lines, "\n")+"\n"), 0644); err != nil {
+		t.Fatal(err)
+	}
+	return p
+}
+
+const (
+	promptLine = `{"type":"user","timestamp":"%s","sessionId":"%s","cwd":"%s","message":{"role":"user","content":"do it"}}`
+	resultLine = `{"type":"user","timestamp":"%s","sessionId":"%s","message":{"role":"user","content":[{"type":"tool_result","content":"ok"}]}}`
+	replyLine  = `{"type":"assistant","timestamp":"%s","sessionId":"%s","requestId":"%s","message":{"id":"%s","model":"%s","usage":{"input_tokens":%d,"output_tokens":%d,"cache_creation_input_tokens":%d,"cache_read_input_tokens":%d}}}`
+)
+
+func Test_ParseFile_dedupes_split_responses_and_counts_prompts(t *testing.T) {
+	dir := t.TempDir()
+	p := writeTranscript(t, dir, "s1.jsonl",
+		fmt.Sprintf(promptLine, "2026-01-05T10:00:00Z", "s1", "/work/api"),
+		fmt.Sprintf(replyLine, "2026-01-05T10:00:05Z", "s1", "r1", "m1", "claude-sonnet-4-5", 100, 50, 1000, 2000),
+		fmt.Sprintf(replyLine, "2026-01-05T10:00:06Z", "s1", "r1", "m1", "claude-sonnet-4-5", 100, 50, 1000, 2000), // second content block
+		fmt.Sprintf(resultLine, "2026-01-05T10:00:07Z", "s1"),
+		fmt.Sprintf(replyLine, "2026-01-05T10:10:00Z", "s1", "r2", "m2", "claude-sonnet-4-5", 10, 5, 0, 3000),
+		`not json`,
+	)
+
+	s, entries, err := ParseFile(p)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if s.ID != "s1" || s.Project != "/work/api" || s.Turns != 1 || s.Requests != 2 {
+		t.Errorf("session: %+v", s)
+	}
+	want := Tokens{Input: 110, Output: 55, CacheCreation: 1000, CacheRead: 5000}
+	if s.Tokens != want {
+		t.Errorf("tokens: got %+v, want %+v", s.Tokens, want)
+	}
+	if s.Duration() != 10*time.Minute {
+		t.Errorf("duration: %v", s.Duration())
+	}
+	if len(entries) != 3 || !entries[0].Prompt || entries[0].Model != "claude-sonnet-4-5" {
+		t.Errorf("entries: %+v", entries)
+	}
+}
+
+func Test_ParseFile_counts_subagent_transcripts_toward_parent(t *testing.T) {
+	dir := t.TempDir()
+	p := writeTranscript(t, dir, "s1.jsonl",
+		fmt.Sprintf(promptLine, "2026-01-05T10:00:00Z", "s1", "/work/api"),
+		fmt.Sprintf(replyLine, "2026-01-05T10:00:05Z", "s1", "r1", "m1", "claude-sonnet-4-5", 100, 50, 0, 0),
+	)
+	sub := filepath.Join(dir, "s1", "subagents")
+	if err := os.MkdirAll(sub, 0755); err != nil {
+		t.Fatal(err)
+	}
+	writeTranscript(t, sub, "agent-a1.jsonl",
+		fmt.Sprintf(promptLine, "2026-01-05T10:00:06Z", "s1", "/work/api"),
+		fmt.Sprintf(replyLine, "2026-01-05T10:00:08Z", "s1", "r2", "m2", "claude-haiku-4-5", 10, 5, 0, 0),
+		// Also recorded in the parent transcript by older versions.
+		fmt.Sprintf(replyLine, "2026-01-05T10:00:05Z", "s1", "r1", "m1", "claude-sonnet-4-5", 100, 50, 0, 0),
+	)
+
+	data, errs := Load([]string{p})
+	if len(errs) != 0 || len(data.Sessions) != 1 {
+		t.Fatalf("load: %+v, %v", data, errs)
+	}
+	s := data.Sessions[0]
+	if s.ID != "s1" || s.Turns != 1 || s.Requests != 2 || s.Tokens != (Tokens{Input: 110, Output: 55}) {
+		t.Errorf("session: %+v", s)
+	}
+	if len(data.Entries) != 3 || data.Entries[2].SessionID != "s1" || data.Entries[2].Model != "claude-haiku-4-5" {
+		t.Errorf("entries: %+v", data.Entries)
+	}
+}
+
+func Test_Aggregate_by_day_and_model_with_cache_pricing(t *testing.T) {
+	dir := t.TempDir()
+	a := writeTranscript(t, dir, "a.jsonl",
+		fmt.Sprintf(promptLine, "2026-01-05T10:00:00Z", "a", "/work/api"),
+		fmt.Sprintf(replyLine, "2026-01-05T10:00:05Z", "a", "r1", "m1", "claude-opus-4-5-20251101", 1_000_000, 0, 0, 0),
+		fmt.Sprintf(promptLine, "2026-01-06T09:00:00Z", "a", "/work/api"),
+		fmt.Sprintf(replyLine, "2026-01-06T09:30:00Z", "a", "r2", "m2", "claude-sonnet-4-5", 0, 0, 1_000_000, 1_000_000),
+	)
+	b := writeTranscript(t, dir, "b.jsonl",
+		fmt.Sprintf(promptLine, "2026-01-06T12:00:00Z", "b", "/work/web"),
+		fmt.Sprintf(replyLine, "2026-01-06T12:00:10Z", "b", "r3", "m3", "mystery-model", 500, 500, 0, 0),
+	)
+
+	data, errs := Load([]string{a, b})
+	if len(errs) > 0 {
+		t.Fatal(errs)
+	}
+	r, err := Aggregate(data, ByDay, DefaultPrices(), time.UTC, 1)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(r.Groups) != 2 || r.Groups[0].Key != "2026-01-05" || r.Groups[1].Sessions != 2 || r.Groups[1].Turns != 2 {
+		t.Fatalf("groups: %+v", r.Groups)
+	}
+	// opus-4-5 input $5/M; sonnet cache write $3.75/M + cache read $0.30/M.
+	if got := r.Groups[0].Cost; got != 5 {
+		t.Errorf("day 1 cost: got %v, want 5", got)
+	}
+	if got := r.Groups[1].Cost; got < 4.049 || got > 4.051 {
+		t.Errorf("day 2 cost: got %v, want 4.05", got)
+	}
+	if r.Groups[1].Duration != 30*time.Minute+10*time.Second {
+		t.Errorf("day 2 duration: %v", r.Groups[1].Duration)
+	}
+	if len(r.Unpriced) != 1 || r.Unpriced[0] != "mystery-model" {
+		t.Errorf("unpriced: %v", r.Unpriced)
+	}
+	if len(r.TopSessions) != 1 || r.TopSessions[0].Key != "a" || r.TopSessions[0].Project != "/work/api" {
+		t.Errorf("top sessions: %+v", r.TopSessions)
+	}
+
+	r, err = Aggregate(data.Filter(time.Time{}, time.Time{}, "web"), ByModel, DefaultPrices(), time.UTC, 0)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(r.Groups) != 1 || r.Groups[0].Key != "mystery-model" || r.Groups[0].Turns != 1 {
+		t.Errorf("filtered by project: %+v", r.Groups)
+	}
+
+	var buf bytes.Buffer
+	if err := WriteReport(&buf, FormatCSV, r); err != nil {
+		t.Fatal(err)
+	}
+	recs, err := csv.NewReader(&buf).ReadAll()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(recs) != 2 || recs[0][0] != "model" || recs[1][8] != "1000" {
+		t.Errorf("csv: %v", recs)
+	}
+}
+
+func Test_LoadPrices_overrides_and_longest_match(t *testing.T) {
+	p := filepath.Join(t.TempDir(), "prices.yaml")
+	if err := os.WriteFile(p, []byte("models:\n  glm-4.6: {input: 0.6, output: 2.2}\n  sonnet: {input: 1}\n"), 0644); err != nil {
+		t.Fatal(err)
+	}
+	table, err := LoadPrices(p)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if pr, ok := table.Lookup("GLM-4.6"); !ok || pr.Output != 2.2 {
+		t.Errorf("glm: %+v %v", pr, ok)
+	}
+	if pr, _ := table.Lookup("claude-sonnet-4-5"); pr.Input != 1 {
+		t.Errorf("override: %+v", pr)
+	}
+	if pr, _ := table.Lookup("claude-haiku-4-5-20251001"); pr.Input != 1 {
+		t.Errorf("haiku-4-5 should beat haiku: %+v", pr)
+	}
+	if _, ok := table.Lookup("gpt-x"); ok {
+		t.Error("unknown model should not be priced")
+	}
+}
@@ -3,13 +3,16 @@ package validator
 import (
 	"bufio"
 	"encoding/json"
+	"errors"
 	"fmt"
+	"io/fs"
 	"os"
 	"os/exec"
 	"path/filepath"
 	"regexp"
 	"strings"
 
+	"github.com/yejune/godo/internal/hook"
 	"github.com/yejune/godo/internal/model"
 )
 
@@ -28,15 +31,6 @@ type DependencyValidator struct {
 	JobDir string
 }
 
-// stateJSON mirrors the structure of state.json used for phase tracking.
-type stateJSON struct {
-	Phases map[string]phaseEntry `json:"phases"`
-}
-
-type phaseEntry struct {
-	Status string `json:"status"`
-}
-
 // dockerPSEntry represents one service line from `docker compose ps --format json`.
 type dockerPSEntry struct {
 	Name    string `json:"Name"`
@@ -52,23 +46,33 @@ var statusRegex = regexp.MustCompile(`\[([ ~*!ox])\]`)
 var itemIDRegex = regexp.MustCompile(`#(\d+)`)
 
 // ValidatePhase checks whether the given phase is marked "complete" in state.json.
+// When state.json names a workflow, the phase must also be defined by it (see
+// hook.Workflows); legacy state without a workflow_type is not checked.
 func (v *DependencyValidator) ValidatePhase(phase string) error {
-	path := filepath.Join(v.JobDir, "state.json")
-	data, err := os.ReadFile(path)
+	state, err := hook.LoadJobState(filepath.Join(v.JobDir, "state.json"))
 	if err != nil {
-		return fmt.Errorf("phase %q: cannot read state.json: %w", phase, err)
+		var pathErr *fs.PathError
+		if errors.As(err, &pathErr) {
+			return fmt.Errorf("phase %q: cannot read state.json: %w", phase, err)
+		}
+		return fmt.Errorf("phase %q: cannot parse state.json: %w", phase, err)
 	}
 
-	var state stateJSON
-	if err := json.Unmarshal(data, &state); err != nil {
-		return fmt.Errorf("phase %q: cannot parse state.json: %w", phase, err)
+	if state.WorkflowType != "" {
+		wf, err := hook.WorkflowFor(state.WorkflowType)
+		if err != nil {
+			return fmt.Errorf("phase %q: %w", phase, err)
+		}
+		if _, ok := wf.Phase(phase); !ok {
+			return fmt.Errorf("phase %q: not defined in %s workflow", phase, wf.Type)
+		}
 	}
 
 	entry, ok := state.Phases[phase]
 	if !ok {
 		return fmt.Errorf("phase %q: not found in state.json", phase)
 	}
-	if entry.Status != "complete" {
+	if entry.Status != hook.PhaseComplete {
 		return fmt.Errorf("phase %q: status is %q, not complete", phase, entry.Status)
 	}
 	return nil
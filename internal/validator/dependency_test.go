@@ -7,6 +7,7 @@ import (
 	"strings"
 	"testing"
 
+	"github.com/yejune/godo/internal/hook"
 	"github.com/yejune/godo/internal/model"
 )
 
@@ -50,6 +51,36 @@ func TestValidatePhase_NotFound(t *testing.T) {
 	}
 }
 
+func TestValidatePhase_NotInWorkflow(t *testing.T) {
+	dir := t.TempDir()
+	writeFile(t, filepath.Join(dir, "state.json"),
+		`{"workflow_type":"simple","phases":{"analysis":{"status":"complete"}}}`)
+
+	v := &DependencyValidator{JobDir: dir}
+	err := v.ValidatePhase("analysis")
+	if err == nil {
+		t.Fatal("expected error for phase outside the simple workflow")
+	}
+	if !strings.Contains(err.Error(), "not defined in simple workflow") {
+		t.Errorf("expected 'not defined in simple workflow' in error, got: %v", err)
+	}
+}
+
+func TestValidatePhase_LegacyStateWithoutWorkflowType(t *testing.T) {
+	dir := t.TempDir()
+	writeFile(t, filepath.Join(dir, "state.json"),
+		`{"phases":{"implementation":{"status":"complete"},"review":{"status":"in_progress"}}}`)
+
+	v := &DependencyValidator{JobDir: dir}
+	if err := v.ValidatePhase("implementation"); err != nil {
+		t.Fatalf("legacy phase outside the workflows should validate, got: %v", err)
+	}
+	err := v.ValidatePhase("review")
+	if err == nil || !strings.Contains(err.Error(), "not complete") {
+		t.Errorf("expected 'not complete' for legacy in-progress phase, got: %v", err)
+	}
+}
+
 func TestValidatePhase_MissingStateJSON(t *testing.T) {
 	dir := t.TempDir()
 
@@ -623,11 +654,11 @@ func TestValidateAll_MixedBlockedAndWarnings(t *testing.T) {
 // writeStateJSON creates a state.json file in dir with given phase statuses.
 func writeStateJSON(t *testing.T, dir string, phases map[string]string) {
 	t.Helper()
-	state := stateJSON{
-		Phases: make(map[string]phaseEntry, len(phases)),
+	state := hook.JobState{
+		Phases: make(map[string]hook.PhaseState, len(phases)),
 	}
 	for name, status := range phases {
-		state.Phases[name] = phaseEntry{Status: status}
+		state.Phases[name] = hook.PhaseState{Status: status}
 	}
 	data, err := json.Marshal(state)
 	if err != nil {